@@ -6,32 +6,41 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"go.mau.fi/whatsmeow/types/events"
 
 	"orion-agent/internal/data/store"
 	"orion-agent/internal/infra/config"
 	"orion-agent/internal/infra/logger"
+	"orion-agent/internal/infra/metrics"
 	"orion-agent/internal/service/agent"
+	"orion-agent/internal/service/autoreact"
 	"orion-agent/internal/service/event"
+	"orion-agent/internal/service/group"
 	"orion-agent/internal/service/media"
+	"orion-agent/internal/service/privacy"
 	"orion-agent/internal/service/send"
 	"orion-agent/internal/service/sync"
+	"orion-agent/internal/service/webhook"
 	"orion-agent/internal/utils"
 )
 
 // App is the main application orchestrator.
 type App struct {
-	Config       *config.Config
-	Log          *logger.Logger
-	Store        *store.Store
-	Client       *Client
-	Utils        *utils.Utils
-	EventService *event.EventService
-	SyncService  *sync.SyncService
-	SendService  *send.SendService
-	AgentService *agent.AgentService
-	MediaService *media.MediaService
+	Config         *config.Config
+	Log            *logger.Logger
+	Store          *store.Store
+	Client         *Client
+	Utils          *utils.Utils
+	EventService   *event.EventService
+	SyncService    *sync.SyncService
+	SendService    *send.SendService
+	GroupService   *group.GroupService
+	PrivacyService *privacy.PrivacyService
+	AgentService   *agent.AgentService
+	MediaService   *media.MediaService
+	Webhook        *webhook.WebhookHandler // nil unless enabled
 
 	// Sub-stores for convenience
 	ContactStore    *store.ContactStore
@@ -57,7 +66,13 @@ func New(cfg *config.Config) (*App, error) {
 
 	// Create store
 	dbPath := cfg.StorePath + "/orion.db"
-	appStore, err := store.New(dbPath, log)
+	appStore, err := store.NewWithOptions(dbPath, store.Options{
+		JournalMode:   cfg.Database.JournalMode,
+		Synchronous:   cfg.Database.Synchronous,
+		BusyTimeoutMs: cfg.Database.BusyTimeoutMs,
+		MaxOpenConns:  cfg.Database.MaxOpenConns,
+		MaxIdleConns:  cfg.Database.MaxIdleConns,
+	}, log)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create store: %w", err)
 	}
@@ -79,6 +94,14 @@ func New(cfg *config.Config) (*App, error) {
 	summaryStore := store.NewSummaryStore(appStore)
 	settingsStore := store.NewSettingsStore(appStore, cfg)
 	mediaCacheStore := store.NewMediaCacheStore(appStore)
+	botStore := store.NewBotStore(appStore)
+	failedSendStore := store.NewFailedSendStore(appStore)
+	scheduledStore := store.NewScheduledMessageStore(appStore)
+	statusStore := store.NewStatusStore(appStore)
+	idempotencyStore := store.NewIdempotencyStore(appStore)
+	presenceWatchStore := store.NewPresenceWatchStore(appStore)
+	broadcastStore := store.NewBroadcastStore(appStore)
+	tagStore := store.NewTagStore(appStore)
 
 	// Create client
 	waClient, err := NewClient(cfg, appStore, log)
@@ -89,12 +112,23 @@ func New(cfg *config.Config) (*App, error) {
 
 	// Create utils
 	appUtils := utils.New(contactStore, waClient.Underlying())
+	appUtils.SetJIDCacheLimits(cfg.JIDCacheSize, time.Duration(cfg.JIDCacheTTLMins)*time.Minute)
+	contactStore.SetMappingListener(appUtils.InvalidateJIDCache)
 
 	// Create sync state store
 	syncStateStore := store.NewSyncStateStore(appStore)
 
 	// Create media service
-	mediaService := media.NewMediaService(waClient.Underlying(), &cfg.Media, cfg.StorePath, mediaCacheStore, log)
+	mediaStorage, err := media.NewStorage(&cfg.Media.Storage, cfg.StorePath)
+	if err != nil {
+		appStore.Close()
+		return nil, fmt.Errorf("failed to create media storage: %w", err)
+	}
+	messageStore.SetMediaRemover(mediaStorage.Delete)
+	mediaService := media.NewMediaService(waClient.Underlying(), &cfg.Media, cfg.StorePath, mediaStorage, mediaCacheStore, messageStore, log)
+	if cfg.Media.OCR.Enabled {
+		mediaService.SetOCR(media.NewTesseractOCR(&cfg.Media.OCR), cfg.Media.OCR.Concurrency)
+	}
 
 	// Create sync service with ALL stores
 	syncService := sync.NewSyncService(
@@ -108,14 +142,40 @@ func New(cfg *config.Config) (*App, error) {
 		privacyStore,
 		newsletterStore,
 		syncStateStore,
+		presenceWatchStore,
 		log,
 	)
+	syncService.SetRateLimit(cfg.Sync.RateLimit)
 
 	// Create send service
-	sendService := send.NewSendService(waClient.Underlying(), appUtils, messageStore, reactionStore, pollStore, log)
+	sendService := send.NewSendService(waClient.Underlying(), appUtils, send.Stores{
+		Messages:    messageStore,
+		Reactions:   reactionStore,
+		Polls:       pollStore,
+		Chats:       chatStore,
+		Groups:      groupStore,
+		FailedSends: failedSendStore,
+		Scheduled:   scheduledStore,
+		Statuses:    statusStore,
+		Idempotency: idempotencyStore,
+		Blocklist:   blocklistStore,
+		Broadcasts:  broadcastStore,
+	}, log)
+	sendService.SetMaxOutboxSize(cfg.MaxOutboxSize)
+	sendService.SetMediaQueue(mediaService)
+	sendService.SetRateLimiter(syncService)
+	sendService.SetFooter(cfg.Send.MessageFooter, cfg.Send.FooterTypes)
+	sendService.SetIdempotencyWindow(time.Duration(cfg.Send.IdempotencyWindowMins) * time.Minute)
+	send.StripImageMetadata = cfg.Send.StripImageMetadata
+
+	// Create group service
+	groupService := group.NewGroupService(waClient.Underlying(), appUtils, groupStore, chatStore, log)
+
+	// Create privacy service
+	privacyService := privacy.NewPrivacyService(waClient.Underlying(), privacyStore, log)
 
 	// Create agent service
-	agentService := agent.NewAgentService(cfg, appStore, settingsStore, summaryStore, toolStore, sendService, log)
+	agentService := agent.NewAgentService(cfg, appStore, settingsStore, summaryStore, toolStore, contactStore, tagStore, sendService, log)
 
 	// Create event service with ALL stores
 	eventService := event.NewEventService(
@@ -135,6 +195,7 @@ func New(cfg *config.Config) (*App, error) {
 		labelStore,
 		privacyStore,
 		blocklistStore,
+		botStore,
 	)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -148,6 +209,8 @@ func New(cfg *config.Config) (*App, error) {
 		Utils:           appUtils,
 		SyncService:     syncService,
 		SendService:     sendService,
+		GroupService:    groupService,
+		PrivacyService:  privacyService,
 		AgentService:    agentService,
 		ContactStore:    contactStore,
 		ChatStore:       chatStore,
@@ -163,6 +226,25 @@ func New(cfg *config.Config) (*App, error) {
 	// Set up sync dispatcher for coalescence
 	syncService.SetDispatcher(ctx)
 
+	// Set up keyword auto-reactions
+	if cfg.AutoReact.Enabled {
+		eventService.SetAutoReactor(autoreact.NewAutoReactService(&cfg.AutoReact, sendService, log))
+	}
+
+	// Record Prometheus metrics
+	if cfg.Metrics.Enabled {
+		metrics.Enable(mediaService.QueueDepth)
+	}
+
+	// Forward events to the configured webhook
+	if cfg.Webhook.Enabled && cfg.Webhook.URL != "" {
+		app.Webhook = webhook.NewWebhookHandler(ctx, &cfg.Webhook, appUtils, log)
+	}
+
+	// Decrypt incoming poll votes
+	eventService.SetPollDecrypter(waClient.Underlying())
+	eventService.SetIgnoredMessageTypes(cfg.Storage.IgnoredMessageTypes)
+
 	// Set up event dispatcher
 	eventService.SetDispatcher(ctx)
 
@@ -179,6 +261,23 @@ func (a *App) Run() error {
 	// Start media service
 	a.MediaService.Start()
 
+	// Send scheduled messages when due
+	a.SendService.StartScheduler()
+
+	if a.Webhook != nil {
+		a.Webhook.Start()
+	}
+
+	// Serve metrics until shutdown
+	if metrics.Enabled() {
+		go func() {
+			a.Log.Infof("Serving metrics on http://%s/metrics", a.Config.Metrics.Listen)
+			if err := metrics.Serve(a.ctx, a.Config.Metrics.Listen); err != nil {
+				a.Log.Errorf("Metrics server failed: %v", err)
+			}
+		}()
+	}
+
 	// Setup signal handling to cancel context
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -239,6 +338,14 @@ func (a *App) handleEvent(evt interface{}) {
 		if a.Client.Underlying().Store.ID != nil {
 			a.AgentService.SetOwnJID(*a.Client.Underlying().Store.ID)
 		}
+		// Retry sends that failed while offline
+		if a.Config.RetryFailedSendsOnConnect {
+			go func() {
+				if _, err := a.SendService.RetryFailedSends(a.ctx); err != nil {
+					a.Log.Warnf("Failed to retry failed sends: %v", err)
+				}
+			}()
+		}
 
 	case *events.PairSuccess:
 		a.Log.Infof("Paired successfully as %s", e.ID)
@@ -253,13 +360,28 @@ func (a *App) handleEvent(evt interface{}) {
 	a.EventService.Handle(evt)
 	// Route to sync service for coalescence
 	a.SyncService.Handle(evt)
+	// Forward to the webhook
+	if a.Webhook != nil {
+		a.Webhook.Handle(evt)
+	}
 }
 
+// syncStopTimeout is how long Shutdown waits for in-flight syncs to cancel.
+const syncStopTimeout = 10 * time.Second
+
 // Shutdown gracefully shuts down the application.
 func (a *App) Shutdown() error {
 	a.cancel()
 	a.MediaService.Stop()
-	a.SyncService.StopScheduler()
+	a.SendService.StopScheduler()
+	stopCtx, cancel := context.WithTimeout(context.Background(), syncStopTimeout)
+	if err := a.SyncService.Stop(stopCtx); err != nil {
+		a.Log.Warnf("Failed to stop sync service: %v", err)
+	}
+	cancel()
+	if a.Webhook != nil {
+		a.Webhook.Stop()
+	}
 	a.Client.Disconnect()
 	return a.Store.Close()
 }
@@ -96,6 +96,38 @@ func GroupFromJoinedEvent(evt *events.JoinedGroup) (*store.Group, []store.GroupP
 	return g, participants
 }
 
+// GroupFromInfo converts whatsmeow group info into a store.Group.
+func GroupFromInfo(g *types.GroupInfo) *store.Group {
+	group := &store.Group{
+		JID:               g.JID,
+		Name:              g.Name,
+		NameSetAt:         g.NameSetAt,
+		NameSetByLID:      g.NameSetBy,
+		Topic:             g.Topic,
+		TopicID:           g.TopicID,
+		TopicSetAt:        g.TopicSetAt,
+		TopicSetByLID:     g.TopicSetBy,
+		OwnerLID:          g.OwnerJID,
+		CreatedAtWA:       g.GroupCreated,
+		IsAnnounce:        g.IsAnnounce,
+		IsLocked:          g.IsLocked,
+		IsIncognito:       g.IsIncognito,
+		EphemeralDuration: uint32(g.DisappearingTimer),
+		MemberAddMode:     string(g.MemberAddMode),
+		IsCommunity:       g.IsParent,
+		IsParentGroup:     g.IsParent,
+		IsDefaultSubgroup: g.IsDefaultSubGroup,
+		ParticipantCount:  g.ParticipantCount,
+		UpdatedAt:         time.Now(),
+	}
+
+	if !g.LinkedParentJID.IsEmpty() {
+		group.LinkedParentJID = g.LinkedParentJID
+	}
+
+	return group
+}
+
 // ContactFromPushName creates a minimal contact from a push name event.
 func ContactFromPushName(evt *events.PushName) *store.Contact {
 	return &store.Contact{
@@ -237,6 +237,9 @@ func extractHistoryMessage(histMsg *waHistorySync.HistorySyncMsg, chatJID types.
 
 		// Extract interactive
 		extractInteractive(protoMsg, msg)
+
+		// Extract bot invocation or reply
+		extractBot(protoMsg, chatJID, msg)
 	}
 
 	// Status flags
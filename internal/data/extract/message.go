@@ -1,6 +1,7 @@
 package extract
 
 import (
+	"strings"
 	"time"
 
 	"go.mau.fi/whatsmeow/proto/waE2E"
@@ -68,6 +69,9 @@ func MessageFromEvent(evt *events.Message) *store.Message {
 	// Extract interactive message data (buttons, lists, etc.)
 	extractInteractive(evt.Message, msg)
 
+	// Extract bot invocation / bot reply metadata
+	extractBot(evt.Message, evt.Info.Chat, msg)
+
 	return msg
 }
 
@@ -86,11 +90,7 @@ func extractTextContent(msg *waE2E.Message, m *store.Message) {
 	// Extended text message with link preview
 	if ext := msg.GetExtendedTextMessage(); ext != nil {
 		m.TextContent = ext.GetText()
-		// Extract link preview
-		m.PreviewTitle = ext.GetTitle()
-		m.PreviewDescription = ext.GetDescription()
-		m.PreviewMatchedText = ext.GetMatchedText()
-		m.PreviewURL = ext.GetMatchedText() // URL is in MatchedText
+		LinkPreview(ext, m)
 		return
 	}
 
@@ -113,6 +113,23 @@ func extractTextContent(msg *waE2E.Message, m *store.Message) {
 	}
 }
 
+// LinkPreview extracts link preview metadata from an extended text message.
+func LinkPreview(ext *waE2E.ExtendedTextMessage, m *store.Message) {
+	m.PreviewTitle = ext.GetTitle()
+	m.PreviewDescription = ext.GetDescription()
+	m.PreviewMatchedText = ext.GetMatchedText()
+	m.PreviewURL = ext.GetMatchedText() // URL is in MatchedText
+	m.PreviewThumbnail = ext.GetJPEGThumbnail()
+	if ext.PreviewType != nil {
+		m.PreviewType = strings.ToLower(ext.GetPreviewType().String())
+	}
+}
+
+// MessageType returns the stored type of a message, e.g. "image".
+func MessageType(msg *waE2E.Message) string {
+	return determineMessageType(msg)
+}
+
 // determineMessageType determines the message type from the protobuf.
 // This handles ALL known message types comprehensively.
 func determineMessageType(msg *waE2E.Message) string {
@@ -138,8 +155,12 @@ func determineMessageType(msg *waE2E.Message) string {
 		return "document"
 	case msg.GetStickerMessage() != nil:
 		return "sticker"
+	case msg.GetStickerPackMessage() != nil:
+		return "sticker_pack"
 	case msg.GetPtvMessage() != nil:
 		return "ptv" // Push-to-talk video (video note)
+	case msg.GetAlbumMessage() != nil:
+		return "album" // Parent of grouped images/videos
 
 	// Location messages
 	case msg.GetLocationMessage() != nil:
@@ -255,6 +276,10 @@ func determineMessageType(msg *waE2E.Message) string {
 	case msg.GetBotInvokeMessage() != nil:
 		return "bot_invoke"
 
+	// Group encryption key, sent alone before a sender's first group message
+	case msg.GetSenderKeyDistributionMessage() != nil:
+		return "sender_key_distribution"
+
 	default:
 		return "unknown"
 	}
@@ -331,6 +356,11 @@ func extractMedia(msg *waE2E.Message, m *store.Message) {
 		return
 	}
 
+	if pack := msg.GetStickerPackMessage(); pack != nil {
+		extractStickerPackMedia(pack, m)
+		return
+	}
+
 	// Handle view once wrappers
 	if vo := msg.GetViewOnceMessage(); vo != nil {
 		extractMedia(vo.GetMessage(), m)
@@ -360,6 +390,7 @@ func extractImageMedia(img *waE2E.ImageMessage, m *store.Message) {
 	m.Mimetype = img.GetMimetype()
 	m.Width = int(img.GetWidth())
 	m.Height = int(img.GetHeight())
+	m.Thumbnail = img.GetJPEGThumbnail()
 	m.Caption = img.GetCaption()
 }
 
@@ -375,6 +406,7 @@ func extractVideoMedia(vid *waE2E.VideoMessage, m *store.Message) {
 	m.Width = int(vid.GetWidth())
 	m.Height = int(vid.GetHeight())
 	m.DurationSeconds = int(vid.GetSeconds())
+	m.Thumbnail = vid.GetJPEGThumbnail()
 	m.Caption = vid.GetCaption()
 	m.IsGIF = vid.GetGifPlayback()
 }
@@ -390,6 +422,7 @@ func extractAudioMedia(aud *waE2E.AudioMessage, m *store.Message) {
 	m.Mimetype = aud.GetMimetype()
 	m.DurationSeconds = int(aud.GetSeconds())
 	m.IsPTT = aud.GetPTT()
+	m.Waveform = aud.GetWaveform()
 }
 
 func extractDocumentMedia(doc *waE2E.DocumentMessage, m *store.Message) {
@@ -401,6 +434,7 @@ func extractDocumentMedia(doc *waE2E.DocumentMessage, m *store.Message) {
 	m.FileEncSHA256 = doc.GetFileEncSHA256()
 	m.FileLength = int64(doc.GetFileLength())
 	m.Mimetype = doc.GetMimetype()
+	m.Thumbnail = doc.GetJPEGThumbnail()
 	m.Caption = doc.GetCaption()
 	m.DisplayName = doc.GetFileName()
 }
@@ -417,6 +451,21 @@ func extractStickerMedia(stk *waE2E.StickerMessage, m *store.Message) {
 	m.Width = int(stk.GetWidth())
 	m.Height = int(stk.GetHeight())
 	m.IsAnimated = stk.GetIsAnimated()
+	// Pack metadata isn't in the protobuf; it lives in the WebP EXIF and is
+	// filled in by the media service once the sticker is downloaded.
+}
+
+func extractStickerPackMedia(pack *waE2E.StickerPackMessage, m *store.Message) {
+	m.MediaDirectPath = pack.GetDirectPath()
+	m.MediaKey = pack.GetMediaKey()
+	m.MediaKeyTimestamp = pack.GetMediaKeyTimestamp()
+	m.FileSHA256 = pack.GetFileSHA256()
+	m.FileEncSHA256 = pack.GetFileEncSHA256()
+	m.FileLength = int64(pack.GetFileLength())
+	m.Caption = pack.GetCaption()
+	m.StickerPackID = pack.GetStickerPackID()
+	m.StickerPackName = pack.GetName()
+	m.StickerAuthor = pack.GetPublisher()
 }
 
 // extractContext extracts context info (quotes, mentions, forwarding).
@@ -468,6 +517,7 @@ func extractContext(msg *waE2E.Message, m *store.Message) {
 	if ctx.GetIsForwarded() {
 		m.IsForwarded = true
 		m.ForwardingScore = int(ctx.GetForwardingScore())
+		extractForwardOrigin(ctx, m)
 	}
 
 	// Ephemeral duration from context
@@ -476,6 +526,52 @@ func extractContext(msg *waE2E.Message, m *store.Message) {
 	}
 }
 
+// extractForwardOrigin extracts where a forwarded message came from.
+// Most forwards strip this; channel, business and bot forwards may retain it.
+func extractForwardOrigin(ctx *waE2E.ContextInfo, m *store.Message) {
+	if ctx.ForwardOrigin != nil {
+		switch ctx.GetForwardOrigin() {
+		case waE2E.ContextInfo_CHAT:
+			m.ForwardOrigin = "chat"
+		case waE2E.ContextInfo_STATUS:
+			m.ForwardOrigin = "status"
+		case waE2E.ContextInfo_CHANNELS:
+			m.ForwardOrigin = "channels"
+		case waE2E.ContextInfo_META_AI:
+			m.ForwardOrigin = "meta_ai"
+		case waE2E.ContextInfo_UGC:
+			m.ForwardOrigin = "ugc"
+		}
+	}
+
+	var originJID string
+	switch {
+	case ctx.GetForwardedNewsletterMessageInfo() != nil:
+		info := ctx.GetForwardedNewsletterMessageInfo()
+		originJID = info.GetNewsletterJID()
+		m.ForwardedFromName = info.GetNewsletterName()
+		if m.ForwardOrigin == "" {
+			m.ForwardOrigin = "channels"
+		}
+	case ctx.GetForwardedAiBotMessageInfo() != nil:
+		info := ctx.GetForwardedAiBotMessageInfo()
+		originJID = info.GetBotJID()
+		m.ForwardedFromName = info.GetBotName()
+		if m.ForwardOrigin == "" {
+			m.ForwardOrigin = "meta_ai"
+		}
+	case ctx.GetBusinessMessageForwardInfo() != nil:
+		originJID = ctx.GetBusinessMessageForwardInfo().GetBusinessOwnerJID()
+	case ctx.GetStanzaID() == "" && ctx.GetRemoteJID() != "":
+		// Without a quote, RemoteJID points at the origin chat
+		originJID = ctx.GetRemoteJID()
+	}
+
+	if originJID != "" {
+		m.ForwardedFromJID, _ = types.ParseJID(originJID)
+	}
+}
+
 // extractQuotedContent extracts text content from a quoted message.
 func extractQuotedContent(msg *waE2E.Message) string {
 	if msg == nil {
@@ -584,6 +680,15 @@ func getContextInfo(msg *waE2E.Message) *waE2E.ContextInfo {
 	if groupInvite := msg.GetGroupInviteMessage(); groupInvite != nil {
 		return groupInvite.GetContextInfo()
 	}
+	if buttons := msg.GetButtonsMessage(); buttons != nil {
+		return buttons.GetContextInfo()
+	}
+	if list := msg.GetListMessage(); list != nil {
+		return list.GetContextInfo()
+	}
+	if album := msg.GetAlbumMessage(); album != nil {
+		return album.GetContextInfo()
+	}
 
 	return nil
 }
@@ -723,4 +828,60 @@ func extractInteractive(msg *waE2E.Message, m *store.Message) {
 		}
 		return
 	}
+
+	// Interactive response (flow submissions, native-flow replies)
+	if resp := msg.GetInteractiveResponseMessage(); resp != nil {
+		m.TextContent = resp.GetBody().GetText()
+		m.InteractiveResponse = resp.GetNativeFlowResponseMessage().GetParamsJSON()
+		return
+	}
+}
+
+// extractBot extracts bot invocation and bot reply metadata.
+// Bot invocations wrap the user prompt in a BotInvokeMessage; bot replies
+// carry BotMetadata in the MessageContextInfo.
+func extractBot(msg *waE2E.Message, chat types.JID, m *store.Message) {
+	if msg == nil {
+		return
+	}
+
+	if invoke := msg.GetBotInvokeMessage(); invoke != nil {
+		m.IsBotInvoke = true
+		inner := invoke.GetMessage()
+		if m.TextContent == "" {
+			extractTextContent(inner, m)
+		}
+		m.BotPrompt = m.TextContent
+
+		// Mentions of the bot live on the wrapped message
+		if m.MentionedJIDs == nil {
+			extractContext(inner, m)
+		}
+	}
+
+	if meta := msg.GetMessageContextInfo().GetBotMetadata(); meta != nil {
+		m.BotPersonaID = meta.GetPersonaID()
+		if invoker := meta.GetInvokerJID(); invoker != "" {
+			m.BotInvokerLID, _ = types.ParseJID(invoker)
+		}
+	}
+
+	if !m.IsBotInvoke && m.BotPersonaID == "" {
+		return
+	}
+
+	// Resolve which bot: direct bot chat, or a bot mentioned in the chat
+	switch {
+	case chat.Server == types.BotServer:
+		m.BotJID = chat
+	case m.SenderLID.Server == types.BotServer:
+		m.BotJID = m.SenderLID
+	default:
+		for _, jid := range m.MentionedJIDs {
+			if jid.Server == types.BotServer {
+				m.BotJID = jid
+				break
+			}
+		}
+	}
 }
@@ -0,0 +1,101 @@
+package extract
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waAICommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMessageFromEventAudioWaveform(t *testing.T) {
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+	waveform := []byte{0, 25, 50, 75, 100}
+	evt := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+			ID:            "PTT1",
+			Timestamp:     time.Unix(1700000000, 0),
+		},
+		Message: &waE2E.Message{AudioMessage: &waE2E.AudioMessage{
+			Mimetype: proto.String("audio/ogg; codecs=opus"),
+			Seconds:  proto.Uint32(7),
+			PTT:      proto.Bool(true),
+			Waveform: waveform,
+		}},
+	}
+
+	m := MessageFromEvent(evt)
+	if !m.IsPTT || m.DurationSeconds != 7 || !bytes.Equal(m.Waveform, waveform) {
+		t.Errorf("extracted ptt %v, duration %d, waveform %v", m.IsPTT, m.DurationSeconds, m.Waveform)
+	}
+}
+
+func TestMessageFromEventBot(t *testing.T) {
+	bot := types.NewJID("867051314767696", types.BotServer)
+	group := types.NewJID("120363000000000001", types.GroupServer)
+	user := types.NewJID("900000000000002", types.HiddenUserServer)
+	botReply := func(text string) *waE2E.Message {
+		return &waE2E.Message{
+			Conversation: proto.String(text),
+			MessageContextInfo: &waE2E.MessageContextInfo{BotMetadata: &waAICommon.BotMetadata{
+				PersonaID:  proto.String("persona1"),
+				InvokerJID: proto.String(user.String()),
+			}},
+		}
+	}
+
+	for _, tc := range []struct {
+		name         string
+		chat, sender types.JID
+		msg          *waE2E.Message
+		botJID       types.JID
+		invoke       bool
+		prompt       string
+		invoker      types.JID
+	}{
+		{
+			name: "invoke by mention", chat: group, sender: user,
+			msg: &waE2E.Message{BotInvokeMessage: &waE2E.FutureProofMessage{Message: &waE2E.Message{
+				ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+					Text:        proto.String("@bot what's the weather"),
+					ContextInfo: &waE2E.ContextInfo{MentionedJID: []string{bot.String()}},
+				},
+			}}},
+			botJID: bot, invoke: true, prompt: "@bot what's the weather",
+		},
+		{
+			name: "reply in the bot's chat", chat: bot, sender: bot,
+			msg:    botReply("sunny"),
+			botJID: bot, invoker: user,
+		},
+		{
+			name: "reply in a group", chat: group, sender: bot,
+			msg:    botReply("sunny"),
+			botJID: bot, invoker: user,
+		},
+		{
+			name: "not a bot message", chat: group, sender: user,
+			msg: &waE2E.Message{Conversation: proto.String("hello")},
+		},
+	} {
+		m := MessageFromEvent(&events.Message{
+			Info: types.MessageInfo{
+				MessageSource: types.MessageSource{Chat: tc.chat, Sender: tc.sender, IsGroup: tc.chat == group},
+				ID:            "B1",
+				Timestamp:     time.Unix(1700000000, 0),
+			},
+			Message: tc.msg,
+		})
+		if m.BotJID != tc.botJID || m.IsBotInvoke != tc.invoke || m.BotPrompt != tc.prompt || m.BotInvokerLID != tc.invoker {
+			t.Errorf("%s: got bot %v, invoke %v, prompt %q, invoker %v", tc.name, m.BotJID, m.IsBotInvoke, m.BotPrompt, m.BotInvokerLID)
+		}
+		if !tc.botJID.IsEmpty() && !tc.invoke && m.BotPersonaID != "persona1" {
+			t.Errorf("%s: persona = %q", tc.name, m.BotPersonaID)
+		}
+	}
+}
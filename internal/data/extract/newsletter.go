@@ -57,6 +57,37 @@ func NewsletterFromMetadata(meta *types.NewsletterMetadata) *store.Newsletter {
 	return n
 }
 
+// MessageFromNewsletter extracts a store.Message from a newsletter post.
+// Returns nil if the post carries no message content (live updates usually
+// only carry view and reaction counts).
+func MessageFromNewsletter(newsletterJID types.JID, nm *types.NewsletterMessage) *store.Message {
+	if nm == nil || nm.Message == nil {
+		return nil
+	}
+
+	msg := &store.Message{
+		ID:          string(nm.MessageID),
+		ChatJID:     newsletterJID,
+		SenderLID:   newsletterJID, // Posts are authored by the channel itself
+		Timestamp:   nm.Timestamp,
+		ServerID:    int(nm.MessageServerID),
+		MessageType: determineMessageType(nm.Message),
+		CreatedAt:   time.Now(),
+	}
+
+	extractTextContent(nm.Message, msg)
+	extractMedia(nm.Message, msg)
+	extractContext(nm.Message, msg)
+	extractLocation(nm.Message, msg)
+	extractContactCard(nm.Message, msg)
+	extractPoll(nm.Message, msg)
+	extractEventMessage(nm.Message, msg)
+	extractGroupInvite(nm.Message, msg)
+	extractInteractive(nm.Message, msg)
+
+	return msg
+}
+
 // ContactFromEvent extracts full contact data from events.Contact.
 // The Contact event contains a ContactAction with the actual data.
 func ContactFromEvent(evt *events.Contact) *store.Contact {
@@ -90,20 +121,21 @@ func BlocklistFromEvent(evt *events.Blocklist) (blocked []types.JID, unblocked [
 
 // PrivacySettingsFromEvent extracts privacy settings from events.PrivacySettings.
 func PrivacySettingsFromEvent(evt *events.PrivacySettings) *store.PrivacySettings {
-	settings := &store.PrivacySettings{
-		UpdatedAt: time.Now(),
-	}
-
-	// The NewSettings is a types.PrivacySettings struct, not a slice
-	settings.GroupAdd = string(evt.NewSettings.GroupAdd)
-	settings.LastSeen = string(evt.NewSettings.LastSeen)
-	settings.Status = string(evt.NewSettings.Status)
-	settings.Profile = string(evt.NewSettings.Profile)
-	settings.ReadReceipts = string(evt.NewSettings.ReadReceipts)
-	settings.Online = string(evt.NewSettings.Online)
-	settings.CallAdd = string(evt.NewSettings.CallAdd)
+	return PrivacySettingsFromInfo(evt.NewSettings)
+}
 
-	return settings
+// PrivacySettingsFromInfo extracts privacy settings from types.PrivacySettings.
+func PrivacySettingsFromInfo(info types.PrivacySettings) *store.PrivacySettings {
+	return &store.PrivacySettings{
+		GroupAdd:     string(info.GroupAdd),
+		LastSeen:     string(info.LastSeen),
+		Status:       string(info.Status),
+		Profile:      string(info.Profile),
+		ReadReceipts: string(info.ReadReceipts),
+		Online:       string(info.Online),
+		CallAdd:      string(info.CallAdd),
+		UpdatedAt:    time.Now(),
+	}
 }
 
 // LabelFromEvent extracts label data from events.LabelEdit.
@@ -39,7 +39,7 @@ func (s *BlocklistStore) PutMany(jids []types.JID) error {
 		return nil
 	}
 
-	tx, err := s.store.Begin()
+	tx, err := s.store.BeginImmediate()
 	if err != nil {
 		return err
 	}
@@ -83,7 +83,7 @@ func (s *BlocklistStore) Unblock(jid types.JID) error {
 
 // Replace replaces the entire blocklist with new JIDs.
 func (s *BlocklistStore) Replace(jids []types.JID) error {
-	tx, err := s.store.Begin()
+	tx, err := s.store.BeginImmediate()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// BotMessage links a message to the bot it invoked or was sent by.
+type BotMessage struct {
+	MessageID  string
+	ChatJID    types.JID
+	BotJID     types.JID
+	InvokerLID types.JID
+	Prompt     string
+	PersonaID  string
+	IsInvoke   bool // true for user → bot invocations, false for bot replies
+	Timestamp  time.Time
+}
+
+// BotStore handles bot message operations.
+type BotStore struct {
+	store *Store
+}
+
+// NewBotStore creates a new BotStore.
+func NewBotStore(s *Store) *BotStore {
+	return &BotStore{store: s}
+}
+
+// PutMessage saves or updates bot metadata for a message.
+func (s *BotStore) PutMessage(b *BotMessage) error {
+	_, err := s.store.Exec(`
+		INSERT INTO orion_bot_messages (message_id, chat_jid, bot_jid, invoker_lid, prompt, persona_id, is_invoke, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(message_id, chat_jid) DO UPDATE SET
+			bot_jid = excluded.bot_jid,
+			invoker_lid = COALESCE(excluded.invoker_lid, orion_bot_messages.invoker_lid),
+			prompt = COALESCE(excluded.prompt, orion_bot_messages.prompt),
+			persona_id = COALESCE(excluded.persona_id, orion_bot_messages.persona_id),
+			is_invoke = excluded.is_invoke
+	`, b.MessageID, b.ChatJID.String(), b.BotJID.String(), nullJID(b.InvokerLID),
+		nullString(b.Prompt), nullString(b.PersonaID), boolToInt(b.IsInvoke), b.Timestamp.Unix())
+	return err
+}
+
+// GetMessage returns bot metadata for a message.
+func (s *BotStore) GetMessage(messageID string, chatJID types.JID) (*BotMessage, error) {
+	row := s.store.QueryRow(`
+		SELECT message_id, chat_jid, bot_jid, invoker_lid, prompt, persona_id, is_invoke, timestamp
+		FROM orion_bot_messages WHERE message_id = ? AND chat_jid = ?
+	`, messageID, chatJID.String())
+
+	var b BotMessage
+	var chatStr, botStr string
+	var invoker, prompt, persona sql.NullString
+	var isInvoke int
+	var ts int64
+	if err := row.Scan(&b.MessageID, &chatStr, &botStr, &invoker, &prompt, &persona, &isInvoke, &ts); err != nil {
+		return nil, err
+	}
+
+	b.ChatJID, _ = types.ParseJID(chatStr)
+	b.BotJID, _ = types.ParseJID(botStr)
+	b.InvokerLID = parseNullJID(invoker)
+	b.Prompt = prompt.String
+	b.PersonaID = persona.String
+	b.IsInvoke = isInvoke == 1
+	b.Timestamp = time.Unix(ts, 0)
+	return &b, nil
+}
+
+// GetByBot returns bot messages for a bot, newest first.
+func (s *BotStore) GetByBot(botJID types.JID, limit int) ([]*BotMessage, error) {
+	rows, err := s.store.Query(`
+		SELECT message_id, chat_jid, bot_jid, invoker_lid, prompt, persona_id, is_invoke, timestamp
+		FROM orion_bot_messages WHERE bot_jid = ?
+		ORDER BY timestamp DESC LIMIT ?
+	`, botJID.String(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*BotMessage
+	for rows.Next() {
+		var b BotMessage
+		var chatStr, botStr string
+		var invoker, prompt, persona sql.NullString
+		var isInvoke int
+		var ts int64
+		if err := rows.Scan(&b.MessageID, &chatStr, &botStr, &invoker, &prompt, &persona, &isInvoke, &ts); err != nil {
+			return nil, err
+		}
+		b.ChatJID, _ = types.ParseJID(chatStr)
+		b.BotJID, _ = types.ParseJID(botStr)
+		b.InvokerLID = parseNullJID(invoker)
+		b.Prompt = prompt.String
+		b.PersonaID = persona.String
+		b.IsInvoke = isInvoke == 1
+		b.Timestamp = time.Unix(ts, 0)
+		result = append(result, &b)
+	}
+	return result, rows.Err()
+}
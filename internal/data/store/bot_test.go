@@ -0,0 +1,53 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestBotMessages(t *testing.T) {
+	bots := NewBotStore(newTestStore(t))
+	bot := types.NewJID("867051314767696", types.BotServer)
+	other := types.NewJID("867051314767697", types.BotServer)
+	chat := types.NewJID("120363000000000001", types.GroupServer)
+	user := types.NewJID("900000000000002", types.HiddenUserServer)
+	base := time.Unix(1700000000, 0)
+
+	for _, b := range []*BotMessage{
+		{MessageID: "INVOKE", ChatJID: chat, BotJID: bot, InvokerLID: user, Prompt: "@bot hi", IsInvoke: true, Timestamp: base},
+		{MessageID: "REPLY", ChatJID: chat, BotJID: bot, PersonaID: "persona1", Timestamp: base.Add(time.Second)},
+		{MessageID: "ELSEWHERE", ChatJID: chat, BotJID: other, Timestamp: base.Add(2 * time.Second)},
+	} {
+		if err := bots.PutMessage(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A later save without the invoker keeps it
+	if err := bots.PutMessage(&BotMessage{MessageID: "INVOKE", ChatJID: chat, BotJID: bot, IsInvoke: true, Timestamp: base}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := bots.GetMessage("INVOKE", chat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.BotJID != bot || got.InvokerLID != user || got.Prompt != "@bot hi" || !got.IsInvoke || !got.Timestamp.Equal(base) {
+		t.Errorf("GetMessage = %+v", got)
+	}
+
+	byBot, err := bots.GetByBot(bot, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byBot) != 2 || byBot[0].MessageID != "REPLY" || byBot[1].MessageID != "INVOKE" {
+		t.Fatalf("GetByBot = %v, want REPLY then INVOKE", byBot)
+	}
+	if byBot[0].PersonaID != "persona1" || byBot[0].IsInvoke || !byBot[0].InvokerLID.IsEmpty() {
+		t.Errorf("reply = %+v", byBot[0])
+	}
+	if limited, _ := bots.GetByBot(bot, 1); len(limited) != 1 {
+		t.Errorf("GetByBot with limit 1 returned %d", len(limited))
+	}
+}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// BroadcastList represents a broadcast list.
+type BroadcastList struct {
+	JID            types.JID
+	Name           string
+	RecipientCount int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// BroadcastStore handles broadcast list operations.
+type BroadcastStore struct {
+	store *Store
+}
+
+// NewBroadcastStore creates a new BroadcastStore.
+func NewBroadcastStore(s *Store) *BroadcastStore {
+	return &BroadcastStore{store: s}
+}
+
+// Put stores or updates a broadcast list and replaces its recipients.
+func (s *BroadcastStore) Put(list *BroadcastList, recipients []types.JID) error {
+	seen := make(map[types.JID]bool, len(recipients))
+	unique := recipients[:0:0]
+	for _, jid := range recipients {
+		if !seen[jid] {
+			seen[jid] = true
+			unique = append(unique, jid)
+		}
+	}
+
+	tx, err := s.store.BeginImmediate()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	_, err = tx.Exec(`
+		INSERT INTO orion_broadcast_lists (jid, name, recipient_count, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			name = excluded.name,
+			recipient_count = excluded.recipient_count,
+			updated_at = excluded.updated_at
+	`, list.JID.String(), nullString(list.Name), len(unique), now, now)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM orion_broadcast_recipients WHERE broadcast_jid = ?`, list.JID.String()); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO orion_broadcast_recipients (broadcast_jid, recipient_lid) VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, jid := range unique {
+		if _, err := stmt.Exec(list.JID.String(), jid.String()); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Get returns a broadcast list, or nil if it isn't known.
+func (s *BroadcastStore) Get(jid types.JID) (*BroadcastList, error) {
+	var name sql.NullString
+	var count sql.NullInt64
+	var createdAt, updatedAt int64
+
+	err := s.store.QueryRow(`
+		SELECT name, recipient_count, created_at, updated_at
+		FROM orion_broadcast_lists WHERE jid = ?
+	`, jid.String()).Scan(&name, &count, &createdAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &BroadcastList{
+		JID:            jid,
+		Name:           name.String,
+		RecipientCount: int(count.Int64),
+		CreatedAt:      time.Unix(createdAt, 0),
+		UpdatedAt:      time.Unix(updatedAt, 0),
+	}, nil
+}
+
+// GetRecipients returns the recipients of a broadcast list.
+func (s *BroadcastStore) GetRecipients(jid types.JID) ([]types.JID, error) {
+	rows, err := s.store.Query(`
+		SELECT recipient_lid FROM orion_broadcast_recipients WHERE broadcast_jid = ?
+	`, jid.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []types.JID
+	for rows.Next() {
+		var jidStr string
+		if err := rows.Scan(&jidStr); err != nil {
+			return nil, err
+		}
+		recipient, _ := types.ParseJID(jidStr)
+		result = append(result, recipient)
+	}
+
+	return result, rows.Err()
+}
+
+// Delete removes a broadcast list and its recipients.
+func (s *BroadcastStore) Delete(jid types.JID) error {
+	tx, err := s.store.BeginImmediate()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM orion_broadcast_recipients WHERE broadcast_jid = ?`, jid.String()); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM orion_broadcast_lists WHERE jid = ?`, jid.String()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -203,6 +203,27 @@ func (s *ChatStore) SetPinned(jid types.JID, pinned bool, timestamp time.Time) e
 	return err
 }
 
+// GetPinnedJIDs returns the JIDs of all pinned chats.
+func (s *ChatStore) GetPinnedJIDs() ([]types.JID, error) {
+	rows, err := s.store.Query(`SELECT jid FROM orion_chats WHERE is_pinned = 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jids []types.JID
+	for rows.Next() {
+		var jidStr string
+		if err := rows.Scan(&jidStr); err != nil {
+			return nil, err
+		}
+		if jid, err := types.ParseJID(jidStr); err == nil {
+			jids = append(jids, jid)
+		}
+	}
+	return jids, rows.Err()
+}
+
 // SetMuted updates mute status.
 func (s *ChatStore) SetMuted(jid types.JID, mutedUntil time.Time) error {
 	now := time.Now().Unix()
@@ -281,18 +302,36 @@ func (s *ChatStore) Delete(jid types.JID) error {
 	return err
 }
 
-// Clear clears all messages but keeps the chat.
+// Clear deletes all messages of a chat, with their reactions, receipts,
+// edits and media cache entries, and resets its unread state. The chat row
+// itself is kept (unlike Delete); its last message time is left for ordering.
 func (s *ChatStore) Clear(jid types.JID) error {
+	tx, err := s.store.BeginImmediate()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	now := time.Now().Unix()
-	_, err := s.store.Exec(`
-		UPDATE orion_chats SET unread_count = 0, unread_mention_count = 0, updated_at = ? WHERE jid = ?
+	_, err = tx.Exec(`
+		UPDATE orion_chats SET
+			unread_count = 0, unread_mention_count = 0, marked_as_unread = 0, last_message_id = NULL, updated_at = ?
+		WHERE jid = ?
 	`, now, jid.String())
 	if err != nil {
 		return err
 	}
 	// Also delete messages
-	_, err = s.store.Exec(`DELETE FROM orion_messages WHERE chat_jid = ?`, jid.String())
-	return err
+	for _, table := range messageChildTables {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE chat_jid = ?`, jid.String()); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM orion_messages WHERE chat_jid = ?`, jid.String()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (s *ChatStore) scanChat(row *sql.Row) (*Chat, error) {
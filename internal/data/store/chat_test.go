@@ -0,0 +1,66 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestClearChat(t *testing.T) {
+	s := newTestStore(t)
+	chats := NewChatStore(s)
+	messages := NewMessageStore(s)
+	reactions := NewReactionStore(s)
+	receipts := NewReceiptStore(s)
+	media := NewMediaCacheStore(s)
+
+	cleared := types.NewJID("900000000000002", types.HiddenUserServer)
+	kept := types.NewJID("900000000000003", types.HiddenUserServer)
+	for _, chat := range []types.JID{cleared, kept} {
+		if err := chats.Put(&Chat{JID: chat, ChatType: ChatTypeUser, UnreadCount: 3, MarkedAsUnread: true, LastMessageAt: time.Unix(1700000000, 0)}); err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range []string{"M1", "M2"} {
+			if err := messages.Put(&Message{ID: id, ChatJID: chat, SenderLID: chat, Timestamp: time.Now(), MessageType: "image", Caption: "before"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := reactions.Put(&Reaction{MessageID: id, ChatJID: chat, SenderLID: chat, Emoji: "👍", Timestamp: time.Now()}); err != nil {
+				t.Fatal(err)
+			}
+			if err := receipts.Put(&Receipt{MessageID: id, ChatJID: chat, RecipientLID: chat, ReceiptType: "read", Timestamp: time.Now()}); err != nil {
+				t.Fatal(err)
+			}
+			if err := media.Put(&MediaCache{MessageID: id, ChatJID: chat, MediaType: "image", LocalPath: "/tmp/" + id}); err != nil {
+				t.Fatal(err)
+			}
+			if err := messages.UpdateCaption(id, chat, "after", time.Now()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := chats.Clear(cleared); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range append([]string{"orion_messages"}, messageChildTables...) {
+		for chat, want := range map[types.JID]int{cleared: 0, kept: 2} {
+			var n int
+			if err := s.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE chat_jid = ?`, chat.String()).Scan(&n); err != nil {
+				t.Fatal(err)
+			}
+			if n != want {
+				t.Errorf("%s has %d rows for %s, want %d", table, n, chat, want)
+			}
+		}
+	}
+
+	chat, err := chats.Get(cleared)
+	if err != nil {
+		t.Fatalf("chat row removed: %v", err)
+	}
+	if chat.UnreadCount != 0 || chat.MarkedAsUnread || chat.LastMessageID != "" || chat.LastMessageAt.IsZero() {
+		t.Errorf("cleared chat = %+v, want unread state reset and last message time kept", chat)
+	}
+}
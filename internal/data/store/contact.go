@@ -2,9 +2,14 @@ package store
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"go.mau.fi/whatsmeow/types"
+
+	"orion-agent/internal/data/vcard"
 )
 
 // Contact represents a complete contact with all fields.
@@ -51,14 +56,44 @@ type Contact struct {
 // ContactStore handles contact operations.
 type ContactStore struct {
 	store *Store
+
+	onMappingChanged func(pn types.JID)
+
+	// JIDs known to have no name, so unknown senders aren't queried for
+	// every message
+	unknownMu    sync.Mutex
+	unknownNames map[string]time.Time
 }
 
+// Negative display name cache settings.
+const (
+	unknownNameTTL  = 10 * time.Minute
+	maxUnknownNames = 10000
+)
+
 // NewContactStore creates a new ContactStore.
 func NewContactStore(s *Store) *ContactStore {
-	return &ContactStore{store: s}
+	return &ContactStore{store: s, unknownNames: make(map[string]time.Time)}
+}
+
+// SetMappingListener sets a callback run with the PN of every LID/PN
+// mapping written by UpdatePN and PutJIDMappings, for invalidating caches.
+func (s *ContactStore) SetMappingListener(fn func(pn types.JID)) {
+	s.onMappingChanged = fn
+}
+
+func (s *ContactStore) mappingChanged(lid, pn types.JID) {
+	s.forgetUnknownName(lid, pn)
+	if s.onMappingChanged != nil && !pn.IsEmpty() {
+		s.onMappingChanged(pn)
+	}
 }
 
 // Put stores or updates a contact.
+// Empty fields keep their stored value. Put only ever raises is_online and
+// is_business and moves last_seen forward: callers build contacts from
+// push names, business names and syncs that don't know the presence or
+// business state, so a false there means unknown, not "no".
 func (s *ContactStore) Put(c *Contact) error {
 	now := time.Now().Unix()
 	var statusSetAt, lastSeen sql.NullInt64
@@ -91,8 +126,8 @@ func (s *ContactStore) Put(c *Contact) error {
 			profile_pic_url = COALESCE(excluded.profile_pic_url, orion_contacts.profile_pic_url),
 			status = COALESCE(excluded.status, orion_contacts.status),
 			status_set_at = COALESCE(excluded.status_set_at, orion_contacts.status_set_at),
-			last_seen = COALESCE(excluded.last_seen, orion_contacts.last_seen),
-			is_online = excluded.is_online,
+			last_seen = CASE WHEN excluded.last_seen > COALESCE(orion_contacts.last_seen, 0) THEN excluded.last_seen ELSE orion_contacts.last_seen END,
+			is_online = CASE WHEN excluded.is_online = 1 THEN 1 ELSE orion_contacts.is_online END,
 			is_business = CASE WHEN excluded.is_business = 1 THEN 1 ELSE orion_contacts.is_business END,
 			business_description = COALESCE(excluded.business_description, orion_contacts.business_description),
 			business_category = COALESCE(excluded.business_category, orion_contacts.business_category),
@@ -111,7 +146,11 @@ func (s *ContactStore) Put(c *Contact) error {
 		nullString(c.BusinessEmail), nullString(c.BusinessWebsite), nullString(c.BusinessAddress),
 		nullString(c.VerifiedName), nullInt(c.VerifiedLevel), now, now,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	s.forgetUnknownName(c.LID, c.PN)
+	return nil
 }
 
 // Get retrieves a contact by matching either LID or PN.
@@ -183,7 +222,169 @@ func (s *ContactStore) GetAll() ([]*Contact, error) {
 	return contacts, nil
 }
 
+// DisplayNames holds the names a contact can be shown by.
+type DisplayNames struct {
+	Full     string
+	First    string
+	Push     string
+	Business string
+	Verified string
+}
+
+// Best returns the best name, in the order full name, first name, push
+// name, business name, verified name; "" if there is none. pushName is the
+// name a message was sent with, used ahead of the stored push name.
+func (n DisplayNames) Best(pushName string) string {
+	if pushName == "" {
+		pushName = n.Push
+	}
+	for _, name := range []string{n.Full, n.First, pushName, n.Business, n.Verified} {
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// ResolveDisplayName returns the best name for a contact (see
+// DisplayNames.Best), falling back to the JID's user part.
+func (s *ContactStore) ResolveDisplayName(jid types.JID) (string, error) {
+	name, err := s.LookupDisplayName(jid)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return jid.User, nil
+	}
+	return name, nil
+}
+
+// LookupDisplayName is ResolveDisplayName without the fallback: it returns
+// "" for contacts without a name. jid may be a LID or PN.
+// Unknown JIDs are remembered for a while, so repeated lookups don't query.
+func (s *ContactStore) LookupDisplayName(jid types.JID) (string, error) {
+	key := jid.ToNonAD().String()
+	if s.isUnknownName(key) {
+		return "", nil
+	}
+
+	names, err := s.GetDisplayNames([]types.JID{jid})
+	if err != nil {
+		return "", err
+	}
+	if name := names[jid.ToNonAD()].Best(""); name != "" {
+		return name, nil
+	}
+	s.markUnknownName(key)
+	return "", nil
+}
+
+// displayNamesBatch bounds the JIDs looked up per query.
+const displayNamesBatch = 400
+
+// GetDisplayNames returns the names of the contacts among jids, keyed by
+// the JID as given (without device). jids may be LIDs or PNs; a contact
+// stored under the JID as its LID wins over one with it as its PN.
+func (s *ContactStore) GetDisplayNames(jids []types.JID) (map[types.JID]DisplayNames, error) {
+	result := make(map[types.JID]DisplayNames, len(jids))
+	for start := 0; start < len(jids); start += displayNamesBatch {
+		batch := jids[start:min(start+displayNamesBatch, len(jids))]
+		if err := s.getDisplayNames(batch, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (s *ContactStore) getDisplayNames(jids []types.JID, result map[types.JID]DisplayNames) error {
+	wanted := make(map[string]types.JID, len(jids))
+	placeholders := make([]string, 0, len(jids))
+	args := make([]any, 0, 2*len(jids))
+	for _, jid := range jids {
+		jid = jid.ToNonAD()
+		if _, ok := wanted[jid.String()]; ok || jid.IsEmpty() {
+			continue
+		}
+		wanted[jid.String()] = jid
+		placeholders = append(placeholders, "?")
+		args = append(args, jid.String())
+	}
+	if len(placeholders) == 0 {
+		return nil
+	}
+	in := strings.Join(placeholders, ", ")
+	args = append(args, args...)
+
+	rows, err := s.store.Query(`
+		SELECT lid, pn, full_name, first_name, push_name, business_name, verified_name
+		FROM orion_contacts WHERE lid IN (`+in+`) OR pn IN (`+in+`)
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byLID := make(map[types.JID]bool)
+	for rows.Next() {
+		var lid string
+		var pn, fullName, firstName, pushName, businessName, verifiedName sql.NullString
+		if err := rows.Scan(&lid, &pn, &fullName, &firstName, &pushName, &businessName, &verifiedName); err != nil {
+			return err
+		}
+		names := DisplayNames{
+			Full:     fullName.String,
+			First:    firstName.String,
+			Push:     pushName.String,
+			Business: businessName.String,
+			Verified: verifiedName.String,
+		}
+		if jid, ok := wanted[lid]; ok {
+			result[jid] = names
+			byLID[jid] = true
+		}
+		if jid, ok := wanted[pn.String]; ok && pn.Valid && !byLID[jid] {
+			result[jid] = names
+		}
+	}
+	return rows.Err()
+}
+
+func (s *ContactStore) isUnknownName(key string) bool {
+	s.unknownMu.Lock()
+	defer s.unknownMu.Unlock()
+	expires, ok := s.unknownNames[key]
+	if ok && time.Now().After(expires) {
+		delete(s.unknownNames, key)
+		return false
+	}
+	return ok
+}
+
+func (s *ContactStore) markUnknownName(key string) {
+	s.unknownMu.Lock()
+	defer s.unknownMu.Unlock()
+	if len(s.unknownNames) >= maxUnknownNames {
+		s.unknownNames = make(map[string]time.Time)
+	}
+	s.unknownNames[key] = time.Now().Add(unknownNameTTL)
+}
+
+// forgetUnknownName drops jids from the unknown name cache after their
+// contact was written.
+func (s *ContactStore) forgetUnknownName(jids ...types.JID) {
+	s.unknownMu.Lock()
+	defer s.unknownMu.Unlock()
+	for _, jid := range jids {
+		if !jid.IsEmpty() {
+			delete(s.unknownNames, jid.ToNonAD().String())
+		}
+	}
+}
+
 // UpdatePresence updates online/last seen status.
+// An offline update with a last seen older than the stored one arrived out
+// of order and is ignored, so it can't mark an online contact offline or
+// move last_seen back.
 func (s *ContactStore) UpdatePresence(lid types.JID, isOnline bool, lastSeen time.Time) error {
 	now := time.Now().Unix()
 	var lastSeenTs sql.NullInt64
@@ -192,8 +393,12 @@ func (s *ContactStore) UpdatePresence(lid types.JID, isOnline bool, lastSeen tim
 		lastSeenTs.Valid = true
 	}
 	_, err := s.store.Exec(`
-		UPDATE orion_contacts SET is_online = ?, last_seen = COALESCE(?, last_seen), updated_at = ? WHERE lid = ?
-	`, boolToInt(isOnline), lastSeenTs, now, lid.String())
+		UPDATE orion_contacts SET
+			is_online = ?,
+			last_seen = CASE WHEN ? > COALESCE(last_seen, 0) THEN ? ELSE last_seen END,
+			updated_at = ?
+		WHERE lid = ? AND NOT (? = 0 AND COALESCE(? < last_seen, 0))
+	`, boolToInt(isOnline), lastSeenTs, lastSeenTs, now, lid.String(), boolToInt(isOnline), lastSeenTs)
 	return err
 }
 
@@ -205,7 +410,11 @@ func (s *ContactStore) UpdatePushName(lid types.JID, pushName string) error {
 		VALUES (?, ?, ?, ?)
 		ON CONFLICT(lid) DO UPDATE SET push_name = excluded.push_name, updated_at = excluded.updated_at
 	`, lid.String(), pushName, now, now)
-	return err
+	if err != nil {
+		return err
+	}
+	s.forgetUnknownName(lid)
+	return nil
 }
 
 // UpdateBusinessName updates the business name.
@@ -216,7 +425,11 @@ func (s *ContactStore) UpdateBusinessName(lid types.JID, businessName string) er
 		VALUES (?, ?, 1, ?, ?)
 		ON CONFLICT(lid) DO UPDATE SET business_name = excluded.business_name, is_business = 1, updated_at = excluded.updated_at
 	`, lid.String(), businessName, now, now)
-	return err
+	if err != nil {
+		return err
+	}
+	s.forgetUnknownName(lid)
+	return nil
 }
 
 // UpdateProfilePic updates the profile picture.
@@ -436,7 +649,11 @@ func (s *ContactStore) UpdatePN(lid, pn types.JID) error {
 		VALUES (?, ?, ?, ?)
 		ON CONFLICT(lid) DO UPDATE SET pn = excluded.pn, updated_at = excluded.updated_at
 	`, lid.String(), pn.String(), now, now)
-	return err
+	if err != nil {
+		return err
+	}
+	s.mappingChanged(lid, pn)
+	return nil
 }
 
 // PutJIDMappings stores multiple JID mappings.
@@ -444,7 +661,7 @@ func (s *ContactStore) PutJIDMappings(mappings []JIDMapping) error {
 	if len(mappings) == 0 {
 		return nil
 	}
-	tx, err := s.store.Begin()
+	tx, err := s.store.BeginImmediate()
 	if err != nil {
 		return err
 	}
@@ -467,7 +684,13 @@ func (s *ContactStore) PutJIDMappings(mappings []JIDMapping) error {
 		}
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, m := range mappings {
+		s.mappingChanged(m.LID, m.PN)
+	}
+	return nil
 }
 
 // Exists checks if a contact exists by matching either LID or PN.
@@ -477,3 +700,40 @@ func (s *ContactStore) Exists(jid types.JID) (bool, error) {
 	err := s.store.QueryRow(`SELECT COUNT(*) FROM orion_contacts WHERE lid = ? OR pn = ?`, jidStr, jidStr).Scan(&count)
 	return count > 0, err
 }
+
+// ImportFromVCard upserts the WhatsApp user of a contact card.
+// The contact is keyed by its LID when the phone number mapping is known,
+// otherwise by the phone number JID, like NormalizeJID does.
+func (s *ContactStore) ImportFromVCard(card string) (*Contact, error) {
+	parsed, err := vcard.ParseVCard(card)
+	if err != nil {
+		return nil, err
+	}
+
+	user := parsed.WhatsAppUser()
+	if user == "" {
+		return nil, fmt.Errorf("vCard has no WhatsApp number or international phone number")
+	}
+
+	pn := types.NewJID(user, types.DefaultUserServer)
+	lid, err := s.GetLIDForPN(pn)
+	if err != nil {
+		return nil, err
+	}
+	if lid.IsEmpty() {
+		lid = pn
+	}
+
+	c := &Contact{
+		LID:       lid,
+		FullName:  parsed.FullName,
+		FirstName: parsed.FirstName,
+	}
+	if lid != pn {
+		c.PN = pn
+	}
+	if err := s.Put(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestDisplayNamesBest(t *testing.T) {
+	tests := []struct {
+		names    DisplayNames
+		pushName string
+		want     string
+	}{
+		{DisplayNames{Full: "Alice Smith", First: "Alice", Push: "al"}, "ally", "Alice Smith"},
+		{DisplayNames{First: "Alice", Push: "al"}, "", "Alice"},
+		{DisplayNames{Push: "al", Business: "Alice's Shop"}, "", "al"},
+		{DisplayNames{Business: "Alice's Shop", Verified: "Shop Inc"}, "ally", "ally"},
+		{DisplayNames{Business: "Alice's Shop", Verified: "Shop Inc"}, "", "Alice's Shop"},
+		{DisplayNames{Verified: "Shop Inc"}, "", "Shop Inc"},
+		{DisplayNames{}, "", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.names.Best(tt.pushName); got != tt.want {
+			t.Errorf("%+v.Best(%q) = %q, want %q", tt.names, tt.pushName, got, tt.want)
+		}
+	}
+}
+
+func TestGetDisplayNames(t *testing.T) {
+	contacts := NewContactStore(newTestStore(t))
+
+	aliceLID := types.NewJID("100", types.HiddenUserServer)
+	alicePN := types.NewJID("15550000100", types.DefaultUserServer)
+	bobLID := types.NewJID("200", types.HiddenUserServer)
+	unknown := types.NewJID("300", types.HiddenUserServer)
+	for _, c := range []*Contact{
+		{LID: aliceLID, PN: alicePN, FullName: "Alice Smith"},
+		{LID: bobLID, BusinessName: "Bob's Bikes"},
+	} {
+		if err := contacts.Put(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	device := aliceLID
+	device.Device = 3
+	names, err := contacts.GetDisplayNames([]types.JID{device, alicePN, bobLID, unknown})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names[aliceLID].Full; got != "Alice Smith" {
+		t.Errorf("by LID: got %q", got)
+	}
+	if got := names[alicePN].Full; got != "Alice Smith" {
+		t.Errorf("by PN: got %q", got)
+	}
+	if got := names[bobLID].Best(""); got != "Bob's Bikes" {
+		t.Errorf("bob: got %q", got)
+	}
+	if _, ok := names[unknown]; ok {
+		t.Error("unknown JID has names")
+	}
+
+	if name, err := contacts.ResolveDisplayName(unknown); err != nil || name != "300" {
+		t.Errorf("ResolveDisplayName(unknown) = %q, %v; want the user part", name, err)
+	}
+}
+
+// TestContactStateNeverDowngrades replays events out of order and checks
+// business and presence state only move forward.
+func TestContactStateNeverDowngrades(t *testing.T) {
+	s := newTestStore(t)
+	contacts := NewContactStore(s)
+	lid := types.NewJID("900000000000002", types.HiddenUserServer)
+	t1, t2, t3 := time.Unix(1700000000, 0), time.Unix(1700000100, 0), time.Unix(1700000200, 0)
+
+	get := func() *Contact {
+		t.Helper()
+		c, err := contacts.Get(lid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	if err := contacts.UpdateBusinessName(lid, "Alice's Shop"); err != nil {
+		t.Fatal(err)
+	}
+	// A later upsert that knows nothing of the business, e.g. from a push name
+	if err := contacts.Put(&Contact{LID: lid, PushName: "al", LastSeen: t2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := contacts.UpdatePushName(lid, "ally"); err != nil {
+		t.Fatal(err)
+	}
+	if c := get(); !c.IsBusiness || c.BusinessName != "Alice's Shop" || c.PushName != "ally" {
+		t.Errorf("after push name updates: business %v %q, push %q", c.IsBusiness, c.BusinessName, c.PushName)
+	}
+
+	// An upsert carrying an older last seen doesn't move it back
+	if err := contacts.Put(&Contact{LID: lid, LastSeen: t1}); err != nil {
+		t.Fatal(err)
+	}
+	if c := get(); !c.LastSeen.Equal(t2) {
+		t.Errorf("last seen = %v after stale upsert, want %v", c.LastSeen, t2)
+	}
+
+	// Online, then an upsert without presence doesn't take it offline
+	if err := contacts.UpdatePresence(lid, true, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := contacts.Put(&Contact{LID: lid, PushName: "ally"}); err != nil {
+		t.Fatal(err)
+	}
+	if c := get(); !c.IsOnline {
+		t.Error("upsert without presence took the contact offline")
+	}
+
+	// A stale offline event, older than the last seen, is ignored
+	if err := contacts.UpdatePresence(lid, false, t1); err != nil {
+		t.Fatal(err)
+	}
+	if c := get(); !c.IsOnline || !c.LastSeen.Equal(t2) {
+		t.Errorf("after stale offline: online %v, last seen %v; want online, %v", c.IsOnline, c.LastSeen, t2)
+	}
+
+	// A newer one goes through
+	if err := contacts.UpdatePresence(lid, false, t3); err != nil {
+		t.Fatal(err)
+	}
+	if c := get(); c.IsOnline || !c.LastSeen.Equal(t3) {
+		t.Errorf("after offline: online %v, last seen %v; want offline, %v", c.IsOnline, c.LastSeen, t3)
+	}
+}
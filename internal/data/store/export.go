@@ -0,0 +1,100 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// exportQuery selects every message column plus the message's reactions and
+// receipts, aggregated to JSON arrays so each message is a single row.
+const exportQuery = `
+	SELECT m.*,
+		(SELECT json_group_array(json_object('sender_lid', r.sender_lid, 'emoji', r.emoji, 'timestamp', r.timestamp))
+			FROM orion_reactions r WHERE r.message_id = m.id AND r.chat_jid = m.chat_jid) AS reactions,
+		(SELECT json_group_array(json_object('recipient_lid', rc.recipient_lid, 'receipt_type', rc.receipt_type, 'timestamp', rc.timestamp))
+			FROM orion_message_receipts rc WHERE rc.message_id = m.id AND rc.chat_jid = m.chat_jid) AS receipts
+	FROM orion_messages m`
+
+// exportJSONColumns are the columns that hold JSON, embedded as-is.
+var exportJSONColumns = map[string]bool{
+	"mentioned_jids": true,
+	"group_mentions": true,
+	"poll_options":   true,
+	"vcards":         true,
+	"reactions":      true,
+	"receipts":       true,
+}
+
+// ExportChat writes a chat's messages to w as JSONL, oldest first.
+// Each line holds every stored column (NULLs omitted, blobs base64-encoded)
+// with the message's reactions and receipts. Rows are streamed, so large
+// chats don't have to fit in memory.
+func (s *MessageStore) ExportChat(chatJID types.JID, w io.Writer) error {
+	return s.export(w, exportQuery+` WHERE m.chat_jid = ? ORDER BY m.timestamp, m.id`, chatJID.String())
+}
+
+// ExportAllChats writes every stored message to w as JSONL, grouped by chat.
+// See ExportChat for the line format.
+func (s *MessageStore) ExportAllChats(w io.Writer) error {
+	return s.export(w, exportQuery+` ORDER BY m.chat_jid, m.timestamp, m.id`)
+}
+
+func (s *MessageStore) export(w io.Writer, query string, args ...interface{}) error {
+	rows, err := s.store.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+
+		line := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			switch v := values[i].(type) {
+			case nil:
+			case string:
+				if exportJSONColumns[col] && json.Valid([]byte(v)) {
+					line[col] = json.RawMessage(v)
+				} else {
+					line[col] = v
+				}
+			case []byte:
+				if exportJSONColumns[col] && json.Valid(v) {
+					line[col] = json.RawMessage(v)
+				} else {
+					line[col] = v
+				}
+			default:
+				line[col] = v
+			}
+		}
+
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// FailedSend represents an outgoing message that failed to send.
+type FailedSend struct {
+	ID            int64
+	MessageID     string
+	ChatJID       types.JID
+	MessageType   string
+	Payload       []byte // Serialized waE2E.Message
+	Error         string
+	IsPermanent   bool // Permanent failures (blocked, invalid recipient) are not retried
+	Attempts      int
+	CreatedAt     time.Time
+	LastAttemptAt time.Time
+}
+
+// FailedSendStore handles failed send persistence.
+type FailedSendStore struct {
+	store *Store
+}
+
+// NewFailedSendStore creates a new FailedSendStore.
+func NewFailedSendStore(s *Store) *FailedSendStore {
+	return &FailedSendStore{store: s}
+}
+
+// Put stores a new failed send.
+func (s *FailedSendStore) Put(f *FailedSend) error {
+	now := time.Now().Unix()
+	attempts := f.Attempts
+	if attempts == 0 {
+		attempts = 1
+	}
+	result, err := s.store.Exec(`
+		INSERT INTO orion_failed_sends (message_id, chat_jid, message_type, payload, error, is_permanent, attempts, created_at, last_attempt_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		f.MessageID, f.ChatJID.String(), nullString(f.MessageType), f.Payload, nullString(f.Error),
+		boolToInt(f.IsPermanent), attempts, now, now,
+	)
+	if err != nil {
+		return err
+	}
+	f.ID, _ = result.LastInsertId()
+	f.Attempts = attempts
+	f.CreatedAt = time.Unix(now, 0)
+	f.LastAttemptAt = f.CreatedAt
+	return nil
+}
+
+// GetRetryable returns transient failures with fewer than maxAttempts attempts, oldest first.
+func (s *FailedSendStore) GetRetryable(maxAttempts, limit int) ([]*FailedSend, error) {
+	rows, err := s.store.Query(`
+		SELECT id, message_id, chat_jid, message_type, payload, error, is_permanent, attempts, created_at, last_attempt_at
+		FROM orion_failed_sends
+		WHERE is_permanent = 0 AND attempts < ?
+		ORDER BY created_at ASC LIMIT ?`,
+		maxAttempts, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return s.scanRows(rows)
+}
+
+// CountRetryable returns the number of transient failures with fewer than maxAttempts attempts.
+func (s *FailedSendStore) CountRetryable(maxAttempts int) (int, error) {
+	var count int
+	err := s.store.QueryRow(`
+		SELECT COUNT(*) FROM orion_failed_sends WHERE is_permanent = 0 AND attempts < ?`,
+		maxAttempts,
+	).Scan(&count)
+	return count, err
+}
+
+// GetAll returns all failed sends, newest first.
+func (s *FailedSendStore) GetAll(limit int) ([]*FailedSend, error) {
+	rows, err := s.store.Query(`
+		SELECT id, message_id, chat_jid, message_type, payload, error, is_permanent, attempts, created_at, last_attempt_at
+		FROM orion_failed_sends ORDER BY created_at DESC LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return s.scanRows(rows)
+}
+
+// RecordAttempt records another failed attempt.
+func (s *FailedSendStore) RecordAttempt(id int64, errMsg string, permanent bool) error {
+	_, err := s.store.Exec(`
+		UPDATE orion_failed_sends SET attempts = attempts + 1, error = ?, is_permanent = ?, last_attempt_at = ?
+		WHERE id = ?`,
+		nullString(errMsg), boolToInt(permanent), time.Now().Unix(), id,
+	)
+	return err
+}
+
+// Delete removes a failed send (after a successful retry or manual discard).
+func (s *FailedSendStore) Delete(id int64) error {
+	_, err := s.store.Exec(`DELETE FROM orion_failed_sends WHERE id = ?`, id)
+	return err
+}
+
+func (s *FailedSendStore) scanRows(rows *sql.Rows) ([]*FailedSend, error) {
+	var result []*FailedSend
+	for rows.Next() {
+		var f FailedSend
+		var chatStr string
+		var msgType, errMsg sql.NullString
+		var isPermanent int
+		var createdAt, lastAttemptAt int64
+		if err := rows.Scan(&f.ID, &f.MessageID, &chatStr, &msgType, &f.Payload, &errMsg,
+			&isPermanent, &f.Attempts, &createdAt, &lastAttemptAt); err != nil {
+			return nil, err
+		}
+		f.ChatJID, _ = types.ParseJID(chatStr)
+		f.MessageType = msgType.String
+		f.Error = errMsg.String
+		f.IsPermanent = isPermanent == 1
+		f.CreatedAt = time.Unix(createdAt, 0)
+		f.LastAttemptAt = time.Unix(lastAttemptAt, 0)
+		result = append(result, &f)
+	}
+	return result, rows.Err()
+}
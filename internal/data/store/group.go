@@ -77,6 +77,12 @@ type PastParticipant struct {
 	LeaveTimestamp time.Time
 }
 
+// Leave reasons of a PastParticipant, as in waHistorySync.PastParticipant_LeaveReason.
+const (
+	LeaveReasonLeft    = 0
+	LeaveReasonRemoved = 1
+)
+
 // GroupStore handles group operations.
 type GroupStore struct {
 	store *Store
@@ -211,6 +217,62 @@ func (s *GroupStore) GetAll() ([]*Group, error) {
 	return groups, nil
 }
 
+// GetSubgroups retrieves groups linked to a community parent, excluding the parent itself.
+func (s *GroupStore) GetSubgroups(parentJID types.JID) ([]*Group, error) {
+	parent := parentJID.ToNonAD().String()
+	rows, err := s.store.Query(`
+		SELECT jid, name, name_set_at, name_set_by_lid,
+			topic, topic_id, topic_set_at, topic_set_by_lid,
+			owner_lid, created_at_wa, created_by_lid,
+			is_announce, is_locked, is_incognito, ephemeral_duration, member_add_mode,
+			is_community, is_parent_group, parent_group_jid, is_default_subgroup, linked_parent_jid,
+			participant_count,
+			invite_link, invite_code, invite_expiration,
+			profile_pic_id, profile_pic_url,
+			created_at, updated_at
+		FROM orion_groups
+		WHERE (parent_group_jid = ? OR linked_parent_jid = ?) AND jid != ?
+		ORDER BY is_default_subgroup DESC, name
+	`, parent, parent, parent)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []*Group
+	for rows.Next() {
+		g, err := s.scanGroupRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// GetParentCommunity retrieves the community a group belongs to.
+// Returns nil, nil if the group is not linked to a community.
+func (s *GroupStore) GetParentCommunity(groupJID types.JID) (*Group, error) {
+	var parentGroupJID, linkedParentJID sql.NullString
+	err := s.store.QueryRow(`
+		SELECT parent_group_jid, linked_parent_jid FROM orion_groups WHERE jid = ?
+	`, groupJID.ToNonAD().String()).Scan(&parentGroupJID, &linkedParentJID)
+	if err != nil {
+		return nil, err
+	}
+
+	parentStr := coalesceString(linkedParentJID.String, parentGroupJID.String)
+	if parentStr == "" || parentStr == groupJID.ToNonAD().String() {
+		return nil, nil
+	}
+
+	parent, err := types.ParseJID(parentStr)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(parent)
+}
+
 // PutParticipant stores or updates a group participant.
 func (s *GroupStore) PutParticipant(p *GroupParticipant) error {
 	var joinedAt sql.NullInt64
@@ -234,7 +296,7 @@ func (s *GroupStore) PutParticipant(p *GroupParticipant) error {
 
 // PutParticipants stores or updates multiple participants.
 func (s *GroupStore) PutParticipants(participants []GroupParticipant) error {
-	tx, err := s.store.Begin()
+	tx, err := s.store.BeginImmediate()
 	if err != nil {
 		return err
 	}
@@ -283,8 +345,8 @@ func (s *GroupStore) GetParticipants(groupJID types.JID) ([]GroupParticipant, er
 	for rows.Next() {
 		var groupJIDStr, memberLIDStr string
 		var displayName, addedByLID sql.NullString
-		var isAdmin, isSuperAdmin, errorCode int
-		var joinedAt sql.NullInt64
+		var isAdmin, isSuperAdmin int
+		var joinedAt, errorCode sql.NullInt64
 
 		if err := rows.Scan(&groupJIDStr, &memberLIDStr, &isAdmin, &isSuperAdmin, &displayName, &joinedAt, &errorCode, &addedByLID); err != nil {
 			return nil, err
@@ -299,7 +361,7 @@ func (s *GroupStore) GetParticipants(groupJID types.JID) ([]GroupParticipant, er
 			IsAdmin:      isAdmin == 1,
 			IsSuperAdmin: isSuperAdmin == 1,
 			DisplayName:  displayName.String,
-			ErrorCode:    errorCode,
+			ErrorCode:    int(errorCode.Int64),
 		}
 		if joinedAt.Valid {
 			p.JoinedAt = time.Unix(joinedAt.Int64, 0)
@@ -313,6 +375,38 @@ func (s *GroupStore) GetParticipants(groupJID types.JID) ([]GroupParticipant, er
 	return participants, nil
 }
 
+// GetParticipant retrieves a single participant of a group.
+func (s *GroupStore) GetParticipant(groupJID, memberLID types.JID) (*GroupParticipant, error) {
+	row := s.store.QueryRow(`
+		SELECT is_admin, is_superadmin, display_name, joined_at, error_code, added_by_lid
+		FROM orion_group_participants WHERE group_jid = ? AND member_lid = ?
+	`, groupJID.String(), memberLID.String())
+
+	var displayName, addedByLID sql.NullString
+	var isAdmin, isSuperAdmin int
+	var joinedAt, errorCode sql.NullInt64
+
+	if err := row.Scan(&isAdmin, &isSuperAdmin, &displayName, &joinedAt, &errorCode, &addedByLID); err != nil {
+		return nil, err
+	}
+
+	p := &GroupParticipant{
+		GroupJID:     groupJID,
+		MemberLID:    memberLID,
+		IsAdmin:      isAdmin == 1,
+		IsSuperAdmin: isSuperAdmin == 1,
+		DisplayName:  displayName.String,
+		ErrorCode:    int(errorCode.Int64),
+	}
+	if joinedAt.Valid {
+		p.JoinedAt = time.Unix(joinedAt.Int64, 0)
+	}
+	if addedByLID.Valid {
+		p.AddedByLID, _ = types.ParseJID(addedByLID.String)
+	}
+	return p, nil
+}
+
 // RemoveParticipant removes a participant from a group.
 func (s *GroupStore) RemoveParticipant(groupJID, memberLID types.JID) error {
 	_, err := s.store.Exec(`DELETE FROM orion_group_participants WHERE group_jid = ? AND member_lid = ?`,
@@ -336,6 +430,130 @@ func (s *GroupStore) PutPastParticipant(p *PastParticipant) error {
 	return err
 }
 
+// GetPastParticipants retrieves everyone who left a group, most recent first.
+// A member who left several times has an entry for each.
+func (s *GroupStore) GetPastParticipants(groupJID types.JID) ([]PastParticipant, error) {
+	rows, err := s.store.Query(`
+		SELECT member_lid, leave_reason, leave_timestamp
+		FROM orion_past_participants WHERE group_jid = ?
+		ORDER BY leave_timestamp DESC
+	`, groupJID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []PastParticipant
+	for rows.Next() {
+		var memberLIDStr string
+		var leaveReason, leaveTimestamp sql.NullInt64
+		if err := rows.Scan(&memberLIDStr, &leaveReason, &leaveTimestamp); err != nil {
+			return nil, err
+		}
+
+		memberLID, _ := types.ParseJID(memberLIDStr)
+		p := PastParticipant{
+			GroupJID:    groupJID,
+			MemberLID:   memberLID,
+			LeaveReason: int(leaveReason.Int64),
+		}
+		if leaveTimestamp.Valid {
+			p.LeaveTimestamp = time.Unix(leaveTimestamp.Int64, 0)
+		}
+		result = append(result, p)
+	}
+	return result, rows.Err()
+}
+
+// ReconcileParticipants replaces a group's stored participants with current,
+// a freshly-fetched list, in one transaction. Members no longer present are
+// moved to orion_past_participants. Returns who was added and removed.
+func (s *GroupStore) ReconcileParticipants(groupJID types.JID, current []GroupParticipant) (added, removed []types.JID, err error) {
+	tx, err := s.store.BeginImmediate()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT member_lid FROM orion_group_participants WHERE group_jid = ?`, groupJID.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	stored := make(map[types.JID]bool)
+	for rows.Next() {
+		var memberStr string
+		if err := rows.Scan(&memberStr); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		if member, err := types.ParseJID(memberStr); err == nil {
+			stored[member] = true
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO orion_group_participants (group_jid, member_lid, is_admin, is_superadmin, display_name, joined_at, error_code, added_by_lid)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(group_jid, member_lid) DO UPDATE SET
+			is_admin = excluded.is_admin,
+			is_superadmin = excluded.is_superadmin,
+			display_name = COALESCE(excluded.display_name, orion_group_participants.display_name),
+			error_code = excluded.error_code
+	`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer stmt.Close()
+
+	present := make(map[types.JID]bool, len(current))
+	for _, p := range current {
+		present[p.MemberLID] = true
+		if !stored[p.MemberLID] {
+			added = append(added, p.MemberLID)
+		}
+
+		var joinedAt sql.NullInt64
+		if !p.JoinedAt.IsZero() {
+			joinedAt.Int64 = p.JoinedAt.Unix()
+			joinedAt.Valid = true
+		}
+		if _, err := stmt.Exec(groupJID.String(), p.MemberLID.String(), boolToInt(p.IsAdmin), boolToInt(p.IsSuperAdmin),
+			nullString(p.DisplayName), joinedAt, nullInt(p.ErrorCode), nullJID(p.AddedByLID)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	now := time.Now().Unix()
+	for member := range stored {
+		if present[member] {
+			continue
+		}
+		removed = append(removed, member)
+
+		if _, err := tx.Exec(`DELETE FROM orion_group_participants WHERE group_jid = ? AND member_lid = ?`,
+			groupJID.String(), member.String()); err != nil {
+			return nil, nil, err
+		}
+		// How they left is unknown after a desync, so it's recorded as leaving
+		if _, err := tx.Exec(`
+			INSERT INTO orion_past_participants (group_jid, member_lid, leave_reason, leave_timestamp)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(group_jid, member_lid, leave_timestamp) DO NOTHING
+		`, groupJID.String(), member.String(), LeaveReasonLeft, now); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return added, removed, nil
+}
+
 // UpdateInviteLink updates the group invite link.
 func (s *GroupStore) UpdateInviteLink(jid types.JID, link, code string, expiration time.Time) error {
 	now := time.Now().Unix()
@@ -350,6 +568,29 @@ func (s *GroupStore) UpdateInviteLink(jid types.JID, link, code string, expirati
 	return err
 }
 
+// SetName updates the group name.
+func (s *GroupStore) SetName(jid types.JID, name string, setBy types.JID, setAt time.Time) error {
+	_, err := s.store.Exec(`
+		UPDATE orion_groups SET name = ?, name_set_at = ?, name_set_by_lid = ?, updated_at = ? WHERE jid = ?
+	`, name, setAt.Unix(), nullJID(setBy), time.Now().Unix(), jid.String())
+	return err
+}
+
+// SetTopic updates the group topic (description).
+func (s *GroupStore) SetTopic(jid types.JID, topic, topicID string, setBy types.JID, setAt time.Time) error {
+	_, err := s.store.Exec(`
+		UPDATE orion_groups SET topic = ?, topic_id = ?, topic_set_at = ?, topic_set_by_lid = ?, updated_at = ? WHERE jid = ?
+	`, nullString(topic), nullString(topicID), setAt.Unix(), nullJID(setBy), time.Now().Unix(), jid.String())
+	return err
+}
+
+// SetAnnounce updates whether only admins can send messages.
+func (s *GroupStore) SetAnnounce(jid types.JID, announce bool) error {
+	_, err := s.store.Exec(`UPDATE orion_groups SET is_announce = ?, updated_at = ? WHERE jid = ?`,
+		boolToInt(announce), time.Now().Unix(), jid.String())
+	return err
+}
+
 // UpdateProfilePic updates the group profile picture.
 func (s *GroupStore) UpdateProfilePic(jid types.JID, picID, picURL string) error {
 	now := time.Now().Unix()
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestCommunityNavigation(t *testing.T) {
+	s := newTestStore(t)
+	groups := NewGroupStore(s)
+
+	community := types.NewJID("120363000000000001", types.GroupServer)
+	announce := types.NewJID("120363000000000002", types.GroupServer)
+	subgroup := types.NewJID("120363000000000003", types.GroupServer)
+	other := types.NewJID("120363000000000004", types.GroupServer)
+	for _, g := range []*Group{
+		{JID: community, Name: "Community", IsCommunity: true, IsParentGroup: true},
+		{JID: subgroup, Name: "Alpha", ParentGroupJID: community},
+		{JID: announce, Name: "Zulu", IsDefaultSubgroup: true, LinkedParentJID: community},
+		{JID: other, Name: "Other"},
+	} {
+		if err := groups.Put(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	subgroups, err := groups.GetSubgroups(community)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subgroups) != 2 || subgroups[0].JID != announce || subgroups[1].JID != subgroup {
+		t.Errorf("subgroups = %v, want the default subgroup then Alpha", subgroups)
+	}
+
+	for jid, want := range map[types.JID]types.JID{
+		subgroup:  community,
+		announce:  community,
+		community: types.EmptyJID,
+		other:     types.EmptyJID,
+	} {
+		parent, err := groups.GetParentCommunity(jid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got types.JID
+		if parent != nil {
+			got = parent.JID
+		}
+		if got != want {
+			t.Errorf("GetParentCommunity(%s) = %s, want %s", jid, got, want)
+		}
+	}
+}
+
+func TestGetParticipantWithoutErrorCode(t *testing.T) {
+	s := newTestStore(t)
+	groups := NewGroupStore(s)
+	group := types.NewJID("120363000000000001", types.GroupServer)
+	admin := types.NewJID("900000000000001", types.HiddenUserServer)
+	member := types.NewJID("900000000000002", types.HiddenUserServer)
+
+	if err := groups.PutParticipant(&GroupParticipant{GroupJID: group, MemberLID: admin, IsAdmin: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := groups.PutParticipants([]GroupParticipant{{GroupJID: group, MemberLID: member, ErrorCode: 403}}); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := groups.GetParticipant(group, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsAdmin || p.ErrorCode != 0 {
+		t.Errorf("GetParticipant = %+v, want an admin without an error code", p)
+	}
+
+	all, err := groups.GetParticipants(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes := make(map[types.JID]int)
+	for _, p := range all {
+		codes[p.MemberLID] = p.ErrorCode
+	}
+	if len(codes) != 2 || codes[admin] != 0 || codes[member] != 403 {
+		t.Errorf("GetParticipants error codes = %v", codes)
+	}
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// IdempotencyRecord links an idempotency key to the message sent with it.
+type IdempotencyRecord struct {
+	Key       string
+	MessageID string
+	ChatJID   types.JID
+	ServerID  int
+	Sender    types.JID
+	Timestamp time.Time // Zero while the send is in progress
+	CreatedAt time.Time
+}
+
+// IdempotencyStore handles send idempotency keys.
+type IdempotencyStore struct {
+	store *Store
+}
+
+// NewIdempotencyStore creates a new IdempotencyStore.
+func NewIdempotencyStore(s *Store) *IdempotencyStore {
+	return &IdempotencyStore{store: s}
+}
+
+// Reserve claims key for r.MessageID. Keys older than window are expired first.
+// If the key is already claimed, the existing record is returned and nothing
+// is stored; otherwise it returns nil.
+func (s *IdempotencyStore) Reserve(r *IdempotencyRecord, window time.Duration) (*IdempotencyRecord, error) {
+	tx, err := s.store.BeginImmediate()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.Exec(`DELETE FROM orion_sent_idempotency WHERE created_at < ?`, now.Add(-window).Unix()); err != nil {
+		return nil, err
+	}
+
+	var existing IdempotencyRecord
+	var chatStr string
+	var serverID, timestamp sql.NullInt64
+	var sender sql.NullString
+	var createdAt int64
+	err = tx.QueryRow(`
+		SELECT key, message_id, chat_jid, server_id, sender, timestamp, created_at
+		FROM orion_sent_idempotency WHERE key = ?`, r.Key,
+	).Scan(&existing.Key, &existing.MessageID, &chatStr, &serverID, &sender, &timestamp, &createdAt)
+	if err == nil {
+		existing.ChatJID, _ = types.ParseJID(chatStr)
+		existing.ServerID = int(serverID.Int64)
+		existing.Sender = parseNullJID(sender)
+		if timestamp.Valid {
+			existing.Timestamp = time.Unix(timestamp.Int64, 0)
+		}
+		existing.CreatedAt = time.Unix(createdAt, 0)
+		return &existing, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO orion_sent_idempotency (key, message_id, chat_jid, created_at)
+		VALUES (?, ?, ?, ?)`,
+		r.Key, r.MessageID, r.ChatJID.String(), now.Unix(),
+	); err != nil {
+		return nil, err
+	}
+	r.CreatedAt = time.Unix(now.Unix(), 0)
+	return nil, tx.Commit()
+}
+
+// Complete records the result of the send a key was reserved for.
+func (s *IdempotencyStore) Complete(key string, serverID int, sender types.JID, timestamp time.Time) error {
+	_, err := s.store.Exec(`
+		UPDATE orion_sent_idempotency SET server_id = ?, sender = ?, timestamp = ? WHERE key = ?`,
+		nullInt(serverID), nullJID(sender), timestamp.Unix(), key,
+	)
+	return err
+}
+
+// Release frees a key whose send failed, so it can be retried.
+func (s *IdempotencyStore) Release(key, messageID string) error {
+	_, err := s.store.Exec(`DELETE FROM orion_sent_idempotency WHERE key = ? AND message_id = ?`, key, messageID)
+	return err
+}
@@ -1,19 +1,25 @@
 package store
 
 import (
+	"database/sql"
 	"time"
 
 	"go.mau.fi/whatsmeow/types"
 )
 
+// MediaTypeViewOnce marks cached media saved from a view-once message.
+// The file is the only copy once the sender's view-once expires.
+const MediaTypeViewOnce = "view_once"
+
 // MediaCache represents a cached media file.
 type MediaCache struct {
 	MessageID    string
 	ChatJID      types.JID
-	MediaType    string
+	MediaType    string // image, video, ... or MediaTypeViewOnce
 	LocalPath    string
 	DownloadedAt *time.Time
 	FileSize     int64
+	OCRText      string // Text recognized in an image, set after download
 }
 
 // MediaCacheStore handles media cache operations.
@@ -44,18 +50,20 @@ func (s *MediaCacheStore) Put(m *MediaCache) error {
 // Get retrieves a media cache entry.
 func (s *MediaCacheStore) Get(messageID string, chatJID types.JID) (*MediaCache, error) {
 	row := s.store.QueryRow(`
-		SELECT message_id, chat_jid, media_type, local_path, downloaded_at, file_size
+		SELECT message_id, chat_jid, media_type, local_path, downloaded_at, file_size, ocr_text
 		FROM orion_media_cache WHERE message_id = ? AND chat_jid = ?
 	`, messageID, chatJID.String())
 
 	var m MediaCache
 	var chatJIDStr string
 	var downloadedAt int64
+	var ocrText sql.NullString
 
-	err := row.Scan(&m.MessageID, &chatJIDStr, &m.MediaType, &m.LocalPath, &downloadedAt, &m.FileSize)
+	err := row.Scan(&m.MessageID, &chatJIDStr, &m.MediaType, &m.LocalPath, &downloadedAt, &m.FileSize, &ocrText)
 	if err != nil {
 		return nil, err
 	}
+	m.OCRText = ocrText.String
 
 	m.ChatJID, _ = types.ParseJID(chatJIDStr)
 	if downloadedAt > 0 {
@@ -75,6 +83,14 @@ func (s *MediaCacheStore) GetLocalPath(messageID string, chatJID types.JID) (str
 	return path, err
 }
 
+// SetOCRText stores the text recognized in a cached image.
+func (s *MediaCacheStore) SetOCRText(messageID string, chatJID types.JID, text string) error {
+	_, err := s.store.Exec(`
+		UPDATE orion_media_cache SET ocr_text = ? WHERE message_id = ? AND chat_jid = ?
+	`, nullString(text), messageID, chatJID.String())
+	return err
+}
+
 // Delete removes a media cache entry.
 func (s *MediaCacheStore) Delete(messageID string, chatJID types.JID) error {
 	_, err := s.store.Exec(`DELETE FROM orion_media_cache WHERE message_id = ? AND chat_jid = ?`,
@@ -85,7 +101,7 @@ func (s *MediaCacheStore) Delete(messageID string, chatJID types.JID) error {
 // GetByChat retrieves all cached media for a chat.
 func (s *MediaCacheStore) GetByChat(chatJID types.JID) ([]*MediaCache, error) {
 	rows, err := s.store.Query(`
-		SELECT message_id, chat_jid, media_type, local_path, downloaded_at, file_size
+		SELECT message_id, chat_jid, media_type, local_path, downloaded_at, file_size, ocr_text
 		FROM orion_media_cache WHERE chat_jid = ?
 		ORDER BY downloaded_at DESC
 	`, chatJID.String())
@@ -99,10 +115,12 @@ func (s *MediaCacheStore) GetByChat(chatJID types.JID) ([]*MediaCache, error) {
 		var m MediaCache
 		var chatJIDStr string
 		var downloadedAt int64
+		var ocrText sql.NullString
 
-		if err := rows.Scan(&m.MessageID, &chatJIDStr, &m.MediaType, &m.LocalPath, &downloadedAt, &m.FileSize); err != nil {
+		if err := rows.Scan(&m.MessageID, &chatJIDStr, &m.MediaType, &m.LocalPath, &downloadedAt, &m.FileSize, &ocrText); err != nil {
 			return nil, err
 		}
+		m.OCRText = ocrText.String
 
 		m.ChatJID, _ = types.ParseJID(chatJIDStr)
 		if downloadedAt > 0 {
@@ -3,6 +3,10 @@ package store
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
 	"time"
 
 	"go.mau.fi/whatsmeow/types"
@@ -40,12 +44,17 @@ type Message struct {
 	Width           int
 	Height          int
 	DurationSeconds int
+	Thumbnail       []byte // JPEG, for quotes
 
 	// Sticker specific
-	IsAnimated bool
+	IsAnimated      bool
+	StickerPackID   string
+	StickerPackName string
+	StickerAuthor   string
 
 	// Audio specific
-	IsPTT bool
+	IsPTT    bool
+	Waveform []byte // 64 samples, 0-100
 
 	// Video specific
 	IsGIF bool
@@ -61,8 +70,11 @@ type Message struct {
 	GroupMentions []GroupMention
 
 	// Forwarding
-	IsForwarded     bool
-	ForwardingScore int
+	IsForwarded       bool
+	ForwardingScore   int
+	ForwardedFromJID  types.JID // Origin chat/channel/bot, when retained
+	ForwardedFromName string
+	ForwardOrigin     string // chat, status, channels, meta_ai, ugc
 
 	// Location
 	Latitude         float64
@@ -91,6 +103,8 @@ type Message struct {
 	PreviewDescription string
 	PreviewURL         string
 	PreviewMatchedText string
+	PreviewThumbnail   []byte // JPEG
+	PreviewType        string // none, video, image, placeholder, ...
 
 	// Group invite
 	InviteGroupJID   types.JID
@@ -105,6 +119,16 @@ type Message struct {
 	EventJoinLink    string
 	EventIsCanceled  bool
 
+	// Interactive response
+	InteractiveResponse string // Native-flow response params JSON
+
+	// Bot (Meta AI / third-party bots)
+	BotJID        types.JID
+	BotInvokerLID types.JID
+	BotPrompt     string
+	BotPersonaID  string
+	IsBotInvoke   bool
+
 	// Flags
 	IsBroadcast      bool
 	BroadcastListJID types.JID
@@ -131,7 +155,8 @@ type GroupMention struct {
 
 // MessageStore handles message operations.
 type MessageStore struct {
-	store *Store
+	store       *Store
+	removeMedia func(uri string) error
 }
 
 // NewMessageStore creates a new MessageStore.
@@ -139,6 +164,12 @@ func NewMessageStore(s *Store) *MessageStore {
 	return &MessageStore{store: s}
 }
 
+// SetMediaRemover sets the function DeleteWithMedia removes downloaded media
+// with, given its cached URI. By default the URI is a local file path.
+func (s *MessageStore) SetMediaRemover(fn func(uri string) error) {
+	s.removeMedia = fn
+}
+
 // Put stores or updates a message.
 func (s *MessageStore) Put(m *Message) error {
 	now := time.Now().Unix()
@@ -161,19 +192,20 @@ func (s *MessageStore) Put(m *Message) error {
 			message_type, text_content, caption,
 			media_url, media_direct_path, media_key, media_key_timestamp,
 			file_sha256, file_enc_sha256, file_length, mimetype,
-			width, height, duration_seconds,
-			is_animated,
-			is_ptt, is_gif,
+			width, height, duration_seconds, thumbnail,
+			is_animated, sticker_pack_id, sticker_pack_name, sticker_author,
+			is_ptt, waveform, is_gif,
 			quoted_message_id, quoted_sender_lid, quoted_message_type, quoted_content,
 			mentioned_jids, group_mentions,
-			is_forwarded, forwarding_score,
+			is_forwarded, forwarding_score, forwarded_from_jid, forwarded_from_name, forward_origin,
 			latitude, longitude, location_name, location_address, location_url,
 			is_live_location, accuracy_meters, speed_mps, degrees_clockwise, live_location_sequence,
 			vcards, display_name,
 			poll_name, poll_options, poll_select_max, poll_encryption_key,
-			preview_title, preview_description, preview_url, preview_matched_text,
+			preview_title, preview_description, preview_url, preview_matched_text, preview_thumbnail, preview_type,
 			invite_group_jid, invite_code, invite_expiration,
 			event_name, event_description, event_start_time, event_end_time, event_join_link, event_is_canceled,
+			interactive_response,
 			is_broadcast, broadcast_list_jid, is_ephemeral, is_view_once,
 			is_starred, is_edited, edit_timestamp, is_revoked,
 			protocol_type, created_at
@@ -182,19 +214,20 @@ func (s *MessageStore) Put(m *Message) error {
 			?, ?, ?,
 			?, ?, ?, ?,
 			?, ?, ?, ?,
+			?, ?, ?, ?,
+			?, ?, ?, ?,
 			?, ?, ?,
-			?,
-			?, ?,
 			?, ?, ?, ?,
 			?, ?,
-			?, ?,
+			?, ?, ?, ?, ?,
 			?, ?, ?, ?, ?,
 			?, ?, ?, ?, ?,
 			?, ?,
 			?, ?, ?, ?,
-			?, ?, ?, ?,
+			?, ?, ?, ?, ?, ?,
 			?, ?, ?,
 			?, ?, ?, ?, ?, ?,
+			?,
 			?, ?, ?, ?,
 			?, ?, ?, ?,
 			?, ?
@@ -211,19 +244,20 @@ func (s *MessageStore) Put(m *Message) error {
 		m.MessageType, nullString(m.TextContent), nullString(m.Caption),
 		nullString(m.MediaURL), nullString(m.MediaDirectPath), m.MediaKey, nullInt64(m.MediaKeyTimestamp),
 		m.FileSHA256, m.FileEncSHA256, nullInt64(m.FileLength), nullString(m.Mimetype),
-		nullInt(m.Width), nullInt(m.Height), nullInt(m.DurationSeconds),
-		boolToInt(m.IsAnimated),
-		boolToInt(m.IsPTT), boolToInt(m.IsGIF),
+		nullInt(m.Width), nullInt(m.Height), nullInt(m.DurationSeconds), m.Thumbnail,
+		boolToInt(m.IsAnimated), nullString(m.StickerPackID), nullString(m.StickerPackName), nullString(m.StickerAuthor),
+		boolToInt(m.IsPTT), m.Waveform, boolToInt(m.IsGIF),
 		nullString(m.QuotedMessageID), nullJID(m.QuotedSenderLID), nullString(m.QuotedMessageType), nullString(m.QuotedContent),
 		mentionedJIDs, groupMentions,
-		boolToInt(m.IsForwarded), nullInt(m.ForwardingScore),
+		boolToInt(m.IsForwarded), nullInt(m.ForwardingScore), nullJID(m.ForwardedFromJID), nullString(m.ForwardedFromName), nullString(m.ForwardOrigin),
 		nullFloat(m.Latitude), nullFloat(m.Longitude), nullString(m.LocationName), nullString(m.LocationAddress), nullString(m.LocationURL),
 		boolToInt(m.IsLiveLocation), nullInt(m.AccuracyMeters), nullFloat(m.SpeedMPS), nullInt(m.DegreesClockwise), nullInt(m.LiveLocationSeq),
 		vcards, nullString(m.DisplayName),
 		nullString(m.PollName), pollOptions, nullInt(m.PollSelectMax), m.PollEncryptionKey,
-		nullString(m.PreviewTitle), nullString(m.PreviewDescription), nullString(m.PreviewURL), nullString(m.PreviewMatchedText),
+		nullString(m.PreviewTitle), nullString(m.PreviewDescription), nullString(m.PreviewURL), nullString(m.PreviewMatchedText), m.PreviewThumbnail, nullString(m.PreviewType),
 		nullJID(m.InviteGroupJID), nullString(m.InviteCode), nullInt64(m.InviteExpiration),
 		nullString(m.EventName), nullString(m.EventDescription), nullInt64(m.EventStartTime), nullInt64(m.EventEndTime), nullString(m.EventJoinLink), boolToInt(m.EventIsCanceled),
+		nullString(m.InteractiveResponse),
 		boolToInt(m.IsBroadcast), nullJID(m.BroadcastListJID), boolToInt(m.IsEphemeral), boolToInt(m.IsViewOnce),
 		boolToInt(m.IsStarred), boolToInt(m.IsEdited), editTs, boolToInt(m.IsRevoked),
 		nullInt(m.ProtocolType), now,
@@ -238,9 +272,12 @@ func (s *MessageStore) Get(id string, chatJID types.JID) (*Message, error) {
 			message_type, text_content, caption,
 			media_url, media_direct_path, media_key, media_key_timestamp,
 			file_sha256, file_enc_sha256, file_length, mimetype,
-			width, height, duration_seconds,
+			width, height, duration_seconds, is_ptt, waveform,
 			quoted_message_id, quoted_sender_lid,
-			mentioned_jids, is_forwarded, forwarding_score,
+			mentioned_jids, is_forwarded, forwarding_score, forwarded_from_jid, forwarded_from_name, forward_origin,
+			preview_title, preview_description, preview_url, preview_thumbnail,
+			sticker_pack_id, sticker_pack_name, sticker_author,
+			interactive_response,
 			is_ephemeral, is_view_once, is_starred, is_edited, edit_timestamp, is_revoked,
 			created_at
 		FROM orion_messages WHERE id = ? AND chat_jid = ?
@@ -249,6 +286,86 @@ func (s *MessageStore) Get(id string, chatJID types.JID) (*Message, error) {
 	return s.scanMessageBasic(row)
 }
 
+// GetContent retrieves a message including the content columns Get leaves
+// out (location, contact cards, sticker and GIF flags, thumbnail), enough
+// to rebuild it.
+func (s *MessageStore) GetContent(id string, chatJID types.JID) (*Message, error) {
+	m, err := s.Get(id, chatJID)
+	if err != nil {
+		return nil, err
+	}
+
+	var isAnimated, isGIF, isLive int
+	var latitude, longitude, speed sql.NullFloat64
+	var locName, locAddress, locURL, vcardsJSON, displayName, matchedText sql.NullString
+	var accuracy, degrees, liveSeq sql.NullInt64
+
+	err = s.store.QueryRow(`
+		SELECT is_animated, is_gif,
+			latitude, longitude, location_name, location_address, location_url,
+			is_live_location, accuracy_meters, speed_mps, degrees_clockwise, live_location_sequence,
+			vcards, display_name, preview_matched_text, thumbnail
+		FROM orion_messages WHERE id = ? AND chat_jid = ?
+	`, id, chatJID.String()).Scan(
+		&isAnimated, &isGIF,
+		&latitude, &longitude, &locName, &locAddress, &locURL,
+		&isLive, &accuracy, &speed, &degrees, &liveSeq,
+		&vcardsJSON, &displayName, &matchedText, &m.Thumbnail,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	m.IsAnimated = isAnimated == 1
+	m.IsGIF = isGIF == 1
+	m.Latitude = latitude.Float64
+	m.Longitude = longitude.Float64
+	m.LocationName = locName.String
+	m.LocationAddress = locAddress.String
+	m.LocationURL = locURL.String
+	m.IsLiveLocation = isLive == 1
+	m.AccuracyMeters = int(accuracy.Int64)
+	m.SpeedMPS = speed.Float64
+	m.DegreesClockwise = int(degrees.Int64)
+	m.LiveLocationSeq = int(liveSeq.Int64)
+	m.DisplayName = displayName.String
+	m.PreviewMatchedText = matchedText.String
+	if vcardsJSON.Valid {
+		json.Unmarshal([]byte(vcardsJSON.String), &m.VCards)
+	}
+
+	return m, nil
+}
+
+// FullMessage is a message with its reactions, receipts and edit history.
+type FullMessage struct {
+	*Message
+	Reactions []Reaction
+	Receipts  []Receipt
+	Edits     []Edit
+}
+
+// GetFull retrieves a message with its full content, reactions, receipts
+// and edit history, one query each.
+func (s *MessageStore) GetFull(id string, chatJID types.JID) (*FullMessage, error) {
+	m, err := s.GetContent(id, chatJID)
+	if err != nil {
+		return nil, err
+	}
+
+	full := &FullMessage{Message: m}
+	if full.Reactions, err = NewReactionStore(s.store).GetByMessage(id, chatJID); err != nil {
+		return nil, err
+	}
+	if full.Receipts, err = NewReceiptStore(s.store).GetForMessage(id, chatJID); err != nil {
+		return nil, err
+	}
+	if full.Edits, err = s.GetEditHistory(id, chatJID); err != nil {
+		return nil, err
+	}
+	return full, nil
+}
+
 // GetByChat retrieves messages for a chat.
 func (s *MessageStore) GetByChat(chatJID types.JID, limit, offset int) ([]*Message, error) {
 	rows, err := s.store.Query(`
@@ -256,13 +373,16 @@ func (s *MessageStore) GetByChat(chatJID types.JID, limit, offset int) ([]*Messa
 			message_type, text_content, caption,
 			media_url, media_direct_path, media_key, media_key_timestamp,
 			file_sha256, file_enc_sha256, file_length, mimetype,
-			width, height, duration_seconds,
+			width, height, duration_seconds, is_ptt, waveform,
 			quoted_message_id, quoted_sender_lid,
-			mentioned_jids, is_forwarded, forwarding_score,
+			mentioned_jids, is_forwarded, forwarding_score, forwarded_from_jid, forwarded_from_name, forward_origin,
+			preview_title, preview_description, preview_url, preview_thumbnail,
+			sticker_pack_id, sticker_pack_name, sticker_author,
+			interactive_response,
 			is_ephemeral, is_view_once, is_starred, is_edited, edit_timestamp, is_revoked,
 			created_at
 		FROM orion_messages WHERE chat_jid = ?
-		ORDER BY timestamp DESC LIMIT ? OFFSET ?
+		ORDER BY timestamp DESC, id DESC LIMIT ? OFFSET ?
 	`, chatJID.String(), limit, offset)
 	if err != nil {
 		return nil, err
@@ -272,12 +392,212 @@ func (s *MessageStore) GetByChat(chatJID types.JID, limit, offset int) ([]*Messa
 	return s.scanMessagesBasic(rows)
 }
 
-// Delete deletes a message.
+// GetByChatBefore retrieves up to limit messages older than the cursor, newest first.
+// The cursor is the Timestamp and ID of the oldest message of the previous
+// page; pass an empty beforeID to start strictly before beforeTimestamp.
+// Unlike GetByChat, pages stay consistent while new messages arrive.
+func (s *MessageStore) GetByChatBefore(chatJID types.JID, beforeTimestamp time.Time, beforeID string, limit int) ([]*Message, error) {
+	// An empty ID sorts before every ID, so the whole second is excluded
+	rows, err := s.store.Query(`
+		SELECT id, chat_jid, sender_lid, from_me, timestamp, server_id, push_name,
+			message_type, text_content, caption,
+			media_url, media_direct_path, media_key, media_key_timestamp,
+			file_sha256, file_enc_sha256, file_length, mimetype,
+			width, height, duration_seconds, is_ptt, waveform,
+			quoted_message_id, quoted_sender_lid,
+			mentioned_jids, is_forwarded, forwarding_score, forwarded_from_jid, forwarded_from_name, forward_origin,
+			preview_title, preview_description, preview_url, preview_thumbnail,
+			sticker_pack_id, sticker_pack_name, sticker_author,
+			interactive_response,
+			is_ephemeral, is_view_once, is_starred, is_edited, edit_timestamp, is_revoked,
+			created_at
+		FROM orion_messages
+		WHERE chat_jid = ? AND (timestamp, id) < (?, ?)
+		ORDER BY timestamp DESC, id DESC LIMIT ?
+	`, chatJID.String(), beforeTimestamp.Unix(), beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return s.scanMessagesBasic(rows)
+}
+
+// GetByChatAfter retrieves up to limit messages newer than the cursor, newest first.
+// The cursor is the Timestamp and ID of the newest message already seen;
+// pass an empty afterID to start strictly after afterTimestamp. The messages
+// closest to the cursor are returned, so repeat with the newest returned
+// message until no more messages come back.
+func (s *MessageStore) GetByChatAfter(chatJID types.JID, afterTimestamp time.Time, afterID string, limit int) ([]*Message, error) {
+	ts := afterTimestamp.Unix()
+	if afterID == "" {
+		// Every ID sorts after an empty one, so skip to the next second
+		ts++
+	}
+	rows, err := s.store.Query(`
+		SELECT * FROM (
+			SELECT id, chat_jid, sender_lid, from_me, timestamp, server_id, push_name,
+				message_type, text_content, caption,
+				media_url, media_direct_path, media_key, media_key_timestamp,
+				file_sha256, file_enc_sha256, file_length, mimetype,
+				width, height, duration_seconds, is_ptt, waveform,
+				quoted_message_id, quoted_sender_lid,
+				mentioned_jids, is_forwarded, forwarding_score, forwarded_from_jid, forwarded_from_name, forward_origin,
+				preview_title, preview_description, preview_url, preview_thumbnail,
+				sticker_pack_id, sticker_pack_name, sticker_author,
+				interactive_response,
+				is_ephemeral, is_view_once, is_starred, is_edited, edit_timestamp, is_revoked,
+				created_at
+			FROM orion_messages
+			WHERE chat_jid = ? AND (timestamp, id) > (?, ?)
+			ORDER BY timestamp ASC, id ASC LIMIT ?
+		) ORDER BY timestamp DESC, id DESC
+	`, chatJID.String(), ts, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return s.scanMessagesBasic(rows)
+}
+
+// GetQuotedOriginal retrieves the message msg replies to.
+// Returns nil if msg isn't a reply, and sql.ErrNoRows if the original
+// isn't stored.
+func (s *MessageStore) GetQuotedOriginal(msg *Message) (*Message, error) {
+	if msg == nil || msg.QuotedMessageID == "" {
+		return nil, nil
+	}
+	return s.Get(msg.QuotedMessageID, msg.ChatJID)
+}
+
+// GetReplies retrieves the messages that quote a message, oldest first.
+func (s *MessageStore) GetReplies(msgID string, chatJID types.JID) ([]*Message, error) {
+	rows, err := s.store.Query(`
+		SELECT id, chat_jid, sender_lid, from_me, timestamp, server_id, push_name,
+			message_type, text_content, caption,
+			media_url, media_direct_path, media_key, media_key_timestamp,
+			file_sha256, file_enc_sha256, file_length, mimetype,
+			width, height, duration_seconds, is_ptt, waveform,
+			quoted_message_id, quoted_sender_lid,
+			mentioned_jids, is_forwarded, forwarding_score, forwarded_from_jid, forwarded_from_name, forward_origin,
+			preview_title, preview_description, preview_url, preview_thumbnail,
+			sticker_pack_id, sticker_pack_name, sticker_author,
+			interactive_response,
+			is_ephemeral, is_view_once, is_starred, is_edited, edit_timestamp, is_revoked,
+			created_at
+		FROM orion_messages
+		WHERE chat_jid = ? AND quoted_message_id = ?
+		ORDER BY timestamp ASC, id ASC
+	`, chatJID.String(), msgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return s.scanMessagesBasic(rows)
+}
+
+// GetUnreplied retrieves incoming messages in a chat since the given time that
+// have no later message from us, oldest first. A message of ours in the same
+// second counts as a reply. Revoked messages are skipped.
+func (s *MessageStore) GetUnreplied(chatJID types.JID, since time.Time) ([]*Message, error) {
+	rows, err := s.store.Query(`
+		SELECT id, chat_jid, sender_lid, from_me, timestamp, server_id, push_name,
+			message_type, text_content, caption,
+			media_url, media_direct_path, media_key, media_key_timestamp,
+			file_sha256, file_enc_sha256, file_length, mimetype,
+			width, height, duration_seconds, is_ptt, waveform,
+			quoted_message_id, quoted_sender_lid,
+			mentioned_jids, is_forwarded, forwarding_score, forwarded_from_jid, forwarded_from_name, forward_origin,
+			preview_title, preview_description, preview_url, preview_thumbnail,
+			sticker_pack_id, sticker_pack_name, sticker_author,
+			interactive_response,
+			is_ephemeral, is_view_once, is_starred, is_edited, edit_timestamp, is_revoked,
+			created_at
+		FROM orion_messages m
+		WHERE m.chat_jid = ? AND m.timestamp >= ? AND m.from_me = 0 AND m.is_revoked = 0
+			AND NOT EXISTS (
+				SELECT 1 FROM orion_messages r
+				WHERE r.chat_jid = m.chat_jid AND r.timestamp >= m.timestamp AND r.from_me = 1
+			)
+		ORDER BY m.timestamp ASC, m.id ASC
+	`, chatJID.String(), since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return s.scanMessagesBasic(rows)
+}
+
+// messageChildTables hold rows keyed by (message_id, chat_jid) that are
+// deleted along with their message.
+var messageChildTables = []string{
+	"orion_reactions",
+	"orion_message_receipts",
+	"orion_message_edits",
+	"orion_media_cache",
+}
+
+// Delete deletes a message along with its reactions, receipts, edit history
+// and media cache entry. A downloaded media file is left on disk; use
+// DeleteWithMedia to remove it too.
 func (s *MessageStore) Delete(id string, chatJID types.JID) error {
-	_, err := s.store.Exec(`DELETE FROM orion_messages WHERE id = ? AND chat_jid = ?`, id, chatJID.String())
+	_, err := s.deleteCascade(id, chatJID)
 	return err
 }
 
+// DeleteWithMedia deletes a message like Delete and removes its downloaded
+// media file, if any, from wherever it's stored.
+func (s *MessageStore) DeleteWithMedia(id string, chatJID types.JID) error {
+	path, err := s.deleteCascade(id, chatJID)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return nil
+	}
+	if s.removeMedia != nil {
+		return s.removeMedia(path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// deleteCascade deletes a message and its related rows in one transaction.
+// Returns the local path of its downloaded media, if any.
+func (s *MessageStore) deleteCascade(id string, chatJID types.JID) (string, error) {
+	tx, err := s.store.BeginImmediate()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var path sql.NullString
+	err = tx.QueryRow(`SELECT local_path FROM orion_media_cache WHERE message_id = ? AND chat_jid = ?`,
+		id, chatJID.String()).Scan(&path)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+
+	for _, table := range messageChildTables {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE message_id = ? AND chat_jid = ?`, id, chatJID.String()); err != nil {
+			return "", err
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM orion_messages WHERE id = ? AND chat_jid = ?`, id, chatJID.String()); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return path.String, nil
+}
+
 // SetStarred updates starred status.
 func (s *MessageStore) SetStarred(id string, chatJID types.JID, starred bool) error {
 	_, err := s.store.Exec(`UPDATE orion_messages SET is_starred = ? WHERE id = ? AND chat_jid = ?`,
@@ -292,15 +612,283 @@ func (s *MessageStore) SetRevoked(id string, chatJID types.JID) error {
 	return err
 }
 
-// MarkEdited marks a message as edited.
+// Edit is one entry of a message's edit history.
+type Edit struct {
+	MessageID  string
+	ChatJID    types.JID
+	EditNumber int
+	OldContent string
+	NewContent string
+	EditedAt   time.Time
+}
+
+// MarkEdited replaces a message's text, marks it edited and records the
+// change in its edit history.
 func (s *MessageStore) MarkEdited(id string, chatJID types.JID, newContent string, editTime time.Time) error {
+	return s.applyEdit(id, chatJID, "text_content", newContent, editTime)
+}
+
+// UpdateCaption replaces the caption of a media message, marks it edited and
+// records the change in its edit history.
+func (s *MessageStore) UpdateCaption(id string, chatJID types.JID, caption string, editTime time.Time) error {
+	return s.applyEdit(id, chatJID, "caption", caption, editTime)
+}
+
+// applyEdit sets column to newContent and appends the old value to the edit
+// history. A repeated edit (same content and time) isn't recorded twice.
+func (s *MessageStore) applyEdit(id string, chatJID types.JID, column, newContent string, editTime time.Time) error {
+	tx, err := s.store.BeginImmediate()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	chat := chatJID.String()
+	_, err = tx.Exec(`
+		INSERT INTO orion_message_edits (message_id, chat_jid, edit_number, old_content, new_content, edited_at)
+		SELECT id, chat_jid,
+			(SELECT COALESCE(MAX(edit_number), 0) + 1 FROM orion_message_edits WHERE message_id = ? AND chat_jid = ?),
+			`+column+`, ?, ?
+		FROM orion_messages
+		WHERE id = ? AND chat_jid = ? AND NOT EXISTS (
+			SELECT 1 FROM orion_message_edits
+			WHERE message_id = ? AND chat_jid = ? AND new_content = ? AND edited_at = ?
+		)
+	`, id, chat, newContent, editTime.Unix(), id, chat, id, chat, newContent, editTime.Unix())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE orion_messages SET `+column+` = ?, is_edited = 1, edit_timestamp = ?
+		WHERE id = ? AND chat_jid = ?
+	`, newContent, editTime.Unix(), id, chat)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetEditHistory returns a message's edit history, oldest first.
+func (s *MessageStore) GetEditHistory(id string, chatJID types.JID) ([]Edit, error) {
+	rows, err := s.store.Query(`
+		SELECT edit_number, old_content, new_content, edited_at
+		FROM orion_message_edits WHERE message_id = ? AND chat_jid = ?
+		ORDER BY edit_number
+	`, id, chatJID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edits []Edit
+	for rows.Next() {
+		var oldContent, newContent sql.NullString
+		var editedAt int64
+		e := Edit{MessageID: id, ChatJID: chatJID}
+		if err := rows.Scan(&e.EditNumber, &oldContent, &newContent, &editedAt); err != nil {
+			return nil, err
+		}
+		e.OldContent = oldContent.String
+		e.NewContent = newContent.String
+		e.EditedAt = time.Unix(editedAt, 0)
+		edits = append(edits, e)
+	}
+	return edits, rows.Err()
+}
+
+// UpdateLiveLocation records the latest position of a live location.
+func (s *MessageStore) UpdateLiveLocation(id string, chatJID types.JID, lat, lon float64, accuracy int, speed float64, seq int) error {
 	_, err := s.store.Exec(`
-		UPDATE orion_messages SET text_content = ?, is_edited = 1, edit_timestamp = ?
+		UPDATE orion_messages SET latitude = ?, longitude = ?, accuracy_meters = ?, speed_mps = ?, live_location_sequence = ?
 		WHERE id = ? AND chat_jid = ?
-	`, newContent, editTime.Unix(), id, chatJID.String())
+	`, lat, lon, nullInt(accuracy), nullFloat(speed), seq, id, chatJID.String())
 	return err
 }
 
+// UpdateMediaPath replaces the CDN direct path of a message's media.
+// Used when the sender re-uploads media whose old path expired.
+func (s *MessageStore) UpdateMediaPath(id string, chatJID types.JID, directPath string) error {
+	_, err := s.store.Exec(`UPDATE orion_messages SET media_direct_path = ? WHERE id = ? AND chat_jid = ?`,
+		directPath, id, chatJID.String())
+	return err
+}
+
+// UpdateServerID sets the server-assigned ID of a message.
+// Used to reconcile sent messages persisted before the server ID was known.
+func (s *MessageStore) UpdateServerID(id string, chatJID types.JID, serverID int) error {
+	_, err := s.store.Exec(`UPDATE orion_messages SET server_id = ? WHERE id = ? AND chat_jid = ?`,
+		serverID, id, chatJID.String())
+	return err
+}
+
+// GetSentBroadcastChat returns the broadcast list chat a message with the
+// given ID was sent to by us, or an empty JID if it wasn't a broadcast.
+// Receipts for broadcasts arrive per recipient chat; this maps them back.
+func (s *MessageStore) GetSentBroadcastChat(id string) (types.JID, error) {
+	var chat string
+	err := s.store.QueryRow(`
+		SELECT chat_jid FROM orion_messages
+		WHERE id = ? AND from_me = 1 AND is_broadcast = 1
+		LIMIT 1
+	`, id).Scan(&chat)
+	if err == sql.ErrNoRows {
+		return types.EmptyJID, nil
+	}
+	if err != nil {
+		return types.EmptyJID, err
+	}
+	return types.ParseJID(chat)
+}
+
+// GetByServerID retrieves a message by its server ID (newsletters/channels).
+func (s *MessageStore) GetByServerID(chatJID types.JID, serverID int) (*Message, error) {
+	row := s.store.QueryRow(`
+		SELECT id, chat_jid, sender_lid, from_me, timestamp, server_id, push_name,
+			message_type, text_content, caption,
+			media_url, media_direct_path, media_key, media_key_timestamp,
+			file_sha256, file_enc_sha256, file_length, mimetype,
+			width, height, duration_seconds, is_ptt, waveform,
+			quoted_message_id, quoted_sender_lid,
+			mentioned_jids, is_forwarded, forwarding_score, forwarded_from_jid, forwarded_from_name, forward_origin,
+			preview_title, preview_description, preview_url, preview_thumbnail,
+			sticker_pack_id, sticker_pack_name, sticker_author,
+			interactive_response,
+			is_ephemeral, is_view_once, is_starred, is_edited, edit_timestamp, is_revoked,
+			created_at
+		FROM orion_messages WHERE chat_jid = ? AND server_id = ?
+	`, chatJID.String(), serverID)
+
+	return s.scanMessageBasic(row)
+}
+
+// SetStickerPack sets the pack metadata of a sticker message.
+func (s *MessageStore) SetStickerPack(id string, chatJID types.JID, packID, packName, author string) error {
+	_, err := s.store.Exec(`
+		UPDATE orion_messages SET sticker_pack_id = ?, sticker_pack_name = ?, sticker_author = ?
+		WHERE id = ? AND chat_jid = ?
+	`, nullString(packID), nullString(packName), nullString(author), id, chatJID.String())
+	return err
+}
+
+// GetStickersByPack retrieves sticker messages from a pack, newest first.
+func (s *MessageStore) GetStickersByPack(packID string) ([]*Message, error) {
+	rows, err := s.store.Query(`
+		SELECT id, chat_jid, sender_lid, from_me, timestamp, server_id, push_name,
+			message_type, text_content, caption,
+			media_url, media_direct_path, media_key, media_key_timestamp,
+			file_sha256, file_enc_sha256, file_length, mimetype,
+			width, height, duration_seconds, is_ptt, waveform,
+			quoted_message_id, quoted_sender_lid,
+			mentioned_jids, is_forwarded, forwarding_score, forwarded_from_jid, forwarded_from_name, forward_origin,
+			preview_title, preview_description, preview_url, preview_thumbnail,
+			sticker_pack_id, sticker_pack_name, sticker_author,
+			interactive_response,
+			is_ephemeral, is_view_once, is_starred, is_edited, edit_timestamp, is_revoked,
+			created_at
+		FROM orion_messages WHERE sticker_pack_id = ? AND message_type = 'sticker'
+		ORDER BY timestamp DESC, id DESC
+	`, packID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return s.scanMessagesBasic(rows)
+}
+
+// Search finds messages whose text or caption matches query, best match first.
+func (s *MessageStore) Search(query string, limit, offset int) ([]*Message, error) {
+	return s.search(types.EmptyJID, query, limit, offset)
+}
+
+// SearchInChat finds messages in a chat whose text or caption matches query, best match first.
+func (s *MessageStore) SearchInChat(chatJID types.JID, query string, limit, offset int) ([]*Message, error) {
+	return s.search(chatJID, query, limit, offset)
+}
+
+// search runs a full-text search, optionally limited to one chat.
+// Without the FTS5 index it falls back to a substring match, newest first.
+func (s *MessageStore) search(chatJID types.JID, query string, limit, offset int) ([]*Message, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var chatFilter string
+	if !chatJID.IsEmpty() {
+		chatFilter = chatJID.String()
+	}
+
+	var rows *sql.Rows
+	var err error
+	if s.store.fts {
+		// Quote each term so user input is never parsed as FTS syntax,
+		// and match it as a prefix so partial words still find results
+		for i, t := range terms {
+			terms[i] = `"` + strings.ReplaceAll(t, `"`, `""`) + `"*`
+		}
+		rows, err = s.store.Query(`
+			SELECT id, chat_jid, sender_lid, from_me, timestamp, server_id, push_name,
+				message_type, text_content, caption,
+				media_url, media_direct_path, media_key, media_key_timestamp,
+				file_sha256, file_enc_sha256, file_length, mimetype,
+				width, height, duration_seconds, is_ptt, waveform,
+				quoted_message_id, quoted_sender_lid,
+				mentioned_jids, is_forwarded, forwarding_score, forwarded_from_jid, forwarded_from_name, forward_origin,
+				preview_title, preview_description, preview_url, preview_thumbnail,
+				sticker_pack_id, sticker_pack_name, sticker_author,
+				interactive_response,
+				is_ephemeral, is_view_once, is_starred, is_edited, edit_timestamp, is_revoked,
+				created_at
+			FROM orion_messages
+			JOIN (
+				SELECT k.id AS fts_id, k.chat_jid AS fts_chat_jid, f.rank AS fts_rank
+				FROM orion_messages_fts f JOIN orion_messages_fts_keys k ON k.doc_id = f.rowid
+				WHERE orion_messages_fts MATCH ?
+			) ON fts_id = orion_messages.id AND fts_chat_jid = orion_messages.chat_jid
+			WHERE (? = '' OR chat_jid = ?)
+			ORDER BY fts_rank, timestamp DESC LIMIT ? OFFSET ?
+		`, strings.Join(terms, " "), chatFilter, chatFilter, limit, offset)
+	} else {
+		// Like the index, every term must appear in the text or caption.
+		// Terms match anywhere in a word here, not only at its start.
+		escape := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+		var conds []string
+		var args []interface{}
+		for _, t := range terms {
+			pattern := "%" + escape.Replace(t) + "%"
+			conds = append(conds, `(text_content LIKE ? ESCAPE '\' OR caption LIKE ? ESCAPE '\')`)
+			args = append(args, pattern, pattern)
+		}
+		args = append(args, chatFilter, chatFilter, limit, offset)
+		rows, err = s.store.Query(`
+			SELECT id, chat_jid, sender_lid, from_me, timestamp, server_id, push_name,
+				message_type, text_content, caption,
+				media_url, media_direct_path, media_key, media_key_timestamp,
+				file_sha256, file_enc_sha256, file_length, mimetype,
+				width, height, duration_seconds, is_ptt, waveform,
+				quoted_message_id, quoted_sender_lid,
+				mentioned_jids, is_forwarded, forwarding_score, forwarded_from_jid, forwarded_from_name, forward_origin,
+				preview_title, preview_description, preview_url, preview_thumbnail,
+				sticker_pack_id, sticker_pack_name, sticker_author,
+				interactive_response,
+				is_ephemeral, is_view_once, is_starred, is_edited, edit_timestamp, is_revoked,
+				created_at
+			FROM orion_messages
+			WHERE `+strings.Join(conds, " AND ")+`
+				AND (? = '' OR chat_jid = ?)
+			ORDER BY timestamp DESC, id DESC LIMIT ? OFFSET ?
+		`, args...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return s.scanMessagesBasic(rows)
+}
+
 // SetPinned updates pinned status.
 func (s *MessageStore) SetPinned(id string, chatJID types.JID, pinned bool, pinTime time.Time) error {
 	var pinTs interface{}
@@ -315,29 +903,70 @@ func (s *MessageStore) SetPinned(id string, chatJID types.JID, pinned bool, pinT
 // GetMediaForDownload retrieves media fields needed for download.
 func (s *MessageStore) GetMediaForDownload(id string, chatJID types.JID) (*MediaDownloadInfo, error) {
 	row := s.store.QueryRow(`
-		SELECT media_url, media_direct_path, media_key, file_sha256, file_enc_sha256, file_length, mimetype
+		SELECT message_type, sender_lid, from_me, display_name, media_url, media_direct_path, media_key, file_sha256, file_enc_sha256, file_length, mimetype, is_view_once
 		FROM orion_messages WHERE id = ? AND chat_jid = ?
 	`, id, chatJID.String())
 
 	var info MediaDownloadInfo
-	var url, directPath, mimetype sql.NullString
+	var senderLID string
+	var fromMe, viewOnce int
+	var displayName, url, directPath, mimetype sql.NullString
 	var fileLength sql.NullInt64
 
-	err := row.Scan(&url, &directPath, &info.MediaKey, &info.FileSHA256, &info.FileEncSHA256, &fileLength, &mimetype)
+	err := row.Scan(&info.MessageType, &senderLID, &fromMe, &displayName, &url, &directPath, &info.MediaKey, &info.FileSHA256, &info.FileEncSHA256, &fileLength, &mimetype, &viewOnce)
 	if err != nil {
 		return nil, err
 	}
 
+	info.SenderLID, _ = types.ParseJID(senderLID)
+	info.FromMe = fromMe == 1
+	info.DisplayName = displayName.String
 	info.URL = url.String
 	info.DirectPath = directPath.String
 	info.FileLength = fileLength.Int64
 	info.Mimetype = mimetype.String
+	info.IsViewOnce = viewOnce == 1
 
 	return &info, nil
 }
 
+// GetAudioMeta retrieves playback metadata for an audio message.
+func (s *MessageStore) GetAudioMeta(id string, chatJID types.JID) (*AudioMeta, error) {
+	row := s.store.QueryRow(`
+		SELECT duration_seconds, waveform, is_ptt, mimetype
+		FROM orion_messages WHERE id = ? AND chat_jid = ?
+	`, id, chatJID.String())
+
+	var meta AudioMeta
+	var duration sql.NullInt64
+	var isPTT int
+	var mimetype sql.NullString
+
+	if err := row.Scan(&duration, &meta.Waveform, &isPTT, &mimetype); err != nil {
+		return nil, err
+	}
+
+	meta.DurationSeconds = int(duration.Int64)
+	meta.IsPTT = isPTT == 1
+	meta.Mimetype = mimetype.String
+
+	return &meta, nil
+}
+
+// AudioMeta contains fields needed to render an audio player.
+type AudioMeta struct {
+	DurationSeconds int
+	Waveform        []byte
+	IsPTT           bool
+	Mimetype        string
+}
+
 // MediaDownloadInfo contains fields needed to download media.
 type MediaDownloadInfo struct {
+	MessageType   string
+	SenderLID     types.JID
+	FromMe        bool
+	DisplayName   string // Original filename (for documents)
 	URL           string
 	DirectPath    string
 	MediaKey      []byte
@@ -345,6 +974,7 @@ type MediaDownloadInfo struct {
 	FileEncSHA256 []byte
 	FileLength    int64
 	Mimetype      string
+	IsViewOnce    bool
 }
 
 func (s *MessageStore) scanMessageBasic(row *sql.Row) (*Message, error) {
@@ -352,22 +982,29 @@ func (s *MessageStore) scanMessageBasic(row *sql.Row) (*Message, error) {
 	var senderLID, pushName, textContent, caption sql.NullString
 	var mediaURL, mediaDirectPath, mimetype sql.NullString
 	var quotedMsgID, quotedSenderLID sql.NullString
+	var forwardedFrom, forwardedFromName, forwardOrigin sql.NullString
+	var previewTitle, previewDescription, previewURL, interactiveResponse sql.NullString
+	var stickerPackID, stickerPackName, stickerAuthor sql.NullString
 	var mentionedJIDsJSON sql.NullString
 	var timestamp, createdAt int64
-	var serverID, width, height, durationSecs, forwardingScore int
+	var serverID int
+	var width, height, durationSecs, forwardingScore sql.NullInt64
 	var mediaKeyTs, fileLength sql.NullInt64
-	var fromMe, isForwarded, isEphemeral, isViewOnce, isStarred, isEdited, isRevoked int
+	var fromMe, isPTT, isForwarded, isEphemeral, isViewOnce, isStarred, isEdited, isRevoked int
 	var editTs sql.NullInt64
-	var mediaKey, fileSHA, fileEncSHA []byte
+	var mediaKey, fileSHA, fileEncSHA, waveform, previewThumbnail []byte
 
 	err := row.Scan(
 		&id, &chatJIDStr, &senderLID, &fromMe, &timestamp, &serverID, &pushName,
 		&msgType, &textContent, &caption,
 		&mediaURL, &mediaDirectPath, &mediaKey, &mediaKeyTs,
 		&fileSHA, &fileEncSHA, &fileLength, &mimetype,
-		&width, &height, &durationSecs,
+		&width, &height, &durationSecs, &isPTT, &waveform,
 		&quotedMsgID, &quotedSenderLID,
-		&mentionedJIDsJSON, &isForwarded, &forwardingScore,
+		&mentionedJIDsJSON, &isForwarded, &forwardingScore, &forwardedFrom, &forwardedFromName, &forwardOrigin,
+		&previewTitle, &previewDescription, &previewURL, &previewThumbnail,
+		&stickerPackID, &stickerPackName, &stickerAuthor,
+		&interactiveResponse,
 		&isEphemeral, &isViewOnce, &isStarred, &isEdited, &editTs, &isRevoked,
 		&createdAt,
 	)
@@ -377,33 +1014,46 @@ func (s *MessageStore) scanMessageBasic(row *sql.Row) (*Message, error) {
 
 	chatJID, _ := types.ParseJID(chatJIDStr)
 	m := &Message{
-		ID:              id,
-		ChatJID:         chatJID,
-		FromMe:          fromMe == 1,
-		Timestamp:       time.Unix(timestamp, 0),
-		ServerID:        serverID,
-		PushName:        pushName.String,
-		MessageType:     msgType,
-		TextContent:     textContent.String,
-		Caption:         caption.String,
-		MediaURL:        mediaURL.String,
-		MediaDirectPath: mediaDirectPath.String,
-		MediaKey:        mediaKey,
-		FileSHA256:      fileSHA,
-		FileEncSHA256:   fileEncSHA,
-		Mimetype:        mimetype.String,
-		Width:           width,
-		Height:          height,
-		DurationSeconds: durationSecs,
-		QuotedMessageID: quotedMsgID.String,
-		IsForwarded:     isForwarded == 1,
-		ForwardingScore: forwardingScore,
-		IsEphemeral:     isEphemeral == 1,
-		IsViewOnce:      isViewOnce == 1,
-		IsStarred:       isStarred == 1,
-		IsEdited:        isEdited == 1,
-		IsRevoked:       isRevoked == 1,
-		CreatedAt:       time.Unix(createdAt, 0),
+		ID:                  id,
+		ChatJID:             chatJID,
+		FromMe:              fromMe == 1,
+		Timestamp:           time.Unix(timestamp, 0),
+		ServerID:            serverID,
+		PushName:            pushName.String,
+		MessageType:         msgType,
+		TextContent:         textContent.String,
+		Caption:             caption.String,
+		MediaURL:            mediaURL.String,
+		MediaDirectPath:     mediaDirectPath.String,
+		MediaKey:            mediaKey,
+		FileSHA256:          fileSHA,
+		FileEncSHA256:       fileEncSHA,
+		Mimetype:            mimetype.String,
+		Width:               int(width.Int64),
+		Height:              int(height.Int64),
+		DurationSeconds:     int(durationSecs.Int64),
+		IsPTT:               isPTT == 1,
+		Waveform:            waveform,
+		QuotedMessageID:     quotedMsgID.String,
+		IsForwarded:         isForwarded == 1,
+		ForwardingScore:     int(forwardingScore.Int64),
+		ForwardedFromJID:    parseNullJID(forwardedFrom),
+		ForwardedFromName:   forwardedFromName.String,
+		ForwardOrigin:       forwardOrigin.String,
+		PreviewTitle:        previewTitle.String,
+		PreviewDescription:  previewDescription.String,
+		PreviewURL:          previewURL.String,
+		PreviewThumbnail:    previewThumbnail,
+		StickerPackID:       stickerPackID.String,
+		StickerPackName:     stickerPackName.String,
+		StickerAuthor:       stickerAuthor.String,
+		InteractiveResponse: interactiveResponse.String,
+		IsEphemeral:         isEphemeral == 1,
+		IsViewOnce:          isViewOnce == 1,
+		IsStarred:           isStarred == 1,
+		IsEdited:            isEdited == 1,
+		IsRevoked:           isRevoked == 1,
+		CreatedAt:           time.Unix(createdAt, 0),
 	}
 
 	if senderLID.Valid {
@@ -437,22 +1087,29 @@ func (s *MessageStore) scanMessagesBasic(rows *sql.Rows) ([]*Message, error) {
 		var senderLID, pushName, textContent, caption sql.NullString
 		var mediaURL, mediaDirectPath, mimetype sql.NullString
 		var quotedMsgID, quotedSenderLID sql.NullString
+		var forwardedFrom, forwardedFromName, forwardOrigin sql.NullString
+		var previewTitle, previewDescription, previewURL, interactiveResponse sql.NullString
+		var stickerPackID, stickerPackName, stickerAuthor sql.NullString
 		var mentionedJIDsJSON sql.NullString
 		var timestamp, createdAt int64
-		var serverID, width, height, durationSecs, forwardingScore int
+		var serverID int
+		var width, height, durationSecs, forwardingScore sql.NullInt64
 		var mediaKeyTs, fileLength sql.NullInt64
-		var fromMe, isForwarded, isEphemeral, isViewOnce, isStarred, isEdited, isRevoked int
+		var fromMe, isPTT, isForwarded, isEphemeral, isViewOnce, isStarred, isEdited, isRevoked int
 		var editTs sql.NullInt64
-		var mediaKey, fileSHA, fileEncSHA []byte
+		var mediaKey, fileSHA, fileEncSHA, waveform, previewThumbnail []byte
 
 		err := rows.Scan(
 			&id, &chatJIDStr, &senderLID, &fromMe, &timestamp, &serverID, &pushName,
 			&msgType, &textContent, &caption,
 			&mediaURL, &mediaDirectPath, &mediaKey, &mediaKeyTs,
 			&fileSHA, &fileEncSHA, &fileLength, &mimetype,
-			&width, &height, &durationSecs,
+			&width, &height, &durationSecs, &isPTT, &waveform,
 			&quotedMsgID, &quotedSenderLID,
-			&mentionedJIDsJSON, &isForwarded, &forwardingScore,
+			&mentionedJIDsJSON, &isForwarded, &forwardingScore, &forwardedFrom, &forwardedFromName, &forwardOrigin,
+			&previewTitle, &previewDescription, &previewURL, &previewThumbnail,
+			&stickerPackID, &stickerPackName, &stickerAuthor,
+			&interactiveResponse,
 			&isEphemeral, &isViewOnce, &isStarred, &isEdited, &editTs, &isRevoked,
 			&createdAt,
 		)
@@ -462,33 +1119,46 @@ func (s *MessageStore) scanMessagesBasic(rows *sql.Rows) ([]*Message, error) {
 
 		chatJID, _ := types.ParseJID(chatJIDStr)
 		m := &Message{
-			ID:              id,
-			ChatJID:         chatJID,
-			FromMe:          fromMe == 1,
-			Timestamp:       time.Unix(timestamp, 0),
-			ServerID:        serverID,
-			PushName:        pushName.String,
-			MessageType:     msgType,
-			TextContent:     textContent.String,
-			Caption:         caption.String,
-			MediaURL:        mediaURL.String,
-			MediaDirectPath: mediaDirectPath.String,
-			MediaKey:        mediaKey,
-			FileSHA256:      fileSHA,
-			FileEncSHA256:   fileEncSHA,
-			Mimetype:        mimetype.String,
-			Width:           width,
-			Height:          height,
-			DurationSeconds: durationSecs,
-			QuotedMessageID: quotedMsgID.String,
-			IsForwarded:     isForwarded == 1,
-			ForwardingScore: forwardingScore,
-			IsEphemeral:     isEphemeral == 1,
-			IsViewOnce:      isViewOnce == 1,
-			IsStarred:       isStarred == 1,
-			IsEdited:        isEdited == 1,
-			IsRevoked:       isRevoked == 1,
-			CreatedAt:       time.Unix(createdAt, 0),
+			ID:                  id,
+			ChatJID:             chatJID,
+			FromMe:              fromMe == 1,
+			Timestamp:           time.Unix(timestamp, 0),
+			ServerID:            serverID,
+			PushName:            pushName.String,
+			MessageType:         msgType,
+			TextContent:         textContent.String,
+			Caption:             caption.String,
+			MediaURL:            mediaURL.String,
+			MediaDirectPath:     mediaDirectPath.String,
+			MediaKey:            mediaKey,
+			FileSHA256:          fileSHA,
+			FileEncSHA256:       fileEncSHA,
+			Mimetype:            mimetype.String,
+			Width:               int(width.Int64),
+			Height:              int(height.Int64),
+			DurationSeconds:     int(durationSecs.Int64),
+			IsPTT:               isPTT == 1,
+			Waveform:            waveform,
+			QuotedMessageID:     quotedMsgID.String,
+			IsForwarded:         isForwarded == 1,
+			ForwardingScore:     int(forwardingScore.Int64),
+			ForwardedFromJID:    parseNullJID(forwardedFrom),
+			ForwardedFromName:   forwardedFromName.String,
+			ForwardOrigin:       forwardOrigin.String,
+			PreviewTitle:        previewTitle.String,
+			PreviewDescription:  previewDescription.String,
+			PreviewURL:          previewURL.String,
+			PreviewThumbnail:    previewThumbnail,
+			StickerPackID:       stickerPackID.String,
+			StickerPackName:     stickerPackName.String,
+			StickerAuthor:       stickerAuthor.String,
+			InteractiveResponse: interactiveResponse.String,
+			IsEphemeral:         isEphemeral == 1,
+			IsViewOnce:          isViewOnce == 1,
+			IsStarred:           isStarred == 1,
+			IsEdited:            isEdited == 1,
+			IsRevoked:           isRevoked == 1,
+			CreatedAt:           time.Unix(createdAt, 0),
 		}
 
 		if senderLID.Valid {
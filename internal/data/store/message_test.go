@@ -0,0 +1,244 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestGetSentBroadcastChat(t *testing.T) {
+	s := newTestStore(t)
+	messages := NewMessageStore(s)
+
+	own := types.NewJID("900000000000001", types.HiddenUserServer)
+	list := types.NewJID("1700000000", types.BroadcastServer)
+	alice := types.NewJID("900000000000002", types.HiddenUserServer)
+
+	for _, m := range []*Message{
+		{ID: "B1", ChatJID: list, SenderLID: own, FromMe: true, IsBroadcast: true, BroadcastListJID: list},
+		// A broadcast received from alice is stored in her chat
+		{ID: "B2", ChatJID: alice, SenderLID: alice, IsBroadcast: true, BroadcastListJID: list},
+		{ID: "D1", ChatJID: alice, SenderLID: own, FromMe: true},
+	} {
+		m.Timestamp = time.Now()
+		m.MessageType = "text"
+		if err := messages.Put(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for id, want := range map[string]types.JID{"B1": list, "B2": types.EmptyJID, "D1": types.EmptyJID, "X": types.EmptyJID} {
+		got, err := messages.GetSentBroadcastChat(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("GetSentBroadcastChat(%s) = %s, want %s", id, got, want)
+		}
+	}
+}
+
+func putSearchMessages(t *testing.T, messages *MessageStore, chat types.JID, texts map[string]string) {
+	t.Helper()
+	for id, text := range texts {
+		m := &Message{ID: id, ChatJID: chat, SenderLID: chat, Timestamp: time.Now(), MessageType: "text", TextContent: text}
+		if err := messages.Put(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func searchIDs(t *testing.T, messages *MessageStore, query string) map[string]bool {
+	t.Helper()
+	found, err := messages.Search(query, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[string]bool)
+	for _, m := range found {
+		ids[m.ID] = true
+	}
+	return ids
+}
+
+// TestSearchMatchesEveryTerm checks the FTS index and the LIKE fallback,
+// whichever this build uses, both require every term.
+func TestSearchMatchesEveryTerm(t *testing.T) {
+	s := newTestStore(t)
+	messages := NewMessageStore(s)
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+	putSearchMessages(t, messages, chat, map[string]string{
+		"M1": "dinner on friday at eight",
+		"M2": "friday works for dinner",
+		"M3": "lunch on friday",
+	})
+
+	ids := searchIDs(t, messages, "dinner friday")
+	if len(ids) != 2 || !ids["M1"] || !ids["M2"] {
+		t.Errorf("got %v, want M1 and M2", ids)
+	}
+	ids = searchIDs(t, messages, "frid")
+	if len(ids) != 3 {
+		t.Errorf("prefix search got %v, want all three", ids)
+	}
+}
+
+func TestSearchIndexFollowsWrites(t *testing.T) {
+	s := newTestStore(t)
+	if !s.fts {
+		t.Skip("built without FTS5")
+	}
+	messages := NewMessageStore(s)
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+	putSearchMessages(t, messages, chat, map[string]string{"M1": "original text", "M2": "other text"})
+
+	if err := messages.MarkEdited("M1", chat, "edited words", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, messages, "original"); len(ids) != 0 {
+		t.Errorf("old text still found: %v", ids)
+	}
+	if ids := searchIDs(t, messages, "edited"); !ids["M1"] {
+		t.Errorf("edited text not found: %v", ids)
+	}
+
+	if err := messages.Delete("M2", chat); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, messages, "other"); len(ids) != 0 {
+		t.Errorf("deleted message found: %v", ids)
+	}
+
+	// The index survives rowids being renumbered
+	if _, err := s.Exec(`VACUUM`); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, messages, "edited"); len(ids) != 1 || !ids["M1"] {
+		t.Errorf("after VACUUM got %v, want M1", ids)
+	}
+}
+
+// TestSearchIndexRebuiltWithoutTriggers simulates a database opened by a
+// build without FTS5, which drops the triggers, and checks the index is
+// rebuilt when FTS5 is back.
+func TestSearchIndexRebuiltWithoutTriggers(t *testing.T) {
+	s := newTestStore(t)
+	if !s.fts {
+		t.Skip("built without FTS5")
+	}
+	messages := NewMessageStore(s)
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+	for _, name := range ftsTriggers {
+		if _, err := s.Exec("DROP TRIGGER " + name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	putSearchMessages(t, messages, chat, map[string]string{"M1": "missed while dropped"})
+
+	if err := s.createFTS(); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, messages, "missed"); !ids["M1"] {
+		t.Errorf("got %v, want M1", ids)
+	}
+}
+
+// TestOpenWithoutFTS5DropsTriggers checks a database indexed by an FTS5
+// build still takes writes in a build without it.
+func TestOpenWithoutFTS5DropsTriggers(t *testing.T) {
+	s := newTestStore(t)
+	if s.fts {
+		t.Skip("built with FTS5")
+	}
+	if _, err := s.Exec(`
+		CREATE TRIGGER trg_orion_messages_fts_insert AFTER INSERT ON orion_messages
+		BEGIN
+			INSERT INTO orion_messages_fts (rowid, text_content) VALUES (NEW.rowid, NEW.text_content);
+		END`); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.createFTS(); err != nil {
+		t.Fatal(err)
+	}
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+	putSearchMessages(t, NewMessageStore(s), chat, map[string]string{"M1": "still writable"})
+}
+
+func TestUpdateServerID(t *testing.T) {
+	s := newTestStore(t)
+	messages := NewMessageStore(s)
+
+	channel := types.NewJID("120363000000000009", types.NewsletterServer)
+	other := types.NewJID("120363000000000010", types.NewsletterServer)
+	own := types.NewJID("900000000000001", types.HiddenUserServer)
+	for _, chat := range []types.JID{channel, other} {
+		m := &Message{ID: "SENT1", ChatJID: chat, SenderLID: own, FromMe: true, Timestamp: time.Now(), MessageType: "text", TextContent: "hi"}
+		if err := messages.Put(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := messages.UpdateServerID("SENT1", channel, 42); err != nil {
+		t.Fatal(err)
+	}
+	got, err := messages.GetByServerID(channel, 42)
+	if err != nil || got == nil || got.ID != "SENT1" {
+		t.Fatalf("GetByServerID = %v, %v, want SENT1", got, err)
+	}
+	if got, _ := messages.GetByServerID(other, 42); got != nil {
+		t.Errorf("server ID set on the same ID in another chat")
+	}
+
+	// A later save of the message without its server ID keeps it
+	m := &Message{ID: "SENT1", ChatJID: channel, SenderLID: own, FromMe: true, Timestamp: time.Now(), MessageType: "text", TextContent: "hi"}
+	if err := messages.Put(m); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := messages.GetByServerID(channel, 42); got == nil {
+		t.Error("server ID lost on re-save")
+	}
+}
+
+func TestAudioWaveformRoundTrip(t *testing.T) {
+	s := newTestStore(t)
+	messages := NewMessageStore(s)
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+
+	waveform := make([]byte, 64)
+	for i := range waveform {
+		waveform[i] = byte(i * 100 / 63)
+	}
+	m := &Message{
+		ID: "PTT1", ChatJID: chat, SenderLID: chat, Timestamp: time.Now(), MessageType: "audio",
+		Mimetype: "audio/ogg; codecs=opus", DurationSeconds: 7, IsPTT: true, Waveform: waveform,
+	}
+	if err := messages.Put(m); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := messages.Get("PTT1", chat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsPTT || !bytes.Equal(got.Waveform, waveform) || got.DurationSeconds != 7 || got.Width != 0 {
+		t.Errorf("Get = ptt %v, waveform %v, duration %d, width %d", got.IsPTT, got.Waveform, got.DurationSeconds, got.Width)
+	}
+
+	listed, err := messages.GetByChat(chat, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || !bytes.Equal(listed[0].Waveform, waveform) {
+		t.Errorf("GetByChat lost the waveform: %v", listed)
+	}
+
+	meta, err := messages.GetAudioMeta("PTT1", chat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !meta.IsPTT || meta.DurationSeconds != 7 || meta.Mimetype != m.Mimetype || !bytes.Equal(meta.Waveform, waveform) {
+		t.Errorf("GetAudioMeta = %+v", meta)
+	}
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"go.mau.fi/whatsmeow/types"
@@ -201,3 +202,42 @@ func (s *NewsletterStore) SetMuted(jid types.JID, muted bool) error {
 	`, boolToInt(muted), now, jid.String())
 	return err
 }
+
+// UpdateMessageViews sets the view count of a newsletter post.
+func (s *NewsletterStore) UpdateMessageViews(newsletterJID types.JID, serverID, views int) error {
+	_, err := s.store.Exec(`
+		INSERT INTO orion_newsletter_message_stats (newsletter_jid, server_id, views, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(newsletter_jid, server_id) DO UPDATE SET
+			views = excluded.views,
+			updated_at = excluded.updated_at
+	`, newsletterJID.String(), serverID, views, time.Now().Unix())
+	return err
+}
+
+// UpdateMessageReactionCounts sets the per-emoji reaction counts of a newsletter post.
+func (s *NewsletterStore) UpdateMessageReactionCounts(newsletterJID types.JID, serverID int, counts map[string]int) error {
+	data, err := json.Marshal(counts)
+	if err != nil {
+		return err
+	}
+	_, err = s.store.Exec(`
+		INSERT INTO orion_newsletter_message_stats (newsletter_jid, server_id, reaction_counts, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(newsletter_jid, server_id) DO UPDATE SET
+			reaction_counts = excluded.reaction_counts,
+			updated_at = excluded.updated_at
+	`, newsletterJID.String(), serverID, string(data), time.Now().Unix())
+	return err
+}
+
+// GetNewsletterStats returns how many posts of a newsletter are stored and
+// their total views.
+func (s *NewsletterStore) GetNewsletterStats(newsletterJID types.JID) (posts int, totalViews int, err error) {
+	err = s.store.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM orion_messages WHERE chat_jid = ?),
+			(SELECT COALESCE(SUM(views), 0) FROM orion_newsletter_message_stats WHERE newsletter_jid = ?)
+	`, newsletterJID.String(), newsletterJID.String()).Scan(&posts, &totalViews)
+	return posts, totalViews, err
+}
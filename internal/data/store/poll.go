@@ -1,7 +1,9 @@
 package store
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"time"
 
@@ -127,6 +129,51 @@ func (s *PollStore) GetVoteByVoter(messageID string, chatJID, voterLID types.JID
 	return &v, nil
 }
 
+// TallyPoll counts the votes for each option of a poll.
+// Selected options are stored either as option names (own votes) or as hex
+// SHA-256 hashes of the names (decrypted incoming votes); both are resolved
+// against the poll's options. Each voter's latest vote is counted once.
+func (s *PollStore) TallyPoll(messageID string, chatJID types.JID) (map[string]int, error) {
+	var optionsJSON []byte
+	err := s.store.QueryRow(`
+		SELECT options FROM orion_polls WHERE message_id = ? AND chat_jid = ?
+	`, messageID, chatJID.String()).Scan(&optionsJSON)
+	if err != nil {
+		return nil, err
+	}
+	var options []string
+	if err := json.Unmarshal(optionsJSON, &options); err != nil {
+		return nil, err
+	}
+
+	tally := make(map[string]int, len(options))
+	byKey := make(map[string]string, len(options)*2)
+	for _, opt := range options {
+		tally[opt] = 0
+		hash := sha256.Sum256([]byte(opt))
+		byKey[hex.EncodeToString(hash[:])] = opt
+		byKey[opt] = opt
+	}
+
+	votes, err := s.GetVotes(messageID, chatJID)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range votes {
+		counted := make(map[string]bool, len(v.SelectedOptions))
+		for _, selected := range v.SelectedOptions {
+			opt, ok := byKey[selected]
+			if !ok || counted[opt] {
+				continue
+			}
+			counted[opt] = true
+			tally[opt]++
+		}
+	}
+
+	return tally, nil
+}
+
 // DeleteVote removes a vote (when voter retracts).
 func (s *PollStore) DeleteVote(messageID string, chatJID, voterLID types.JID) error {
 	_, err := s.store.Exec(`
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// PresenceWatchStore persists the JIDs whose presence is kept subscribed
+// across reconnects and restarts.
+type PresenceWatchStore struct {
+	store *Store
+}
+
+// NewPresenceWatchStore creates a new PresenceWatchStore.
+func NewPresenceWatchStore(s *Store) *PresenceWatchStore {
+	return &PresenceWatchStore{store: s}
+}
+
+// Put adds a JID to the watch list.
+func (s *PresenceWatchStore) Put(jid types.JID) error {
+	_, err := s.store.Exec(`
+		INSERT INTO orion_presence_watches (jid, created_at)
+		VALUES (?, ?)
+		ON CONFLICT(jid) DO NOTHING
+	`, jid.String(), time.Now().Unix())
+	return err
+}
+
+// Remove removes a JID from the watch list.
+func (s *PresenceWatchStore) Remove(jid types.JID) error {
+	_, err := s.store.Exec(`DELETE FROM orion_presence_watches WHERE jid = ?`, jid.String())
+	return err
+}
+
+// GetAll returns the watched JIDs, oldest first.
+func (s *PresenceWatchStore) GetAll() ([]types.JID, error) {
+	rows, err := s.store.Query(`SELECT jid FROM orion_presence_watches ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []types.JID
+	for rows.Next() {
+		var jidStr string
+		if err := rows.Scan(&jidStr); err != nil {
+			return nil, err
+		}
+		jid, _ := types.ParseJID(jidStr)
+		result = append(result, jid)
+	}
+
+	return result, rows.Err()
+}
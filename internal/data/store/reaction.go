@@ -78,6 +78,60 @@ func (s *ReactionStore) GetByMessage(messageID string, chatJID types.JID) ([]Rea
 	return reactions, nil
 }
 
+// GetReactionsForMessage returns the reactors of a message grouped by emoji,
+// oldest reaction first. Removed reactions (empty emoji) are excluded.
+func (s *ReactionStore) GetReactionsForMessage(messageID string, chatJID types.JID) (map[string][]types.JID, error) {
+	rows, err := s.store.Query(`
+		SELECT emoji, sender_lid
+		FROM orion_reactions
+		WHERE message_id = ? AND chat_jid = ? AND emoji != ''
+		ORDER BY timestamp ASC
+	`, messageID, chatJID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][]types.JID)
+	for rows.Next() {
+		var emoji, senderLIDStr string
+		if err := rows.Scan(&emoji, &senderLIDStr); err != nil {
+			return nil, err
+		}
+		senderLID, _ := types.ParseJID(senderLIDStr)
+		result[emoji] = append(result[emoji], senderLID)
+	}
+
+	return result, rows.Err()
+}
+
+// CountReactions returns the number of reactions per emoji on a message.
+// Removed reactions (empty emoji) are excluded.
+func (s *ReactionStore) CountReactions(messageID string, chatJID types.JID) (map[string]int, error) {
+	rows, err := s.store.Query(`
+		SELECT emoji, COUNT(*)
+		FROM orion_reactions
+		WHERE message_id = ? AND chat_jid = ? AND emoji != ''
+		GROUP BY emoji
+	`, messageID, chatJID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var emoji string
+		var count int
+		if err := rows.Scan(&emoji, &count); err != nil {
+			return nil, err
+		}
+		counts[emoji] = count
+	}
+
+	return counts, rows.Err()
+}
+
 // GetBySender returns all reactions by a specific sender.
 func (s *ReactionStore) GetBySender(senderLID types.JID) ([]Reaction, error) {
 	rows, err := s.store.Query(`
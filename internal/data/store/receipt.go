@@ -1,6 +1,8 @@
 package store
 
 import (
+	"database/sql"
+	"strings"
 	"time"
 
 	"go.mau.fi/whatsmeow/types"
@@ -35,9 +37,13 @@ func (s *ReceiptStore) Put(r *Receipt) error {
 	return err
 }
 
-// PutMany stores multiple receipts.
+// PutMany stores multiple receipts in a single transaction.
 func (s *ReceiptStore) PutMany(receipts []Receipt) error {
-	tx, err := s.store.Begin()
+	if len(receipts) == 0 {
+		return nil
+	}
+
+	tx, err := s.store.BeginImmediate()
 	if err != nil {
 		return err
 	}
@@ -106,3 +112,133 @@ func (s *ReceiptStore) IsRead(messageID string, chatJID types.JID) (bool, error)
 	`, messageID, chatJID.String()).Scan(&count)
 	return count > 0, err
 }
+
+// Delivery states, from least to most advanced.
+const (
+	DeliveryStateSent      = "sent"
+	DeliveryStateDelivered = "delivered"
+	DeliveryStateRead      = "read"
+	DeliveryStatePlayed    = "played"
+)
+
+// RecipientStatus is the delivery progress of a message for one recipient.
+// Zero times mean the state wasn't reached.
+type RecipientStatus struct {
+	RecipientLID types.JID
+	DeliveredAt  time.Time
+	ReadAt       time.Time
+	PlayedAt     time.Time
+}
+
+// DeliveryStatus summarizes the receipts of a sent message.
+type DeliveryStatus struct {
+	MessageID  string
+	ChatJID    types.JID
+	State      string // Most advanced state reached by any recipient
+	Total      int    // Expected recipients (group members other than the sender, or 1)
+	Delivered  int    // Recipients that received the message (including those that read it)
+	Read       int    // Recipients that read or played the message
+	Played     int    // Recipients that played the voice note/video
+	Recipients []RecipientStatus
+}
+
+// ReadByAll reports whether every expected recipient has read the message.
+func (d *DeliveryStatus) ReadByAll() bool {
+	return d.Total > 0 && d.Read >= d.Total
+}
+
+// GetDeliveryStatus summarizes the receipts of a message per recipient.
+// In groups the expected recipients are the stored participants other than
+// the sender, so the result can be read as "read by Read of Total". self
+// lists the account's own JIDs (PN and LID); participants stored under
+// either are not counted.
+func (s *ReceiptStore) GetDeliveryStatus(messageID string, chatJID types.JID, self ...types.JID) (*DeliveryStatus, error) {
+	// Delivery receipts have an empty type; self receipts and retries are ignored
+	rows, err := s.store.Query(`
+		SELECT recipient_lid,
+			MAX(CASE WHEN receipt_type IN ('', 'delivered') THEN timestamp END),
+			MAX(CASE WHEN receipt_type = 'read' THEN timestamp END),
+			MAX(CASE WHEN receipt_type = 'played' THEN timestamp END)
+		FROM orion_message_receipts
+		WHERE message_id = ? AND chat_jid = ? AND receipt_type IN ('', 'delivered', 'read', 'played')
+		GROUP BY recipient_lid
+		ORDER BY MIN(timestamp)
+	`, messageID, chatJID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	status := &DeliveryStatus{
+		MessageID: messageID,
+		ChatJID:   chatJID,
+		State:     DeliveryStateSent,
+		Total:     1,
+	}
+	for rows.Next() {
+		var recipientStr string
+		var delivered, read, played sql.NullInt64
+		if err := rows.Scan(&recipientStr, &delivered, &read, &played); err != nil {
+			return nil, err
+		}
+
+		r := RecipientStatus{}
+		r.RecipientLID, _ = types.ParseJID(recipientStr)
+		if delivered.Valid {
+			r.DeliveredAt = time.Unix(delivered.Int64, 0)
+		}
+		if read.Valid {
+			r.ReadAt = time.Unix(read.Int64, 0)
+		}
+		if played.Valid {
+			r.PlayedAt = time.Unix(played.Int64, 0)
+		}
+
+		// Later states imply the earlier ones
+		status.Delivered++
+		if read.Valid || played.Valid {
+			status.Read++
+		}
+		if played.Valid {
+			status.Played++
+		}
+		status.Recipients = append(status.Recipients, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case status.Played > 0:
+		status.State = DeliveryStatePlayed
+	case status.Read > 0:
+		status.State = DeliveryStateRead
+	case status.Delivered > 0:
+		status.State = DeliveryStateDelivered
+	}
+
+	if chatJID.Server == types.GroupServer {
+		excluded := make([]string, 0, len(self))
+		args := []any{chatJID.String(), messageID, chatJID.String()}
+		for _, jid := range self {
+			if !jid.IsEmpty() {
+				excluded = append(excluded, "?")
+				args = append(args, jid.ToNonAD().String())
+			}
+		}
+		query := `
+			SELECT COUNT(*) FROM orion_group_participants
+			WHERE group_jid = ? AND member_lid != COALESCE(
+				(SELECT sender_lid FROM orion_messages WHERE id = ? AND chat_jid = ?), '')`
+		if len(excluded) > 0 {
+			query += ` AND member_lid NOT IN (` + strings.Join(excluded, ", ") + `)`
+		}
+		if err := s.store.QueryRow(query, args...).Scan(&status.Total); err != nil {
+			return nil, err
+		}
+		// Participants may be unknown or stale
+		status.Total = max(status.Total, status.Delivered)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+func TestDeliveryStatusExcludesSelf(t *testing.T) {
+	s := newTestStore(t)
+	groups := NewGroupStore(s)
+	receipts := NewReceiptStore(s)
+
+	group := types.NewJID("120363000000000001", types.GroupServer)
+	ownPN := types.NewJID("15550000001", types.DefaultUserServer)
+	ownLID := types.NewJID("900000000000001", types.HiddenUserServer)
+	alice := types.NewJID("900000000000002", types.HiddenUserServer)
+	bob := types.NewJID("900000000000003", types.HiddenUserServer)
+
+	// The account appears under both its LID and its PN
+	for _, member := range []types.JID{ownPN, ownLID, alice, bob} {
+		if err := groups.PutParticipant(&GroupParticipant{GroupJID: group, MemberLID: member}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := receipts.Put(&Receipt{MessageID: "M1", ChatJID: group, RecipientLID: alice, ReceiptType: "read", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := receipts.GetDeliveryStatus("M1", group, ownPN, ownLID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Total != 2 || status.Read != 1 || status.State != DeliveryStateRead {
+		t.Errorf("got total %d, read %d, state %s; want 2, 1, read", status.Total, status.Read, status.State)
+	}
+	if status.ReadByAll() {
+		t.Error("ReadByAll with bob not having read")
+	}
+}
+
+// benchmarkReceipts returns a ReceiptStore on a database file, where each
+// commit is paid for, and 100 receipts for one group message.
+func benchmarkReceipts(b *testing.B) (*ReceiptStore, []Receipt) {
+	b.Helper()
+	s, err := New(filepath.Join(b.TempDir(), "bench.db"), waLog.Noop)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { s.Close() })
+
+	group := types.NewJID("120363000000000001", types.GroupServer)
+	receipts := make([]Receipt, 100)
+	for i := range receipts {
+		receipts[i] = Receipt{
+			MessageID:    "M1",
+			ChatJID:      group,
+			RecipientLID: types.NewJID(fmt.Sprintf("9000000000%05d", i), types.HiddenUserServer),
+			ReceiptType:  "read",
+			Timestamp:    time.Now(),
+		}
+	}
+	return NewReceiptStore(s), receipts
+}
+
+func BenchmarkReceiptPutMany(b *testing.B) {
+	receipts, batch := benchmarkReceipts(b)
+	for b.Loop() {
+		if err := receipts.PutMany(batch); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkReceiptPutEach is the per-row commit baseline for BenchmarkReceiptPutMany.
+func BenchmarkReceiptPutEach(b *testing.B) {
+	receipts, batch := benchmarkReceipts(b)
+	for b.Loop() {
+		for i := range batch {
+			if err := receipts.Put(&batch[i]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Scheduled message statuses.
+const (
+	ScheduledPending   = "pending"
+	ScheduledSent      = "sent"
+	ScheduledFailed    = "failed"
+	ScheduledCancelled = "cancelled"
+)
+
+// ScheduledMessage represents an outgoing message queued for future delivery.
+type ScheduledMessage struct {
+	ID          string
+	ChatJID     types.JID
+	MessageType string
+	Payload     []byte // Serialized waE2E.Message
+	Media       []byte // Media to upload when due; Payload lacks its upload fields
+	MediaType   string // whatsmeow.MediaType of Media
+	FireAt      time.Time
+	Status      string
+	MessageID   string // Set once sent
+	Error       string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// ScheduledMessageStore handles scheduled message persistence.
+type ScheduledMessageStore struct {
+	store *Store
+}
+
+// NewScheduledMessageStore creates a new ScheduledMessageStore.
+func NewScheduledMessageStore(s *Store) *ScheduledMessageStore {
+	return &ScheduledMessageStore{store: s}
+}
+
+// Put stores a new pending scheduled message.
+func (s *ScheduledMessageStore) Put(m *ScheduledMessage) error {
+	now := time.Now().Unix()
+	_, err := s.store.Exec(`
+		INSERT INTO orion_scheduled_messages (id, chat_jid, message_type, payload, media, media_type, fire_at, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		m.ID, m.ChatJID.String(), nullString(m.MessageType), m.Payload, m.Media, nullString(m.MediaType),
+		m.FireAt.Unix(), ScheduledPending, now, now,
+	)
+	if err != nil {
+		return err
+	}
+	m.Status = ScheduledPending
+	m.CreatedAt = time.Unix(now, 0)
+	m.UpdatedAt = m.CreatedAt
+	return nil
+}
+
+// Get retrieves a scheduled message by ID.
+func (s *ScheduledMessageStore) Get(id string) (*ScheduledMessage, error) {
+	rows, err := s.store.Query(`
+		SELECT id, chat_jid, message_type, payload, media, media_type, fire_at, status, message_id, error, created_at, updated_at
+		FROM orion_scheduled_messages WHERE id = ?`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return result[0], nil
+}
+
+// GetDue returns pending messages whose fire time has passed, oldest first.
+func (s *ScheduledMessageStore) GetDue(now time.Time, limit int) ([]*ScheduledMessage, error) {
+	rows, err := s.store.Query(`
+		SELECT id, chat_jid, message_type, payload, media, media_type, fire_at, status, message_id, error, created_at, updated_at
+		FROM orion_scheduled_messages
+		WHERE status = ? AND fire_at <= ?
+		ORDER BY fire_at ASC LIMIT ?`,
+		ScheduledPending, now.Unix(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return s.scanRows(rows)
+}
+
+// GetPending returns all pending messages, soonest first.
+func (s *ScheduledMessageStore) GetPending() ([]*ScheduledMessage, error) {
+	rows, err := s.store.Query(`
+		SELECT id, chat_jid, message_type, payload, media, media_type, fire_at, status, message_id, error, created_at, updated_at
+		FROM orion_scheduled_messages
+		WHERE status = ?
+		ORDER BY fire_at ASC`,
+		ScheduledPending,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return s.scanRows(rows)
+}
+
+// CountPending returns the number of pending messages.
+func (s *ScheduledMessageStore) CountPending() (int, error) {
+	var count int
+	err := s.store.QueryRow(`SELECT COUNT(*) FROM orion_scheduled_messages WHERE status = ?`, ScheduledPending).Scan(&count)
+	return count, err
+}
+
+// MarkSent records that a scheduled message was sent.
+func (s *ScheduledMessageStore) MarkSent(id, messageID string) error {
+	_, err := s.store.Exec(`
+		UPDATE orion_scheduled_messages SET status = ?, message_id = ?, error = NULL, updated_at = ?
+		WHERE id = ?`,
+		ScheduledSent, messageID, time.Now().Unix(), id,
+	)
+	return err
+}
+
+// MarkFailed records that sending a scheduled message failed.
+func (s *ScheduledMessageStore) MarkFailed(id, errMsg string) error {
+	_, err := s.store.Exec(`
+		UPDATE orion_scheduled_messages SET status = ?, error = ?, updated_at = ?
+		WHERE id = ?`,
+		ScheduledFailed, nullString(errMsg), time.Now().Unix(), id,
+	)
+	return err
+}
+
+// Cancel cancels a pending scheduled message.
+// Returns sql.ErrNoRows if there is no pending message with the ID.
+func (s *ScheduledMessageStore) Cancel(id string) error {
+	result, err := s.store.Exec(`
+		UPDATE orion_scheduled_messages SET status = ?, updated_at = ?
+		WHERE id = ? AND status = ?`,
+		ScheduledCancelled, time.Now().Unix(), id, ScheduledPending,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (s *ScheduledMessageStore) scanRows(rows *sql.Rows) ([]*ScheduledMessage, error) {
+	var result []*ScheduledMessage
+	for rows.Next() {
+		var m ScheduledMessage
+		var chatStr string
+		var msgType, mediaType, messageID, errMsg sql.NullString
+		var fireAt, createdAt, updatedAt int64
+		if err := rows.Scan(&m.ID, &chatStr, &msgType, &m.Payload, &m.Media, &mediaType, &fireAt, &m.Status,
+			&messageID, &errMsg, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		m.ChatJID, _ = types.ParseJID(chatStr)
+		m.MessageType = msgType.String
+		m.MediaType = mediaType.String
+		m.MessageID = messageID.String
+		m.Error = errMsg.String
+		m.FireAt = time.Unix(fireAt, 0)
+		m.CreatedAt = time.Unix(createdAt, 0)
+		m.UpdatedAt = time.Unix(updatedAt, 0)
+		result = append(result, &m)
+	}
+	return result, rows.Err()
+}
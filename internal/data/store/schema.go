@@ -16,10 +16,12 @@ package store
 //   - orion_broadcast_lists - Broadcast lists
 //   - orion_broadcast_recipients - Broadcast recipients
 //   - orion_newsletters - Channel/newsletter data
+//   - orion_newsletter_message_stats - View and reaction counts of channel posts
 //   - orion_status_updates - Status updates
 //   - orion_polls - Poll data
 //   - orion_poll_votes - Poll votes
 //   - orion_blocklist - Blocked contacts
+//   - orion_presence_watches - JIDs kept subscribed to presence
 //   - orion_labels - Business labels
 //   - orion_label_associations - Label assignments
 //   - orion_calls - Call history
@@ -27,6 +29,14 @@ package store
 //   - orion_settings - Global settings
 //   - orion_media_cache - Downloaded media cache
 //   - orion_sync_state - Sync progress tracking
+//   - orion_bot_messages - Bot invocations linked to messages
+//   - orion_failed_sends - Outgoing messages that failed to send
+//   - orion_tags - Local tags (not synced, unlike labels)
+//   - orion_tag_associations - Tag assignments
+//   - orion_scheduled_messages - Messages queued for future delivery
+//   - orion_sent_idempotency - Idempotency keys of recent sends
+//   - orion_messages_fts - Full-text index of message text (see ftsSchema)
+//   - orion_messages_fts_keys - Message keys of full-text index entries
 const schema = `
 -- ============================================================
 -- Contacts (with PN - replaces jid_mapping)
@@ -137,14 +147,21 @@ CREATE TABLE IF NOT EXISTS orion_messages (
     width INTEGER,
     height INTEGER,
     duration_seconds INTEGER,
+    thumbnail BLOB, -- JPEG, shown in quotes
     
     -- Audio specific
     is_ptt INTEGER DEFAULT 0,
+    waveform BLOB,
     
     -- Video specific
     is_gif INTEGER DEFAULT 0,
     is_animated INTEGER DEFAULT 0,
     
+    -- Sticker pack
+    sticker_pack_id TEXT,
+    sticker_pack_name TEXT,
+    sticker_author TEXT,
+    
     -- Quote/Reply context
     quoted_message_id TEXT,
     quoted_sender_lid TEXT,
@@ -158,6 +175,9 @@ CREATE TABLE IF NOT EXISTS orion_messages (
     -- Forwarding
     is_forwarded INTEGER DEFAULT 0,
     forwarding_score INTEGER,
+    forwarded_from_jid TEXT,
+    forwarded_from_name TEXT,
+    forward_origin TEXT,
     
     -- Location (if location message)
     latitude REAL,
@@ -186,6 +206,8 @@ CREATE TABLE IF NOT EXISTS orion_messages (
     preview_description TEXT,
     preview_url TEXT,
     preview_matched_text TEXT,
+    preview_thumbnail BLOB,
+    preview_type TEXT,
     
     -- Group invite
     invite_group_jid TEXT,
@@ -200,6 +222,9 @@ CREATE TABLE IF NOT EXISTS orion_messages (
     event_join_link TEXT,
     event_is_canceled INTEGER DEFAULT 0,
     
+    -- Interactive response (flow submissions)
+    interactive_response TEXT,
+    
     -- Flags
     is_broadcast INTEGER DEFAULT 0,
     broadcast_list_jid TEXT,
@@ -221,6 +246,8 @@ CREATE TABLE IF NOT EXISTS orion_messages (
 CREATE INDEX IF NOT EXISTS idx_orion_messages_chat ON orion_messages(chat_jid, timestamp);
 CREATE INDEX IF NOT EXISTS idx_orion_messages_sender ON orion_messages(sender_lid);
 CREATE INDEX IF NOT EXISTS idx_orion_messages_starred ON orion_messages(is_starred) WHERE is_starred = 1;
+CREATE INDEX IF NOT EXISTS idx_orion_messages_server_id ON orion_messages(chat_jid, server_id) WHERE server_id IS NOT NULL AND server_id != 0;
+CREATE INDEX IF NOT EXISTS idx_orion_messages_quoted ON orion_messages(chat_jid, quoted_message_id) WHERE quoted_message_id IS NOT NULL;
 
 -- ============================================================
 -- Message receipts (delivery/read status)
@@ -312,6 +339,8 @@ CREATE TABLE IF NOT EXISTS orion_groups (
     created_at INTEGER NOT NULL,
     updated_at INTEGER NOT NULL
 );
+CREATE INDEX IF NOT EXISTS idx_orion_groups_parent ON orion_groups(parent_group_jid);
+CREATE INDEX IF NOT EXISTS idx_orion_groups_linked_parent ON orion_groups(linked_parent_jid);
 
 -- ============================================================
 -- Group participants
@@ -393,6 +422,18 @@ CREATE TABLE IF NOT EXISTS orion_newsletters (
     updated_at INTEGER NOT NULL
 );
 
+-- ============================================================
+-- Newsletter post stats (keyed by server ID, like live updates)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS orion_newsletter_message_stats (
+    newsletter_jid TEXT NOT NULL,
+    server_id INTEGER NOT NULL,
+    views INTEGER NOT NULL DEFAULT 0,
+    reaction_counts TEXT,
+    updated_at INTEGER NOT NULL,
+    PRIMARY KEY (newsletter_jid, server_id)
+);
+
 -- ============================================================
 -- Status updates
 -- ============================================================
@@ -455,6 +496,14 @@ CREATE TABLE IF NOT EXISTS orion_blocklist (
     blocked_at INTEGER NOT NULL
 );
 
+-- ============================================================
+-- Presence Watches
+-- ============================================================
+CREATE TABLE IF NOT EXISTS orion_presence_watches (
+    jid TEXT PRIMARY KEY,
+    created_at INTEGER NOT NULL
+);
+
 -- ============================================================
 -- Labels (for business)
 -- ============================================================
@@ -550,6 +599,7 @@ CREATE TABLE IF NOT EXISTS orion_media_cache (
     local_path TEXT,
     downloaded_at INTEGER,
     file_size INTEGER,
+    ocr_text TEXT, -- Text recognized in images, if OCR is enabled
     PRIMARY KEY (message_id, chat_jid)
 );
 CREATE INDEX IF NOT EXISTS idx_orion_media_cache_path ON orion_media_cache(local_path);
@@ -579,6 +629,109 @@ CREATE TABLE IF NOT EXISTS orion_bots (
     updated_at INTEGER NOT NULL
 );
 
+-- ============================================================
+-- Bot messages (bot invocations and bot-generated replies)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS orion_bot_messages (
+    message_id TEXT NOT NULL,
+    chat_jid TEXT NOT NULL,
+    bot_jid TEXT NOT NULL,
+    invoker_lid TEXT,
+    prompt TEXT,
+    persona_id TEXT,
+    is_invoke INTEGER DEFAULT 0,
+    timestamp INTEGER NOT NULL,
+    PRIMARY KEY (message_id, chat_jid)
+);
+CREATE INDEX IF NOT EXISTS idx_orion_bot_messages_bot ON orion_bot_messages(bot_jid, timestamp DESC);
+
+-- ============================================================
+-- Failed sends (persisted for retry after restart)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS orion_failed_sends (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message_id TEXT NOT NULL,
+    chat_jid TEXT NOT NULL,
+    message_type TEXT,
+    payload BLOB NOT NULL,      -- Serialized waE2E.Message
+    error TEXT,
+    is_permanent INTEGER DEFAULT 0,
+    attempts INTEGER DEFAULT 1,
+    created_at INTEGER NOT NULL,
+    last_attempt_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_orion_failed_sends_retry ON orion_failed_sends(is_permanent, attempts);
+
+-- ============================================================
+-- Tags (local-only, for personal organization)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS orion_tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    created_at INTEGER NOT NULL
+);
+
+-- ============================================================
+-- Tag associations
+-- ============================================================
+CREATE TABLE IF NOT EXISTS orion_tag_associations (
+    tag_id INTEGER NOT NULL REFERENCES orion_tags(id) ON DELETE CASCADE,
+    target_type TEXT NOT NULL,  -- chat, contact, message
+    target_jid TEXT NOT NULL,
+    message_id TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL,
+    PRIMARY KEY (tag_id, target_type, target_jid, message_id)
+);
+CREATE INDEX IF NOT EXISTS idx_orion_tag_assoc_target ON orion_tag_associations(target_type, target_jid);
+
+-- Drop tags of deleted chats, contacts and messages
+CREATE TRIGGER IF NOT EXISTS trg_orion_chats_untag AFTER DELETE ON orion_chats
+BEGIN
+    DELETE FROM orion_tag_associations WHERE target_type = 'chat' AND target_jid = OLD.jid;
+END;
+CREATE TRIGGER IF NOT EXISTS trg_orion_contacts_untag AFTER DELETE ON orion_contacts
+BEGIN
+    DELETE FROM orion_tag_associations WHERE target_type = 'contact' AND target_jid = OLD.lid;
+END;
+CREATE TRIGGER IF NOT EXISTS trg_orion_messages_untag AFTER DELETE ON orion_messages
+BEGIN
+    DELETE FROM orion_tag_associations
+    WHERE target_type = 'message' AND target_jid = OLD.chat_jid AND message_id = OLD.id;
+END;
+
+-- ============================================================
+-- Scheduled messages (sent by SendService when due)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS orion_scheduled_messages (
+    id TEXT PRIMARY KEY,        -- Also the message ID it is sent with
+    chat_jid TEXT NOT NULL,
+    message_type TEXT,
+    payload BLOB NOT NULL,      -- Serialized waE2E.Message
+    media BLOB,                 -- Media uploaded when due, if any
+    media_type TEXT,            -- whatsmeow.MediaType of media
+    fire_at INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending', -- pending, sent, failed, cancelled
+    message_id TEXT,            -- Set once sent
+    error TEXT,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_orion_scheduled_messages_due ON orion_scheduled_messages(status, fire_at);
+
+-- ============================================================
+-- Send idempotency keys (key -> message sent with it)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS orion_sent_idempotency (
+    key TEXT PRIMARY KEY,
+    message_id TEXT NOT NULL,
+    chat_jid TEXT NOT NULL,
+    server_id INTEGER,
+    sender TEXT,
+    timestamp INTEGER,          -- Set once sent
+    created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_orion_sent_idempotency_created ON orion_sent_idempotency(created_at);
+
 -- ============================================================
 -- AI Conversation Summaries
 -- ============================================================
@@ -605,3 +758,89 @@ CREATE TABLE IF NOT EXISTS orion_tools (
 );
 CREATE INDEX IF NOT EXISTS idx_orion_tools_chat ON orion_tools(chat_jid);
 `
+
+// ftsSchema creates the full-text index over message text and the triggers
+// that keep it in sync. It needs SQLite built with FTS5 (go build -tags sqlite_fts5),
+// so it is applied separately and search falls back to LIKE without it.
+//
+// orion_messages has no INTEGER PRIMARY KEY, so its rowids may change on
+// VACUUM. Index entries are keyed on orion_messages_fts_keys.doc_id instead,
+// which maps them to the message's (id, chat_jid).
+const ftsSchema = `
+CREATE TABLE IF NOT EXISTS orion_messages_fts_keys (
+    doc_id INTEGER PRIMARY KEY,
+    id TEXT NOT NULL,
+    chat_jid TEXT NOT NULL,
+    UNIQUE (id, chat_jid)
+);
+CREATE VIRTUAL TABLE IF NOT EXISTS orion_messages_fts USING fts5(
+    text_content,
+    caption
+);
+CREATE TRIGGER IF NOT EXISTS trg_orion_messages_fts_insert AFTER INSERT ON orion_messages
+BEGIN
+    INSERT OR IGNORE INTO orion_messages_fts_keys (id, chat_jid) VALUES (NEW.id, NEW.chat_jid);
+    INSERT INTO orion_messages_fts (rowid, text_content, caption)
+    SELECT doc_id, NEW.text_content, NEW.caption FROM orion_messages_fts_keys
+    WHERE id = NEW.id AND chat_jid = NEW.chat_jid;
+END;
+CREATE TRIGGER IF NOT EXISTS trg_orion_messages_fts_delete AFTER DELETE ON orion_messages
+BEGIN
+    DELETE FROM orion_messages_fts WHERE rowid IN (
+        SELECT doc_id FROM orion_messages_fts_keys WHERE id = OLD.id AND chat_jid = OLD.chat_jid
+    );
+    DELETE FROM orion_messages_fts_keys WHERE id = OLD.id AND chat_jid = OLD.chat_jid;
+END;
+CREATE TRIGGER IF NOT EXISTS trg_orion_messages_fts_update AFTER UPDATE OF text_content, caption ON orion_messages
+BEGIN
+    UPDATE orion_messages_fts SET text_content = NEW.text_content, caption = NEW.caption
+    WHERE rowid IN (
+        SELECT doc_id FROM orion_messages_fts_keys WHERE id = NEW.id AND chat_jid = NEW.chat_jid
+    );
+END;
+`
+
+// ftsTriggers are the triggers created by ftsSchema. They're dropped when
+// SQLite lacks FTS5, as writes to orion_messages would fail on them.
+var ftsTriggers = []string{
+	"trg_orion_messages_fts_insert",
+	"trg_orion_messages_fts_delete",
+	"trg_orion_messages_fts_update",
+}
+
+// ftsRebuild reindexes every message, for an index that was just created
+// or missed writes while its triggers were dropped.
+const ftsRebuild = `
+DELETE FROM orion_messages_fts;
+DELETE FROM orion_messages_fts_keys;
+INSERT INTO orion_messages_fts_keys (id, chat_jid) SELECT id, chat_jid FROM orion_messages;
+INSERT INTO orion_messages_fts (rowid, text_content, caption)
+SELECT k.doc_id, m.text_content, m.caption
+FROM orion_messages_fts_keys k JOIN orion_messages m ON m.id = k.id AND m.chat_jid = k.chat_jid;
+`
+
+// addedColumns lists columns added to tables after their first release.
+// CREATE TABLE IF NOT EXISTS leaves existing tables alone, so these are
+// added to older databases on startup.
+var addedColumns = []struct {
+	table, column, definition string
+}{
+	{"orion_messages", "forwarded_from_jid", "TEXT"},
+	{"orion_messages", "forwarded_from_name", "TEXT"},
+	{"orion_messages", "forward_origin", "TEXT"},
+	{"orion_messages", "waveform", "BLOB"},
+	{"orion_messages", "interactive_response", "TEXT"},
+	{"orion_messages", "preview_thumbnail", "BLOB"},
+	{"orion_messages", "preview_type", "TEXT"},
+	{"orion_messages", "sticker_pack_id", "TEXT"},
+	{"orion_messages", "sticker_pack_name", "TEXT"},
+	{"orion_messages", "sticker_author", "TEXT"},
+	{"orion_messages", "thumbnail", "BLOB"},
+	{"orion_media_cache", "ocr_text", "TEXT"},
+}
+
+// addedColumnIndexes indexes columns in addedColumns. It runs after they
+// are added, since an older table lacks them when schema runs.
+const addedColumnIndexes = `
+CREATE INDEX IF NOT EXISTS idx_orion_messages_sticker_pack ON orion_messages(sticker_pack_id) WHERE sticker_pack_id IS NOT NULL;
+`
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// StatusUpdate represents a status (story) post.
+type StatusUpdate struct {
+	ID          string
+	SenderLID   types.JID
+	MessageType string
+
+	TextContent string
+	Caption     string
+
+	MediaDirectPath string
+	MediaKey        []byte
+	FileSHA256      []byte
+	FileEncSHA256   []byte
+	FileLength      int64
+	Mimetype        string
+
+	Timestamp time.Time
+	ExpiresAt time.Time
+}
+
+// StatusStore handles status update persistence.
+type StatusStore struct {
+	store *Store
+}
+
+// NewStatusStore creates a new StatusStore.
+func NewStatusStore(s *Store) *StatusStore {
+	return &StatusStore{store: s}
+}
+
+// Put stores or replaces a status update.
+func (s *StatusStore) Put(u *StatusUpdate) error {
+	_, err := s.store.Exec(`
+		INSERT OR REPLACE INTO orion_status_updates (
+			id, sender_lid, message_type, text_content, caption,
+			media_direct_path, media_key, file_sha256, file_enc_sha256, file_length, mimetype,
+			timestamp, expires_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		u.ID, u.SenderLID.String(), u.MessageType, nullString(u.TextContent), nullString(u.Caption),
+		nullString(u.MediaDirectPath), u.MediaKey, u.FileSHA256, u.FileEncSHA256, nullInt64(u.FileLength), nullString(u.Mimetype),
+		u.Timestamp.Unix(), u.ExpiresAt.Unix(),
+	)
+	return err
+}
+
+// GetActive returns unexpired status updates, newest first.
+func (s *StatusStore) GetActive() ([]*StatusUpdate, error) {
+	rows, err := s.store.Query(`
+		SELECT id, sender_lid, message_type, text_content, caption,
+			media_direct_path, media_key, file_sha256, file_enc_sha256, file_length, mimetype,
+			timestamp, expires_at
+		FROM orion_status_updates WHERE expires_at > ?
+		ORDER BY timestamp DESC`,
+		time.Now().Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*StatusUpdate
+	for rows.Next() {
+		var u StatusUpdate
+		var senderStr string
+		var text, caption, directPath, mimetype sql.NullString
+		var fileLength sql.NullInt64
+		var timestamp, expiresAt int64
+		if err := rows.Scan(&u.ID, &senderStr, &u.MessageType, &text, &caption,
+			&directPath, &u.MediaKey, &u.FileSHA256, &u.FileEncSHA256, &fileLength, &mimetype,
+			&timestamp, &expiresAt); err != nil {
+			return nil, err
+		}
+		u.SenderLID, _ = types.ParseJID(senderStr)
+		u.TextContent = text.String
+		u.Caption = caption.String
+		u.MediaDirectPath = directPath.String
+		u.FileLength = fileLength.Int64
+		u.Mimetype = mimetype.String
+		u.Timestamp = time.Unix(timestamp, 0)
+		u.ExpiresAt = time.Unix(expiresAt, 0)
+		result = append(result, &u)
+	}
+	return result, rows.Err()
+}
+
+// DeleteExpired removes expired status updates.
+func (s *StatusStore) DeleteExpired() error {
+	_, err := s.store.Exec(`DELETE FROM orion_status_updates WHERE expires_at <= ?`, time.Now().Unix())
+	return err
+}
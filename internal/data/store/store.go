@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	_ "github.com/mattn/go-sqlite3"
 	"go.mau.fi/whatsmeow/store"
@@ -18,20 +20,52 @@ type Store struct {
 	db        *sql.DB
 	container *sqlstore.Container
 	log       waLog.Logger
+
+	// fts reports whether the FTS5 message index is available.
+	fts bool
+}
+
+// Options tunes the SQLite connection.
+type Options struct {
+	JournalMode   string // SQLite journal_mode, e.g. WAL
+	Synchronous   string // SQLite synchronous level, e.g. NORMAL
+	BusyTimeoutMs int    // How long a connection waits for a lock before failing
+	MaxOpenConns  int    // Connection pool size (0 = unlimited)
+	MaxIdleConns  int    // Idle connections kept open
+}
+
+// DefaultOptions returns options suited to concurrent writers: WAL lets
+// readers proceed during writes, and the busy timeout makes writers wait
+// for each other instead of failing with "database is locked".
+func DefaultOptions() Options {
+	return Options{
+		JournalMode:   "WAL",
+		Synchronous:   "NORMAL",
+		BusyTimeoutMs: 5000,
+		MaxOpenConns:  4,
+		MaxIdleConns:  4,
+	}
 }
 
-// New creates a new Store with the given database path.
+// New creates a new Store with the given database path and default options.
 func New(dbPath string, log waLog.Logger) (*Store, error) {
+	return NewWithOptions(dbPath, DefaultOptions(), log)
+}
+
+// NewWithOptions creates a new Store with the given database path and options.
+func NewWithOptions(dbPath string, opts Options, log waLog.Logger) (*Store, error) {
 	// Ensure directory exists
 	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
 		return nil, fmt.Errorf("failed to create store directory: %w", err)
 	}
 
 	// Open database
-	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on&_journal_mode=WAL")
+	db, err := sql.Open("sqlite3", dbPath+"?"+dsnParams(opts))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
 
 	// Create whatsmeow container
 	container := sqlstore.NewWithDB(db, "sqlite3", log.Sub("whatsmeow"))
@@ -57,6 +91,24 @@ func New(dbPath string, log waLog.Logger) (*Store, error) {
 	return s, nil
 }
 
+// dsnParams builds the connection parameters for opts. The pragmas go in
+// the DSN rather than through Exec so that every pooled connection gets
+// them, not just the first one.
+func dsnParams(opts Options) string {
+	params := url.Values{}
+	params.Set("_foreign_keys", "on")
+	if opts.JournalMode != "" {
+		params.Set("_journal_mode", opts.JournalMode)
+	}
+	if opts.Synchronous != "" {
+		params.Set("_synchronous", opts.Synchronous)
+	}
+	if opts.BusyTimeoutMs > 0 {
+		params.Set("_busy_timeout", strconv.Itoa(opts.BusyTimeoutMs))
+	}
+	return params.Encode()
+}
+
 // Container returns the whatsmeow sqlstore container.
 func (s *Store) Container() *sqlstore.Container {
 	return s.container
@@ -88,8 +140,80 @@ func (s *Store) Close() error {
 
 // createTables creates all app-specific tables.
 func (s *Store) createTables() error {
-	_, err := s.db.Exec(schema)
-	return err
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+	if err := s.addColumns(); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(addedColumnIndexes); err != nil {
+		return err
+	}
+	return s.createFTS()
+}
+
+// addColumns adds the columns in addedColumns that an older database lacks.
+func (s *Store) addColumns() error {
+	for _, c := range addedColumns {
+		var exists int
+		if err := s.db.QueryRow(`
+			SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`,
+			c.table, c.column,
+		).Scan(&exists); err != nil {
+			return err
+		}
+		if exists > 0 {
+			continue
+		}
+		if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition)); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
+// createFTS creates the full-text message index if SQLite supports FTS5.
+// The index is rebuilt when it's new or had its triggers dropped by a build
+// without FTS5.
+func (s *Store) createFTS() error {
+	var fts5 bool
+	if err := s.db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5); err != nil {
+		return err
+	}
+	if !fts5 {
+		// An index created by an FTS5 build would break every message write
+		for _, name := range ftsTriggers {
+			if _, err := s.db.Exec("DROP TRIGGER IF EXISTS " + name); err != nil {
+				return fmt.Errorf("failed to drop %s: %w", name, err)
+			}
+		}
+		s.log.Warnf("Full-text search unavailable, build with -tags sqlite_fts5 to enable it")
+		return nil
+	}
+
+	var tables, triggers int
+	if err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'orion_messages_fts'`,
+	).Scan(&tables); err != nil {
+		return err
+	}
+	if err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'trg_orion_messages_fts_%'`,
+	).Scan(&triggers); err != nil {
+		return err
+	}
+
+	if _, err := s.db.Exec(ftsSchema); err != nil {
+		return fmt.Errorf("failed to create message index: %w", err)
+	}
+	s.fts = true
+
+	if tables == 0 || triggers < len(ftsTriggers) {
+		if _, err := s.db.Exec(ftsRebuild); err != nil {
+			return fmt.Errorf("failed to build message index: %w", err)
+		}
+	}
+	return nil
 }
 
 // Exec executes a query without returning rows.
@@ -107,7 +231,86 @@ func (s *Store) QueryRow(query string, args ...interface{}) *sql.Row {
 	return s.db.QueryRow(query, args...)
 }
 
-// Begin starts a transaction.
-func (s *Store) Begin() (*sql.Tx, error) {
-	return s.db.Begin()
+// Tx is a write transaction holding one pooled connection until it's
+// committed or rolled back.
+type Tx struct {
+	conn *sql.Conn
+	done bool
+}
+
+// BeginImmediate starts a write transaction with BEGIN IMMEDIATE. Taking the
+// write lock up front makes concurrent writers wait on the busy timeout,
+// where a deferred transaction reading first would fail with SQLITE_BUSY
+// when it tried to write. Other transactions, like whatsmeow's, keep
+// SQLite's deferred default.
+func (s *Store) BeginImmediate() (*Tx, error) {
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &Tx{conn: conn}, nil
+}
+
+// Exec executes a query without returning rows.
+func (tx *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if tx.done {
+		return nil, sql.ErrTxDone
+	}
+	return tx.conn.ExecContext(context.Background(), query, args...)
+}
+
+// Query executes a query that returns rows.
+func (tx *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	if tx.done {
+		return nil, sql.ErrTxDone
+	}
+	return tx.conn.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRow executes a query that returns a single row.
+func (tx *Tx) QueryRow(query string, args ...interface{}) *sql.Row {
+	// Once done, the closed connection makes Scan fail with sql.ErrConnDone
+	return tx.conn.QueryRowContext(context.Background(), query, args...)
+}
+
+// Prepare creates a prepared statement for use within the transaction.
+func (tx *Tx) Prepare(query string) (*sql.Stmt, error) {
+	if tx.done {
+		return nil, sql.ErrTxDone
+	}
+	return tx.conn.PrepareContext(context.Background(), query)
+}
+
+// Commit commits the transaction. If that fails it's rolled back, so the
+// connection goes back to the pool outside a transaction.
+func (tx *Tx) Commit() error {
+	return tx.end("COMMIT")
+}
+
+// Rollback aborts the transaction. After Commit it returns sql.ErrTxDone
+// and does nothing, so it can be deferred.
+func (tx *Tx) Rollback() error {
+	return tx.end("ROLLBACK")
+}
+
+func (tx *Tx) end(stmt string) error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+	defer tx.conn.Close()
+
+	ctx := context.Background()
+	if _, err := tx.conn.ExecContext(ctx, stmt); err != nil {
+		if stmt != "ROLLBACK" {
+			tx.conn.ExecContext(ctx, "ROLLBACK")
+		}
+		return err
+	}
+	return nil
 }
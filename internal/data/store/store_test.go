@@ -0,0 +1,145 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// newTestStore opens a Store on an in-memory database. A single connection
+// keeps every query on the same database.
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := NewWithOptions(":memory:", Options{MaxOpenConns: 1, MaxIdleConns: 1}, waLog.Noop)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// TestOpenOlderDatabase opens a database whose tables predate addedColumns
+// and checks the columns are added.
+func TestOpenOlderDatabase(t *testing.T) {
+	// Recreate the tables of addedColumns without those columns
+	current := newTestStore(t)
+	added := make(map[string]map[string]bool)
+	for _, c := range addedColumns {
+		if added[c.table] == nil {
+			added[c.table] = make(map[string]bool)
+		}
+		added[c.table][c.column] = true
+	}
+	var ddl []string
+	for table, columns := range added {
+		rows, err := current.Query(`SELECT name, type FROM pragma_table_info(?)`, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var defs []string
+		for rows.Next() {
+			var name, typ string
+			if err := rows.Scan(&name, &typ); err != nil {
+				t.Fatal(err)
+			}
+			if !columns[name] {
+				defs = append(defs, name+" "+typ)
+			}
+		}
+		rows.Close()
+		ddl = append(ddl, fmt.Sprintf("CREATE TABLE %s (%s);", table, strings.Join(defs, ", ")))
+	}
+
+	path := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(strings.Join(ddl, "\n")); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	s, err := New(path, waLog.Noop)
+	if err != nil {
+		t.Fatalf("open older database: %v", err)
+	}
+
+	for _, c := range addedColumns {
+		var n int
+		if err := s.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, c.table, c.column).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Errorf("column %s.%s not added", c.table, c.column)
+		}
+	}
+
+	// Opening again finds nothing to add
+	s.Close()
+	if s, err = New(path, waLog.Noop); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	s.Close()
+}
+
+// TestBeginImmediate checks the store's transactions take the write lock
+// when they start while other transactions stay deferred.
+func TestBeginImmediate(t *testing.T) {
+	s, err := NewWithOptions(filepath.Join(t.TempDir(), "orion.db"),
+		Options{JournalMode: "WAL", BusyTimeoutMs: 50, MaxOpenConns: 4, MaxIdleConns: 4}, waLog.Noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	tx, err := s.BeginImmediate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(`INSERT INTO orion_blocklist (jid, blocked_at) VALUES ('a@s.whatsapp.net', 1)`); err != nil {
+		t.Fatal(err)
+	}
+
+	// A deferred transaction starts without the lock, and only waits for it
+	// on its first write
+	deferred, err := s.DB().Begin()
+	if err != nil {
+		t.Fatalf("deferred transaction blocked: %v", err)
+	}
+	deferred.Rollback()
+	if other, err := s.BeginImmediate(); err == nil {
+		other.Rollback()
+		t.Fatal("second immediate transaction took the write lock")
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != sql.ErrTxDone {
+		t.Errorf("Rollback after Commit = %v, want sql.ErrTxDone", err)
+	}
+
+	// Rolled back writes are discarded and the connection is reusable
+	tx, err = s.BeginImmediate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(`INSERT INTO orion_blocklist (jid, blocked_at) VALUES ('b@s.whatsapp.net', 1)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := s.QueryRow(`SELECT COUNT(*) FROM orion_blocklist`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("%d blocklist rows, %v; want only the committed one", n, err)
+	}
+	if strings.Contains(dsnParams(Options{}), "txlock") {
+		t.Error("every transaction opened with BEGIN IMMEDIATE")
+	}
+}
@@ -47,7 +47,7 @@ func (s *SummaryStore) Put(sum *Summary) error {
 func (s *SummaryStore) GetLatest(chatJID types.JID) (*Summary, error) {
 	row := s.store.QueryRow(`
 		SELECT id, chat_jid, summary_text, token_count, from_message_id, to_message_id, created_at
-		FROM orion_summaries WHERE chat_jid = ? ORDER BY created_at DESC LIMIT 1`,
+		FROM orion_summaries WHERE chat_jid = ? ORDER BY created_at DESC, id DESC LIMIT 1`,
 		chatJID.String(),
 	)
 
@@ -67,7 +67,7 @@ func (s *SummaryStore) GetLatest(chatJID types.JID) (*Summary, error) {
 func (s *SummaryStore) GetByChatJID(chatJID types.JID) ([]*Summary, error) {
 	rows, err := s.store.Query(`
 		SELECT id, chat_jid, summary_text, token_count, from_message_id, to_message_id, created_at
-		FROM orion_summaries WHERE chat_jid = ? ORDER BY created_at DESC`,
+		FROM orion_summaries WHERE chat_jid = ? ORDER BY created_at DESC, id DESC`,
 		chatJID.String(),
 	)
 	if err != nil {
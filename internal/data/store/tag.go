@@ -0,0 +1,180 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Tag target types.
+const (
+	TagTargetChat    = "chat"
+	TagTargetContact = "contact"
+	TagTargetMessage = "message"
+)
+
+// Tag represents a local tag. Unlike labels, tags are never synced.
+type Tag struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+}
+
+// TagAssociation represents a tag assignment to a chat, contact or message.
+type TagAssociation struct {
+	TagID      int64
+	TargetType string // "chat", "contact" or "message"
+	TargetJID  types.JID
+	MessageID  string // Only for message associations
+	CreatedAt  time.Time
+}
+
+// TagStore handles tag operations.
+type TagStore struct {
+	store *Store
+}
+
+// NewTagStore creates a new TagStore.
+func NewTagStore(s *Store) *TagStore {
+	return &TagStore{store: s}
+}
+
+// CreateTag creates a tag, or returns the existing one. Names are case-insensitive.
+func (s *TagStore) CreateTag(name string) (*Tag, error) {
+	_, err := s.store.Exec(`
+		INSERT INTO orion_tags (name, created_at) VALUES (?, ?)
+		ON CONFLICT(name) DO NOTHING
+	`, name, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTag(name)
+}
+
+// GetTag retrieves a tag by name.
+func (s *TagStore) GetTag(name string) (*Tag, error) {
+	row := s.store.QueryRow(`SELECT id, name, created_at FROM orion_tags WHERE name = ?`, name)
+
+	var tag Tag
+	var createdAt int64
+	if err := row.Scan(&tag.ID, &tag.Name, &createdAt); err != nil {
+		return nil, err
+	}
+	tag.CreatedAt = time.Unix(createdAt, 0)
+	return &tag, nil
+}
+
+// GetAllTags retrieves all tags.
+func (s *TagStore) GetAllTags() ([]*Tag, error) {
+	rows, err := s.store.Query(`SELECT id, name, created_at FROM orion_tags ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return s.scanTags(rows)
+}
+
+// DeleteTag deletes a tag and all its associations.
+func (s *TagStore) DeleteTag(name string) error {
+	_, err := s.store.Exec(`DELETE FROM orion_tags WHERE name = ?`, name)
+	return err
+}
+
+// TagChat tags a chat, creating the tag if needed.
+func (s *TagStore) TagChat(tag string, chatJID types.JID) error {
+	return s.associate(tag, TagTargetChat, chatJID, "")
+}
+
+// TagContact tags a contact, creating the tag if needed.
+func (s *TagStore) TagContact(tag string, lid types.JID) error {
+	return s.associate(tag, TagTargetContact, lid, "")
+}
+
+// TagMessage tags a message, creating the tag if needed.
+func (s *TagStore) TagMessage(tag string, chatJID types.JID, messageID string) error {
+	return s.associate(tag, TagTargetMessage, chatJID, messageID)
+}
+
+// Untag removes a tag from a target. messageID is only used for messages.
+func (s *TagStore) Untag(tag, targetType string, targetJID types.JID, messageID string) error {
+	_, err := s.store.Exec(`
+		DELETE FROM orion_tag_associations
+		WHERE tag_id = (SELECT id FROM orion_tags WHERE name = ?)
+			AND target_type = ? AND target_jid = ? AND message_id = ?
+	`, tag, targetType, targetJID.String(), messageID)
+	return err
+}
+
+// GetTagged retrieves everything of targetType carrying tag, newest first.
+func (s *TagStore) GetTagged(tag, targetType string) ([]*TagAssociation, error) {
+	rows, err := s.store.Query(`
+		SELECT a.tag_id, a.target_type, a.target_jid, a.message_id, a.created_at
+		FROM orion_tag_associations a
+		JOIN orion_tags t ON t.id = a.tag_id
+		WHERE t.name = ? AND a.target_type = ?
+		ORDER BY a.created_at DESC
+	`, tag, targetType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assocs []*TagAssociation
+	for rows.Next() {
+		var a TagAssociation
+		var targetJID string
+		var createdAt int64
+		if err := rows.Scan(&a.TagID, &a.TargetType, &targetJID, &a.MessageID, &createdAt); err != nil {
+			return nil, err
+		}
+		a.TargetJID, _ = types.ParseJID(targetJID)
+		a.CreatedAt = time.Unix(createdAt, 0)
+		assocs = append(assocs, &a)
+	}
+	return assocs, rows.Err()
+}
+
+// GetTagsFor retrieves the tags on a target. messageID is only used for messages.
+func (s *TagStore) GetTagsFor(targetType string, targetJID types.JID, messageID string) ([]*Tag, error) {
+	rows, err := s.store.Query(`
+		SELECT t.id, t.name, t.created_at
+		FROM orion_tags t
+		JOIN orion_tag_associations a ON t.id = a.tag_id
+		WHERE a.target_type = ? AND a.target_jid = ? AND a.message_id = ?
+		ORDER BY t.name
+	`, targetType, targetJID.String(), messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return s.scanTags(rows)
+}
+
+// associate creates the tag if needed and assigns it to the target.
+func (s *TagStore) associate(tag, targetType string, targetJID types.JID, messageID string) error {
+	t, err := s.CreateTag(tag)
+	if err != nil {
+		return err
+	}
+	_, err = s.store.Exec(`
+		INSERT INTO orion_tag_associations (tag_id, target_type, target_jid, message_id, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(tag_id, target_type, target_jid, message_id) DO NOTHING
+	`, t.ID, targetType, targetJID.String(), messageID, time.Now().Unix())
+	return err
+}
+
+func (s *TagStore) scanTags(rows *sql.Rows) ([]*Tag, error) {
+	var tags []*Tag
+	for rows.Next() {
+		var tag Tag
+		var createdAt int64
+		if err := rows.Scan(&tag.ID, &tag.Name, &createdAt); err != nil {
+			return nil, err
+		}
+		tag.CreatedAt = time.Unix(createdAt, 0)
+		tags = append(tags, &tag)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,201 @@
+// Package vcard parses the vCards carried by WhatsApp contact messages.
+package vcard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Phone is a phone number from a vCard.
+type Phone struct {
+	Number string   // As written, e.g. "+62 812-3456-789"
+	WAID   string   // WhatsApp user (digits only), if the card links one
+	Types  []string // CELL, WORK, HOME, ...
+}
+
+// ParsedContact holds the fields extracted from a vCard.
+type ParsedContact struct {
+	FullName  string
+	FirstName string
+	LastName  string
+	Phones    []Phone
+	Emails    []string
+	Org       string
+	Title     string
+}
+
+// WhatsAppUser returns the WhatsApp user of the contact: the first phone
+// linked with a waid, otherwise the digits of the first number written in
+// international form ("+62 812-..."). Local numbers lack the country code
+// and aren't guessed at.
+func (c *ParsedContact) WhatsAppUser() string {
+	for _, p := range c.Phones {
+		if p.WAID != "" {
+			return p.WAID
+		}
+	}
+	for _, p := range c.Phones {
+		if !strings.HasPrefix(p.Number, "+") {
+			continue
+		}
+		if digits := onlyDigits(p.Number); digits != "" {
+			return digits
+		}
+	}
+	return ""
+}
+
+// ParseVCard parses a vCard 2.1/3.0/4.0 string.
+func ParseVCard(vcard string) (*ParsedContact, error) {
+	lines := unfold(vcard)
+
+	var c ParsedContact
+	var begun, ended bool
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		name, params, value, ok := splitProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case "BEGIN":
+			begun = strings.EqualFold(value, "VCARD")
+		case "END":
+			ended = begun && strings.EqualFold(value, "VCARD")
+		case "FN":
+			c.FullName = unescape(value)
+		case "N":
+			// Family;Given;Additional;Prefix;Suffix
+			parts := splitUnescaped(value, ';')
+			if len(parts) > 0 {
+				c.LastName = parts[0]
+			}
+			if len(parts) > 1 {
+				c.FirstName = parts[1]
+			}
+		case "TEL":
+			phone := Phone{Number: unescape(value), WAID: onlyDigits(params["WAID"])}
+			if t := params["TYPE"]; t != "" {
+				phone.Types = strings.Split(strings.ToUpper(t), ",")
+			}
+			c.Phones = append(c.Phones, phone)
+		case "EMAIL":
+			if email := unescape(value); email != "" {
+				c.Emails = append(c.Emails, email)
+			}
+		case "ORG":
+			c.Org = strings.Join(nonEmpty(splitUnescaped(value, ';')), ", ")
+		case "TITLE":
+			c.Title = unescape(value)
+		}
+	}
+
+	if !begun || !ended {
+		return nil, fmt.Errorf("not a vCard")
+	}
+	if c.FullName == "" {
+		c.FullName = strings.TrimSpace(c.FirstName + " " + c.LastName)
+	}
+	if c.FirstName == "" && c.FullName != "" {
+		c.FirstName = strings.Fields(c.FullName)[0]
+	}
+	return &c, nil
+}
+
+// unfold splits the vCard into logical lines, joining folded continuation lines.
+func unfold(vcard string) []string {
+	raw := strings.Split(strings.ReplaceAll(vcard, "\r\n", "\n"), "\n")
+	lines := make([]string, 0, len(raw))
+	for _, line := range raw {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, "\r"))
+	}
+	return lines
+}
+
+// splitProperty splits "group.NAME;PARAM=x;FLAG:value" into its parts.
+// Names and parameter keys are upper-cased; bare parameters (vCard 2.1
+// "TEL;CELL:...") are treated as types.
+func splitProperty(line string) (name string, params map[string]string, value string, ok bool) {
+	colon := strings.IndexByte(line, ':')
+	if colon < 0 {
+		return "", nil, "", false
+	}
+	head, value := line[:colon], line[colon+1:]
+
+	fields := strings.Split(head, ";")
+	name = strings.ToUpper(fields[0])
+	if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+		name = name[dot+1:]
+	}
+
+	params = make(map[string]string)
+	for _, f := range fields[1:] {
+		key, val, found := strings.Cut(f, "=")
+		if !found {
+			key, val = "TYPE", f
+		}
+		key = strings.ToUpper(key)
+		if params[key] != "" {
+			val = params[key] + "," + val
+		}
+		params[key] = val
+	}
+	return name, params, value, true
+}
+
+// splitUnescaped splits a structured value on sep, honoring backslash escapes.
+func splitUnescaped(value string, sep byte) []string {
+	var parts []string
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && i+1 < len(value):
+			i++
+			if value[i] == 'n' || value[i] == 'N' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(value[i])
+			}
+		case value[i] == sep:
+			parts = append(parts, strings.TrimSpace(b.String()))
+			b.Reset()
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return append(parts, strings.TrimSpace(b.String()))
+}
+
+// unescape decodes vCard text escapes.
+func unescape(value string) string {
+	return strings.TrimSpace(strings.NewReplacer(
+		`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\:`, ":", `\\`, `\`,
+	).Replace(value))
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func nonEmpty(parts []string) []string {
+	result := parts[:0]
+	for _, p := range parts {
+		if p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
@@ -0,0 +1,83 @@
+package vcard
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseVCard(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		lines []string
+		want  ParsedContact
+	}{{
+		name: "WhatsApp",
+		lines: []string{"BEGIN:VCARD", "VERSION:3.0", "N:Doe;Jane;;;", "FN:Jane Doe",
+			"item1.TEL;type=CELL;waid=6281234567890:+62 812-3456-7890", "item1.X-ABLabel:Mobile", "END:VCARD"},
+		want: ParsedContact{FullName: "Jane Doe", FirstName: "Jane", LastName: "Doe",
+			Phones: []Phone{{Number: "+62 812-3456-7890", WAID: "6281234567890", Types: []string{"CELL"}}}},
+	}, {
+		name: "folded",
+		lines: []string{"BEGIN:VCARD", "FN:Jane Q", "  Public", "EMAIL:jane@", "\texample.com",
+			"TITLE:Head of Th", " ings", "END:VCARD"},
+		want: ParsedContact{FullName: "Jane Q Public", FirstName: "Jane", Emails: []string{"jane@example.com"},
+			Title: "Head of Things"},
+	}, {
+		name: "escapes",
+		lines: []string{"BEGIN:VCARD", `FN:Doe\, Jane`, `N:O\;Neil;Jane\, J.;;;`,
+			`ORG:Acme\; Sons;;Sales`, `TITLE:Line\none`, "END:VCARD"},
+		want: ParsedContact{FullName: "Doe, Jane", FirstName: "Jane, J.", LastName: "O;Neil",
+			Org: "Acme; Sons, Sales", Title: "Line\none"},
+	}, {
+		name:  "2.1 bare params",
+		lines: []string{"BEGIN:VCARD", "VERSION:2.1", "FN:Jane", "TEL;CELL;work:+1 555 0100", "TEL;HOME;TYPE=voice:0812", "END:VCARD"},
+		want: ParsedContact{FullName: "Jane", FirstName: "Jane", Phones: []Phone{
+			{Number: "+1 555 0100", Types: []string{"CELL", "WORK"}},
+			{Number: "0812", Types: []string{"HOME", "VOICE"}},
+		}},
+	}, {
+		name:  "name from N",
+		lines: []string{"BEGIN:VCARD", "N:Doe;Jane;;;", "END:VCARD"},
+		want:  ParsedContact{FullName: "Jane Doe", FirstName: "Jane", LastName: "Doe"},
+	}, {
+		name:  "first name from FN",
+		lines: []string{"BEGIN:VCARD", "FN:Jane Q. Public", "END:VCARD"},
+		want:  ParsedContact{FullName: "Jane Q. Public", FirstName: "Jane"},
+	}} {
+		got, err := ParseVCard(strings.Join(tc.lines, "\r\n"))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(*got, tc.want) {
+			t.Errorf("%s: parsed %+v, want %+v", tc.name, *got, tc.want)
+		}
+	}
+}
+
+func TestParseNotVCard(t *testing.T) {
+	for _, card := range []string{"", "FN:Jane", "BEGIN:VCARD\nFN:Jane", "BEGIN:VCALENDAR\nEND:VCALENDAR"} {
+		if c, err := ParseVCard(card); err == nil {
+			t.Errorf("ParseVCard(%q) = %+v, want an error", card, c)
+		}
+	}
+}
+
+func TestWhatsAppUser(t *testing.T) {
+	for _, tc := range []struct {
+		phones []Phone
+		want   string
+	}{
+		{[]Phone{{Number: "+62 812-3456-7890"}, {Number: "+1 555 0100", WAID: "15550100"}}, "15550100"},
+		{[]Phone{{Number: "0812-3456-7890"}, {Number: "+62 812-3456-7890"}}, "6281234567890"},
+		{[]Phone{{Number: "0812-3456-7890"}, {Number: "(555) 0100"}}, ""},
+		{[]Phone{{Number: "+"}}, ""},
+		{nil, ""},
+	} {
+		c := &ParsedContact{Phones: tc.phones}
+		if got := c.WhatsAppUser(); got != tc.want {
+			t.Errorf("WhatsAppUser(%+v) = %q, want %q", tc.phones, got, tc.want)
+		}
+	}
+}
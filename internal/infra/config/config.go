@@ -14,7 +14,13 @@ type Config struct {
 	LogLevel string `json:"log_level"`
 
 	// Storage
-	StorePath string `json:"store_path"`
+	StorePath string         `json:"store_path"`
+	Database  DatabaseConfig `json:"database"`
+	Storage   StorageConfig  `json:"storage"`
+
+	// PN → LID lookup cache
+	JIDCacheSize    int `json:"jid_cache_size"`     // Lookups kept in memory (default 50000)
+	JIDCacheTTLMins int `json:"jid_cache_ttl_mins"` // How long a cached lookup is trusted (default 60)
 
 	// Device
 	DeviceName string `json:"device_name"`
@@ -23,12 +29,56 @@ type Config struct {
 	SyncOnConnect    bool          `json:"sync_on_connect"`
 	SyncInterval     time.Duration `json:"-"`
 	SyncIntervalMins int           `json:"sync_interval_mins"`
+	Sync             SyncConfig    `json:"sync"`
+
+	// Sending
+	RetryFailedSendsOnConnect bool       `json:"retry_failed_sends_on_connect"` // Resend transient failures after (re)connecting
+	MaxOutboxSize             int        `json:"max_outbox_size"`               // Max in-flight + pending-retry sends before Send refuses (0 = no limit)
+	Send                      SendConfig `json:"send"`
 
 	// Media
 	Media MediaConfig `json:"media"`
 
 	// AI Configuration
 	AI AIConfig `json:"ai"`
+
+	// Keyword auto-reactions
+	AutoReact AutoReactConfig `json:"auto_react"`
+
+	// Event forwarding to an HTTP endpoint
+	Webhook WebhookConfig `json:"webhook"`
+
+	// Prometheus metrics
+	Metrics MetricsConfig `json:"metrics"`
+}
+
+// StorageConfig controls what incoming data is persisted.
+type StorageConfig struct {
+	IgnoredMessageTypes []string `json:"ignored_message_types"` // Message types not saved to orion_messages (default protocol and key-share noise)
+}
+
+// DatabaseConfig holds SQLite connection settings.
+type DatabaseConfig struct {
+	JournalMode   string `json:"journal_mode"`    // SQLite journal mode (default WAL)
+	Synchronous   string `json:"synchronous"`     // SQLite synchronous level (default NORMAL)
+	BusyTimeoutMs int    `json:"busy_timeout_ms"` // Wait this long for a locked database before failing (default 5000)
+	MaxOpenConns  int    `json:"max_open_conns"`  // Connection pool size (0 = unlimited, default 4)
+	MaxIdleConns  int    `json:"max_idle_conns"`  // Idle connections kept open (default 4)
+}
+
+// SyncConfig holds sync settings.
+type SyncConfig struct {
+	RateLimit float64 `json:"rate_limit"` // Outbound WhatsApp queries per second (0 = unlimited, default 0.5)
+}
+
+// SendConfig holds outgoing message settings.
+type SendConfig struct {
+	MessageFooter string   `json:"message_footer"` // Appended to outgoing text and captions (empty = disabled)
+	FooterTypes   []string `json:"footer_types"`   // Message types that get the footer: text, image, video, document
+
+	IdempotencyWindowMins int `json:"idempotency_window_mins"` // How long idempotency keys are remembered (default 1440)
+
+	StripImageMetadata bool `json:"strip_image_metadata"` // Remove EXIF (GPS, camera) from outgoing images (default true)
 }
 
 // MediaConfig holds media download settings.
@@ -44,6 +94,72 @@ type MediaConfig struct {
 	RetryInitialBackoffMs int  `json:"retry_initial_backoff_ms"` // Initial backoff in ms (default 500)
 	RetryMaxBackoffMs     int  `json:"retry_max_backoff_ms"`     // Max backoff in ms (default 30000)
 	HistorySyncDownload   bool `json:"history_sync_download"`    // Download media from history sync
+	EnableRetry           bool `json:"enable_retry"`             // Ask the sender to re-upload media that expired on the CDN
+	StreamThresholdMB     int  `json:"stream_threshold_mb"`      // Files larger than this stream to disk, smaller ones download in memory (0 = always stream)
+	SaveViewOnce          bool `json:"save_view_once"`           // Keep a copy of view-once media, downloaded ahead of the queue (default false)
+
+	Storage MediaStorageConfig `json:"storage"` // Where downloaded media is kept
+	OCR     OCRConfig          `json:"ocr"`     // Text recognition of downloaded images, shown in the agent's context
+}
+
+// OCRConfig holds settings for recognizing text in downloaded images.
+type OCRConfig struct {
+	Enabled     bool   `json:"enabled"`     // Run OCR on downloaded images (default false)
+	Command     string `json:"command"`     // tesseract binary (default "tesseract")
+	Language    string `json:"language"`    // tesseract languages, e.g. "eng+deu" (default eng)
+	Concurrency int    `json:"concurrency"` // Max images recognized at once, more are skipped (default 1)
+}
+
+// MediaStorageConfig selects the backend downloaded media is written to.
+type MediaStorageConfig struct {
+	Backend string   `json:"backend"` // "local" (under store_path) or "s3" (default local)
+	S3      S3Config `json:"s3"`
+}
+
+// S3Config holds the settings of an S3 or S3-compatible media bucket.
+type S3Config struct {
+	Bucket          string `json:"bucket"`
+	Region          string `json:"region"`            // Bucket region (default us-east-1)
+	Endpoint        string `json:"endpoint"`          // Custom endpoint URL, without a path, for S3-compatible services like MinIO or R2 (default AWS)
+	Prefix          string `json:"prefix"`            // Prepended to every object key
+	PathStyle       bool   `json:"path_style"`        // Put the bucket in the path instead of the host name, needed by most self-hosted services
+	AccessKeyID     string `json:"access_key_id"`     // Falls back to AWS_ACCESS_KEY_ID
+	SecretAccessKey string `json:"secret_access_key"` // Falls back to AWS_SECRET_ACCESS_KEY
+	SessionToken    string `json:"session_token"`     // Falls back to AWS_SESSION_TOKEN
+}
+
+// AutoReactConfig holds keyword → reaction settings.
+type AutoReactConfig struct {
+	Enabled      bool            `json:"enabled"`
+	CooldownSecs int             `json:"cooldown_secs"` // Min seconds between auto-reactions in the same chat
+	IncludeOwn   bool            `json:"include_own"`   // Also react to own messages
+	Rules        []AutoReactRule `json:"rules"`
+}
+
+// WebhookConfig holds settings for POSTing events to an HTTP endpoint.
+type WebhookConfig struct {
+	Enabled bool     `json:"enabled"`
+	URL     string   `json:"url"`    // Endpoint events are POSTed to
+	Secret  string   `json:"secret"` // HMAC-SHA256 key for the X-Orion-Signature header (empty = unsigned)
+	Events  []string `json:"events"` // Event types to forward: message, receipt, reaction (empty = all)
+
+	TimeoutMs      int `json:"timeout_ms"`       // Timeout per delivery attempt in ms (default 10000)
+	MaxAttempts    int `json:"max_attempts"`     // Attempts per event before it's dropped (default 5)
+	RetryBackoffMs int `json:"retry_backoff_ms"` // Wait before the first retry, doubled after each (default 1000)
+	QueueSize      int `json:"queue_size"`       // Events waiting for delivery; new ones are dropped when full (default 1000)
+}
+
+// MetricsConfig holds Prometheus metrics settings.
+type MetricsConfig struct {
+	Enabled bool   `json:"enabled"` // Record metrics and serve them on /metrics (default false)
+	Listen  string `json:"listen"`  // Address of the metrics HTTP server (default 127.0.0.1:9464)
+}
+
+// AutoReactRule maps keywords to a reaction emoji or sticker reply.
+type AutoReactRule struct {
+	Keywords    []string `json:"keywords"`               // Case-insensitive whole-word matches
+	Emoji       string   `json:"emoji"`                  // Reaction emoji
+	StickerPath string   `json:"sticker_path,omitempty"` // Optional WebP sticker sent as a reply instead
 }
 
 // AIConfig holds AI/LLM configuration.
@@ -55,16 +171,36 @@ type AIConfig struct {
 	SystemPrompt  string        `json:"system_prompt"`
 	CommandPrefix string        `json:"command_prefix"`
 	MaxMessageAge int           `json:"max_message_age"` // Max age in seconds for messages to process (0 = no limit)
+	MarkRead      string        `json:"mark_read"`       // When to mark replied-to messages read: "before", "after" or "never"
 
 	// Default trigger settings
 	Triggers TriggerConfig `json:"triggers"`
 
+	// Conversation summarization
+	Summary SummaryConfig `json:"summary"`
+
 	// Security rules
 	Admins    []string `json:"admins"`
 	Whitelist []string `json:"whitelist"`
 	Blacklist []string `json:"blacklist"`
 }
 
+// SummaryConfig controls automatic conversation summarization.
+type SummaryConfig struct {
+	Enabled   bool   `json:"enabled"`   // Summarize chats the agent replies in (default true)
+	Threshold int    `json:"threshold"` // Unsummarized tokens before summarizing (default 4000)
+	Prompt    string `json:"prompt"`    // System prompt for the summarizer (default built-in)
+}
+
+// Mark-read modes for AIConfig.MarkRead.
+// When the account's read receipts privacy is off, marking read only syncs
+// to own devices and is never shown to the sender.
+const (
+	MarkReadBefore = "before" // Mark read before generating the reply
+	MarkReadAfter  = "after"  // Mark read once the reply is sent
+	MarkReadNever  = "never"  // Never mark read
+)
+
 // ModelConfig defines an LLM model configuration.
 type ModelConfig struct {
 	Name string `json:"name"`
@@ -75,6 +211,7 @@ type ModelConfig struct {
 	// Model settings
 	Model               string            `json:"model"`
 	MaxContext          int               `json:"max_context"`           // Legacy explicit context limit
+	Tokenizer           string            `json:"tokenizer,omitempty"`   // tiktoken encoding for exact token counts, e.g. o200k_base (heuristic if empty)
 	MaxTokens           int               `json:"max_tokens"`            // Deprecated
 	MaxCompletionTokens int               `json:"max_completion_tokens"` // Recommended
 	Temperature         float64           `json:"temperature,omitempty"`
@@ -139,12 +276,42 @@ func Default() *Config {
 	defaultStore := filepath.Join(homeDir, ".orion-agent", "store")
 
 	return &Config{
-		LogLevel:         "INFO",
-		StorePath:        defaultStore,
+		LogLevel:  "INFO",
+		StorePath: defaultStore,
+		Database: DatabaseConfig{
+			JournalMode:   "WAL",
+			Synchronous:   "NORMAL",
+			BusyTimeoutMs: 5000,
+			MaxOpenConns:  4,
+			MaxIdleConns:  4,
+		},
+		Storage: StorageConfig{
+			IgnoredMessageTypes: []string{
+				"sender_key_distribution",
+				"app_state_key_share",
+				"history_sync_notification",
+				"security_notification_sync",
+				"app_state_fatal",
+				"peer_data_request",
+				"peer_data_response",
+			},
+		},
+		JIDCacheSize:     50000,
+		JIDCacheTTLMins:  60,
 		DeviceName:       "Orion Agent",
 		SyncOnConnect:    true,
 		SyncInterval:     30 * time.Minute,
 		SyncIntervalMins: 30,
+		Sync: SyncConfig{
+			RateLimit: 0.5,
+		},
+		RetryFailedSendsOnConnect: true,
+		MaxOutboxSize:             500,
+		Send: SendConfig{
+			FooterTypes:           []string{"text", "image", "video", "document"},
+			IdempotencyWindowMins: 1440,
+			StripImageMetadata:    true,
+		},
 		Media: MediaConfig{
 			AutoDownload:          false, // Disabled by default
 			Types:                 []string{"image", "video", "audio", "document", "sticker", "profile_picture"},
@@ -155,6 +322,21 @@ func Default() *Config {
 			RetryInitialBackoffMs: 500,
 			RetryMaxBackoffMs:     30000,
 			HistorySyncDownload:   true,
+			EnableRetry:           true,
+			StreamThresholdMB:     4,
+			SaveViewOnce:          false,
+			Storage: MediaStorageConfig{
+				Backend: "local",
+				S3: S3Config{
+					Region: "us-east-1",
+				},
+			},
+			OCR: OCRConfig{
+				Enabled:     false,
+				Command:     "tesseract",
+				Language:    "eng",
+				Concurrency: 1,
+			},
 		},
 		AI: AIConfig{
 			Enabled:       false,
@@ -162,12 +344,33 @@ func Default() *Config {
 			CommandPrefix: "/",
 			SystemPrompt:  "You are a helpful AI assistant.",
 			MaxMessageAge: 60, // Default 60 seconds
+			MarkRead:      MarkReadNever,
 			Triggers: TriggerConfig{
 				DMAutoRespond:    true,
 				GroupAutoRespond: true,
 				ReplyToMe:        true,
 				TriggerWords:     []string{},
 			},
+			Summary: SummaryConfig{
+				Enabled:   true,
+				Threshold: 4000,
+			},
+		},
+		AutoReact: AutoReactConfig{
+			Enabled:      false,
+			CooldownSecs: 60,
+		},
+		Webhook: WebhookConfig{
+			Enabled:        false,
+			Events:         []string{"message", "receipt", "reaction"},
+			TimeoutMs:      10000,
+			MaxAttempts:    5,
+			RetryBackoffMs: 1000,
+			QueueSize:      1000,
+		},
+		Metrics: MetricsConfig{
+			Enabled: false,
+			Listen:  "127.0.0.1:9464",
 		},
 	}
 }
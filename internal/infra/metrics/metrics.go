@@ -0,0 +1,162 @@
+// Package metrics exposes Prometheus metrics for sends, media downloads and
+// events.
+//
+// Metrics are off until Enable is called, and recording them is a no-op
+// until then, so services can be instrumented unconditionally.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "orion"
+
+var (
+	enabled  atomic.Bool
+	registry = prometheus.NewRegistry()
+
+	messagesSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_sent_total",
+		Help:      "Messages sent, by message type.",
+	}, []string{"type"})
+
+	sendErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "send_errors_total",
+		Help:      "Sends that failed, by message type.",
+	}, []string{"type"})
+
+	sendLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "send_duration_seconds",
+		Help:      "Time taken by sends including media upload, by message type.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"type"})
+
+	mediaDownloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "media_downloads_total",
+		Help:      "Media downloads, by media type and result (ok or error).",
+	}, []string{"type", "result"})
+
+	mediaDownloadBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "media_download_bytes_total",
+		Help:      "Bytes of media downloaded, by media type.",
+	}, []string{"type"})
+
+	eventsProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_processed_total",
+		Help:      "WhatsApp events processed, by event type.",
+	}, []string{"type"})
+)
+
+// Enable registers the metrics, along with Go runtime and process metrics.
+// queueDepth reports the media download queue depth; it may be nil.
+func Enable(queueDepth func() int) {
+	if enabled.Swap(true) {
+		return
+	}
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		messagesSent,
+		sendErrors,
+		sendLatency,
+		mediaDownloads,
+		mediaDownloadBytes,
+		eventsProcessed,
+	)
+	if queueDepth != nil {
+		registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "media_download_queue_depth",
+			Help:      "Media downloads waiting for a worker.",
+		}, func() float64 { return float64(queueDepth()) }))
+	}
+}
+
+// Enabled reports whether metrics are being recorded.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// ObserveSend records a send of msgType that took d and failed with err,
+// if not nil.
+func ObserveSend(msgType string, d time.Duration, err error) {
+	if !enabled.Load() {
+		return
+	}
+	if msgType == "" {
+		msgType = "unknown"
+	}
+	sendLatency.WithLabelValues(msgType).Observe(d.Seconds())
+	if err != nil {
+		sendErrors.WithLabelValues(msgType).Inc()
+	} else {
+		messagesSent.WithLabelValues(msgType).Inc()
+	}
+}
+
+// ObserveDownload records a media download of size bytes, or a failed one
+// if err is set.
+func ObserveDownload(mediaType string, size int64, err error) {
+	if !enabled.Load() {
+		return
+	}
+	if err != nil {
+		mediaDownloads.WithLabelValues(mediaType, "error").Inc()
+		return
+	}
+	mediaDownloads.WithLabelValues(mediaType, "ok").Inc()
+	mediaDownloadBytes.WithLabelValues(mediaType).Add(float64(size))
+}
+
+// ObserveEvent records a processed whatsmeow event, labelled with its type
+// name (e.g. *events.Message is "Message").
+func ObserveEvent(evt interface{}) {
+	if !enabled.Load() {
+		return
+	}
+	name := fmt.Sprintf("%T", evt)
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	eventsProcessed.WithLabelValues(name).Inc()
+}
+
+// Handler returns the HTTP handler serving the metrics.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Serve serves /metrics on addr until ctx is done.
+func Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
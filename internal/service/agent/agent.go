@@ -26,6 +26,7 @@ import (
 type AgentService struct {
 	config       *config.Config
 	settings     *store.SettingsStore
+	privacy      *store.PrivacyStore
 	toolStore    *store.ToolStore
 	llmClient    *llm.Client
 	sendService  *send.SendService
@@ -34,8 +35,13 @@ type AgentService struct {
 	trigger      *trigger.Trigger
 	ctxBuilder   *agentctx.Builder
 	ctxWindow    *agentctx.Window
+	summarizer   *agentctx.Summarizer
 	log          waLog.Logger
 	ownJID       types.JID
+
+	// Replace the send service's MarkReadSingle and Send in tests
+	markReadSingle func(ctx context.Context, chat, sender types.JID, id types.MessageID) error
+	send           func(ctx context.Context, to types.JID, content send.Content, opts ...send.SendOption) (*send.SendResult, error)
 }
 
 // NewAgentService creates a new agent service.
@@ -45,6 +51,8 @@ func NewAgentService(
 	settings *store.SettingsStore,
 	summaryStore *store.SummaryStore,
 	toolStore *store.ToolStore,
+	contacts *store.ContactStore,
+	tags *store.TagStore,
 	sendService *send.SendService,
 	log waLog.Logger,
 ) *AgentService {
@@ -53,6 +61,13 @@ func NewAgentService(
 	var llmClient *llm.Client
 	if modelCfg != nil {
 		llmClient = llm.NewClient(modelCfg)
+		if modelCfg.Tokenizer != "" {
+			if tok, err := llm.LoadBPETokenizer(modelCfg.Tokenizer); err != nil {
+				log.Warnf("Failed to load tokenizer for %s, using estimates: %v", modelCfg.Model, err)
+			} else {
+				llm.RegisterTokenizer(modelCfg.Model, tok)
+			}
+		}
 	}
 
 	// Create tool registry
@@ -61,6 +76,7 @@ func NewAgentService(
 	builtin.RegisterMediaTools(toolRegistry, sendService)
 	builtin.RegisterInteractiveTools(toolRegistry, sendService)
 	builtin.RegisterPresenceTools(toolRegistry, sendService)
+	builtin.RegisterTagTools(toolRegistry, tags)
 
 	// Create command registry
 	cmdRegistry := command.NewRegistry(settings, sendService)
@@ -73,15 +89,22 @@ func NewAgentService(
 	agentName := cfg.AI.AgentName
 
 	// Create context builder and window
-	ctxBuilder := agentctx.NewBuilder(appStore, summaryStore, toolStore, agentName)
+	ctxBuilder := agentctx.NewBuilder(appStore, contacts, summaryStore, toolStore, agentName)
 	var ctxWindow *agentctx.Window
+	var summarizer *agentctx.Summarizer
 	if llmClient != nil {
+		ctxBuilder.SetTokenizer(llmClient.Tokenizer())
 		ctxWindow = agentctx.NewWindow(ctxBuilder, summaryStore, llmClient, llmClient.MaxContext())
+		if cfg.AI.Summary.Enabled {
+			ctxWindow.SetSummaryPrompt(cfg.AI.Summary.Prompt)
+			summarizer = agentctx.NewSummarizer(ctxWindow, cfg.AI.Summary.Threshold, log.Sub("Summarizer"))
+		}
 	}
 
 	return &AgentService{
 		config:       cfg,
 		settings:     settings,
+		privacy:      store.NewPrivacyStore(appStore),
 		toolStore:    toolStore,
 		llmClient:    llmClient,
 		sendService:  sendService,
@@ -90,6 +113,7 @@ func NewAgentService(
 		trigger:      trig,
 		ctxBuilder:   ctxBuilder,
 		ctxWindow:    ctxWindow,
+		summarizer:   summarizer,
 		log:          log.Sub("Agent"),
 	}
 }
@@ -129,6 +153,10 @@ func (s *AgentService) HandleMessage(ctx context.Context, msg *store.Message) {
 	}
 	s.log.Infof("Processing message from %s: %s", inputMsg.SenderJID, result.Reason)
 
+	if s.config.AI.MarkRead == config.MarkReadBefore {
+		s.markRead(ctx, inputMsg)
+	}
+
 	// 4. Show typing indicator
 	s.sendService.StartTyping(ctx, inputMsg.ChatJID)
 	defer s.sendService.StopTyping(ctx, inputMsg.ChatJID)
@@ -140,18 +168,19 @@ func (s *AgentService) HandleMessage(ctx context.Context, msg *store.Message) {
 		s.log.Warnf("Failed to build context: %v", err)
 		return
 	}
+	if ctxResult.Truncated {
+		s.log.Infof("Context for %s over budget, omitted %d earlier messages", inputMsg.ChatJID, ctxResult.OmittedMessages)
+	}
 
-	// 6. Summarization Check
-	if s.ctxWindow != nil && s.ctxWindow.CheckThreshold(ctxResult.TokenCount) {
-		if err := s.ctxWindow.ShouldSummarize(ctx, inputMsg.ChatJID, ctxResult.TokenCount); err != nil {
-			s.log.Warnf("Summarization failed: %v", err)
-		}
-		// Rebuild context after summarization
-		ctxResult, err = s.ctxBuilder.BuildContext(inputMsg.ChatJID, maxTokens, s.ownJID, inputMsg)
-		if err != nil {
-			s.log.Errorf("Failed to rebuild context after summarization: %v", err)
-			return
-		}
+	// 6. Summarization, once the reply is out
+	if s.summarizer != nil {
+		defer func() {
+			go func() {
+				if err := s.summarizer.MaybeSummarize(context.WithoutCancel(ctx), inputMsg.ChatJID); err != nil {
+					s.log.Warnf("Summarization failed: %v", err)
+				}
+			}()
+		}()
 	}
 
 	// 7. Build System Prompt & Combine Messages
@@ -174,15 +203,34 @@ func (s *AgentService) HandleMessage(ctx context.Context, msg *store.Message) {
 		return
 	}
 
-	// 9. Handle Response
+	// 9. Handle Response, as a reply if the model asked for one. A directive
+	// naming no message in the context is kept as text.
+	replyIndex, body := agentctx.ParseReplyDirective(response)
+	replyToID, isReply := ctxResult.ResolveIndex(replyIndex)
+	if isReply {
+		response = body
+	}
 	responseContent := s.sanitizeResponse(response, ctxResult.NextIndex)
 	if responseContent != "" {
-		sendResult, err := s.sendService.Send(ctx, inputMsg.ChatJID, send.Text(responseContent))
+		var sendResult *send.SendResult
+		if isReply {
+			sendResult, err = s.sendService.Reply(ctx, inputMsg.ChatJID, types.MessageID(replyToID), ctxResult.SenderMap[replyIndex], send.Text(responseContent))
+		} else {
+			sendFn := s.sendService.Send
+			if s.send != nil {
+				sendFn = s.send
+			}
+			sendResult, err = sendFn(ctx, inputMsg.ChatJID, send.Text(responseContent))
+		}
 		if err != nil {
 			s.log.Errorf("Failed to send response: %v", err)
 			return
 		}
 
+		if s.config.AI.MarkRead == config.MarkReadAfter {
+			s.markRead(ctx, inputMsg)
+		}
+
 		// Save tool calls to DB if any
 		if len(toolCallsJSON) > 0 || len(toolResultsJSON) > 0 {
 			err = s.toolStore.Put(string(sendResult.MessageID), inputMsg.ChatJID.String(), toolCallsJSON, toolResultsJSON)
@@ -240,6 +288,23 @@ func (s *AgentService) ProcessMessage(msg *store.Message) *agentctx.InputMessage
 	}
 }
 
+// markRead marks the message being replied to as read, unless read
+// receipts are turned off in the privacy settings.
+func (s *AgentService) markRead(ctx context.Context, inputMsg *agentctx.InputMessage) {
+	if settings, err := s.privacy.Get(); err == nil && settings.ReadReceipts == string(types.PrivacySettingNone) {
+		s.log.Debugf("Not marking %s as read, read receipts are off", inputMsg.ID)
+		return
+	}
+
+	mark := s.sendService.MarkReadSingle
+	if s.markReadSingle != nil {
+		mark = s.markReadSingle
+	}
+	if err := mark(ctx, inputMsg.ChatJID, inputMsg.SenderJID, types.MessageID(inputMsg.ID)); err != nil {
+		s.log.Warnf("Failed to mark message %s as read: %v", inputMsg.ID, err)
+	}
+}
+
 // buildSystemPrompt builds the system prompt with format instructions.
 func (s *AgentService) buildSystemPrompt(basePrompt string, nextIndex int) string {
 	agentName := s.config.AI.AgentName
@@ -251,6 +316,7 @@ Example: %d|%s|Hello! How can I help you?
 
 The conversation uses this format where each message has an index number.
 Your response should use the next available index.
+To reply to a specific earlier message, put "> {its index}" on its own line first.
 `, basePrompt, agentName, nextIndex, agentName)
 	return formatInstructions
 }
@@ -266,7 +332,7 @@ func (s *AgentService) sanitizeResponse(response string, nextIndex int) string {
 	parts := strings.SplitN(response, "|", 3)
 	if len(parts) == 3 {
 		// Extract index
-		index, _ := strconv.Atoi(parts[1])
+		index, _ := strconv.Atoi(strings.TrimSpace(parts[0]))
 
 		// If index matches, return the content
 		if index == nextIndex {
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+
+	"orion-agent/internal/data/store"
+	"orion-agent/internal/infra/config"
+	"orion-agent/internal/service/send"
+	"orion-agent/internal/utils"
+)
+
+// markReadRecorder records mark-read calls and whether the LLM had been
+// asked for a reply when each was made.
+type markReadRecorder struct {
+	mu        sync.Mutex
+	llmCalled bool
+	marks     []bool // llmCalled at each mark
+}
+
+// newTestAgent returns an agent whose LLM is a local server answering every
+// request with a plain reply, and whose sends fail unless sendOK is set, as
+// there is no WhatsApp client. readReceipts is stored as the privacy
+// setting, unless empty.
+func newTestAgent(t *testing.T, markRead, readReceipts string, sendOK bool) (*AgentService, *markReadRecorder) {
+	t.Helper()
+	rec := &markReadRecorder{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.mu.Lock()
+		rec.llmCalled = true
+		rec.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"c1","object":"chat.completion","created":0,"model":"test",
+			"choices":[{"index":0,"message":{"role":"assistant","content":"Hi there"},"finish_reason":"stop"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	db, err := store.NewWithOptions(":memory:", store.Options{MaxOpenConns: 1, MaxIdleConns: 1}, waLog.Noop)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if readReceipts != "" {
+		if err := store.NewPrivacyStore(db).Put(&store.PrivacySettings{ReadReceipts: readReceipts}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := config.Default()
+	cfg.AI.Enabled = true
+	cfg.AI.MarkRead = markRead
+	cfg.AI.Summary.Enabled = false
+	cfg.AI.Models = []config.ModelConfig{{Name: "test", BaseURL: srv.URL, APIKey: "test", Model: "test", MaxContext: 8000}}
+
+	contacts := store.NewContactStore(db)
+	sendService := send.NewSendService(nil, utils.New(contacts, nil), send.Stores{
+		Messages: store.NewMessageStore(db),
+		Chats:    store.NewChatStore(db),
+		Groups:   store.NewGroupStore(db),
+	}, waLog.Noop)
+	s := NewAgentService(cfg, db, store.NewSettingsStore(db, cfg), store.NewSummaryStore(db), store.NewToolStore(db),
+		contacts, store.NewTagStore(db), sendService, waLog.Noop)
+	s.markReadSingle = func(ctx context.Context, chat, sender types.JID, id types.MessageID) error {
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		rec.marks = append(rec.marks, rec.llmCalled)
+		return nil
+	}
+	if sendOK {
+		s.send = func(ctx context.Context, to types.JID, content send.Content, opts ...send.SendOption) (*send.SendResult, error) {
+			return &send.SendResult{MessageID: "OUT1", Recipient: to, Timestamp: time.Now()}, nil
+		}
+	}
+	return s, rec
+}
+
+func TestMarkRead(t *testing.T) {
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+	for _, tc := range []struct {
+		mode         string
+		readReceipts string
+		sendOK       bool
+		marks        []bool // LLM called at each mark
+	}{
+		// Marked once, before the reply is generated
+		{config.MarkReadBefore, "", true, []bool{false}},
+		{config.MarkReadBefore, string(types.PrivacySettingAll), true, []bool{false}},
+		{config.MarkReadBefore, string(types.PrivacySettingNone), true, nil},
+
+		// Marked once the reply is sent
+		{config.MarkReadAfter, "", true, []bool{true}},
+		{config.MarkReadAfter, string(types.PrivacySettingAll), true, []bool{true}},
+		{config.MarkReadAfter, string(types.PrivacySettingNone), true, nil},
+		// Not marked, as the reply couldn't be sent
+		{config.MarkReadAfter, string(types.PrivacySettingAll), false, nil},
+
+		{config.MarkReadNever, "", true, nil},
+		{config.MarkReadNever, string(types.PrivacySettingAll), true, nil},
+		{config.MarkReadNever, string(types.PrivacySettingNone), true, nil},
+	} {
+		s, rec := newTestAgent(t, tc.mode, tc.readReceipts, tc.sendOK)
+		s.HandleMessage(context.Background(), &store.Message{
+			ID: "IN1", ChatJID: chat, SenderLID: chat, Timestamp: time.Now(), MessageType: "text", TextContent: "hello",
+		})
+
+		if !rec.llmCalled {
+			t.Fatalf("%s, read receipts %q: the LLM wasn't asked for a reply", tc.mode, tc.readReceipts)
+		}
+		if !slices.Equal(rec.marks, tc.marks) {
+			t.Errorf("%s, read receipts %q, send ok %v: marks (LLM called at each) = %v, want %v",
+				tc.mode, tc.readReceipts, tc.sendOK, rec.marks, tc.marks)
+		}
+	}
+}
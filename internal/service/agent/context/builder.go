@@ -4,6 +4,10 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 
 	"go.mau.fi/whatsmeow/types"
 
@@ -29,28 +33,45 @@ type InputMessage struct {
 type ContextResult struct {
 	Messages   []llm.ChatMessage
 	TokenCount int
-	MessageMap map[int]string // index → real message ID
-	NextIndex  int            // for AI's response
+	MessageMap map[int]string    // index → real message ID
+	SenderMap  map[int]types.JID // index → sender, for quoting
+	NextIndex  int               // for AI's response
+
+	// Truncated is set when the oldest unsummarized messages didn't fit the
+	// budget and were left out; OmittedMessages counts them. The Summarizer
+	// folds them into the summary, in chunks that fit the model context.
+	Truncated       bool
+	OmittedMessages int
 }
 
 // Builder builds conversation context from database.
 type Builder struct {
 	store        *store.Store
+	contacts     *store.ContactStore
 	summaryStore *store.SummaryStore
 	toolStore    *store.ToolStore
 	agentName    string
+	tokenizer    llm.Tokenizer
 }
 
 // NewBuilder creates a new context builder.
-func NewBuilder(s *store.Store, sumStore *store.SummaryStore, toolStore *store.ToolStore, agentName string) *Builder {
+func NewBuilder(s *store.Store, contacts *store.ContactStore, sumStore *store.SummaryStore, toolStore *store.ToolStore, agentName string) *Builder {
 	return &Builder{
 		store:        s,
+		contacts:     contacts,
 		summaryStore: sumStore,
 		toolStore:    toolStore,
 		agentName:    agentName,
+		tokenizer:    llm.HeuristicTokenizer{},
 	}
 }
 
+// SetTokenizer sets the tokenizer used to budget context, normally the
+// model's (see llm.TokenizerFor).
+func (b *Builder) SetTokenizer(t llm.Tokenizer) {
+	b.tokenizer = t
+}
+
 // BuildContext builds conversation context for a chat.
 // Returns ContextResult with messages, token count, and index mapping.
 func (b *Builder) BuildContext(chatJID types.JID, maxTokens int, ownJID types.JID, currentMsg *InputMessage) (*ContextResult, error) {
@@ -66,8 +87,9 @@ func (b *Builder) BuildContext(chatJID types.JID, maxTokens int, ownJID types.JI
 		lastSummarizedMsgID = summary.ToMessageID
 	}
 
-	// Get messages from DB (after last summarized message if exists)
-	messages, err := b.getMessagesAfter(chatJID, lastSummarizedMsgID, maxTokens-summaryTokens)
+	// Get messages from DB (after last summarized message if exists),
+	// the newest that fit next to the summary
+	messages, omitted, err := b.getMessagesAfter(chatJID, lastSummarizedMsgID, maxTokens-summaryTokens)
 	if err != nil {
 		return nil, err
 	}
@@ -76,12 +98,14 @@ func (b *Builder) BuildContext(chatJID types.JID, maxTokens int, ownJID types.JI
 	var result []llm.ChatMessage
 	totalTokens := summaryTokens
 	messageMap := make(map[int]string)
+	senderMap := make(map[int]types.JID)
 	index := 1
 
 	// User mapping state
 	userIndexMap := make(map[string]int)
 	nextUserIndex := 1
 	isDM := chatJID.Server == types.DefaultUserServer
+	names := b.senderNames(messages, currentMsg)
 
 	// Add summary as system context if exists
 	if summaryText != "" {
@@ -91,6 +115,16 @@ func (b *Builder) BuildContext(chatJID types.JID, maxTokens int, ownJID types.JI
 		})
 	}
 
+	// Tell the model there's a gap between the summary and the messages
+	if omitted > 0 {
+		marker := fmt.Sprintf("[%d earlier messages omitted]", omitted)
+		result = append(result, llm.ChatMessage{
+			Role:    llm.RoleSystem,
+			Content: marker,
+		})
+		totalTokens += b.tokenizer.CountTokens(marker) + 4
+	}
+
 	// Add messages with indexed format
 	for _, msg := range messages {
 		role := llm.RoleUser
@@ -99,14 +133,19 @@ func (b *Builder) BuildContext(chatJID types.JID, maxTokens int, ownJID types.JI
 		}
 
 		// Resolve sender name
-		senderName := b.resolveSenderName(msg, isDM, userIndexMap, &nextUserIndex)
+		senderName := b.resolveSenderName(msg, names, isDM, userIndexMap, &nextUserIndex)
 
 		// Store mapping FIRST so replies can reference it
 		messageMap[index] = msg.ID
+		if msg.FromMe {
+			senderMap[index] = ownJID
+		} else if sender, err := types.ParseJID(msg.SenderLID); err == nil {
+			senderMap[index] = sender
+		}
 
 		// Format: {index}|{sender}|{content} (with > prefix for replies)
 		content := b.formatMessageContent(msg, index, senderName, messageMap)
-		tokens := llm.EstimateTokens(content) + 4
+		tokens := b.tokenizer.CountTokens(content) + 4
 
 		// Check if this message has associated tool calls
 		toolRecord, _ := b.toolStore.GetByMessageID(msg.ID)
@@ -115,7 +154,7 @@ func (b *Builder) BuildContext(chatJID types.JID, maxTokens int, ownJID types.JI
 			toolMessages := b.formatToolMessages(toolRecord)
 			for _, tm := range toolMessages {
 				result = append(result, tm)
-				totalTokens += llm.EstimateTokens(tm.Content) + 4
+				totalTokens += b.tokenizer.CountTokens(tm.Content) + 4
 			}
 		}
 
@@ -148,10 +187,11 @@ func (b *Builder) BuildContext(chatJID types.JID, maxTokens int, ownJID types.JI
 				QuotedSenderLID: currentMsg.QuotedSenderLID.String(),
 				QuotedContent:   currentMsg.QuotedContent,
 			}
-			senderName := b.resolveSenderName(tempMsg, currentMsg.IsDM, userIndexMap, &nextUserIndex)
+			senderName := b.resolveSenderName(tempMsg, names, currentMsg.IsDM, userIndexMap, &nextUserIndex)
 
 			// Store in map first so formatMessageContent can use it
 			messageMap[index] = currentMsg.ID
+			senderMap[index] = currentMsg.SenderJID
 
 			// Format content with reply support
 			content := b.formatMessageContent(tempMsg, index, senderName, messageMap)
@@ -185,7 +225,7 @@ func (b *Builder) BuildContext(chatJID types.JID, maxTokens int, ownJID types.JI
 				content = fmt.Sprintf("%d|%s|%s", index, senderName, currentMsg.Text)
 			}
 
-			tokens := llm.EstimateTokens(content) + 4
+			tokens := b.tokenizer.CountTokens(content) + 4
 
 			// Add to result
 			result = append(result, llm.ChatMessage{
@@ -201,10 +241,51 @@ func (b *Builder) BuildContext(chatJID types.JID, maxTokens int, ownJID types.JI
 		Messages:   result,
 		TokenCount: totalTokens,
 		MessageMap: messageMap,
+		SenderMap:  senderMap,
 		NextIndex:  index,
+
+		Truncated:       omitted > 0,
+		OmittedMessages: omitted,
 	}, nil
 }
 
+// ResolveIndex returns the ID of the message at index i of the context.
+func (r *ContextResult) ResolveIndex(i int) (string, bool) {
+	msgID, ok := r.MessageMap[i]
+	return msgID, ok
+}
+
+// ParseReplyDirective splits a "> {index}" first line, which the model
+// writes to reply to the message at that index, from the rest of its
+// output. The line may also carry the quoted message as it's shown in the
+// context ("> 3|User|..."); anything else after the index makes it a
+// quote, not a directive. Returns 0 and the output unchanged if there's no
+// directive.
+func ParseReplyDirective(aiOutput string) (int, string) {
+	trimmed := strings.TrimSpace(aiOutput)
+	rest, ok := strings.CutPrefix(trimmed, ">")
+	if !ok {
+		return 0, aiOutput
+	}
+	line, body, _ := strings.Cut(rest, "\n")
+	line = strings.TrimSpace(line)
+
+	end := 0
+	for end < len(line) && line[end] >= '0' && line[end] <= '9' {
+		end++
+	}
+	index, err := strconv.Atoi(line[:end])
+	if err != nil || index <= 0 {
+		return 0, aiOutput
+	}
+
+	// Only the quoted preview may follow the index
+	if after := strings.TrimSpace(line[end:]); after != "" && !strings.HasPrefix(after, "|") {
+		return 0, aiOutput
+	}
+	return index, strings.TrimSpace(body)
+}
+
 // ContextMessage is a simplified message for context building.
 type ContextMessage struct {
 	ID          string
@@ -221,75 +302,167 @@ type ContextMessage struct {
 	QuotedSenderLID string
 	QuotedContent   string
 
-	// Contact info
-	FullName     string
-	FirstName    string
-	BusinessName string
+	// Media info, nil for non-media messages
+	Media *MediaInfo
 }
 
-// getMessagesAfter fetches messages after a given message ID.
-func (b *Builder) getMessagesAfter(chatJID types.JID, afterMsgID string, maxTokens int) ([]*ContextMessage, error) {
-	query := `
-		SELECT 
-			m.id, m.from_me, m.push_name, m.message_type, m.text_content, m.caption, m.timestamp, m.sender_lid,
-			m.quoted_message_id, m.quoted_sender_lid, m.quoted_content,
-			c.full_name, c.first_name, c.business_name
-		FROM orion_messages m
-		LEFT JOIN orion_contacts c ON m.sender_lid = c.lid
-		WHERE m.chat_jid = ? AND m.is_revoked = 0`
-
-	args := []interface{}{chatJID.String()}
-
-	if afterMsgID != "" {
-		query += ` AND m.timestamp > (SELECT timestamp FROM orion_messages WHERE id = ? AND chat_jid = ?)`
-		args = append(args, afterMsgID, chatJID.String())
-	}
+// MediaInfo describes the media of a context message.
+type MediaInfo struct {
+	Mimetype        string
+	FileName        string // Document file name
+	Width           int
+	Height          int
+	DurationSeconds int
+	LocalPath       string // Set once downloaded, for multimodal input
+	OCRText         string // Text recognized in a downloaded image
+}
 
-	query += ` ORDER BY m.timestamp ASC`
+// maxOCRChars caps the OCR text shown per image, so a screenshot of a
+// document doesn't take over the context.
+const maxOCRChars = 500
+
+// mediaTypes are the message types rendered with their media info.
+var mediaTypes = map[string]bool{
+	"image":    true,
+	"video":    true,
+	"ptv":      true,
+	"audio":    true,
+	"document": true,
+	"sticker":  true,
+}
 
-	rows, err := b.store.Query(query, args...)
+// getMessagesAfter fetches the newest messages after a given message ID
+// that fit in maxTokens. Messages are read newest first, so the budget
+// drops the oldest, and returned in chat order, oldest first. omitted is
+// how many older messages didn't fit; callers that must see every message,
+// like the Summarizer, page through them with getOldestMessagesAfter.
+func (b *Builder) getMessagesAfter(chatJID types.JID, afterMsgID string, maxTokens int) ([]*ContextMessage, int, error) {
+	// Newest first, so the budget drops the oldest
+	rows, err := b.queryMessagesAfter(chatJID, afterMsgID, "DESC")
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
 	var messages []*ContextMessage
 	totalTokens := 0
+	omitted := 0
 
 	for rows.Next() {
+		if omitted > 0 {
+			// Over budget, only count the rest
+			omitted++
+			continue
+		}
+
 		msg, err := b.scanContextMessage(rows)
 		if err != nil {
 			continue
 		}
 
-		content := msg.TextContent
-		if content == "" {
-			content = msg.Caption
+		tokens := b.messageTokens(msg)
+		if totalTokens+tokens > maxTokens {
+			omitted++
+			continue
 		}
-		tokens := llm.EstimateTokens(content) + 4
 
-		if totalTokens+tokens > maxTokens {
+		messages = append(messages, msg)
+		totalTokens += tokens
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	slices.Reverse(messages)
+	return messages, omitted, nil
+}
+
+// getOldestMessagesAfter fetches the oldest messages after a given message
+// ID that fit in maxTokens, oldest first. The first message is returned
+// even if it alone is over budget, so callers always make progress.
+// more reports whether later messages were left out.
+func (b *Builder) getOldestMessagesAfter(chatJID types.JID, afterMsgID string, maxTokens int) (messages []*ContextMessage, more bool, err error) {
+	rows, err := b.queryMessagesAfter(chatJID, afterMsgID, "ASC")
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	totalTokens := 0
+	for rows.Next() {
+		msg, err := b.scanContextMessage(rows)
+		if err != nil {
+			continue
+		}
+
+		tokens := b.messageTokens(msg)
+		if len(messages) > 0 && totalTokens+tokens > maxTokens {
+			more = true
 			break
 		}
 
 		messages = append(messages, msg)
 		totalTokens += tokens
 	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
 
-	return messages, nil
+	return messages, more, nil
+}
+
+// queryMessagesAfter queries a chat's messages after afterMsgID, or all of
+// them if it's empty, in order ("ASC" or "DESC"). Same-second messages fall
+// back to server ID, then arrival order.
+func (b *Builder) queryMessagesAfter(chatJID types.JID, afterMsgID string, order string) (*sql.Rows, error) {
+	query := `
+		SELECT 
+			m.id, m.from_me, m.push_name, m.message_type, m.text_content, m.caption, m.timestamp, m.sender_lid,
+			m.quoted_message_id, m.quoted_sender_lid, m.quoted_content,
+			m.mimetype, m.width, m.height, m.duration_seconds, m.display_name, mc.local_path, mc.ocr_text
+		FROM orion_messages m
+		LEFT JOIN orion_media_cache mc ON mc.message_id = m.id AND mc.chat_jid = m.chat_jid
+		WHERE m.chat_jid = ? AND m.is_revoked = 0`
+
+	args := []interface{}{chatJID.String()}
+
+	if afterMsgID != "" {
+		query += ` AND (m.timestamp, m.id) > (SELECT timestamp, id FROM orion_messages WHERE id = ? AND chat_jid = ?)`
+		args = append(args, afterMsgID, chatJID.String())
+	}
+
+	query += fmt.Sprintf(` ORDER BY m.timestamp %[1]s, m.id %[1]s`, order)
+
+	return b.store.Query(query, args...)
+}
+
+// messageTokens estimates the context tokens of a message: its text or
+// caption, the OCR text as shown (see formatMedia), and per-message overhead.
+func (b *Builder) messageTokens(msg *ContextMessage) int {
+	content := msg.TextContent
+	if content == "" {
+		content = msg.Caption
+	}
+	if msg.Media != nil {
+		if ocr := ocrExcerpt(msg.Media.OCRText); ocr != "" {
+			content += " " + ocr
+		}
+	}
+	return b.tokenizer.CountTokens(content) + 4
 }
 
 func (b *Builder) scanContextMessage(rows *sql.Rows) (*ContextMessage, error) {
 	var msg ContextMessage
 	var pushName, textContent, caption, senderLID sql.NullString
 	var quotedMsgID, quotedSenderLID, quotedContent sql.NullString
-	var fullName, firstName, businessName sql.NullString
+	var mimetype, fileName, localPath, ocrText sql.NullString
+	var width, height, duration sql.NullInt64
 	var fromMe int
 
 	err := rows.Scan(
 		&msg.ID, &fromMe, &pushName, &msg.MessageType, &textContent, &caption, &msg.Timestamp, &senderLID,
 		&quotedMsgID, &quotedSenderLID, &quotedContent,
-		&fullName, &firstName, &businessName,
+		&mimetype, &width, &height, &duration, &fileName, &localPath, &ocrText,
 	)
 	if err != nil {
 		return nil, err
@@ -303,31 +476,37 @@ func (b *Builder) scanContextMessage(rows *sql.Rows) (*ContextMessage, error) {
 	msg.QuotedMessageID = quotedMsgID.String
 	msg.QuotedSenderLID = quotedSenderLID.String
 	msg.QuotedContent = quotedContent.String
-	msg.FullName = fullName.String
-	msg.FirstName = firstName.String
-	msg.BusinessName = businessName.String
+
+	if mediaTypes[msg.MessageType] {
+		msg.Media = &MediaInfo{
+			Mimetype:        mimetype.String,
+			Width:           int(width.Int64),
+			Height:          int(height.Int64),
+			DurationSeconds: int(duration.Int64),
+			LocalPath:       localPath.String,
+			OCRText:         ocrText.String,
+		}
+		if msg.MessageType == "document" {
+			msg.Media.FileName = fileName.String
+		}
+	}
 
 	return &msg, nil
 }
 
 // resolveSenderName determines the best name for the sender.
-func (b *Builder) resolveSenderName(msg *ContextMessage, isDM bool, userIndexMap map[string]int, nextUserIndex *int) string {
+func (b *Builder) resolveSenderName(msg *ContextMessage, names map[types.JID]store.DisplayNames, isDM bool, userIndexMap map[string]int, nextUserIndex *int) string {
 	if msg.FromMe {
 		return b.agentName
 	}
 
-	// Order: Full Name > First Name > Push Name > Business Name
-	if msg.FullName != "" {
-		return msg.FullName
-	}
-	if msg.FirstName != "" {
-		return msg.FirstName
+	// The contact's names, with the name the message was sent with as its push name
+	var contact store.DisplayNames
+	if sender, err := types.ParseJID(msg.SenderLID); err == nil {
+		contact = names[sender.ToNonAD()]
 	}
-	if msg.PushName != "" {
-		return msg.PushName
-	}
-	if msg.BusinessName != "" {
-		return msg.BusinessName
+	if name := contact.Best(msg.PushName); name != "" {
+		return name
 	}
 
 	// Fallback
@@ -349,10 +528,37 @@ func (b *Builder) resolveSenderName(msg *ContextMessage, isDM bool, userIndexMap
 	return fmt.Sprintf("User%d", idx)
 }
 
+// senderNames looks up the contact names of the senders of messages and
+// currentMsg in one query.
+func (b *Builder) senderNames(messages []*ContextMessage, currentMsg *InputMessage) map[types.JID]store.DisplayNames {
+	if b.contacts == nil {
+		return nil
+	}
+	var senders []types.JID
+	for _, msg := range messages {
+		if sender, err := types.ParseJID(msg.SenderLID); err == nil && !msg.FromMe {
+			senders = append(senders, sender)
+		}
+	}
+	if currentMsg != nil {
+		if sender, err := types.ParseJID(currentMsg.SenderLID); err == nil {
+			senders = append(senders, sender)
+		}
+	}
+	names, err := b.contacts.GetDisplayNames(senders)
+	if err != nil {
+		return nil
+	}
+	return names
+}
+
 // formatMessageContent formats a message with index|sender|content format.
 // For replied messages, it prepends with > quotedIndex|sender|quotedContent
 func (b *Builder) formatMessageContent(msg *ContextMessage, index int, senderName string, messageMap map[int]string) string {
 	content := msg.TextContent
+	if msg.Media != nil {
+		content = formatMedia(msg.MessageType, msg.Media, msg.Caption)
+	}
 	if content == "" {
 		content = msg.Caption
 	}
@@ -400,6 +606,56 @@ func (b *Builder) formatMessageContent(msg *ContextMessage, index int, senderNam
 	return mainLine
 }
 
+// formatMedia renders media as [type: name WxH 12s "caption"], leaving out
+// whatever is unknown. The name is the file name or the downloaded file's,
+// with the mimetype shown when there's neither. Text recognized in an
+// image follows as [image OCR: ...].
+func formatMedia(messageType string, media *MediaInfo, caption string) string {
+	rendered := formatMediaInfo(messageType, media, caption)
+	if ocr := ocrExcerpt(media.OCRText); ocr != "" {
+		rendered += fmt.Sprintf(" [%s OCR: %s]", messageType, ocr)
+	}
+	return rendered
+}
+
+// ocrExcerpt collapses the whitespace of OCR text and truncates it to
+// maxOCRChars.
+func ocrExcerpt(text string) string {
+	ocr := strings.Join(strings.Fields(text), " ")
+	if runes := []rune(ocr); len(runes) > maxOCRChars {
+		ocr = string(runes[:maxOCRChars-3]) + "..."
+	}
+	return ocr
+}
+
+func formatMediaInfo(messageType string, media *MediaInfo, caption string) string {
+	parts := []string{messageType + ":"}
+
+	name := media.FileName
+	if name == "" && media.LocalPath != "" {
+		name = filepath.Base(media.LocalPath)
+	}
+	if name != "" {
+		parts = append(parts, name)
+	} else if media.Mimetype != "" {
+		parts = append(parts, media.Mimetype)
+	}
+	if media.Width > 0 && media.Height > 0 {
+		parts = append(parts, fmt.Sprintf("%dx%d", media.Width, media.Height))
+	}
+	if media.DurationSeconds > 0 {
+		parts = append(parts, fmt.Sprintf("%ds", media.DurationSeconds))
+	}
+	if caption != "" {
+		parts = append(parts, strconv.Quote(caption))
+	}
+
+	if len(parts) == 1 {
+		return fmt.Sprintf("[%s]", messageType)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
 // formatToolMessages formats tool call/result as LLM messages.
 func (b *Builder) formatToolMessages(record *store.ToolRecord) []llm.ChatMessage {
 	var messages []llm.ChatMessage
@@ -428,13 +684,14 @@ func (b *Builder) GetMessagesForSummary(chatJID types.JID, fromMsgID, toMsgID st
 	query := `
 		SELECT 
 			m.id, m.from_me, m.push_name, m.message_type, m.text_content, m.caption, m.timestamp, m.sender_lid,
-			c.full_name, c.first_name, c.business_name
+			m.quoted_message_id, m.quoted_sender_lid, m.quoted_content,
+			m.mimetype, m.width, m.height, m.duration_seconds, m.display_name, mc.local_path, mc.ocr_text
 		FROM orion_messages m
-		LEFT JOIN orion_contacts c ON m.sender_lid = c.lid
+		LEFT JOIN orion_media_cache mc ON mc.message_id = m.id AND mc.chat_jid = m.chat_jid
 		WHERE m.chat_jid = ? AND m.is_revoked = 0
-		AND m.timestamp >= (SELECT timestamp FROM orion_messages WHERE id = ? AND chat_jid = ?)
-		AND m.timestamp <= (SELECT timestamp FROM orion_messages WHERE id = ? AND chat_jid = ?)
-		ORDER BY m.timestamp ASC`
+		AND (m.timestamp, m.id) >= (SELECT timestamp, id FROM orion_messages WHERE id = ? AND chat_jid = ?)
+		AND (m.timestamp, m.id) <= (SELECT timestamp, id FROM orion_messages WHERE id = ? AND chat_jid = ?)
+		ORDER BY m.timestamp ASC, m.id ASC`
 
 	rows, err := b.store.Query(query, chatJID.String(), fromMsgID, chatJID.String(), toMsgID, chatJID.String())
 	if err != nil {
@@ -0,0 +1,223 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"orion-agent/internal/data/store"
+)
+
+func TestGetMessagesAfterKeepsNewest(t *testing.T) {
+	builder, s := newTestBuilder(t)
+	chat := types.NewJID("123", types.GroupServer)
+	ids := putTextMessages(t, s, chat, 10, 100)
+
+	budget := 0
+	messages, omitted, err := builder.getMessagesAfter(chat, "", 1<<30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range messages[len(messages)-3:] {
+		budget += builder.messageTokens(msg)
+	}
+
+	messages, omitted, err = builder.getMessagesAfter(chat, "", budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if omitted != 7 || len(messages) != 3 {
+		t.Fatalf("got %d messages and %d omitted, want 3 and 7", len(messages), omitted)
+	}
+	for i, msg := range messages {
+		if want := ids[7+i]; msg.ID != want {
+			t.Errorf("message %d = %s, want %s (newest, oldest first)", i, msg.ID, want)
+		}
+	}
+
+	// Only messages after the given ID
+	messages, omitted, err = builder.getMessagesAfter(chat, ids[8], budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if omitted != 0 || len(messages) != 1 || messages[0].ID != ids[9] {
+		t.Errorf("after %s: got %d messages, %d omitted", ids[8], len(messages), omitted)
+	}
+}
+
+func TestGetOldestMessagesAfter(t *testing.T) {
+	builder, s := newTestBuilder(t)
+	chat := types.NewJID("123", types.GroupServer)
+	ids := putTextMessages(t, s, chat, 10, 100)
+
+	var seen []string
+	after := ""
+	for {
+		chunk, more, err := builder.getOldestMessagesAfter(chat, after, 250)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunk) == 0 {
+			t.Fatal("empty chunk")
+		}
+		for _, msg := range chunk {
+			seen = append(seen, msg.ID)
+		}
+		after = chunk[len(chunk)-1].ID
+		if !more {
+			break
+		}
+	}
+	if len(seen) != len(ids) {
+		t.Fatalf("paged through %d messages, want %d", len(seen), len(ids))
+	}
+	for i := range ids {
+		if seen[i] != ids[i] {
+			t.Errorf("message %d = %s, want %s", i, seen[i], ids[i])
+		}
+	}
+
+	// A message over budget on its own is still returned
+	chunk, more, err := builder.getOldestMessagesAfter(chat, "", 1)
+	if err != nil || len(chunk) != 1 || !more {
+		t.Errorf("got %d messages (more %v, err %v), want the first alone", len(chunk), more, err)
+	}
+}
+
+// TestPagingSameSecond checks messages sharing a timestamp, and without a
+// server ID, page and summarize in ID order.
+func TestPagingSameSecond(t *testing.T) {
+	builder, s := newTestBuilder(t)
+	chat := types.NewJID("123", types.GroupServer)
+	messages := store.NewMessageStore(s)
+	ts := time.Unix(1700000000, 0)
+	ids := []string{"A", "B", "C", "D", "E"}
+	for _, id := range []string{"C", "A", "E", "B", "D"} {
+		if err := messages.Put(&store.Message{
+			ID: id, ChatJID: chat, SenderLID: chat, Timestamp: ts, MessageType: "text", TextContent: "same second",
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var seen []string
+	after := ""
+	for {
+		chunk, more, err := builder.getOldestMessagesAfter(chat, after, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, msg := range chunk {
+			seen = append(seen, msg.ID)
+		}
+		if !more {
+			break
+		}
+		after = chunk[len(chunk)-1].ID
+	}
+	if strings.Join(seen, "") != strings.Join(ids, "") {
+		t.Errorf("paged %v, want %v", seen, ids)
+	}
+
+	summary, err := builder.GetMessagesForSummary(chat, "B", "D")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, msg := range summary {
+		got = append(got, msg.ID)
+	}
+	if strings.Join(got, "") != "BCD" {
+		t.Errorf("summarized %v, want B, C, D", got)
+	}
+}
+
+func TestBuildContextSenderNames(t *testing.T) {
+	builder, s := newTestBuilder(t)
+	chat := types.NewJID("123", types.GroupServer)
+	contacts := store.NewContactStore(s)
+	messages := store.NewMessageStore(s)
+
+	named := types.NewJID("100", types.HiddenUserServer)
+	business := types.NewJID("200", types.HiddenUserServer)
+	stranger := types.NewJID("300", types.HiddenUserServer)
+	if err := contacts.Put(&store.Contact{LID: named, FullName: "Alice Smith"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := contacts.Put(&store.Contact{LID: business, BusinessName: "Bob's Bikes"}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Unix(1700000000, 0)
+	for i, m := range []struct {
+		sender   types.JID
+		pushName string
+	}{{named, "al"}, {business, "Bob"}, {business, ""}, {stranger, ""}} {
+		err := messages.Put(&store.Message{
+			ID:          fmt.Sprintf("MSG%d", i),
+			ChatJID:     chat,
+			SenderLID:   m.sender,
+			PushName:    m.pushName,
+			Timestamp:   start.Add(time.Duration(i) * time.Second),
+			MessageType: "text",
+			TextContent: "hi",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := builder.BuildContext(chat, 10000, types.NewJID("999", types.HiddenUserServer), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"1|Alice Smith|hi", "2|Bob|hi", "3|Bob's Bikes|hi", "4|User1|hi"}
+	if len(result.Messages) != len(want) {
+		t.Fatalf("got %d messages, want %d", len(result.Messages), len(want))
+	}
+	for i, msg := range result.Messages {
+		if msg.Content != want[i] {
+			t.Errorf("message %d = %q, want %q", i+1, msg.Content, want[i])
+		}
+	}
+}
+
+func TestMessageTokensCountsShownOCRText(t *testing.T) {
+	builder, _ := newTestBuilder(t)
+	msg := &ContextMessage{
+		MessageType: "image",
+		Media:       &MediaInfo{OCRText: strings.Repeat("lorem ipsum ", 1000)},
+	}
+
+	shown := formatMedia(msg.MessageType, msg.Media, "")
+	if tokens, limit := builder.messageTokens(msg), builder.tokenizer.CountTokens(shown)+4; tokens > limit {
+		t.Errorf("counted %d tokens for a message shown in at most %d", tokens, limit)
+	}
+}
+
+func TestParseReplyDirective(t *testing.T) {
+	tests := []struct {
+		output string
+		index  int
+		body   string
+	}{
+		{"> 3\nSure thing", 3, "Sure thing"},
+		{"  > 12  \n\nSure thing  ", 12, "Sure thing"},
+		{"> 3|User1|are you coming?\nYes", 3, "Yes"},
+		{"> 3 | User1 | are you coming?\nYes", 3, "Yes"},
+		{"> 3 apples were in the basket\nthat's the quote", 0, "> 3 apples were in the basket\nthat's the quote"},
+		{"> 3.5 stars\nnot bad", 0, "> 3.5 stars\nnot bad"},
+		{"> quoting someone\nreply", 0, "> quoting someone\nreply"},
+		{"> 0\nreply", 0, "> 0\nreply"},
+		{"no directive", 0, "no directive"},
+	}
+	for _, tt := range tests {
+		index, body := ParseReplyDirective(tt.output)
+		if index != tt.index || body != tt.body {
+			t.Errorf("ParseReplyDirective(%q) = %d, %q; want %d, %q", tt.output, index, body, tt.index, tt.body)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package context
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// Summarizer rolls a chat's older messages into its summary once the
+// messages since the last summary grow past a token threshold.
+type Summarizer struct {
+	window    *Window
+	threshold int
+	log       waLog.Logger
+
+	mu      sync.Mutex
+	running map[types.JID]bool
+}
+
+// NewSummarizer creates a summarizer that summarizes a chat once its
+// unsummarized messages exceed threshold tokens.
+func NewSummarizer(window *Window, threshold int, log waLog.Logger) *Summarizer {
+	return &Summarizer{
+		window:    window,
+		threshold: threshold,
+		log:       log,
+		running:   make(map[types.JID]bool),
+	}
+}
+
+// MaybeSummarize summarizes the chat if the messages since the last summary
+// are over the threshold. The newest third of the threshold stays verbatim;
+// everything older is folded into the summary, oldest first, in chunks that
+// fit the model context, so a long backlog never needs one oversized
+// request. Concurrent calls for a chat that's already being summarized
+// return immediately.
+func (s *Summarizer) MaybeSummarize(ctx context.Context, chatJID types.JID) error {
+	s.mu.Lock()
+	if s.running[chatJID] {
+		s.mu.Unlock()
+		return nil
+	}
+	s.running[chatJID] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, chatJID)
+		s.mu.Unlock()
+	}()
+
+	var oldSummary, lastSummarizedMsgID string
+	if summary, err := s.window.summaryStore.GetLatest(chatJID); err == nil && summary != nil {
+		oldSummary = summary.SummaryText
+		lastSummarizedMsgID = summary.ToMessageID
+	}
+
+	builder := s.window.builder
+	if _, omitted, err := builder.getMessagesAfter(chatJID, lastSummarizedMsgID, s.threshold); err != nil || omitted == 0 {
+		return err
+	}
+
+	// The newest messages are kept; summarizing stops at the oldest of them
+	kept, _, err := builder.getMessagesAfter(chatJID, lastSummarizedMsgID, s.threshold/3)
+	if err != nil {
+		return err
+	}
+	var keepFrom string
+	if len(kept) > 0 {
+		keepFrom = kept[0].ID
+	}
+
+	for {
+		chunk, more, err := builder.getOldestMessagesAfter(chatJID, lastSummarizedMsgID, s.window.chunkBudget(oldSummary))
+		if err != nil {
+			return err
+		}
+		if i := slices.IndexFunc(chunk, func(m *ContextMessage) bool { return m.ID == keepFrom }); i >= 0 {
+			chunk, more = chunk[:i], false
+		} else if !more && len(chunk) > 0 {
+			// Always leave the newest message unsummarized
+			chunk = chunk[:len(chunk)-1]
+		}
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		summary, err := s.window.Summarize(ctx, chatJID, oldSummary, chunk)
+		if err != nil {
+			return err
+		}
+		s.log.Debugf("Summarized %d messages of %s up to %s", len(chunk), chatJID, summary.ToMessageID)
+		if !more {
+			return nil
+		}
+		oldSummary, lastSummarizedMsgID = summary.SummaryText, summary.ToMessageID
+	}
+}
@@ -0,0 +1,149 @@
+package context
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+
+	"orion-agent/internal/data/store"
+	"orion-agent/internal/infra/config"
+	"orion-agent/internal/service/agent/llm"
+)
+
+// newTestBuilder returns a Builder on an in-memory store.
+func newTestBuilder(t *testing.T) (*Builder, *store.Store) {
+	t.Helper()
+	s, err := store.NewWithOptions(":memory:", store.Options{MaxOpenConns: 1, MaxIdleConns: 1}, waLog.Noop)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	b := NewBuilder(s, store.NewContactStore(s), store.NewSummaryStore(s), store.NewToolStore(s), "Orion")
+	return b, s
+}
+
+// putTextMessages stores n text messages of about tokens tokens each, one
+// second apart, and returns their IDs oldest first.
+func putTextMessages(t *testing.T, s *store.Store, chat types.JID, n, tokens int) []string {
+	t.Helper()
+	messages := store.NewMessageStore(s)
+	start := time.Unix(1700000000, 0)
+	sender := types.NewJID("100", types.HiddenUserServer)
+	ids := make([]string, n)
+	for i := range n {
+		ids[i] = fmt.Sprintf("MSG%04d", i)
+		err := messages.Put(&store.Message{
+			ID:          ids[i],
+			ChatJID:     chat,
+			SenderLID:   sender,
+			Timestamp:   start.Add(time.Duration(i) * time.Second),
+			MessageType: "text",
+			TextContent: fmt.Sprintf("%d %s", i, strings.Repeat("word ", tokens*4/5)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ids
+}
+
+// fakeCompletions serves chat completions, recording each prompt.
+type fakeCompletions struct {
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (f *fakeCompletions) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Messages []struct {
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var prompt strings.Builder
+	for _, m := range req.Messages {
+		prompt.WriteString(m.Content)
+	}
+
+	f.mu.Lock()
+	f.prompts = append(f.prompts, prompt.String())
+	n := len(f.prompts)
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"id":"x","object":"chat.completion","created":0,"model":"test","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"summary %d"}}]}`, n)
+}
+
+func TestMaybeSummarizeChunksBacklog(t *testing.T) {
+	builder, s := newTestBuilder(t)
+	chat := types.NewJID("123", types.GroupServer)
+	ids := putTextMessages(t, s, chat, 200, 100) // ~20k tokens
+
+	fake := &fakeCompletions{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	const maxContext = 4000
+	client := llm.NewClient(&config.ModelConfig{BaseURL: server.URL, APIKey: "test", Model: "test", MaxContext: maxContext})
+	summaries := store.NewSummaryStore(s)
+	window := NewWindow(builder, summaries, client, maxContext)
+	summarizer := NewSummarizer(window, 2000, waLog.Noop)
+
+	if err := summarizer.MaybeSummarize(context.Background(), chat); err != nil {
+		t.Fatalf("MaybeSummarize: %v", err)
+	}
+
+	if len(fake.prompts) < 2 {
+		t.Fatalf("expected the backlog to be summarized in several requests, got %d", len(fake.prompts))
+	}
+	for i, prompt := range fake.prompts {
+		if tokens := llm.EstimateTokens(prompt); tokens > maxContext {
+			t.Errorf("request %d has %d tokens, over the %d token context", i+1, tokens, maxContext)
+		}
+	}
+
+	latest, err := summaries.GetLatest(chat)
+	if err != nil || latest == nil {
+		t.Fatalf("no summary stored: %v", err)
+	}
+	if want := fmt.Sprintf("summary %d", len(fake.prompts)); latest.SummaryText != want {
+		t.Errorf("latest summary = %q, want %q", latest.SummaryText, want)
+	}
+
+	// The newest third of the threshold is left verbatim
+	kept, omitted, err := builder.getMessagesAfter(chat, latest.ToMessageID, maxContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if omitted != 0 || len(kept) == 0 || kept[len(kept)-1].ID != ids[len(ids)-1] {
+		t.Fatalf("unexpected messages after summary: %d kept, %d omitted", len(kept), omitted)
+	}
+	tokens := 0
+	for _, msg := range kept {
+		tokens += builder.messageTokens(msg)
+	}
+	if tokens > 2000 {
+		t.Errorf("%d tokens left unsummarized, want at most the threshold", tokens)
+	}
+
+	// Under the threshold now, so nothing more to do
+	before := len(fake.prompts)
+	if err := summarizer.MaybeSummarize(context.Background(), chat); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.prompts) != before {
+		t.Errorf("summarized again under the threshold")
+	}
+}
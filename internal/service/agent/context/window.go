@@ -11,61 +11,45 @@ import (
 	"orion-agent/internal/service/agent/llm"
 )
 
+// DefaultSummaryPrompt is the system prompt used to write summaries.
+const DefaultSummaryPrompt = "You are a summarization assistant. Create concise summaries that preserve important context."
+
 // Window manages the sliding context window with summarization.
 type Window struct {
-	builder      *Builder
-	summaryStore *store.SummaryStore
-	llmClient    *llm.Client
-	maxContext   int
+	builder       *Builder
+	summaryStore  *store.SummaryStore
+	llmClient     *llm.Client
+	maxContext    int
+	summaryPrompt string
 }
 
 // NewWindow creates a new context window manager.
 func NewWindow(builder *Builder, summaryStore *store.SummaryStore, llmClient *llm.Client, maxContext int) *Window {
 	return &Window{
-		builder:      builder,
-		summaryStore: summaryStore,
-		llmClient:    llmClient,
-		maxContext:   maxContext,
+		builder:       builder,
+		summaryStore:  summaryStore,
+		llmClient:     llmClient,
+		maxContext:    maxContext,
+		summaryPrompt: DefaultSummaryPrompt,
 	}
 }
 
-// CheckThreshold returns true if token count exceeds 50% of max context.
-func (w *Window) CheckThreshold(tokenCount int) bool {
-	return tokenCount > w.maxContext/2
-}
-
-// FindSummarizationPoint finds the message ID at the 2/3 mark for summarization.
-// Returns (fromMsgID, toMsgID, tokensToSummarize) - summarize from first message to toMsgID.
-func (w *Window) FindSummarizationPoint(messages []llm.ChatMessage, chatJID types.JID) (string, string, int, error) {
-	if len(messages) == 0 {
-		return "", "", 0, fmt.Errorf("no messages to summarize")
-	}
-
-	// Calculate total tokens
-	totalTokens := llm.EstimateMessagesTokens(messages)
-
-	// Find 2/3 mark (we want to summarize oldest 2/3)
-	targetTokens := (totalTokens * 2) / 3
-	currentTokens := 0
-	splitIndex := 0
-
-	for i, msg := range messages {
-		msgTokens := llm.EstimateTokens(msg.Content) + 4
-		currentTokens += msgTokens
-		if currentTokens >= targetTokens {
-			splitIndex = i
-			break
-		}
+// SetSummaryPrompt overrides the system prompt used to write summaries.
+func (w *Window) SetSummaryPrompt(prompt string) {
+	if prompt != "" {
+		w.summaryPrompt = prompt
 	}
+}
 
-	if splitIndex == 0 {
-		splitIndex = len(messages) - 1
-	}
+// summaryMaxTokens is the most tokens a summary is written in.
+const summaryMaxTokens = 1000
 
-	// Get message IDs from DB for the range
-	// Note: We need to map back to actual message IDs
-	// For now, return the index-based split info
-	return "", "", currentTokens, nil
+// chunkBudget returns how many tokens of messages fit in one summarization
+// request next to oldSummary, leaving a quarter of the model context for
+// the prompt and formatting overhead.
+func (w *Window) chunkBudget(oldSummary string) int {
+	budget := w.maxContext*3/4 - w.builder.tokenizer.CountTokens(oldSummary) - summaryMaxTokens
+	return max(budget, summaryMaxTokens)
 }
 
 // Summarize generates a rolling summary from old summary + new messages.
@@ -90,6 +74,9 @@ func (w *Window) Summarize(ctx context.Context, chatJID types.JID, oldSummary st
 			role = "Assistant"
 		}
 		content := msg.TextContent
+		if msg.Media != nil {
+			content = formatMedia(msg.MessageType, msg.Media, msg.Caption)
+		}
 		if content == "" {
 			content = msg.Caption
 		}
@@ -106,10 +93,10 @@ func (w *Window) Summarize(ctx context.Context, chatJID types.JID, oldSummary st
 	// Call LLM for summarization
 	req := &llm.ChatCompletionRequest{
 		Messages: []llm.ChatMessage{
-			{Role: llm.RoleSystem, Content: "You are a summarization assistant. Create concise summaries that preserve important context."},
+			{Role: llm.RoleSystem, Content: w.summaryPrompt},
 			{Role: llm.RoleUser, Content: sb.String()},
 		},
-		MaxTokens:   1000,
+		MaxTokens:   summaryMaxTokens,
 		Temperature: 0.3,
 	}
 
@@ -123,7 +110,7 @@ func (w *Window) Summarize(ctx context.Context, chatJID types.JID, oldSummary st
 	}
 
 	summaryText := resp.Choices[0].Message.Content
-	tokenCount := llm.EstimateTokens(summaryText)
+	tokenCount := w.builder.tokenizer.CountTokens(summaryText)
 
 	summary := &store.Summary{
 		ChatJID:       chatJID,
@@ -140,62 +127,3 @@ func (w *Window) Summarize(ctx context.Context, chatJID types.JID, oldSummary st
 
 	return summary, nil
 }
-
-// ShouldSummarize checks if summarization is needed and performs it if so.
-func (w *Window) ShouldSummarize(ctx context.Context, chatJID types.JID, tokenCount int) error {
-	if !w.CheckThreshold(tokenCount) {
-		return nil
-	}
-
-	// Get existing summary
-	var oldSummary string
-	existingSummary, err := w.summaryStore.GetLatest(chatJID)
-	if err == nil && existingSummary != nil {
-		oldSummary = existingSummary.SummaryText
-	}
-
-	// Get messages to summarize (oldest 2/3)
-	messages, err := w.builder.getMessagesAfter(chatJID, "", w.maxContext)
-	if err != nil {
-		return err
-	}
-
-	if len(messages) < 3 {
-		return nil // Not enough messages to summarize
-	}
-
-	// Find 2/3 point
-	totalTokens := 0
-	for _, msg := range messages {
-		content := msg.TextContent
-		if content == "" {
-			content = msg.Caption
-		}
-		totalTokens += llm.EstimateTokens(content) + 4
-	}
-
-	targetTokens := (totalTokens * 2) / 3
-	currentTokens := 0
-	splitIndex := 0
-
-	for i, msg := range messages {
-		content := msg.TextContent
-		if content == "" {
-			content = msg.Caption
-		}
-		currentTokens += llm.EstimateTokens(content) + 4
-		if currentTokens >= targetTokens {
-			splitIndex = i
-			break
-		}
-	}
-
-	if splitIndex == 0 {
-		return nil
-	}
-
-	// Summarize oldest 2/3
-	messagesToSummarize := messages[:splitIndex+1]
-	_, err = w.Summarize(ctx, chatJID, oldSummary, messagesToSummarize)
-	return err
-}
@@ -0,0 +1,33 @@
+package llm
+
+import (
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+func init() {
+	// Use the embedded rank files rather than downloading them on first use
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// BPETokenizer counts tokens with one of OpenAI's tiktoken encodings, each
+// with its own rank file and split pattern.
+type BPETokenizer struct {
+	encoding *tiktoken.Tiktoken
+}
+
+// LoadBPETokenizer loads a tiktoken encoding by name: o200k_base,
+// cl100k_base, p50k_base or r50k_base.
+func LoadBPETokenizer(encoding string) (*BPETokenizer, error) {
+	enc, err := tiktoken.GetEncoding(encoding)
+	if err != nil {
+		return nil, err
+	}
+	return &BPETokenizer{encoding: enc}, nil
+}
+
+// CountTokens implements Tokenizer. Special tokens in text are counted as
+// the plain text they are.
+func (t *BPETokenizer) CountTokens(text string) int {
+	return len(t.encoding.EncodeOrdinary(text))
+}
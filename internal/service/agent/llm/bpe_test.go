@@ -0,0 +1,37 @@
+package llm
+
+import "testing"
+
+// TestCountTokens checks counts match what tiktoken gives for each encoding.
+// They differ where o200k_base splits or merges text differently.
+func TestCountTokens(t *testing.T) {
+	for _, tc := range []struct {
+		text          string
+		cl100k, o200k int
+	}{
+		{"hello world", 2, 2},
+		{"Hello, world!", 4, 4},
+		{"tiktoken is great!", 6, 6},
+		{"HelloWorld and camelCaseIdentifiers", 7, 6},
+		{"Привет, как дела?", 8, 6},
+		{"こんにちは世界", 4, 2},
+		{"    indented code();\n\n", 5, 5},
+		{"<|endoftext|>", 7, 7}, // Special tokens count as text
+	} {
+		for encoding, want := range map[string]int{"cl100k_base": tc.cl100k, "o200k_base": tc.o200k} {
+			tok, err := LoadBPETokenizer(encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tok.CountTokens(tc.text); got != want {
+				t.Errorf("%s: CountTokens(%q) = %d, want %d", encoding, tc.text, got, want)
+			}
+		}
+	}
+}
+
+func TestLoadUnknownEncoding(t *testing.T) {
+	if _, err := LoadBPETokenizer("cl50k_base"); err == nil {
+		t.Error("loaded an unknown encoding")
+	}
+}
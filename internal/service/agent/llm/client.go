@@ -320,6 +320,11 @@ func (c *Client) Complete(ctx context.Context, req *ChatCompletionRequest) (*Cha
 	return result, nil
 }
 
+// Tokenizer returns the tokenizer registered for the model.
+func (c *Client) Tokenizer() Tokenizer {
+	return TokenizerFor(c.config.Model)
+}
+
 // MaxContext returns the model's max context size.
 func (c *Client) MaxContext() int {
 	return c.config.MaxContext
@@ -1,5 +1,60 @@
 package llm
 
+import (
+	"strings"
+	"sync"
+)
+
+// Tokenizer counts the tokens a model sees for a piece of text.
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// HeuristicTokenizer approximates ~4 chars per token.
+// It's used for models without a registered tokenizer.
+type HeuristicTokenizer struct{}
+
+// CountTokens implements Tokenizer.
+func (HeuristicTokenizer) CountTokens(text string) int {
+	return EstimateTokens(text)
+}
+
+var (
+	tokenizersMu sync.RWMutex
+	tokenizers   = make(map[string]Tokenizer)
+)
+
+// RegisterTokenizer registers the tokenizer for a model.
+// A name ending in "*" registers a prefix, e.g. "gpt-4o*".
+func RegisterTokenizer(model string, t Tokenizer) {
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+	tokenizers[model] = t
+}
+
+// TokenizerFor returns the tokenizer registered for model, preferring an
+// exact match over the longest matching prefix. Falls back to the heuristic.
+func TokenizerFor(model string) Tokenizer {
+	tokenizersMu.RLock()
+	defer tokenizersMu.RUnlock()
+
+	if t, ok := tokenizers[model]; ok {
+		return t
+	}
+	var best Tokenizer
+	bestLen := -1
+	for name, t := range tokenizers {
+		prefix, ok := strings.CutSuffix(name, "*")
+		if ok && strings.HasPrefix(model, prefix) && len(prefix) > bestLen {
+			best, bestLen = t, len(prefix)
+		}
+	}
+	if best != nil {
+		return best
+	}
+	return HeuristicTokenizer{}
+}
+
 // EstimateTokens estimates token count using ~4 chars per token approximation.
 func EstimateTokens(text string) int {
 	if len(text) == 0 {
@@ -10,9 +65,14 @@ func EstimateTokens(text string) int {
 
 // EstimateMessagesTokens estimates total tokens for a slice of messages.
 func EstimateMessagesTokens(messages []ChatMessage) int {
+	return CountMessagesTokens(HeuristicTokenizer{}, messages)
+}
+
+// CountMessagesTokens counts total tokens for a slice of messages with t.
+func CountMessagesTokens(t Tokenizer, messages []ChatMessage) int {
 	total := 0
 	for _, msg := range messages {
-		total += EstimateTokens(msg.Content) + 4 // +4 for role/formatting overhead
+		total += t.CountTokens(msg.Content) + 4 // +4 for role/formatting overhead
 	}
 	return total
 }
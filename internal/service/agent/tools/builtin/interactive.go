@@ -203,10 +203,7 @@ func (t *CreateEventTool) Execute(ctx context.Context, args json.RawMessage, exe
 		return tools.ErrorResult("invalid end_time format"), nil
 	}
 
-	content := send.Event(params.Name, startTime, endTime)
-	if params.Description != "" {
-		content = content.WithDescription(params.Description)
-	}
+	content := send.Event(params.Name, params.Description, startTime, nil).WithEndTime(endTime)
 
 	result, err := t.sendService.Send(ctx, execCtx.ChatJID, content)
 	if err != nil {
@@ -138,9 +138,9 @@ func (t *SetPresenceTool) Execute(ctx context.Context, args json.RawMessage, exe
 
 	var err error
 	if params.Available {
-		err = t.sendService.SetAvailable(ctx)
+		err = t.sendService.SetOnline(ctx)
 	} else {
-		err = t.sendService.SetUnavailable(ctx)
+		err = t.sendService.SetOffline(ctx)
 	}
 
 	if err != nil {
@@ -0,0 +1,158 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"orion-agent/internal/data/store"
+	"orion-agent/internal/service/agent/tools"
+)
+
+// TagTool adds or removes a local tag on the chat or a message.
+type TagTool struct {
+	tags *store.TagStore
+}
+
+func NewTagTool(tags *store.TagStore) *TagTool {
+	return &TagTool{tags: tags}
+}
+
+func (t *TagTool) Name() string { return "tag" }
+
+func (t *TagTool) Description() string {
+	return "Add or remove a private tag on the current chat, or on a message by index. Tags are never shared"
+}
+
+func (t *TagTool) Parameters() json.RawMessage {
+	return tools.MustMarshal(tools.ParameterSchema{
+		Type: "object",
+		Properties: map[string]tools.PropertySchema{
+			"tag":           {Type: "string", Description: "The tag name"},
+			"message_index": {Type: "integer", Description: "Index of the message to tag (optional, defaults to the chat)"},
+			"remove":        {Type: "boolean", Description: "true to remove the tag instead"},
+		},
+		Required: []string{"tag"},
+	})
+}
+
+func (t *TagTool) Execute(ctx context.Context, args json.RawMessage, execCtx *tools.ExecutionContext) (*tools.Result, error) {
+	var params struct {
+		Tag          string `json:"tag"`
+		MessageIndex int    `json:"message_index"`
+		Remove       bool   `json:"remove"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return tools.ErrorResult("invalid parameters"), nil
+	}
+	tag := strings.TrimSpace(params.Tag)
+	if tag == "" {
+		return tools.ErrorResult("tag is required"), nil
+	}
+
+	targetType, msgID := store.TagTargetChat, ""
+	if params.MessageIndex > 0 {
+		id, ok := execCtx.MessageMap[params.MessageIndex]
+		if !ok {
+			return tools.ErrorResult("message index not found"), nil
+		}
+		targetType, msgID = store.TagTargetMessage, id
+	}
+
+	var err error
+	switch {
+	case params.Remove:
+		err = t.tags.Untag(tag, targetType, execCtx.ChatJID, msgID)
+	case targetType == store.TagTargetMessage:
+		err = t.tags.TagMessage(tag, execCtx.ChatJID, msgID)
+	default:
+		err = t.tags.TagChat(tag, execCtx.ChatJID)
+	}
+	if err != nil {
+		return tools.ErrorResult(err.Error()), nil
+	}
+
+	status := "tagged"
+	if params.Remove {
+		status = "untagged"
+	}
+	return tools.SuccessResult(map[string]string{"status": status, "target": targetType}), nil
+}
+
+// GetTagsTool lists the tags on the current chat, or what carries a tag.
+type GetTagsTool struct {
+	tags *store.TagStore
+}
+
+func NewGetTagsTool(tags *store.TagStore) *GetTagsTool {
+	return &GetTagsTool{tags: tags}
+}
+
+func (t *GetTagsTool) Name() string { return "get_tags" }
+
+func (t *GetTagsTool) Description() string {
+	return "List the tags on the current chat, or the chats and messages carrying a tag"
+}
+
+func (t *GetTagsTool) Parameters() json.RawMessage {
+	return tools.MustMarshal(tools.ParameterSchema{
+		Type: "object",
+		Properties: map[string]tools.PropertySchema{
+			"tag": {Type: "string", Description: "Tag to look up (optional, defaults to the current chat's tags)"},
+		},
+	})
+}
+
+func (t *GetTagsTool) Execute(ctx context.Context, args json.RawMessage, execCtx *tools.ExecutionContext) (*tools.Result, error) {
+	var params struct {
+		Tag string `json:"tag"`
+	}
+	json.Unmarshal(args, &params)
+
+	tag := strings.TrimSpace(params.Tag)
+	if tag == "" {
+		tags, err := t.tags.GetTagsFor(store.TagTargetChat, execCtx.ChatJID, "")
+		if err != nil {
+			return tools.ErrorResult(err.Error()), nil
+		}
+		names := make([]string, len(tags))
+		for i, tag := range tags {
+			names[i] = tag.Name
+		}
+		return tools.SuccessResult(map[string][]string{"tags": names}), nil
+	}
+
+	chats, err := t.tags.GetTagged(tag, store.TagTargetChat)
+	if err != nil {
+		return tools.ErrorResult(err.Error()), nil
+	}
+	messages, err := t.tags.GetTagged(tag, store.TagTargetMessage)
+	if err != nil {
+		return tools.ErrorResult(err.Error()), nil
+	}
+
+	type taggedMessage struct {
+		Chat      string `json:"chat"`
+		MessageID string `json:"message_id"`
+	}
+	result := struct {
+		Chats    []string        `json:"chats"`
+		Messages []taggedMessage `json:"messages"`
+	}{Chats: []string{}, Messages: []taggedMessage{}}
+	for _, a := range chats {
+		result.Chats = append(result.Chats, a.TargetJID.String())
+	}
+	for _, a := range messages {
+		result.Messages = append(result.Messages, taggedMessage{Chat: a.TargetJID.String(), MessageID: a.MessageID})
+	}
+	return tools.SuccessResult(result), nil
+}
+
+// RegisterTagTools registers local tagging tools.
+func RegisterTagTools(registry *tools.Registry, tags *store.TagStore) {
+	registry.Register(NewTagTool(tags))
+	registry.Register(NewGetTagsTool(tags))
+}
+
+var _ tools.Tool = (*TagTool)(nil)
+var _ tools.Tool = (*GetTagsTool)(nil)
@@ -0,0 +1,66 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+
+	"orion-agent/internal/data/store"
+	"orion-agent/internal/service/agent/tools"
+)
+
+func newTestTagStore(t *testing.T) *store.TagStore {
+	t.Helper()
+	s, err := store.NewWithOptions(":memory:", store.Options{MaxOpenConns: 1, MaxIdleConns: 1}, waLog.Noop)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return store.NewTagStore(s)
+}
+
+func TestTagTools(t *testing.T) {
+	tags := newTestTagStore(t)
+	registry := tools.NewRegistry()
+	RegisterTagTools(registry, tags)
+	ctx := context.Background()
+	execCtx := &tools.ExecutionContext{
+		ChatJID:    types.NewJID("900000000000002", types.HiddenUserServer),
+		MessageMap: map[int]string{1: "M1"},
+	}
+
+	for _, args := range []string{
+		`{"tag":"invoices"}`,
+		`{"tag":"invoices","message_index":1}`,
+		`{"tag":"follow up"}`,
+	} {
+		res, err := registry.Execute(ctx, "tag", args, execCtx)
+		if err != nil || !res.Success {
+			t.Fatalf("tag %s: %+v, %v", args, res, err)
+		}
+	}
+	if res, _ := registry.Execute(ctx, "tag", `{"tag":"x","message_index":9}`, execCtx); res.Success {
+		t.Error("tagged an unknown message index")
+	}
+
+	res, err := registry.Execute(ctx, "get_tags", `{"tag":"invoices"}`, execCtx)
+	if err != nil || !res.Success {
+		t.Fatalf("get_tags: %+v, %v", res, err)
+	}
+	data, _ := json.Marshal(res.Data)
+	if want := `{"chats":["900000000000002@lid"],"messages":[{"chat":"900000000000002@lid","message_id":"M1"}]}`; string(data) != want {
+		t.Errorf("tagged = %s, want %s", data, want)
+	}
+
+	if res, _ := registry.Execute(ctx, "tag", `{"tag":"invoices","remove":true}`, execCtx); !res.Success {
+		t.Fatalf("untag: %+v", res)
+	}
+	res, _ = registry.Execute(ctx, "get_tags", `{}`, execCtx)
+	data, _ = json.Marshal(res.Data)
+	if want := `{"tags":["follow up"]}`; string(data) != want {
+		t.Errorf("chat tags = %s, want %s", data, want)
+	}
+}
@@ -77,7 +77,7 @@ func (t *Trigger) ShouldRespond(messageText string, chatJID, senderJID types.JID
 	isGroup := chatJID.Server == types.GroupServer
 
 	// Never respond if not DM or group
-	if !isDm || !isGroup {
+	if !isDm && !isGroup {
 		return Result{false, "not DM or group"}
 	}
 
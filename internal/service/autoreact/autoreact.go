@@ -0,0 +1,175 @@
+// Package autoreact provides keyword-triggered auto-reactions.
+// Incoming messages matching a configured keyword get a reaction emoji
+// (or a sticker reply), rate-limited per chat.
+package autoreact
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+
+	"orion-agent/internal/data/store"
+	"orion-agent/internal/infra/config"
+	"orion-agent/internal/service/send"
+)
+
+// dedupTTL is how long handled message IDs are remembered.
+const dedupTTL = 10 * time.Minute
+
+// rule is a compiled AutoReactRule.
+type rule struct {
+	patterns []*regexp.Regexp
+	emoji    string
+	sticker  []byte
+}
+
+// AutoReactService reacts to messages matching keyword rules.
+type AutoReactService struct {
+	cfg         *config.AutoReactConfig
+	sendService *send.SendService
+	rules       []rule
+	log         waLog.Logger
+
+	mu        sync.Mutex
+	lastReact map[types.JID]time.Time // chat → last auto-reaction
+	handled   map[string]time.Time    // chat/message ID → handled at
+}
+
+// NewAutoReactService creates a new AutoReactService.
+func NewAutoReactService(cfg *config.AutoReactConfig, sendService *send.SendService, log waLog.Logger) *AutoReactService {
+	s := &AutoReactService{
+		cfg:         cfg,
+		sendService: sendService,
+		log:         log.Sub("AutoReact"),
+		lastReact:   make(map[types.JID]time.Time),
+		handled:     make(map[string]time.Time),
+	}
+	s.rules = s.compileRules(cfg.Rules)
+	return s
+}
+
+// compileRules builds whole-word matchers for each rule.
+func (s *AutoReactService) compileRules(rules []config.AutoReactRule) []rule {
+	compiled := make([]rule, 0, len(rules))
+	for _, r := range rules {
+		cr := rule{emoji: r.Emoji}
+		if r.StickerPath != "" {
+			data, err := os.ReadFile(r.StickerPath)
+			if err != nil {
+				s.log.Warnf("Failed to read sticker %s: %v", r.StickerPath, err)
+			} else {
+				cr.sticker = data
+			}
+		}
+		if cr.emoji == "" && cr.sticker == nil {
+			continue
+		}
+		for _, kw := range r.Keywords {
+			kw = strings.TrimSpace(kw)
+			if kw == "" {
+				continue
+			}
+			re, err := regexp.Compile(`(?i)(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(kw) + `($|[^\p{L}\p{N}])`)
+			if err != nil {
+				s.log.Warnf("Invalid auto-react keyword %q: %v", kw, err)
+				continue
+			}
+			cr.patterns = append(cr.patterns, re)
+		}
+		if len(cr.patterns) > 0 {
+			compiled = append(compiled, cr)
+		}
+	}
+	return compiled
+}
+
+// HandleMessage reacts to the message if it matches a rule.
+func (s *AutoReactService) HandleMessage(ctx context.Context, msg *store.Message) {
+	if !s.cfg.Enabled || len(s.rules) == 0 || msg == nil {
+		return
+	}
+	if msg.FromMe && !s.cfg.IncludeOwn {
+		return
+	}
+
+	text := msg.TextContent
+	if text == "" {
+		text = msg.Caption
+	}
+	if text == "" {
+		return
+	}
+
+	r := s.match(text)
+	if r == nil {
+		return
+	}
+
+	if !s.acquire(msg.ChatJID, msg.ID) {
+		return
+	}
+
+	if r.sticker != nil {
+		sticker := send.Sticker(r.sticker)
+		if _, err := s.sendService.Reply(ctx, msg.ChatJID, types.MessageID(msg.ID), msg.SenderLID, sticker); err != nil {
+			s.log.Warnf("Failed to send auto-react sticker to %s: %v", msg.ID, err)
+		}
+		return
+	}
+
+	if _, err := s.sendService.React(ctx, msg.ChatJID, types.MessageID(msg.ID), msg.SenderLID, r.emoji); err != nil {
+		s.log.Warnf("Failed to auto-react to %s: %v", msg.ID, err)
+	}
+}
+
+// match returns the first rule matching text.
+func (s *AutoReactService) match(text string) *rule {
+	for i := range s.rules {
+		for _, re := range s.rules[i].patterns {
+			if re.MatchString(text) {
+				return &s.rules[i]
+			}
+		}
+	}
+	return nil
+}
+
+// acquire records the reaction if the message wasn't handled yet and the
+// chat is outside its cooldown.
+func (s *AutoReactService) acquire(chat types.JID, msgID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	key := chat.String() + "/" + msgID
+	if _, ok := s.handled[key]; ok {
+		return false
+	}
+
+	cooldown := time.Duration(s.cfg.CooldownSecs) * time.Second
+	if last, ok := s.lastReact[chat]; ok && now.Sub(last) < cooldown {
+		return false
+	}
+
+	// Prune expired dedup entries and cooldowns
+	for k, t := range s.handled {
+		if now.Sub(t) > dedupTTL {
+			delete(s.handled, k)
+		}
+	}
+	for c, t := range s.lastReact {
+		if now.Sub(t) >= cooldown {
+			delete(s.lastReact, c)
+		}
+	}
+
+	s.handled[key] = now
+	s.lastReact[chat] = now
+	return true
+}
@@ -0,0 +1,78 @@
+package autoreact
+
+import (
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+
+	"orion-agent/internal/infra/config"
+)
+
+func TestMatchWholeWords(t *testing.T) {
+	s := NewAutoReactService(&config.AutoReactConfig{Rules: []config.AutoReactRule{
+		{Keywords: []string{"thanks", "terima kasih"}, Emoji: "🙏"},
+		{Keywords: []string{"c++", " "}, Emoji: "💻"},
+		{Keywords: []string{"ignored"}}, // Neither emoji nor sticker
+	}}, nil, waLog.Noop)
+
+	for text, want := range map[string]string{
+		"thanks":                 "🙏",
+		"Thanks!":                "🙏",
+		"ok, THANKS a lot":       "🙏",
+		"Terima kasih ya":        "🙏",
+		"thanksgiving":           "",
+		"nothanks":               "",
+		"thanks2":                "",
+		"terima  kasih":          "",
+		"I write C++ daily":      "💻",
+		"c++17":                  "",
+		"ignored":                "",
+		"(thanks)":               "🙏",
+		"merci, thanks, gracias": "🙏",
+	} {
+		got := ""
+		if r := s.match(text); r != nil {
+			got = r.emoji
+		}
+		if got != want {
+			t.Errorf("match(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	s := NewAutoReactService(&config.AutoReactConfig{CooldownSecs: 60}, nil, waLog.Noop)
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+	other := types.NewJID("900000000000003", types.HiddenUserServer)
+
+	for _, tc := range []struct {
+		chat types.JID
+		id   string
+		want bool
+	}{
+		{chat, "M1", true},
+		{chat, "M1", false}, // Already handled
+		{chat, "M2", false}, // Cooling down
+		{other, "M1", true}, // Cooldowns and IDs are per chat
+	} {
+		if got := s.acquire(tc.chat, tc.id); got != tc.want {
+			t.Errorf("acquire(%s, %s) = %v, want %v", tc.chat.User, tc.id, got, tc.want)
+		}
+	}
+
+	// Once the cooldown passes, the chat is reacted to again and the expired
+	// cooldown is dropped
+	s.lastReact[chat] = time.Now().Add(-time.Minute)
+	s.lastReact[other] = time.Now().Add(-time.Minute)
+	if !s.acquire(chat, "M2") {
+		t.Error("still cooling down after the cooldown")
+	}
+	if _, ok := s.lastReact[other]; ok || len(s.lastReact) != 1 {
+		t.Errorf("expired cooldowns kept: %v", s.lastReact)
+	}
+	if s.acquire(chat, "M1") {
+		t.Error("handled message reacted to again")
+	}
+}
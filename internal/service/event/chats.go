@@ -53,7 +53,7 @@ func (h *EventService) OnStarMessage(evt *events.Star) {
 // OnDeleteForMe marks message as deleted.
 func (h *EventService) OnDeleteForMe(evt *events.DeleteForMe) {
 	chatJID := h.utils.NormalizeJID(h.ctx, evt.ChatJID)
-	if err := h.messages.Delete(evt.MessageID, chatJID); err != nil {
+	if err := h.messages.DeleteWithMedia(evt.MessageID, chatJID); err != nil {
 		h.log.Errorf("Failed to delete message: %v", err)
 	}
 }
@@ -6,6 +6,8 @@ import (
 
 	"go.mau.fi/whatsmeow/types/events"
 	waLog "go.mau.fi/whatsmeow/util/log"
+
+	"orion-agent/internal/infra/metrics"
 )
 
 // Dispatcher routes incoming events to EventService handlers.
@@ -30,6 +32,7 @@ func (d *Dispatcher) Handle(evt interface{}) {
 	if d.ctx.Err() != nil {
 		return
 	}
+	metrics.ObserveEvent(evt)
 
 	switch e := evt.(type) {
 	// Message events
@@ -42,6 +45,9 @@ func (d *Dispatcher) Handle(evt interface{}) {
 	case *events.UndecryptableMessage:
 		d.log.Warnf("Undecryptable message from %s", e.Info.Sender)
 		d.service.OnUndecryptableMessage(e)
+	case *events.MediaRetry:
+		d.log.Debugf("Media retry response for %s", e.MessageID)
+		d.service.OnMediaRetry(e)
 
 	// Presence events
 	case *events.Presence:
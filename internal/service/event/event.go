@@ -5,6 +5,8 @@ package event
 import (
 	"context"
 
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
 	waLog "go.mau.fi/whatsmeow/util/log"
 
 	"orion-agent/internal/data/store"
@@ -18,6 +20,16 @@ type AgentProcessor interface {
 	HandleMessage(ctx context.Context, msg *store.Message)
 }
 
+// AutoReactor handles keyword-triggered auto-reactions.
+type AutoReactor interface {
+	HandleMessage(ctx context.Context, msg *store.Message)
+}
+
+// PollDecrypter decrypts poll votes (implemented by *whatsmeow.Client).
+type PollDecrypter interface {
+	DecryptPollVote(ctx context.Context, vote *events.Message) (*waE2E.PollVoteMessage, error)
+}
+
 // EventService manages event handling and data persistence.
 // All JIDs are normalized to LID form before saving.
 type EventService struct {
@@ -27,6 +39,15 @@ type EventService struct {
 	agent AgentProcessor
 	media *media.MediaService
 
+	// Optional auto-reaction handler
+	autoReact AutoReactor
+
+	// Optional poll vote decryption
+	pollDecrypter PollDecrypter
+
+	// Message types that aren't saved
+	ignoredTypes map[string]bool
+
 	// Internal dispatcher
 	dispatcher *Dispatcher
 
@@ -43,6 +64,7 @@ type EventService struct {
 	labels      *store.LabelStore
 	privacy     *store.PrivacyStore
 	blocklist   *store.BlocklistStore
+	bots        *store.BotStore
 }
 
 // NewEventService creates a new EventService.
@@ -63,6 +85,7 @@ func NewEventService(
 	labels *store.LabelStore,
 	privacy *store.PrivacyStore,
 	blocklist *store.BlocklistStore,
+	bots *store.BotStore,
 ) *EventService {
 	return &EventService{
 		log:         log.Sub("EventService"),
@@ -81,6 +104,28 @@ func NewEventService(
 		labels:      labels,
 		privacy:     privacy,
 		blocklist:   blocklist,
+		bots:        bots,
+	}
+}
+
+// SetAutoReactor sets the auto-reaction handler.
+func (s *EventService) SetAutoReactor(r AutoReactor) {
+	s.autoReact = r
+}
+
+// SetPollDecrypter sets the poll vote decrypter.
+// Without one, votes are saved without their selected options.
+func (s *EventService) SetPollDecrypter(d PollDecrypter) {
+	s.pollDecrypter = d
+}
+
+// SetIgnoredMessageTypes sets the message types that aren't saved, live or
+// from history sync. Reactions, edits, revokes, votes and pins are still
+// applied.
+func (s *EventService) SetIgnoredMessageTypes(msgTypes []string) {
+	s.ignoredTypes = make(map[string]bool, len(msgTypes))
+	for _, t := range msgTypes {
+		s.ignoredTypes[t] = true
 	}
 }
 
@@ -1,6 +1,9 @@
 package event
 
 import (
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 
 	"orion-agent/internal/data/extract"
@@ -32,11 +35,33 @@ func (h *EventService) OnGroupInfo(evt *events.GroupInfo) {
 			h.log.Errorf("Failed to add participant: %v", err)
 		}
 	}
+	var sender types.JID
+	if evt.Sender != nil {
+		sender = h.utils.NormalizeJID(h.ctx, *evt.Sender)
+	}
+	leftAt := evt.Timestamp
+	if leftAt.IsZero() {
+		leftAt = time.Now()
+	}
 	for _, jid := range evt.Leave {
 		normalizedJID := h.utils.NormalizeJID(h.ctx, jid)
 		if err := h.groups.RemoveParticipant(groupJID, normalizedJID); err != nil {
 			h.log.Errorf("Failed to remove participant: %v", err)
 		}
+
+		// Someone else making the change means the member was removed
+		reason := store.LeaveReasonLeft
+		if !sender.IsEmpty() && sender.ToNonAD() != normalizedJID.ToNonAD() {
+			reason = store.LeaveReasonRemoved
+		}
+		if err := h.groups.PutPastParticipant(&store.PastParticipant{
+			GroupJID:       groupJID,
+			MemberLID:      normalizedJID,
+			LeaveReason:    reason,
+			LeaveTimestamp: leftAt,
+		}); err != nil {
+			h.log.Errorf("Failed to record past participant: %v", err)
+		}
 	}
 	for _, jid := range evt.Promote {
 		normalizedJID := h.utils.NormalizeJID(h.ctx, jid)
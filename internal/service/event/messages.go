@@ -1,6 +1,8 @@
 package event
 
 import (
+	"encoding/hex"
+
 	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
@@ -34,21 +36,13 @@ func (h *EventService) OnMessage(evt *events.Message) {
 	}
 
 	msg := extract.MessageFromEvent(evt)
-
-	// Normalize all JIDs in the message
-	msg.ChatJID = h.utils.NormalizeJID(h.ctx, msg.ChatJID)
-	msg.SenderLID = h.utils.NormalizeJID(h.ctx, msg.SenderLID)
-	msg.QuotedSenderLID = h.utils.NormalizeJID(h.ctx, msg.QuotedSenderLID)
-	msg.BroadcastListJID = h.utils.NormalizeJID(h.ctx, msg.BroadcastListJID)
-
-	// Normalize mentioned JIDs
-	for i := range msg.MentionedJIDs {
-		msg.MentionedJIDs[i] = h.utils.NormalizeJID(h.ctx, msg.MentionedJIDs[i])
-	}
-	for i := range msg.GroupMentions {
-		msg.GroupMentions[i].GroupJID = h.utils.NormalizeJID(h.ctx, msg.GroupMentions[i].GroupJID)
+	if h.ignoredTypes[msg.MessageType] {
+		h.log.Debugf("Not saving message %s of ignored type %s", msg.ID, msg.MessageType)
+		return
 	}
 
+	h.normalizeMessage(msg)
+
 	// Normalize chat JID for operations
 	chatJID := h.utils.NormalizeJID(h.ctx, evt.Info.Chat)
 	senderJID := h.utils.NormalizeJID(h.ctx, evt.Info.Sender)
@@ -57,6 +51,9 @@ func (h *EventService) OnMessage(evt *events.Message) {
 	if h.agent != nil {
 		go h.agent.HandleMessage(h.ctx, msg)
 	}
+	if h.autoReact != nil {
+		go h.autoReact.HandleMessage(h.ctx, msg)
+	}
 
 	// Ensure chat exists
 	chatType := store.ChatTypeUser
@@ -99,6 +96,29 @@ func (h *EventService) OnMessage(evt *events.Message) {
 	if msg.MessageType == "poll" || msg.MessageType == "poll_v2" || msg.MessageType == "poll_v3" {
 		h.savePollCreation(msg, chatJID, senderJID)
 	}
+
+	// Link bot invocations and bot replies to the bot
+	if !msg.BotJID.IsEmpty() {
+		h.saveBotMessage(msg, chatJID, senderJID)
+	}
+}
+
+// normalizeMessage converts all JIDs in a message to LID form.
+func (h *EventService) normalizeMessage(msg *store.Message) {
+	msg.ChatJID = h.utils.NormalizeJID(h.ctx, msg.ChatJID)
+	msg.SenderLID = h.utils.NormalizeJID(h.ctx, msg.SenderLID)
+	msg.QuotedSenderLID = h.utils.NormalizeJID(h.ctx, msg.QuotedSenderLID)
+	msg.BroadcastListJID = h.utils.NormalizeJID(h.ctx, msg.BroadcastListJID)
+	msg.BotInvokerLID = h.utils.NormalizeJID(h.ctx, msg.BotInvokerLID)
+	msg.ForwardedFromJID = h.utils.NormalizeJID(h.ctx, msg.ForwardedFromJID)
+
+	// Normalize mentioned JIDs
+	for i := range msg.MentionedJIDs {
+		msg.MentionedJIDs[i] = h.utils.NormalizeJID(h.ctx, msg.MentionedJIDs[i])
+	}
+	for i := range msg.GroupMentions {
+		msg.GroupMentions[i].GroupJID = h.utils.NormalizeJID(h.ctx, msg.GroupMentions[i].GroupJID)
+	}
 }
 
 // handleReaction handles reaction messages.
@@ -184,6 +204,19 @@ func (h *EventService) handlePollUpdate(evt *events.Message) {
 	vote.ChatJID = h.utils.NormalizeJID(h.ctx, vote.ChatJID)
 	vote.VoterLID = h.utils.NormalizeJID(h.ctx, vote.VoterLID)
 
+	// Selected options are stored as hex option hashes, resolved by PollStore.TallyPoll
+	if h.pollDecrypter != nil {
+		decrypted, err := h.pollDecrypter.DecryptPollVote(h.ctx, evt)
+		if err != nil {
+			h.log.Warnf("Failed to decrypt poll vote %s: %v", evt.Info.ID, err)
+		} else {
+			vote.SelectedOptions = make([]string, len(decrypted.GetSelectedOptions()))
+			for i, hash := range decrypted.GetSelectedOptions() {
+				vote.SelectedOptions[i] = hex.EncodeToString(hash)
+			}
+		}
+	}
+
 	if err := h.polls.SaveVote(vote); err != nil {
 		h.log.Errorf("Failed to save poll vote: %v", err)
 	}
@@ -207,6 +240,27 @@ func (h *EventService) savePollCreation(msg *store.Message, chatJID, creatorLID
 	}
 }
 
+// saveBotMessage saves bot invocation metadata for a message.
+func (h *EventService) saveBotMessage(msg *store.Message, chatJID, senderLID types.JID) {
+	invoker := msg.BotInvokerLID
+	if invoker.IsEmpty() && msg.IsBotInvoke {
+		invoker = senderLID
+	}
+	bot := &store.BotMessage{
+		MessageID:  msg.ID,
+		ChatJID:    chatJID,
+		BotJID:     msg.BotJID,
+		InvokerLID: invoker,
+		Prompt:     msg.BotPrompt,
+		PersonaID:  msg.BotPersonaID,
+		IsInvoke:   msg.IsBotInvoke,
+		Timestamp:  msg.Timestamp,
+	}
+	if err := h.bots.PutMessage(bot); err != nil {
+		h.log.Errorf("Failed to save bot message: %v", err)
+	}
+}
+
 // handlePinMessage handles pin/unpin messages.
 func (h *EventService) handlePinMessage(evt *events.Message) {
 	pin := evt.Message.GetPinInChatMessage()
@@ -272,6 +326,26 @@ func (h *EventService) OnReceipt(evt *events.Receipt) {
 		receipts[i].RecipientLID = h.utils.NormalizeJID(h.ctx, receipts[i].RecipientLID)
 	}
 
+	// Broadcasts are sent to each recipient's own chat; file their receipts
+	// under the broadcast list too, where the message is saved.
+	if len(receipts) > 0 && receipts[0].ChatJID.Server != types.GroupServer {
+		broadcasts := make(map[string]types.JID)
+		for _, r := range receipts {
+			bc, seen := broadcasts[r.MessageID]
+			if !seen {
+				var err error
+				if bc, err = h.messages.GetSentBroadcastChat(r.MessageID); err != nil {
+					h.log.Warnf("Failed to look up broadcast for receipt %s: %v", r.MessageID, err)
+				}
+				broadcasts[r.MessageID] = bc
+			}
+			if !bc.IsEmpty() {
+				r.ChatJID = bc
+				receipts = append(receipts, r)
+			}
+		}
+	}
+
 	if err := h.receipts.PutMany(receipts); err != nil {
 		h.log.Errorf("Failed to save receipts: %v", err)
 	}
@@ -281,3 +355,10 @@ func (h *EventService) OnReceipt(evt *events.Receipt) {
 func (h *EventService) OnUndecryptableMessage(evt *events.UndecryptableMessage) {
 	h.log.Warnf("Undecryptable message from %s in %s: %v", evt.Info.Sender, evt.Info.Chat, evt.DecryptFailMode)
 }
+
+// OnMediaRetry passes re-upload responses for expired media to the media service.
+func (h *EventService) OnMediaRetry(evt *events.MediaRetry) {
+	if h.media != nil {
+		h.media.HandleMediaRetry(evt)
+	}
+}
@@ -0,0 +1,191 @@
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waAICommon"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	waLog "go.mau.fi/whatsmeow/util/log"
+	"google.golang.org/protobuf/proto"
+
+	"orion-agent/internal/data/store"
+	"orion-agent/internal/utils"
+)
+
+func newTestEventService(t *testing.T) (*EventService, *store.Store) {
+	t.Helper()
+	db, err := store.NewWithOptions(":memory:", store.Options{MaxOpenConns: 1, MaxIdleConns: 1}, waLog.Noop)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	contacts := store.NewContactStore(db)
+	s := NewEventService(waLog.Noop, utils.New(contacts, nil), nil, nil,
+		store.NewMessageStore(db), contacts, store.NewChatStore(db), store.NewGroupStore(db),
+		store.NewNewsletterStore(db), store.NewReceiptStore(db), store.NewReactionStore(db),
+		store.NewCallStore(db), store.NewPollStore(db), store.NewLabelStore(db),
+		store.NewPrivacyStore(db), store.NewBlocklistStore(db), store.NewBotStore(db))
+	s.ctx = context.Background()
+	s.SetIgnoredMessageTypes([]string{"sender_key_distribution"})
+	return s, db
+}
+
+func countRows(t *testing.T, db *store.Store, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	return n
+}
+
+func senderKeyMessage() *waE2E.Message {
+	return &waE2E.Message{
+		SenderKeyDistributionMessage: &waE2E.SenderKeyDistributionMessage{
+			GroupID:                             proto.String("120363000000000001@g.us"),
+			AxolotlSenderKeyDistributionMessage: []byte{1, 2, 3},
+		},
+	}
+}
+
+func TestIgnoredTypesNotSaved(t *testing.T) {
+	s, db := newTestEventService(t)
+	chat := types.NewJID("120363000000000001", types.GroupServer)
+	sender := types.NewJID("900000000000001", types.HiddenUserServer)
+	info := func(id string) types.MessageInfo {
+		return types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: chat, Sender: sender, IsGroup: true},
+			ID:            id,
+			Timestamp:     time.Unix(1700000000, 0),
+		}
+	}
+
+	s.OnMessage(&events.Message{Info: info("SKD1"), Message: senderKeyMessage()})
+	if n := countRows(t, db, `SELECT COUNT(*) FROM orion_messages`); n != 0 {
+		t.Fatalf("ignored message saved: %d rows", n)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM orion_chats`); n != 0 {
+		t.Fatalf("ignored message created a chat: %d rows", n)
+	}
+
+	s.OnMessage(&events.Message{Info: info("TEXT1"), Message: &waE2E.Message{Conversation: proto.String("hi")}})
+	if n := countRows(t, db, `SELECT COUNT(*) FROM orion_messages WHERE id = 'TEXT1'`); n != 1 {
+		t.Fatalf("text message rows = %d, want 1", n)
+	}
+}
+
+func TestIgnoredTypesNotSavedFromHistorySync(t *testing.T) {
+	s, db := newTestEventService(t)
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+	webMsg := func(id string, m *waE2E.Message) *waHistorySync.HistorySyncMsg {
+		return &waHistorySync.HistorySyncMsg{Message: &waWeb.WebMessageInfo{
+			Key: &waCommon.MessageKey{
+				RemoteJID: proto.String(chat.String()),
+				FromMe:    proto.Bool(false),
+				ID:        proto.String(id),
+			},
+			Message:          m,
+			MessageTimestamp: proto.Uint64(1700000000),
+		}}
+	}
+
+	s.OnHistorySync(&events.HistorySync{Data: &waHistorySync.HistorySync{
+		SyncType: waHistorySync.HistorySync_RECENT.Enum(),
+		Conversations: []*waHistorySync.Conversation{{
+			ID: proto.String(chat.String()),
+			Messages: []*waHistorySync.HistorySyncMsg{
+				webMsg("SKD1", senderKeyMessage()),
+				webMsg("TEXT1", &waE2E.Message{Conversation: proto.String("hi")}),
+			},
+		}},
+	}})
+
+	if n := countRows(t, db, `SELECT COUNT(*) FROM orion_messages WHERE id = 'SKD1'`); n != 0 {
+		t.Fatalf("ignored history message saved: %d rows", n)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM orion_messages WHERE id = 'TEXT1'`); n != 1 {
+		t.Fatalf("text history message rows = %d, want 1", n)
+	}
+}
+
+func TestHistorySyncNormalizesJIDs(t *testing.T) {
+	s, _ := newTestEventService(t)
+	chat := types.NewJID("120363000000000001", types.GroupServer)
+	sender := types.NewJID("900000000000001", types.HiddenUserServer)
+	lid := types.NewJID("900000000000003", types.HiddenUserServer)
+	pn := types.NewJID("15550000003", types.DefaultUserServer)
+	s.utils.StoreMappingFromEvent(pn, lid)
+
+	s.OnHistorySync(&events.HistorySync{Data: &waHistorySync.HistorySync{
+		SyncType: waHistorySync.HistorySync_RECENT.Enum(),
+		Conversations: []*waHistorySync.Conversation{{
+			ID: proto.String(chat.String()),
+			Messages: []*waHistorySync.HistorySyncMsg{{Message: &waWeb.WebMessageInfo{
+				Key: &waCommon.MessageKey{
+					RemoteJID: proto.String(chat.String()),
+					FromMe:    proto.Bool(false),
+					ID:        proto.String("M1"),
+				},
+				Participant: proto.String(sender.String()),
+				Message: &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+					Text:        proto.String("hi @15550000003"),
+					ContextInfo: &waE2E.ContextInfo{MentionedJID: []string{pn.String()}},
+				}},
+				MessageTimestamp: proto.Uint64(1700000000),
+			}}},
+		}},
+	}})
+
+	// Stored as the live path would have stored it
+	got, err := s.messages.Get("M1", chat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.MentionedJIDs) != 1 || got.MentionedJIDs[0] != lid {
+		t.Errorf("mentions = %v, want %v", got.MentionedJIDs, lid)
+	}
+}
+
+func TestHistorySyncSavesBotMessages(t *testing.T) {
+	s, db := newTestEventService(t)
+	bot := types.NewJID("867051314767696", types.BotServer)
+	user := types.NewJID("900000000000002", types.HiddenUserServer)
+
+	s.OnHistorySync(&events.HistorySync{Data: &waHistorySync.HistorySync{
+		SyncType: waHistorySync.HistorySync_RECENT.Enum(),
+		Conversations: []*waHistorySync.Conversation{{
+			ID: proto.String(bot.String()),
+			Messages: []*waHistorySync.HistorySyncMsg{{Message: &waWeb.WebMessageInfo{
+				Key: &waCommon.MessageKey{
+					RemoteJID: proto.String(bot.String()),
+					FromMe:    proto.Bool(false),
+					ID:        proto.String("REPLY1"),
+				},
+				Message: &waE2E.Message{
+					Conversation: proto.String("sunny"),
+					MessageContextInfo: &waE2E.MessageContextInfo{BotMetadata: &waAICommon.BotMetadata{
+						PersonaID:  proto.String("persona1"),
+						InvokerJID: proto.String(user.String()),
+					}},
+				},
+				MessageTimestamp: proto.Uint64(1700000000),
+			}}},
+		}},
+	}})
+
+	got, err := store.NewBotStore(db).GetMessage("REPLY1", bot)
+	if err != nil {
+		t.Fatalf("bot message not saved: %v", err)
+	}
+	if got.BotJID != bot || got.PersonaID != "persona1" || got.InvokerLID != user {
+		t.Errorf("bot message = %+v", got)
+	}
+}
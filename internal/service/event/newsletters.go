@@ -41,10 +41,53 @@ func (h *EventService) OnNewsletterMuteChange(evt *events.NewsletterMuteChange)
 	}
 }
 
-// OnNewsletterLiveUpdate handles live newsletter updates.
+// OnNewsletterLiveUpdate persists newsletter posts from live updates.
+// Posts without content only have their server ID reconciled, so reactions
+// and view counts can be correlated with the stored message.
 func (h *EventService) OnNewsletterLiveUpdate(evt *events.NewsletterLiveUpdate) {
-	for _, msg := range evt.Messages {
-		// Process each message in the newsletter update
-		h.log.Debugf("Newsletter %s update: message %s", evt.JID, msg.MessageServerID)
+	if len(evt.Messages) == 0 {
+		return
+	}
+
+	if err := h.chats.EnsureExists(evt.JID, store.ChatTypeNewsletter); err != nil {
+		h.log.Errorf("Failed to ensure newsletter chat exists: %v", err)
+	}
+
+	for _, nm := range evt.Messages {
+		h.saveNewsletterStats(evt.JID, nm)
+
+		msg := extract.MessageFromNewsletter(evt.JID, nm)
+		if msg == nil {
+			if nm.MessageID == "" {
+				continue
+			}
+			if err := h.messages.UpdateServerID(string(nm.MessageID), evt.JID, int(nm.MessageServerID)); err != nil {
+				h.log.Errorf("Failed to update newsletter message %s server ID: %v", nm.MessageID, err)
+			}
+			continue
+		}
+
+		if err := h.messages.Put(msg); err != nil {
+			h.log.Errorf("Failed to save newsletter message %s: %v", msg.ID, err)
+		}
+	}
+}
+
+// saveNewsletterStats persists the view and reaction counts of a post.
+func (h *EventService) saveNewsletterStats(newsletterJID types.JID, nm *types.NewsletterMessage) {
+	if nm.MessageServerID == 0 {
+		return
+	}
+	serverID := int(nm.MessageServerID)
+
+	if nm.ViewsCount > 0 {
+		if err := h.newsletters.UpdateMessageViews(newsletterJID, serverID, nm.ViewsCount); err != nil {
+			h.log.Errorf("Failed to update views of newsletter post %d: %v", serverID, err)
+		}
+	}
+	if len(nm.ReactionCounts) > 0 {
+		if err := h.newsletters.UpdateMessageReactionCounts(newsletterJID, serverID, nm.ReactionCounts); err != nil {
+			h.log.Errorf("Failed to update reactions of newsletter post %d: %v", serverID, err)
+		}
 	}
 }
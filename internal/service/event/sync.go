@@ -67,9 +67,10 @@ func (h *EventService) OnHistorySync(evt *events.HistorySync) {
 	// Save messages - normalize JIDs, infer sender if missing
 	savedMsgs := 0
 	for _, msg := range data.Messages {
-		msg.ChatJID = h.utils.NormalizeJID(h.ctx, msg.ChatJID)
-		msg.SenderLID = h.utils.NormalizeJID(h.ctx, msg.SenderLID)
-		msg.QuotedSenderLID = h.utils.NormalizeJID(h.ctx, msg.QuotedSenderLID)
+		if h.ignoredTypes[msg.MessageType] {
+			continue
+		}
+		h.normalizeMessage(msg)
 
 		// Infer sender if missing
 		if msg.SenderLID.IsEmpty() {
@@ -86,8 +87,13 @@ func (h *EventService) OnHistorySync(evt *events.HistorySync) {
 
 		if err := h.messages.Put(msg); err != nil {
 			h.log.Errorf("Failed to save message: %v", err)
-		} else {
-			savedMsgs++
+			continue
+		}
+		savedMsgs++
+
+		// Link bot invocations and bot replies to the bot
+		if !msg.BotJID.IsEmpty() {
+			h.saveBotMessage(msg, msg.ChatJID, msg.SenderLID)
 		}
 	}
 
@@ -0,0 +1,263 @@
+// Package group provides group membership management.
+package group
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+
+	"orion-agent/internal/data/extract"
+	"orion-agent/internal/data/store"
+	"orion-agent/internal/utils"
+)
+
+// Participant error codes returned by WhatsApp.
+const (
+	ErrorCodeNotAuthorized = 401 // We are not an admin
+	ErrorCodePrivacy       = 403 // The user's privacy settings don't allow adding them; see InviteCode
+	ErrorCodeNotFound      = 404 // Not a participant / not on WhatsApp
+	ErrorCodeConflict      = 409 // Already a participant
+)
+
+// ParticipantResult is the outcome of a participant change for one JID.
+type ParticipantResult struct {
+	JID   types.JID
+	Error int // 0 on success
+
+	// For adds refused by privacy settings, an invite that can be sent instead
+	InviteCode       string
+	InviteExpiration time.Time
+}
+
+// OK reports whether the change succeeded for this participant.
+func (r ParticipantResult) OK() bool {
+	return r.Error == 0
+}
+
+// GroupService manages groups and their membership, keeping the store in sync.
+type GroupService struct {
+	client *whatsmeow.Client
+	utils  *utils.Utils
+	groups *store.GroupStore
+	chats  *store.ChatStore
+	log    waLog.Logger
+}
+
+// NewGroupService creates a new GroupService.
+func NewGroupService(client *whatsmeow.Client, utils *utils.Utils, groups *store.GroupStore, chats *store.ChatStore, log waLog.Logger) *GroupService {
+	return &GroupService{
+		client: client,
+		utils:  utils,
+		groups: groups,
+		chats:  chats,
+		log:    log.Sub("GroupService"),
+	}
+}
+
+// CreateGroup creates a group with the given participants (excluding ourselves).
+// Participants that couldn't be added have their error code stored; see
+// ParticipantResult for the codes.
+func (s *GroupService) CreateGroup(ctx context.Context, name string, participants []types.JID) (*store.Group, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("client not initialized")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("group name is required")
+	}
+
+	info, err := s.client.CreateGroup(ctx, whatsmeow.ReqCreateGroup{
+		Name:         name,
+		Participants: participants,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create group: %w", err)
+	}
+
+	info.JID = s.utils.NormalizeJID(ctx, info.JID)
+	info.OwnerJID = s.utils.NormalizeJID(ctx, info.OwnerJID)
+	info.NameSetBy = s.utils.NormalizeJID(ctx, info.NameSetBy)
+	info.TopicSetBy = s.utils.NormalizeJID(ctx, info.TopicSetBy)
+
+	group := extract.GroupFromInfo(info)
+	group.CreatedByLID = s.utils.NormalizeJID(ctx, s.utils.OwnJID())
+	if group.ParticipantCount == 0 {
+		group.ParticipantCount = len(info.Participants)
+	}
+
+	if s.groups != nil {
+		if err := s.groups.Put(group); err != nil {
+			s.log.Warnf("Failed to save created group %s: %v", group.JID, err)
+		}
+
+		members := make([]store.GroupParticipant, 0, len(info.Participants))
+		for _, p := range info.Participants {
+			members = append(members, store.GroupParticipant{
+				GroupJID:     group.JID,
+				MemberLID:    s.utils.NormalizeJID(ctx, p.JID),
+				IsAdmin:      p.IsAdmin || p.IsSuperAdmin,
+				IsSuperAdmin: p.IsSuperAdmin,
+				DisplayName:  p.DisplayName,
+				ErrorCode:    p.Error,
+			})
+		}
+		if err := s.groups.PutParticipants(members); err != nil {
+			s.log.Warnf("Failed to save participants of %s: %v", group.JID, err)
+		}
+	}
+
+	if s.chats != nil {
+		if err := s.chats.EnsureExists(group.JID, store.ChatTypeGroup); err != nil {
+			s.log.Warnf("Failed to create chat for %s: %v", group.JID, err)
+		}
+	}
+
+	return group, nil
+}
+
+// SetGroupName changes the group name.
+func (s *GroupService) SetGroupName(ctx context.Context, group types.JID, name string) error {
+	if s.client == nil {
+		return fmt.Errorf("client not initialized")
+	}
+	if err := s.client.SetGroupName(ctx, group, name); err != nil {
+		return fmt.Errorf("failed to set group name: %w", err)
+	}
+
+	if s.groups != nil {
+		own := s.utils.NormalizeJID(ctx, s.utils.OwnJID())
+		if err := s.groups.SetName(s.utils.NormalizeJID(ctx, group), name, own, time.Now()); err != nil {
+			s.log.Warnf("Failed to save name of %s: %v", group, err)
+		}
+	}
+	return nil
+}
+
+// SetGroupTopic changes the group description. An empty topic removes it.
+func (s *GroupService) SetGroupTopic(ctx context.Context, group types.JID, topic string) error {
+	if s.client == nil {
+		return fmt.Errorf("client not initialized")
+	}
+	topicID := s.client.GenerateMessageID()
+	if err := s.client.SetGroupTopic(ctx, group, "", topicID, topic); err != nil {
+		return fmt.Errorf("failed to set group topic: %w", err)
+	}
+
+	if s.groups != nil {
+		own := s.utils.NormalizeJID(ctx, s.utils.OwnJID())
+		if err := s.groups.SetTopic(s.utils.NormalizeJID(ctx, group), topic, string(topicID), own, time.Now()); err != nil {
+			s.log.Warnf("Failed to save topic of %s: %v", group, err)
+		}
+	}
+	return nil
+}
+
+// SetGroupAnnounce sets whether only admins can send messages.
+func (s *GroupService) SetGroupAnnounce(ctx context.Context, group types.JID, announce bool) error {
+	if s.client == nil {
+		return fmt.Errorf("client not initialized")
+	}
+	if err := s.client.SetGroupAnnounce(ctx, group, announce); err != nil {
+		return fmt.Errorf("failed to set group announce: %w", err)
+	}
+
+	if s.groups != nil {
+		if err := s.groups.SetAnnounce(s.utils.NormalizeJID(ctx, group), announce); err != nil {
+			s.log.Warnf("Failed to save announce mode of %s: %v", group, err)
+		}
+	}
+	return nil
+}
+
+// AddParticipants adds members to a group.
+func (s *GroupService) AddParticipants(ctx context.Context, group types.JID, participants []types.JID) ([]ParticipantResult, error) {
+	return s.updateParticipants(ctx, group, participants, whatsmeow.ParticipantChangeAdd)
+}
+
+// RemoveParticipants removes members from a group.
+func (s *GroupService) RemoveParticipants(ctx context.Context, group types.JID, participants []types.JID) ([]ParticipantResult, error) {
+	return s.updateParticipants(ctx, group, participants, whatsmeow.ParticipantChangeRemove)
+}
+
+// PromoteParticipants makes members group admins.
+func (s *GroupService) PromoteParticipants(ctx context.Context, group types.JID, participants []types.JID) ([]ParticipantResult, error) {
+	return s.updateParticipants(ctx, group, participants, whatsmeow.ParticipantChangePromote)
+}
+
+// DemoteParticipants revokes the admin rights of members.
+func (s *GroupService) DemoteParticipants(ctx context.Context, group types.JID, participants []types.JID) ([]ParticipantResult, error) {
+	return s.updateParticipants(ctx, group, participants, whatsmeow.ParticipantChangeDemote)
+}
+
+// updateParticipants applies a participant change and stores the successful ones.
+// The returned error is only set if the whole request failed; per-participant
+// failures are reported in the results.
+func (s *GroupService) updateParticipants(ctx context.Context, group types.JID, participants []types.JID, action whatsmeow.ParticipantChange) ([]ParticipantResult, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("client not initialized")
+	}
+	if group.Server != types.GroupServer {
+		return nil, fmt.Errorf("not a group JID: %s", group)
+	}
+	if len(participants) == 0 {
+		return nil, fmt.Errorf("no participants provided")
+	}
+
+	changed, err := s.client.UpdateGroupParticipants(ctx, group, participants, action)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s participants: %w", action, err)
+	}
+
+	groupJID := s.utils.NormalizeJID(ctx, group)
+	results := make([]ParticipantResult, 0, len(changed))
+	for _, p := range changed {
+		jid := p.JID
+		if !p.LID.IsEmpty() {
+			jid = p.LID
+		}
+		result := ParticipantResult{JID: s.utils.NormalizeJID(ctx, jid), Error: p.Error}
+		if p.AddRequest != nil {
+			result.InviteCode = p.AddRequest.Code
+			result.InviteExpiration = p.AddRequest.Expiration
+		}
+		results = append(results, result)
+
+		if result.OK() {
+			s.storeChange(ctx, groupJID, result.JID, action)
+		}
+	}
+
+	return results, nil
+}
+
+// storeChange mirrors a successful participant change in the store.
+func (s *GroupService) storeChange(ctx context.Context, groupJID, member types.JID, action whatsmeow.ParticipantChange) {
+	if s.groups == nil {
+		return
+	}
+
+	var err error
+	switch action {
+	case whatsmeow.ParticipantChangeAdd:
+		err = s.groups.PutParticipant(&store.GroupParticipant{
+			GroupJID:   groupJID,
+			MemberLID:  member,
+			JoinedAt:   time.Now(),
+			AddedByLID: s.utils.NormalizeJID(ctx, s.utils.OwnJID()),
+		})
+	case whatsmeow.ParticipantChangeRemove:
+		err = s.groups.RemoveParticipant(groupJID, member)
+	case whatsmeow.ParticipantChangePromote, whatsmeow.ParticipantChangeDemote:
+		err = s.groups.PutParticipant(&store.GroupParticipant{
+			GroupJID:  groupJID,
+			MemberLID: member,
+			IsAdmin:   action == whatsmeow.ParticipantChangePromote,
+		})
+	}
+	if err != nil {
+		s.log.Warnf("Failed to store %s of %s in %s: %v", action, member, groupJID, err)
+	}
+}
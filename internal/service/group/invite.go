@@ -0,0 +1,129 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	"orion-agent/internal/data/extract"
+	"orion-agent/internal/data/store"
+)
+
+// Invite link errors. They also match whatsmeow's errors with errors.Is.
+var (
+	ErrInviteLinkInvalid = errors.New("invite link is invalid")
+	ErrInviteLinkRevoked = errors.New("invite link has expired or was reset")
+
+	// ErrJoinRequestPending is returned by JoinGroupViaLink when the group
+	// requires admin approval; we're a member once an admin accepts.
+	ErrJoinRequestPending = errors.New("join request is waiting for admin approval")
+)
+
+// GetInviteLink returns the group's invite link. With reset, the current
+// link is revoked and a new one created. Only admins can get the link.
+func (s *GroupService) GetInviteLink(ctx context.Context, group types.JID, reset bool) (string, error) {
+	if s.client == nil {
+		return "", fmt.Errorf("client not initialized")
+	}
+	if group.Server != types.GroupServer {
+		return "", fmt.Errorf("not a group JID: %s", group)
+	}
+
+	link, err := s.client.GetGroupInviteLink(ctx, group, reset)
+	if err != nil {
+		return "", fmt.Errorf("failed to get invite link: %w", err)
+	}
+
+	if s.groups != nil {
+		code := strings.TrimPrefix(link, whatsmeow.InviteLinkPrefix)
+		if err := s.groups.UpdateInviteLink(s.utils.NormalizeJID(ctx, group), link, code, time.Time{}); err != nil {
+			s.log.Warnf("Failed to save invite link of %s: %v", group, err)
+		}
+	}
+	return link, nil
+}
+
+// RevokeInviteLink resets the group's invite link so the old one stops
+// working, and returns the new one.
+func (s *GroupService) RevokeInviteLink(ctx context.Context, group types.JID) (string, error) {
+	return s.GetInviteLink(ctx, group, true)
+}
+
+// JoinGroupViaLink joins a group with an invite code or full
+// chat.whatsapp.com link and stores the group.
+func (s *GroupService) JoinGroupViaLink(ctx context.Context, code string) (*store.Group, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("client not initialized")
+	}
+	code = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(code), whatsmeow.InviteLinkPrefix))
+	if code == "" || strings.ContainsAny(code, "/ ") {
+		return nil, ErrInviteLinkInvalid
+	}
+
+	jid, err := s.client.JoinGroupWithLink(ctx, code)
+	switch {
+	case errors.Is(err, whatsmeow.ErrInviteLinkRevoked):
+		return nil, fmt.Errorf("%w: %w", ErrInviteLinkRevoked, err)
+	case errors.Is(err, whatsmeow.ErrInviteLinkInvalid):
+		return nil, fmt.Errorf("%w: %w", ErrInviteLinkInvalid, err)
+	case err != nil:
+		return nil, fmt.Errorf("failed to join group: %w", err)
+	}
+
+	info, err := s.client.GetGroupInfo(ctx, jid)
+	if errors.Is(err, whatsmeow.ErrNotInGroup) {
+		return nil, ErrJoinRequestPending
+	}
+	if err != nil {
+		return nil, fmt.Errorf("joined %s but failed to get group info: %w", jid, err)
+	}
+
+	return s.storeJoinedGroup(ctx, info), nil
+}
+
+// storeJoinedGroup saves a group we joined with its current participants.
+func (s *GroupService) storeJoinedGroup(ctx context.Context, info *types.GroupInfo) *store.Group {
+	info.JID = s.utils.NormalizeJID(ctx, info.JID)
+	info.OwnerJID = s.utils.NormalizeJID(ctx, info.OwnerJID)
+	info.NameSetBy = s.utils.NormalizeJID(ctx, info.NameSetBy)
+	info.TopicSetBy = s.utils.NormalizeJID(ctx, info.TopicSetBy)
+
+	group := extract.GroupFromInfo(info)
+	if group.ParticipantCount == 0 {
+		group.ParticipantCount = len(info.Participants)
+	}
+
+	if s.groups != nil {
+		if err := s.groups.Put(group); err != nil {
+			s.log.Warnf("Failed to save joined group %s: %v", group.JID, err)
+		}
+
+		members := make([]store.GroupParticipant, 0, len(info.Participants))
+		for _, p := range info.Participants {
+			members = append(members, store.GroupParticipant{
+				GroupJID:     group.JID,
+				MemberLID:    s.utils.NormalizeJID(ctx, p.JID),
+				IsAdmin:      p.IsAdmin || p.IsSuperAdmin,
+				IsSuperAdmin: p.IsSuperAdmin,
+				DisplayName:  p.DisplayName,
+				ErrorCode:    p.Error,
+			})
+		}
+		if _, _, err := s.groups.ReconcileParticipants(group.JID, members); err != nil {
+			s.log.Warnf("Failed to save participants of %s: %v", group.JID, err)
+		}
+	}
+
+	if s.chats != nil {
+		if err := s.chats.EnsureExists(group.JID, store.ChatTypeGroup); err != nil {
+			s.log.Warnf("Failed to create chat for %s: %v", group.JID, err)
+		}
+	}
+
+	return group
+}
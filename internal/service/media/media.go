@@ -1,12 +1,13 @@
 // Package media provides automatic media downloading and file management.
 //
 // MediaService downloads media files from WhatsApp messages and saves them to
-// the local filesystem. It uses a worker pool for concurrent downloads with
+// a Storage backend, the local filesystem or S3. It uses a worker pool for concurrent downloads with
 // retry logic and duplicate detection.
 
 package media
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
@@ -14,6 +15,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -21,32 +23,64 @@ import (
 
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
 	waLog "go.mau.fi/whatsmeow/util/log"
 
 	"orion-agent/internal/data/store"
 	"orion-agent/internal/infra/config"
+	"orion-agent/internal/infra/metrics"
 )
 
 const (
 	backoffFactor = 2.0
+
+	// progressInterval is how many bytes are downloaded between progress reports.
+	progressInterval = 512 * 1024
 )
 
+// ProgressHandler is called while message media downloads.
+// total is the expected file size, 0 if unknown.
+type ProgressHandler func(messageID string, downloaded, total int64)
+
+// ErrorHandler is called when a message media download fails for good.
+type ErrorHandler func(messageID string, err error)
+
 // MediaService handles automatic media downloading.
 //
 // It processes incoming messages and profile pictures, downloading media to
-// local filesystem and tracking downloads in the media cache database.
+// its storage and tracking downloads in the media cache database.
 type MediaService struct {
 	client     *whatsmeow.Client
 	config     *config.MediaConfig
 	storePath  string
+	storage    Storage
 	mediaCache *store.MediaCacheStore
+	messages   *store.MessageStore
 	log        waLog.Logger
 
+	onProgress ProgressHandler
+	onError    ErrorHandler
+
+	// Optional OCR of downloaded images, run by ocrWorkers workers
+	ocr        OCR
+	ocrQueue   chan ocrJob
+	ocrWorkers int
+
+	// Pending media retry requests, keyed by message ID
+	retryMu      sync.Mutex
+	retryWaiters map[string]chan *events.MediaRetry
+
 	// Download queue - buffered channel for pending downloads
 	queue    chan downloadJob
+	priority chan downloadJob // View-once media, taken before queue
 	wg       sync.WaitGroup
 	stopOnce sync.Once
 	stopCh   chan struct{}
+	ctx      context.Context // Cancelled by Stop
+	cancel   context.CancelFunc
+
+	// Replaces fetchMedia in tests
+	fetch func(ctx context.Context, job downloadJob, file *os.File) error
 }
 
 // downloadJob represents a queued download task.
@@ -54,8 +88,11 @@ type downloadJob struct {
 	// For message media
 	MessageID string
 	ChatJID   types.JID
+	SenderJID types.JID
+	FromMe    bool
 	MediaType string
 	Filename  string // Original filename (for documents)
+	ViewOnce  bool   // Cached as store.MediaTypeViewOnce
 
 	// Media download info (for WhatsApp CDN downloads)
 	DirectPath    string
@@ -77,30 +114,52 @@ type downloadJob struct {
 // Parameters:
 //   - client: WhatsApp client for downloading encrypted media
 //   - cfg: Media configuration from config.json
-//   - storePath: Base path for storing downloaded files
+//   - storePath: Base path for temporary files during downloads
+//   - storage: Where downloaded files are saved (nil = local, under storePath)
 //   - mediaCache: Store for tracking downloaded files
+//   - messages: Store for metadata read from downloaded files
 //   - log: Logger instance
 func NewMediaService(
 	client *whatsmeow.Client,
 	cfg *config.MediaConfig,
 	storePath string,
+	storage Storage,
 	mediaCache *store.MediaCacheStore,
+	messages *store.MessageStore,
 	log waLog.Logger,
 ) *MediaService {
 	workerCount := cfg.WorkerCount
 	if workerCount <= 0 {
 		workerCount = 3
 	}
+	if storage == nil {
+		storage = NewLocalStorage(storePath)
+	}
 
-	return &MediaService{
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &MediaService{
 		client:     client,
 		config:     cfg,
 		storePath:  storePath,
+		storage:    storage,
 		mediaCache: mediaCache,
+		messages:   messages,
 		log:        log.Sub("MediaService"),
 		queue:      make(chan downloadJob, 100),
+		priority:   make(chan downloadJob, 20),
 		stopCh:     make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+
+		retryWaiters: make(map[string]chan *events.MediaRetry),
 	}
+	s.fetch = s.fetchMedia
+	return s
+}
+
+// QueueDepth returns the number of downloads waiting for a worker.
+func (s *MediaService) QueueDepth() int {
+	return len(s.queue) + len(s.priority)
 }
 
 // SetClient updates the whatsmeow client.
@@ -108,6 +167,30 @@ func (s *MediaService) SetClient(client *whatsmeow.Client) {
 	s.client = client
 }
 
+// SetProgressHandler sets the callback for download progress.
+// Must be called before Start.
+func (s *MediaService) SetProgressHandler(handler ProgressHandler) {
+	s.onProgress = handler
+}
+
+// SetOCR enables text recognition of downloaded images, running at most
+// concurrency recognitions at once. The text is stored in the media cache
+// for the agent's context. Must be called before Start.
+func (s *MediaService) SetOCR(ocr OCR, concurrency int) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	s.ocr = ocr
+	s.ocrQueue = make(chan ocrJob, ocrQueueSize)
+	s.ocrWorkers = concurrency
+}
+
+// SetErrorHandler sets the callback for failed downloads, both queued ones
+// and those requested with Download. Must be called before Start.
+func (s *MediaService) SetErrorHandler(handler ErrorHandler) {
+	s.onError = handler
+}
+
 // Start starts the download workers.
 //
 // worker_count determines how many concurrent downloads can happen.
@@ -129,13 +212,19 @@ func (s *MediaService) Start() {
 		s.wg.Add(1)
 		go s.worker(i)
 	}
+	for i := 0; i < s.ocrWorkers; i++ {
+		s.wg.Add(1)
+		go s.ocrWorker()
+	}
 }
 
-// Stop stops the download workers gracefully.
+// Stop stops the download and OCR workers gracefully, cancelling running
+// recognitions.
 func (s *MediaService) Stop() {
 	s.stopOnce.Do(func() {
 		s.log.Infof("Stopping media service...")
 		close(s.stopCh)
+		s.cancel()
 		s.wg.Wait()
 		s.log.Infof("Media service stopped")
 	})
@@ -150,6 +239,10 @@ func (s *MediaService) Stop() {
 // - File size within limit
 // - Not already downloaded (checks cache + filesystem)
 // - View-once policy
+//
+// View-once media is downloaded when save_view_once is set (or, as before
+// it existed, when "view_once" is listed in types), whatever the types of
+// its inner media. It jumps the queue since it can only be fetched once.
 func (s *MediaService) QueueMessageMedia(msg *store.Message) {
 	if !s.config.AutoDownload || msg == nil {
 		return
@@ -160,8 +253,8 @@ func (s *MediaService) QueueMessageMedia(msg *store.Message) {
 		return
 	}
 
-	// Skip view-once if not enabled in types
-	if msg.IsViewOnce && !s.isTypeEnabled("view_once") {
+	// Skip view-once unless saving it is enabled
+	if msg.IsViewOnce && !s.config.SaveViewOnce && !s.isTypeEnabled(store.MediaTypeViewOnce) {
 		return
 	}
 
@@ -171,9 +264,12 @@ func (s *MediaService) QueueMessageMedia(msg *store.Message) {
 		return
 	}
 
-	// Check if type is enabled
+	// Check if type is enabled. View-once messages keep the wrapper's type,
+	// so their media is typed from its mimetype.
 	mediaType := getMediaTypeFromMessageType(msg.MessageType)
-	if !s.isTypeEnabled(mediaType) {
+	if msg.IsViewOnce {
+		mediaType = getMediaTypeFromMimetype(msg.Mimetype)
+	} else if !s.isTypeEnabled(mediaType) {
 		return
 	}
 
@@ -183,19 +279,28 @@ func (s *MediaService) QueueMessageMedia(msg *store.Message) {
 		return
 	}
 
-	select {
-	case s.queue <- downloadJob{
+	job := downloadJob{
 		MessageID:     msg.ID,
 		ChatJID:       msg.ChatJID,
+		SenderJID:     msg.SenderLID,
+		FromMe:        msg.FromMe,
 		MediaType:     mediaType,
 		Filename:      msg.DisplayName, // Original filename for documents
+		ViewOnce:      msg.IsViewOnce,
 		DirectPath:    msg.MediaDirectPath,
 		MediaKey:      msg.MediaKey,
 		FileSHA256:    msg.FileSHA256,
 		FileEncSHA256: msg.FileEncSHA256,
 		FileLength:    msg.FileLength,
 		Mimetype:      msg.Mimetype,
-	}:
+	}
+
+	queue := s.queue
+	if job.ViewOnce {
+		queue = s.priority
+	}
+	select {
+	case queue <- job:
 	default:
 		s.log.Warnf("Download queue full, dropping media %s", msg.ID)
 	}
@@ -211,10 +316,10 @@ func (s *MediaService) QueueProfilePicture(jid types.JID, picID, picURL string)
 		return
 	}
 
-	// Check if already downloaded (by checking file existence)
-	filePath := s.buildProfilePicPath(jid, picID)
-	if _, err := os.Stat(filePath); err == nil {
-		s.log.Debugf("Profile pic already exists: %s", filePath)
+	// Check if already downloaded
+	uri := s.storage.URI(buildProfilePicPath(jid, picID))
+	if exists, _ := s.storage.Exists(uri); exists {
+		s.log.Debugf("Profile pic already exists: %s", uri)
 		return
 	}
 
@@ -230,6 +335,67 @@ func (s *MediaService) QueueProfilePicture(jid types.JID, picID, picURL string)
 	}
 }
 
+// Download downloads a message's media right away and returns its storage
+// URI, a file path with local storage.
+//
+// Unlike QueueMessageMedia it ignores the auto-download settings, so it can
+// fetch media the workers skipped. Already downloaded media is returned
+// from the cache.
+func (s *MediaService) Download(ctx context.Context, msgID string, chatJID types.JID) (uri string, err error) {
+	defer func() {
+		if err != nil && s.onError != nil {
+			s.onError(msgID, err)
+		}
+	}()
+
+	if s.messages == nil {
+		return "", errors.New("message store not available")
+	}
+
+	if s.isAlreadyDownloaded(msgID, chatJID) {
+		cached, err := s.mediaCache.Get(msgID, chatJID)
+		if err == nil && cached != nil {
+			return cached.LocalPath, nil
+		}
+	}
+
+	info, err := s.messages.GetMediaForDownload(msgID, chatJID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("message %s not found", msgID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get media info: %w", err)
+	}
+
+	mediaType := getMediaTypeFromMessageType(info.MessageType)
+	if info.IsViewOnce {
+		mediaType = getMediaTypeFromMimetype(info.Mimetype)
+	}
+	if mediaType == "" || info.DirectPath == "" {
+		return "", fmt.Errorf("message %s has no downloadable media", msgID)
+	}
+
+	path, err := s.downloadMedia(ctx, downloadJob{
+		MessageID:     msgID,
+		ChatJID:       chatJID,
+		SenderJID:     info.SenderLID,
+		FromMe:        info.FromMe,
+		MediaType:     mediaType,
+		Filename:      info.DisplayName,
+		ViewOnce:      info.IsViewOnce,
+		DirectPath:    info.DirectPath,
+		MediaKey:      info.MediaKey,
+		FileSHA256:    info.FileSHA256,
+		FileEncSHA256: info.FileEncSHA256,
+		FileLength:    info.FileLength,
+		Mimetype:      info.Mimetype,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to download media: %w", err)
+	}
+	return path, nil
+}
+
 // isAlreadyDownloaded checks if media has already been downloaded.
 func (s *MediaService) isAlreadyDownloaded(messageID string, chatJID types.JID) bool {
 	if s.mediaCache == nil {
@@ -246,7 +412,12 @@ func (s *MediaService) isAlreadyDownloaded(messageID string, chatJID types.JID)
 	}
 
 	// Verify file still exists
-	if _, err := os.Stat(cached.LocalPath); err != nil {
+	exists, err := s.storage.Exists(cached.LocalPath)
+	if err != nil {
+		s.log.Warnf("Failed to check media %s: %v", cached.LocalPath, err)
+		return true // Don't download again because storage is unreachable
+	}
+	if !exists {
 		// File missing, delete cache entry and allow re-download
 		s.mediaCache.Delete(messageID, chatJID)
 		return false
@@ -255,13 +426,12 @@ func (s *MediaService) isAlreadyDownloaded(messageID string, chatJID types.JID)
 	return true
 }
 
-// buildProfilePicPath builds the file path for a profile picture.
-func (s *MediaService) buildProfilePicPath(jid types.JID, picID string) string {
+// buildProfilePicPath builds the storage path for a profile picture.
+func buildProfilePicPath(jid types.JID, picID string) string {
 	jidDir := sanitizeJID(jid.String())
 	filename := fmt.Sprintf("%s.jpg", picID)
 
-	return filepath.Join(
-		s.storePath,
+	return path.Join(
 		"media",
 		jidDir,
 		"profile",
@@ -269,35 +439,49 @@ func (s *MediaService) buildProfilePicPath(jid types.JID, picID string) string {
 	)
 }
 
-// worker processes download jobs.
+// worker processes download jobs, taking priority jobs first.
 func (s *MediaService) worker(id int) {
 	defer s.wg.Done()
 
 	for {
+		var job downloadJob
 		select {
 		case <-s.stopCh:
 			return
-		case job := <-s.queue:
-			if job.IsProfilePic {
-				s.downloadProfilePicWithRetry(job)
-			} else {
-				s.downloadMediaWithRetry(job)
+		case job = <-s.priority:
+		default:
+			select {
+			case <-s.stopCh:
+				return
+			case job = <-s.priority:
+			case job = <-s.queue:
 			}
 		}
+
+		if job.IsProfilePic {
+			s.downloadProfilePicWithRetry(job)
+		} else {
+			s.downloadMediaWithRetry(job)
+		}
 	}
 }
 
 // downloadMediaWithRetry downloads media with exponential backoff retry.
 func (s *MediaService) downloadMediaWithRetry(job downloadJob) {
 	err := s.retryWithBackoff(func() error {
-		return s.downloadMedia(job)
+		_, err := s.downloadMedia(context.Background(), job)
+		return err
 	})
 	if err != nil {
+		metrics.ObserveDownload(job.MediaType, 0, err)
 		max := s.config.RetryMaxAttempts
 		if max <= 0 {
 			max = 3
 		}
 		s.log.Errorf("Failed to download media %s after %d retries: %v", job.MessageID, max, err)
+		if s.onError != nil {
+			s.onError(job.MessageID, err)
+		}
 	}
 }
 
@@ -307,6 +491,7 @@ func (s *MediaService) downloadProfilePicWithRetry(job downloadJob) {
 		return s.downloadProfilePic(job)
 	})
 	if err != nil {
+		metrics.ObserveDownload("profile_picture", 0, err)
 		max := s.config.RetryMaxAttempts
 		if max <= 0 {
 			max = 3
@@ -363,18 +548,14 @@ func (s *MediaService) retryWithBackoff(fn func() error) error {
 	return err
 }
 
-// downloadMedia downloads encrypted message media.
-func (s *MediaService) downloadMedia(job downloadJob) error {
-	if s.client == nil {
-		return errors.New("client not initialized")
-	}
-
-	// Build file path: {store}/media/{chatjid}/{messageid}/{type}/{filename}
+// downloadMedia downloads encrypted message media, saves it to storage and
+// returns its URI.
+func (s *MediaService) downloadMedia(ctx context.Context, job downloadJob) (string, error) {
+	// Build storage path: media/{chatjid}/{messageid}/{type}/{filename}
 	chatDir := sanitizeJID(job.ChatJID.String())
 	filename := buildFilename(job)
 
-	filePath := filepath.Join(
-		s.storePath,
+	objectPath := path.Join(
 		"media",
 		chatDir,
 		job.MessageID,
@@ -382,68 +563,219 @@ func (s *MediaService) downloadMedia(job downloadJob) error {
 		filename,
 	)
 
-	// Create directory
-	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
-		return fmt.Errorf("create directory: %w", err)
+	// Double-check if already downloaded (race condition prevention)
+	uri := s.storage.URI(objectPath)
+	if exists, _ := s.storage.Exists(uri); exists {
+		s.log.Debugf("Media already exists: %s", uri)
+		return uri, nil
 	}
 
-	// Double-check if already downloaded (race condition prevention)
-	if _, err := os.Stat(filePath); err == nil {
-		s.log.Debugf("Media already exists: %s", filePath)
-		return nil
+	// Download to a temporary file first, then hand it to storage
+	tmpDir := filepath.Join(s.storePath, "tmp")
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return "", fmt.Errorf("create directory: %w", err)
+	}
+	file, err := os.CreateTemp(tmpDir, "media-*.part")
+	if err != nil {
+		return "", fmt.Errorf("create file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	err = s.fetch(ctx, job, file)
+	if err != nil && isMediaExpired(err) && s.config.EnableRetry {
+		// The CDN copy expired; ask the sender's phone to re-upload it
+		directPath, retryErr := s.requestMediaRetry(ctx, job)
+		if retryErr != nil {
+			err = fmt.Errorf("%w (media retry: %v)", err, retryErr)
+		} else {
+			job.DirectPath = directPath
+			err = s.fetch(ctx, job, file)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("download: %w", err)
 	}
 
-	// Download using whatsmeow
-	mediaType := whatsmeowMediaType(job.MediaType)
-	data, err := s.client.DownloadMediaWithPath(
-		context.Background(),
-		job.DirectPath,
-		job.FileEncSHA256,
-		job.FileSHA256,
-		job.MediaKey,
-		int(job.FileLength),
-		mediaType,
-		"",
-	)
+	info, err := file.Stat()
 	if err != nil {
-		return fmt.Errorf("download: %w", err)
+		return "", fmt.Errorf("stat file: %w", err)
+	}
+	if s.onProgress != nil {
+		s.onProgress(job.MessageID, info.Size(), info.Size())
 	}
 
-	// Write to file
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("write file: %w", err)
+	if job.MediaType == "sticker" {
+		s.saveStickerPack(job, file.Name())
 	}
 
-	s.log.Infof("Downloaded media: %s (%d bytes)", filePath, len(data))
+	var ocrImage []byte
+	if s.ocr != nil && s.mediaCache != nil && job.MediaType == "image" {
+		if ocrImage, err = os.ReadFile(file.Name()); err != nil {
+			s.log.Warnf("Failed to read %s for OCR: %v", job.MessageID, err)
+		}
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("rewind file: %w", err)
+	}
+	uri, err = s.storage.Put(objectPath, file)
+	if err != nil {
+		return "", fmt.Errorf("store file: %w", err)
+	}
+
+	s.log.Infof("Downloaded media: %s (%d bytes)", uri, info.Size())
+	metrics.ObserveDownload(job.MediaType, info.Size(), nil)
 
 	// Update media cache
 	if s.mediaCache != nil {
+		cacheType := job.MediaType
+		if job.ViewOnce {
+			cacheType = store.MediaTypeViewOnce
+		}
 		if err := s.mediaCache.Put(&store.MediaCache{
 			MessageID: job.MessageID,
 			ChatJID:   job.ChatJID,
-			MediaType: job.MediaType,
-			LocalPath: filePath,
-			FileSize:  int64(len(data)),
+			MediaType: cacheType,
+			LocalPath: uri,
+			FileSize:  info.Size(),
 		}); err != nil {
 			s.log.Warnf("Failed to update media cache for %s: %v", job.MessageID, err)
+		} else if len(ocrImage) > 0 {
+			s.recognizeAsync(job, ocrImage)
 		}
 	}
 
-	return nil
+	return uri, nil
 }
 
-// downloadProfilePic downloads a profile picture via HTTP.
-func (s *MediaService) downloadProfilePic(job downloadJob) error {
-	filePath := s.buildProfilePicPath(job.JID, job.PicID)
+// fetchMedia downloads and decrypts job's media into file, replacing any
+// partial content from an earlier attempt. Files above the stream threshold
+// are streamed to disk so large videos don't have to fit in memory.
+func (s *MediaService) fetchMedia(ctx context.Context, job downloadJob, file *os.File) error {
+	if s.client == nil {
+		return errors.New("client not initialized")
+	}
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	// Small files are quicker to fetch in one piece
+	if s.isSmallFile(job.FileLength) {
+		data, err := s.client.DownloadMediaWithPath(
+			ctx,
+			job.DirectPath,
+			job.FileEncSHA256,
+			job.FileSHA256,
+			job.MediaKey,
+			int(job.FileLength),
+			whatsmeowMediaType(job.MediaType),
+			"",
+		)
+		if err != nil {
+			return err
+		}
+		_, err = s.newProgressFile(file, job).Write(data)
+		return err
+	}
 
-	// Create directory
-	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
-		return fmt.Errorf("create directory: %w", err)
+	return s.client.DownloadMediaWithPathToFile(
+		ctx,
+		job.DirectPath,
+		job.FileEncSHA256,
+		job.FileSHA256,
+		job.MediaKey,
+		int(job.FileLength),
+		whatsmeowMediaType(job.MediaType),
+		"",
+		s.newProgressFile(file, job),
+	)
+}
+
+// isSmallFile reports whether a file is under the stream threshold and can
+// be downloaded in memory.
+func (s *MediaService) isSmallFile(size int64) bool {
+	if s.config.StreamThresholdMB <= 0 || size <= 0 {
+		return false
 	}
+	return size <= int64(s.config.StreamThresholdMB)*1024*1024
+}
+
+// saveStickerPack records the pack metadata embedded in a downloaded sticker.
+func (s *MediaService) saveStickerPack(job downloadJob, filePath string) {
+	if s.messages == nil {
+		return
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		s.log.Warnf("Failed to read sticker %s: %v", job.MessageID, err)
+		return
+	}
+	pack := parseStickerPack(data)
+	if pack == nil {
+		return
+	}
+	if err := s.messages.SetStickerPack(job.MessageID, job.ChatJID, pack.PackID, pack.PackName, pack.Publisher); err != nil {
+		s.log.Warnf("Failed to save sticker pack for %s: %v", job.MessageID, err)
+	}
+}
+
+// progressFile wraps the download target to report progress as the
+// encrypted body is written.
+type progressFile struct {
+	*os.File
+	messageID  string
+	total      int64
+	written    int64
+	lastReport int64
+	onProgress ProgressHandler
+}
+
+// newProgressFile wraps file for progress reporting, or returns it as-is
+// when no progress handler is set.
+func (s *MediaService) newProgressFile(file *os.File, job downloadJob) whatsmeow.File {
+	if s.onProgress == nil {
+		return file
+	}
+	return &progressFile{
+		File:       file,
+		messageID:  job.MessageID,
+		total:      job.FileLength,
+		onProgress: s.onProgress,
+	}
+}
+
+// Write writes to the file and reports progress every progressInterval bytes.
+func (f *progressFile) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+	f.written += int64(n)
+	if f.written-f.lastReport >= progressInterval {
+		f.lastReport = f.written
+		downloaded := f.written
+		if f.total > 0 && downloaded > f.total {
+			downloaded = f.total // Encrypted body includes padding and MAC
+		}
+		f.onProgress(f.messageID, downloaded, f.total)
+	}
+	return n, err
+}
+
+// ReadFrom routes io.Copy through Write instead of the embedded
+// *os.File's ReadFrom, which would bypass progress reporting.
+func (f *progressFile) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{f}, r)
+}
+
+// downloadProfilePic downloads a profile picture via HTTP.
+func (s *MediaService) downloadProfilePic(job downloadJob) error {
+	objectPath := buildProfilePicPath(job.JID, job.PicID)
 
 	// Double-check if already downloaded
-	if _, err := os.Stat(filePath); err == nil {
-		s.log.Debugf("Profile pic already exists: %s", filePath)
+	if exists, _ := s.storage.Exists(s.storage.URI(objectPath)); exists {
+		s.log.Debugf("Profile pic already exists: %s", objectPath)
 		return nil
 	}
 
@@ -481,12 +813,13 @@ func (s *MediaService) downloadProfilePic(job downloadJob) error {
 		return fmt.Errorf("read body: %w", err)
 	}
 
-	// Write to file
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("write file: %w", err)
+	uri, err := s.storage.Put(objectPath, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("store file: %w", err)
 	}
 
-	s.log.Infof("Downloaded profile pic: %s (%d bytes)", filePath, len(data))
+	s.log.Infof("Downloaded profile pic: %s (%d bytes)", uri, len(data))
+	metrics.ObserveDownload("profile_picture", int64(len(data)), nil)
 	return nil
 }
 
@@ -522,6 +855,19 @@ func getMediaTypeFromMessageType(msgType string) string {
 	}
 }
 
+// getMediaTypeFromMimetype returns the media type of view-once media,
+// which is an image, video or voice note.
+func getMediaTypeFromMimetype(mimetype string) string {
+	switch {
+	case strings.HasPrefix(mimetype, "video/"):
+		return "video"
+	case strings.HasPrefix(mimetype, "audio/"):
+		return "audio"
+	default:
+		return "image"
+	}
+}
+
 // whatsmeowMediaType converts our media type to whatsmeow media type.
 func whatsmeowMediaType(mediaType string) whatsmeow.MediaType {
 	switch mediaType {
@@ -0,0 +1,161 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+
+	"orion-agent/internal/data/store"
+	"orion-agent/internal/infra/config"
+)
+
+// newTestMediaService creates a MediaService whose queued jobs stay in its
+// queues, since no workers are started.
+func newTestMediaService(t *testing.T, cfg *config.MediaConfig) *MediaService {
+	t.Helper()
+	cfg.AutoDownload = true
+	return NewMediaService(nil, cfg, t.TempDir(), nil, nil, nil, waLog.Noop)
+}
+
+func viewOnceImage() *store.Message {
+	return &store.Message{
+		ID:              "VO1",
+		MessageType:     "view_once_v2",
+		IsViewOnce:      true,
+		MediaDirectPath: "/v/t62.7118-24/1",
+		Mimetype:        "image/jpeg",
+	}
+}
+
+func TestQueueViewOnceWithSaveViewOnce(t *testing.T) {
+	s := newTestMediaService(t, &config.MediaConfig{
+		SaveViewOnce: true,
+		Types:        []string{"image", "video", "audio", "document", "sticker", "profile_picture"},
+	})
+	s.QueueMessageMedia(viewOnceImage())
+
+	if len(s.priority) != 1 {
+		t.Fatalf("view-once media not queued with save_view_once set and no view_once type")
+	}
+	job := <-s.priority
+	if !job.ViewOnce || job.MediaType != "image" {
+		t.Errorf("got view once %v, media type %q; want true, image", job.ViewOnce, job.MediaType)
+	}
+}
+
+func TestQueueViewOnceDisabled(t *testing.T) {
+	s := newTestMediaService(t, &config.MediaConfig{Types: []string{"image"}})
+	s.QueueMessageMedia(viewOnceImage())
+	if s.QueueDepth() != 0 {
+		t.Error("view-once media queued without save_view_once or the view_once type")
+	}
+}
+
+func TestDownloadViewOnce(t *testing.T) {
+	db, err := store.NewWithOptions(":memory:", store.Options{MaxOpenConns: 1, MaxIdleConns: 1}, waLog.Noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	messages, cache := store.NewMessageStore(db), store.NewMediaCacheStore(db)
+
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+	msg := viewOnceImage()
+	msg.ChatJID, msg.SenderLID, msg.Timestamp = chat, chat, time.Now()
+	if err := messages.Put(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewMediaService(nil, &config.MediaConfig{}, t.TempDir(), nil, cache, messages, waLog.Noop)
+	var fetched downloadJob
+	s.fetch = func(ctx context.Context, job downloadJob, file *os.File) error {
+		fetched = job
+		_, err := file.WriteString("jpeg")
+		return err
+	}
+
+	uri, err := s.Download(context.Background(), msg.ID, chat)
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if fetched.MediaType != "image" || !fetched.ViewOnce {
+		t.Errorf("fetched media type %q, view once %v; want image, true", fetched.MediaType, fetched.ViewOnce)
+	}
+	if data, err := os.ReadFile(uri); err != nil || string(data) != "jpeg" {
+		t.Errorf("stored %q, %v", data, err)
+	}
+	if cached, _ := cache.Get(msg.ID, chat); cached == nil || cached.MediaType != store.MediaTypeViewOnce {
+		t.Errorf("cached as %+v, want view_once", cached)
+	}
+}
+
+// progressStorage is local storage noting the progress reported before each
+// Put.
+type progressStorage struct {
+	Storage
+	reported *[]int64
+	atPut    []int64
+}
+
+func (p *progressStorage) Put(path string, r io.Reader) (string, error) {
+	p.atPut = append([]int64(nil), *p.reported...)
+	return p.Storage.Put(path, r)
+}
+
+func TestDownloadProgressAndErrors(t *testing.T) {
+	db, err := store.NewWithOptions(":memory:", store.Options{MaxOpenConns: 1, MaxIdleConns: 1}, waLog.Noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	messages := store.NewMessageStore(db)
+
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+	msg := &store.Message{ID: "M1", ChatJID: chat, SenderLID: chat, Timestamp: time.Now(),
+		MessageType: "image", MediaDirectPath: "/v/t62.7118-24/1", Mimetype: "image/jpeg", FileLength: 4}
+	if err := messages.Put(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	var reported []int64
+	storage := &progressStorage{Storage: NewLocalStorage(t.TempDir()), reported: &reported}
+	s := NewMediaService(nil, &config.MediaConfig{}, t.TempDir(), storage, nil, messages, waLog.Noop)
+	s.SetProgressHandler(func(messageID string, downloaded, total int64) {
+		reported = append(reported, downloaded, total)
+	})
+	failed := map[string]error{}
+	s.SetErrorHandler(func(messageID string, err error) { failed[messageID] = err })
+
+	fetchErr := errors.New("cdn unreachable")
+	s.fetch = func(ctx context.Context, job downloadJob, file *os.File) error { return fetchErr }
+	if _, err := s.Download(context.Background(), "M1", chat); !errors.Is(err, fetchErr) {
+		t.Fatalf("Download = %v, want the fetch error", err)
+	}
+	if _, err := s.Download(context.Background(), "M2", chat); err == nil {
+		t.Fatal("downloaded a missing message")
+	}
+	if !errors.Is(failed["M1"], fetchErr) || failed["M2"] == nil {
+		t.Errorf("failures reported: %v", failed)
+	}
+
+	// Completion is reported once downloaded, before the slower upload
+	s.fetch = func(ctx context.Context, job downloadJob, file *os.File) error {
+		_, err := file.WriteString("jpeg")
+		return err
+	}
+	if _, err := s.Download(context.Background(), "M1", chat); err != nil {
+		t.Fatal(err)
+	}
+	if len(storage.atPut) != 2 || storage.atPut[0] != 4 || storage.atPut[1] != 4 {
+		t.Errorf("reported %v before storing, want 4 of 4", storage.atPut)
+	}
+	if len(reported) != 2 {
+		t.Errorf("reported %v, want completion once", reported)
+	}
+}
@@ -0,0 +1,113 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"orion-agent/internal/infra/config"
+)
+
+// OCR recognizes text in images.
+type OCR interface {
+	// Recognize returns the text in image, empty if there is none.
+	Recognize(ctx context.Context, image io.Reader) (string, error)
+}
+
+// TesseractOCR runs the tesseract command line tool.
+type TesseractOCR struct {
+	command  string
+	language string
+}
+
+// NewTesseractOCR creates a TesseractOCR from cfg.
+func NewTesseractOCR(cfg *config.OCRConfig) *TesseractOCR {
+	command := cfg.Command
+	if command == "" {
+		command = "tesseract"
+	}
+	return &TesseractOCR{command: command, language: cfg.Language}
+}
+
+// Recognize pipes image through tesseract.
+func (t *TesseractOCR) Recognize(ctx context.Context, image io.Reader) (string, error) {
+	args := []string{"stdin", "stdout"}
+	if t.language != "" {
+		args = append(args, "-l", t.language)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.command, args...)
+	cmd.Stdin = image
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// OCR settings.
+const (
+	ocrTimeout   = 30 * time.Second // Bounds a single OCR run
+	ocrQueueSize = 50
+)
+
+// ocrJob is a downloaded image waiting for OCR.
+type ocrJob struct {
+	download downloadJob
+	image    []byte
+}
+
+// recognizeAsync queues OCR of an image, whose text the OCR workers store
+// in the media cache. It's best effort: the image is skipped when the queue
+// is full, and failures are only logged.
+func (s *MediaService) recognizeAsync(job downloadJob, image []byte) {
+	select {
+	case s.ocrQueue <- ocrJob{download: job, image: image}:
+	default:
+		s.log.Debugf("Skipping OCR for %s: OCR queue full", job.MessageID)
+	}
+}
+
+// ocrWorker runs queued OCR jobs until Stop.
+func (s *MediaService) ocrWorker() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case job := <-s.ocrQueue:
+			s.recognize(job.download, job.image)
+		}
+	}
+}
+
+// recognize runs OCR on an image and stores the text.
+func (s *MediaService) recognize(job downloadJob, image []byte) {
+	ctx, cancel := context.WithTimeout(s.ctx, ocrTimeout)
+	defer cancel()
+
+	text, err := s.ocr.Recognize(ctx, bytes.NewReader(image))
+	if err != nil {
+		if s.ctx.Err() == nil {
+			s.log.Warnf("OCR failed for %s: %v", job.MessageID, err)
+		}
+		return
+	}
+	if text == "" {
+		return
+	}
+	if err := s.mediaCache.SetOCRText(job.MessageID, job.ChatJID, text); err != nil {
+		s.log.Warnf("Failed to save OCR text for %s: %v", job.MessageID, err)
+		return
+	}
+	s.log.Debugf("Recognized %d characters in %s", len(text), job.MessageID)
+}
@@ -0,0 +1,63 @@
+package media
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"orion-agent/internal/infra/config"
+)
+
+// blockingOCR blocks until its context is done.
+type blockingOCR struct {
+	started chan struct{}
+	err     chan error
+}
+
+func (o *blockingOCR) Recognize(ctx context.Context, _ io.Reader) (string, error) {
+	o.started <- struct{}{}
+	<-ctx.Done()
+	o.err <- ctx.Err()
+	return "", ctx.Err()
+}
+
+func TestStopCancelsOCR(t *testing.T) {
+	s := newTestMediaService(t, &config.MediaConfig{})
+	ocr := &blockingOCR{started: make(chan struct{}, 1), err: make(chan error, 1)}
+	s.SetOCR(ocr, 1)
+	s.Start()
+
+	s.recognizeAsync(downloadJob{MessageID: "IMG1"}, []byte("image"))
+	select {
+	case <-ocr.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OCR worker didn't pick up the job")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop waited for the OCR timeout")
+	}
+	if err := <-ocr.err; err != context.Canceled {
+		t.Errorf("OCR context ended with %v, want context.Canceled", err)
+	}
+}
+
+func TestOCRQueueBounded(t *testing.T) {
+	s := newTestMediaService(t, &config.MediaConfig{})
+	s.SetOCR(&blockingOCR{}, 2) // Not started, so nothing is taken off the queue
+
+	for range ocrQueueSize + 10 {
+		s.recognizeAsync(downloadJob{MessageID: "IMG"}, nil)
+	}
+	if len(s.ocrQueue) != ocrQueueSize {
+		t.Errorf("queue holds %d jobs, want %d", len(s.ocrQueue), ocrQueueSize)
+	}
+}
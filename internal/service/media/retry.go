@@ -0,0 +1,109 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waMmsRetry"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// mediaRetryTimeout is how long to wait for the sender's phone to re-upload
+// expired media.
+const mediaRetryTimeout = 30 * time.Second
+
+// isMediaExpired reports whether a download failed because the CDN copy
+// of the media is gone.
+func isMediaExpired(err error) bool {
+	return errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith403) ||
+		errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith404) ||
+		errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith410)
+}
+
+// requestMediaRetry asks the sender's phone to re-upload job's media and
+// waits for the new direct path. The wait is bounded by mediaRetryTimeout,
+// so a phone that never answers doesn't stall the worker.
+func (s *MediaService) requestMediaRetry(ctx context.Context, job downloadJob) (string, error) {
+	if len(job.MediaKey) == 0 {
+		return "", errors.New("no media key")
+	}
+
+	s.retryMu.Lock()
+	if _, pending := s.retryWaiters[job.MessageID]; pending {
+		s.retryMu.Unlock()
+		return "", errors.New("retry already pending")
+	}
+	ch := make(chan *events.MediaRetry, 1)
+	s.retryWaiters[job.MessageID] = ch
+	s.retryMu.Unlock()
+
+	defer func() {
+		s.retryMu.Lock()
+		delete(s.retryWaiters, job.MessageID)
+		s.retryMu.Unlock()
+	}()
+
+	info := &types.MessageInfo{
+		MessageSource: types.MessageSource{
+			Chat:     job.ChatJID,
+			Sender:   job.SenderJID,
+			IsFromMe: job.FromMe,
+			IsGroup:  job.ChatJID.Server == types.GroupServer,
+		},
+		ID: job.MessageID,
+	}
+	if err := s.client.SendMediaRetryReceipt(ctx, info, job.MediaKey); err != nil {
+		return "", fmt.Errorf("send retry receipt: %w", err)
+	}
+	s.log.Infof("Requested re-upload of expired media %s", job.MessageID)
+
+	timer := time.NewTimer(mediaRetryTimeout)
+	defer timer.Stop()
+
+	var evt *events.MediaRetry
+	select {
+	case evt = <-ch:
+	case <-timer.C:
+		return "", errors.New("timed out waiting for re-upload")
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-s.stopCh:
+		return "", errors.New("service stopped")
+	}
+
+	notif, err := whatsmeow.DecryptMediaRetryNotification(evt, job.MediaKey)
+	if err != nil {
+		return "", err
+	}
+	if notif.GetResult() != waMmsRetry.MediaRetryNotification_SUCCESS || notif.GetDirectPath() == "" {
+		return "", fmt.Errorf("re-upload failed: %s", notif.GetResult())
+	}
+
+	if s.messages != nil {
+		if err := s.messages.UpdateMediaPath(job.MessageID, job.ChatJID, notif.GetDirectPath()); err != nil {
+			s.log.Warnf("Failed to save new media path for %s: %v", job.MessageID, err)
+		}
+	}
+	return notif.GetDirectPath(), nil
+}
+
+// HandleMediaRetry delivers a media retry response to the download
+// waiting for it. Responses nobody waits for are ignored.
+func (s *MediaService) HandleMediaRetry(evt *events.MediaRetry) {
+	s.retryMu.Lock()
+	ch, ok := s.retryWaiters[evt.MessageID]
+	s.retryMu.Unlock()
+	if !ok {
+		s.log.Debugf("Ignoring media retry for %s, no download waiting", evt.MessageID)
+		return
+	}
+
+	select {
+	case ch <- evt:
+	default:
+	}
+}
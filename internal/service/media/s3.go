@@ -0,0 +1,164 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"orion-agent/internal/infra/config"
+)
+
+const s3URIScheme = "s3://"
+
+// S3Storage stores media in an S3 or S3-compatible bucket. Its URIs look
+// like s3://bucket/key.
+type S3Storage struct {
+	bucket string
+	prefix string
+	client *minio.Client
+}
+
+// NewS3Storage creates an S3Storage from cfg. Missing credentials are read
+// from the standard AWS environment variables.
+func NewS3Storage(cfg *config.S3Config) (*S3Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3 bucket not set")
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3.amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" || strings.Trim(u.Path, "/") != "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", endpoint)
+	}
+
+	accessKey, secretKey, sessionToken := cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken
+	if accessKey == "" {
+		accessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if secretKey == "" {
+		secretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if sessionToken == "" {
+		sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if accessKey == "" || secretKey == "" {
+		return nil, errors.New("s3 credentials not set")
+	}
+
+	lookup := minio.BucketLookupDNS
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(u.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(accessKey, secretKey, sessionToken),
+		Secure:       u.Scheme == "https",
+		Region:       region, // Known, so the bucket location isn't looked up
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %w", err)
+	}
+
+	return &S3Storage{
+		bucket: cfg.Bucket,
+		prefix: strings.Trim(cfg.Prefix, "/"),
+		client: client,
+	}, nil
+}
+
+// URI returns the s3:// URI of path.
+func (s *S3Storage) URI(p string) string {
+	return s3URIScheme + s.bucket + "/" + s.key(p)
+}
+
+// key returns the object key of path.
+func (s *S3Storage) key(p string) string {
+	return strings.TrimPrefix(path.Join(s.prefix, p), "/")
+}
+
+// Put uploads r to path. Files and other seekable readers are sent in one
+// request; anything else is uploaded in parts as it's read.
+func (s *S3Storage) Put(p string, r io.Reader) (string, error) {
+	size := int64(-1)
+	if body, ok := r.(io.Seeker); ok {
+		start, err := body.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return "", err
+		}
+		end, err := body.Seek(0, io.SeekEnd)
+		if err != nil {
+			return "", err
+		}
+		if _, err := body.Seek(start, io.SeekStart); err != nil {
+			return "", err
+		}
+		size = end - start
+	}
+
+	uri := s.URI(p)
+	if _, err := s.client.PutObject(context.Background(), s.bucket, s.key(p), r, size, minio.PutObjectOptions{}); err != nil {
+		return "", fmt.Errorf("s3 put %s: %w", uri, err)
+	}
+	return uri, nil
+}
+
+// Exists reports whether the object at uri exists.
+func (s *S3Storage) Exists(uri string) (bool, error) {
+	key, err := s.parseURI(uri)
+	if err != nil {
+		// A local path or another bucket's object isn't here
+		return false, nil
+	}
+	if _, err := s.client.StatObject(context.Background(), s.bucket, key, minio.StatObjectOptions{}); err != nil {
+		if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("s3 head %s: %w", uri, err)
+	}
+	return true, nil
+}
+
+// Delete removes the object at uri.
+func (s *S3Storage) Delete(uri string) error {
+	key, err := s.parseURI(uri)
+	if err != nil {
+		return err
+	}
+	if err := s.client.RemoveObject(context.Background(), s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("s3 delete %s: %w", uri, err)
+	}
+	return nil
+}
+
+// parseURI returns the object key of an s3:// URI in this bucket.
+func (s *S3Storage) parseURI(uri string) (string, error) {
+	rest, ok := strings.CutPrefix(uri, s3URIScheme)
+	bucket, key, _ := strings.Cut(rest, "/")
+	if !ok || key == "" {
+		return "", fmt.Errorf("not an s3 uri: %s", uri)
+	}
+	if bucket != s.bucket {
+		return "", fmt.Errorf("%s is not in bucket %s", uri, s.bucket)
+	}
+	return key, nil
+}
@@ -0,0 +1,103 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+)
+
+// stickerPackTag is the EXIF tag WhatsApp uses for sticker pack JSON.
+const stickerPackTag = 0x5741
+
+// stickerPackInfo is the sticker pack JSON embedded in WebP EXIF.
+type stickerPackInfo struct {
+	PackID    string `json:"sticker-pack-id"`
+	PackName  string `json:"sticker-pack-name"`
+	Publisher string `json:"sticker-pack-publisher"`
+}
+
+// parseStickerPack reads sticker pack metadata from a WebP file's EXIF chunk.
+// Returns nil if the file has none.
+func parseStickerPack(data []byte) *stickerPackInfo {
+	exif := findWebPChunk(data, "EXIF")
+	if exif == nil {
+		return nil
+	}
+	raw := findEXIFValue(exif, stickerPackTag)
+	if raw == nil {
+		return nil
+	}
+
+	var info stickerPackInfo
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return nil
+	}
+	if info.PackID == "" && info.PackName == "" && info.Publisher == "" {
+		return nil
+	}
+	return &info
+}
+
+// findWebPChunk returns the payload of the first RIFF chunk with the given FourCC.
+func findWebPChunk(data []byte, fourCC string) []byte {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil
+	}
+	for pos := 12; pos+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		start := pos + 8
+		if size < 0 || start+size > len(data) {
+			return nil
+		}
+		if string(data[pos:pos+4]) == fourCC {
+			return data[start : start+size]
+		}
+		pos = start + size + size%2 // Chunks are padded to even sizes
+	}
+	return nil
+}
+
+// findEXIFValue returns the raw value of a tag in the first IFD of a TIFF-format EXIF block.
+func findEXIFValue(exif []byte, tag uint16) []byte {
+	// Some encoders keep the JPEG-style "Exif\0\0" prefix
+	exif = bytes.TrimPrefix(exif, []byte("Exif\x00\x00"))
+	if len(exif) < 8 {
+		return nil
+	}
+
+	var order binary.ByteOrder
+	switch string(exif[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+
+	ifd := int(order.Uint32(exif[4:8]))
+	if ifd+2 > len(exif) {
+		return nil
+	}
+	count := int(order.Uint16(exif[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(exif) {
+			return nil
+		}
+		if order.Uint16(exif[entry:entry+2]) != tag {
+			continue
+		}
+		// Only byte-sized types (BYTE, ASCII, UNDEFINED) are expected here
+		length := int(order.Uint32(exif[entry+4 : entry+8]))
+		if length <= 4 {
+			return exif[entry+8 : entry+8+length]
+		}
+		offset := int(order.Uint32(exif[entry+8 : entry+12]))
+		if offset < 0 || length < 0 || offset+length > len(exif) {
+			return nil
+		}
+		return exif[offset : offset+length]
+	}
+	return nil
+}
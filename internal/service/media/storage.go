@@ -0,0 +1,104 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"orion-agent/internal/infra/config"
+)
+
+// Storage is where downloaded media is written. Objects are addressed by a
+// slash-separated path relative to the storage root, and recorded by the
+// URI Put returns (orion_media_cache.local_path).
+type Storage interface {
+	// Put writes r to path, replacing any existing object, and returns its URI.
+	Put(path string, r io.Reader) (uri string, err error)
+	// Exists reports whether the object at uri exists. A URI of another
+	// backend, e.g. recorded before the backend was changed, doesn't.
+	Exists(uri string) (bool, error)
+	// Delete removes the object at uri. A missing object isn't an error.
+	Delete(uri string) error
+	// URI returns the URI path is stored at, without checking it exists.
+	URI(path string) string
+}
+
+// NewStorage creates the storage backend selected in cfg. Local storage
+// keeps files under storePath.
+func NewStorage(cfg *config.MediaStorageConfig, storePath string) (Storage, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return NewLocalStorage(storePath), nil
+	case "s3":
+		return NewS3Storage(&cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown media storage backend %q", cfg.Backend)
+	}
+}
+
+// LocalStorage stores media on the local filesystem. Its URIs are plain
+// file paths, so cache entries from before storage backends stay valid.
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage creates a LocalStorage rooted at root.
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{root: root}
+}
+
+// URI returns the file path of path.
+func (l *LocalStorage) URI(path string) string {
+	return filepath.Join(l.root, filepath.FromSlash(path))
+}
+
+// Put writes r to a temporary file and renames it into place, so a partial
+// write never looks complete.
+func (l *LocalStorage) Put(path string, r io.Reader) (string, error) {
+	filePath := l.URI(path)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return "", fmt.Errorf("create directory: %w", err)
+	}
+
+	tmpPath := filePath + ".part"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return "", fmt.Errorf("create file: %w", err)
+	}
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, filePath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("write file: %w", err)
+	}
+	return filePath, nil
+}
+
+// Exists reports whether the file at uri exists.
+func (l *LocalStorage) Exists(uri string) (bool, error) {
+	if strings.HasPrefix(uri, s3URIScheme) {
+		return false, nil
+	}
+	_, err := os.Stat(uri)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Delete removes the file at uri.
+func (l *LocalStorage) Delete(uri string) error {
+	if err := os.Remove(uri); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
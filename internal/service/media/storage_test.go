@@ -0,0 +1,116 @@
+package media
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+
+	"orion-agent/internal/data/store"
+	"orion-agent/internal/infra/config"
+)
+
+// newTestS3Storage returns an S3Storage for bucket "media" on a local server
+// holding only media/a.jpg, and the number of requests the server got.
+func newTestS3Storage(t *testing.T) (*S3Storage, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/media/media/a.jpg" {
+			w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+
+	s, err := NewS3Storage(&config.S3Config{
+		Bucket: "media", Endpoint: srv.URL, PathStyle: true,
+		AccessKeyID: "key", SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, &requests
+}
+
+func TestS3StorageExists(t *testing.T) {
+	s, requests := newTestS3Storage(t)
+
+	for uri, want := range map[string]bool{
+		s.URI("media/a.jpg"): true,
+		s.URI("media/b.jpg"): false,
+	} {
+		if got, err := s.Exists(uri); err != nil || got != want {
+			t.Errorf("Exists(%s) = %v, %v, want %v", uri, got, err, want)
+		}
+	}
+
+	// URIs of other backends aren't looked up
+	requests.Store(0)
+	for _, uri := range []string{
+		filepath.Join(t.TempDir(), "media", "a.jpg"),
+		"s3://other/media/a.jpg",
+		"s3://media",
+	} {
+		if got, err := s.Exists(uri); err != nil || got {
+			t.Errorf("Exists(%s) = %v, %v, want false", uri, got, err)
+		}
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("%d requests for foreign URIs", n)
+	}
+}
+
+func TestLocalStorageExists(t *testing.T) {
+	l := NewLocalStorage(t.TempDir())
+	uri, err := l.Put("media/a.jpg", strings.NewReader("jpeg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for uri, want := range map[string]bool{
+		uri:                      true,
+		l.URI("media/b.jpg"):     false,
+		"s3://media/media/a.jpg": false,
+	} {
+		if got, err := l.Exists(uri); err != nil || got != want {
+			t.Errorf("Exists(%s) = %v, %v, want %v", uri, got, err, want)
+		}
+	}
+}
+
+func TestDownloadedToOtherBackendIsRedownloaded(t *testing.T) {
+	db, err := store.NewWithOptions(":memory:", store.Options{MaxOpenConns: 1, MaxIdleConns: 1}, waLog.Noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	cache := store.NewMediaCacheStore(db)
+
+	s3, _ := newTestS3Storage(t)
+	s := NewMediaService(nil, &config.MediaConfig{}, t.TempDir(), s3, cache, nil, waLog.Noop)
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+
+	// Downloaded to local storage before the switch to S3
+	now := time.Now()
+	if err := cache.Put(&store.MediaCache{MessageID: "M1", ChatJID: chat, MediaType: "image",
+		LocalPath: filepath.Join(t.TempDir(), "media", "a.jpg"), DownloadedAt: &now}); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.isAlreadyDownloaded("M1", chat) {
+		t.Error("media in a previous backend counted as downloaded")
+	}
+	if cached, _ := cache.Get("M1", chat); cached != nil {
+		t.Errorf("stale cache entry kept: %+v", cached)
+	}
+}
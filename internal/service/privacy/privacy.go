@@ -0,0 +1,105 @@
+// Package privacy provides changes to the account's privacy settings.
+package privacy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+
+	"orion-agent/internal/data/extract"
+	"orion-agent/internal/data/store"
+)
+
+// allowedValues are the values WhatsApp accepts for each setting.
+var allowedValues = map[types.PrivacySettingType][]types.PrivacySetting{
+	types.PrivacySettingTypeGroupAdd:     {types.PrivacySettingAll, types.PrivacySettingContacts, types.PrivacySettingContactBlacklist, types.PrivacySettingNone},
+	types.PrivacySettingTypeLastSeen:     {types.PrivacySettingAll, types.PrivacySettingContacts, types.PrivacySettingContactBlacklist, types.PrivacySettingNone},
+	types.PrivacySettingTypeStatus:       {types.PrivacySettingAll, types.PrivacySettingContacts, types.PrivacySettingContactBlacklist, types.PrivacySettingNone},
+	types.PrivacySettingTypeProfile:      {types.PrivacySettingAll, types.PrivacySettingContacts, types.PrivacySettingContactBlacklist, types.PrivacySettingNone},
+	types.PrivacySettingTypeReadReceipts: {types.PrivacySettingAll, types.PrivacySettingNone},
+	types.PrivacySettingTypeOnline:       {types.PrivacySettingAll, types.PrivacySettingMatchLastSeen},
+	types.PrivacySettingTypeCallAdd:      {types.PrivacySettingAll, types.PrivacySettingKnown},
+}
+
+// PrivacyService changes privacy settings, keeping the store in sync.
+type PrivacyService struct {
+	client  *whatsmeow.Client
+	privacy *store.PrivacyStore
+	log     waLog.Logger
+}
+
+// NewPrivacyService creates a new PrivacyService.
+func NewPrivacyService(client *whatsmeow.Client, privacy *store.PrivacyStore, log waLog.Logger) *PrivacyService {
+	return &PrivacyService{
+		client:  client,
+		privacy: privacy,
+		log:     log.Sub("PrivacyService"),
+	}
+}
+
+// SetLastSeen sets who can see when the account was last online.
+func (s *PrivacyService) SetLastSeen(ctx context.Context, value types.PrivacySetting) error {
+	return s.Set(ctx, types.PrivacySettingTypeLastSeen, value)
+}
+
+// SetProfilePhoto sets who can see the profile photo.
+func (s *PrivacyService) SetProfilePhoto(ctx context.Context, value types.PrivacySetting) error {
+	return s.Set(ctx, types.PrivacySettingTypeProfile, value)
+}
+
+// SetStatus sets who can see the about text.
+func (s *PrivacyService) SetStatus(ctx context.Context, value types.PrivacySetting) error {
+	return s.Set(ctx, types.PrivacySettingTypeStatus, value)
+}
+
+// SetReadReceipts turns read receipts on (all) or off (none).
+func (s *PrivacyService) SetReadReceipts(ctx context.Context, value types.PrivacySetting) error {
+	return s.Set(ctx, types.PrivacySettingTypeReadReceipts, value)
+}
+
+// SetGroupAdd sets who can add the account to groups.
+func (s *PrivacyService) SetGroupAdd(ctx context.Context, value types.PrivacySetting) error {
+	return s.Set(ctx, types.PrivacySettingTypeGroupAdd, value)
+}
+
+// SetCallAdd sets who can call the account (all, or known contacts only).
+func (s *PrivacyService) SetCallAdd(ctx context.Context, value types.PrivacySetting) error {
+	return s.Set(ctx, types.PrivacySettingTypeCallAdd, value)
+}
+
+// SetOnline sets who can see when the account is online.
+func (s *PrivacyService) SetOnline(ctx context.Context, value types.PrivacySetting) error {
+	return s.Set(ctx, types.PrivacySettingTypeOnline, value)
+}
+
+// Set changes a privacy setting and saves the resulting settings.
+func (s *PrivacyService) Set(ctx context.Context, name types.PrivacySettingType, value types.PrivacySetting) error {
+	if s.client == nil {
+		return fmt.Errorf("client not initialized")
+	}
+
+	allowed, ok := allowedValues[name]
+	if !ok {
+		return fmt.Errorf("unknown privacy setting %q", name)
+	}
+	if !slices.Contains(allowed, value) {
+		return fmt.Errorf("invalid value %q for privacy setting %q: must be one of %v", value, name, allowed)
+	}
+
+	settings, err := s.client.SetPrivacySetting(ctx, name, value)
+	if err != nil {
+		return fmt.Errorf("failed to set privacy setting: %w", err)
+	}
+
+	if s.privacy != nil {
+		if err := s.privacy.Put(extract.PrivacySettingsFromInfo(settings)); err != nil {
+			s.log.Warnf("Failed to save privacy settings: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package send
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// Album size limits enforced by WhatsApp.
+const (
+	MinAlbumItems = 2
+	MaxAlbumItems = 30
+)
+
+// SendAlbum sends images and videos grouped as a single album.
+// An AlbumMessage is sent first and every item is linked to it; the first
+// item's caption is shown as the album caption. All items are uploaded
+// before anything is sent, and uploaded items are not uploaded again, so a
+// failed album can be retried with the same contents. With an idempotency
+// key, the album and each of its items are deduplicated separately, so a
+// retry only sends the items still missing.
+// Returns the results of the sent items and the album's parent message ID.
+func (s *SendService) SendAlbum(ctx context.Context, chat types.JID, items []Content, opts ...SendOption) ([]*SendResult, types.MessageID, error) {
+	if s.client == nil {
+		return nil, "", fmt.Errorf("client not initialized")
+	}
+	if len(items) < MinAlbumItems || len(items) > MaxAlbumItems {
+		return nil, "", fmt.Errorf("album must have %d to %d items, got %d", MinAlbumItems, MaxAlbumItems, len(items))
+	}
+
+	var images, videos uint32
+	for i, item := range items {
+		switch item.(type) {
+		case *ImageContent:
+			images++
+		case *VideoContent:
+			videos++
+		default:
+			return nil, "", fmt.Errorf("album item %d is %s, only images and videos are allowed", i+1, item.MessageType())
+		}
+	}
+
+	cfg := applyOptions(opts)
+	if !cfg.NoAnnounceCheck && !s.canSendToGroup(ctx, chat) {
+		return nil, "", ErrCannotSendToAnnounceGroup
+	}
+
+	for i, item := range items {
+		if uploader, ok := item.(MediaUploader); ok && !uploader.IsUploaded() {
+			if err := uploader.Upload(ctx, s.client); err != nil {
+				return nil, "", fmt.Errorf("failed to upload album item %d: %w", i+1, err)
+			}
+		}
+	}
+
+	albumMsg := &waE2E.Message{
+		AlbumMessage: &waE2E.AlbumMessage{
+			ExpectedImageCount: proto.Uint32(images),
+			ExpectedVideoCount: proto.Uint32(videos),
+		},
+	}
+	if !cfg.NoAutoEphemeral {
+		s.applyChatEphemeral(ctx, chat, albumMsg)
+	}
+	album, err := s.SendRaw(ctx, chat, albumMsg, opts...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to send album: %w", err)
+	}
+
+	parent := &waCommon.MessageKey{
+		RemoteJID: proto.String(chat.String()),
+		FromMe:    proto.Bool(true),
+		ID:        proto.String(string(album.MessageID)),
+	}
+
+	results := make([]*SendResult, 0, len(items))
+	for i, item := range items {
+		itemOpts := append(opts[:len(opts):len(opts)],
+			WithID(""), // The album used any caller-provided ID
+			WithoutAnnounceCheck(),
+			withMessageAssociation(&waE2E.MessageAssociation{
+				AssociationType:  waE2E.MessageAssociation_MEDIA_ALBUM.Enum(),
+				ParentMessageKey: parent,
+			}),
+		)
+		// Only the album caption carries the footer
+		if i > 0 {
+			itemOpts = append(itemOpts, WithoutFooter())
+		}
+		// Each item needs its own key, or all but the first would be
+		// skipped as repeats of it
+		if cfg.IdempotencyKey != "" {
+			itemOpts = append(itemOpts, WithIdempotencyKey(cfg.IdempotencyKey+":"+strconv.Itoa(i+1)))
+		}
+
+		result, err := s.Send(ctx, chat, item, itemOpts...)
+		if err != nil {
+			return results, album.MessageID, fmt.Errorf("failed to send album item %d: %w", i+1, err)
+		}
+		results = append(results, result)
+	}
+
+	return results, album.MessageID, nil
+}
+
+// setMessageAssociation links the message to a parent message.
+func setMessageAssociation(msg *waE2E.Message, assoc *waE2E.MessageAssociation) {
+	if msg.MessageContextInfo == nil {
+		msg.MessageContextInfo = &waE2E.MessageContextInfo{}
+	}
+	msg.MessageContextInfo.MessageAssociation = assoc
+}
@@ -0,0 +1,53 @@
+package send
+
+import (
+	"context"
+	"fmt"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// BlockContact blocks a contact.
+// Only users can be blocked; groups, channels and the account itself can't.
+func (s *SendService) BlockContact(ctx context.Context, jid types.JID) error {
+	return s.updateBlocklist(ctx, jid, events.BlocklistChangeActionBlock)
+}
+
+// UnblockContact unblocks a contact.
+func (s *SendService) UnblockContact(ctx context.Context, jid types.JID) error {
+	return s.updateBlocklist(ctx, jid, events.BlocklistChangeActionUnblock)
+}
+
+func (s *SendService) updateBlocklist(ctx context.Context, jid types.JID, action events.BlocklistChangeAction) error {
+	if s.client == nil {
+		return fmt.Errorf("client not initialized")
+	}
+	if jid.Server != types.DefaultUserServer && jid.Server != types.HiddenUserServer {
+		return fmt.Errorf("can't %s %s: only users can be blocked", action, jid)
+	}
+
+	jid = jid.ToNonAD()
+	normalized := s.utils.NormalizeJID(ctx, jid)
+	if own := s.utils.OwnJID(); !own.IsEmpty() && normalized == s.utils.NormalizeJID(ctx, own) {
+		return fmt.Errorf("can't %s yourself", action)
+	}
+
+	if _, err := s.client.UpdateBlocklist(ctx, jid, action); err != nil {
+		return fmt.Errorf("failed to %s contact: %w", action, err)
+	}
+
+	if s.blocklist != nil {
+		var err error
+		if action == events.BlocklistChangeActionBlock {
+			err = s.blocklist.Block(normalized)
+		} else {
+			err = s.blocklist.Unblock(normalized)
+		}
+		if err != nil {
+			s.log.Warnf("Failed to save blocklist change for %s: %v", normalized, err)
+		}
+	}
+
+	return nil
+}
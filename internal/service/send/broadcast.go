@@ -0,0 +1,91 @@
+package send
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// ErrEmptyBroadcastList is returned by SendBroadcast when the list has no
+// known recipients.
+var ErrEmptyBroadcastList = errors.New("broadcast list has no recipients")
+
+// BroadcastResult is the outcome of a broadcast for one recipient.
+type BroadcastResult struct {
+	Recipient types.JID
+	Result    *SendResult // nil if the send failed
+	Err       error
+}
+
+// SendBroadcast sends content to every recipient of a broadcast list.
+// WhatsApp doesn't fan broadcast lists out server-side, so each recipient
+// gets their own copy, all with the same message ID as a real broadcast
+// does. The message is saved once, in the broadcast list's chat, and the
+// event service files each recipient's receipts under that chat.
+// It returns a BroadcastResult per recipient, in the list's order; the
+// error is only set if nothing could be sent.
+func (s *SendService) SendBroadcast(ctx context.Context, broadcastJID types.JID, content Content, opts ...SendOption) ([]BroadcastResult, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("client not initialized")
+	}
+	if broadcastJID.Server != types.BroadcastServer || broadcastJID == types.StatusBroadcastJID {
+		return nil, fmt.Errorf("%s is not a broadcast list", broadcastJID)
+	}
+	if s.broadcasts == nil {
+		return nil, fmt.Errorf("broadcast store not initialized")
+	}
+
+	recipients, err := s.broadcasts.GetRecipients(broadcastJID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broadcast recipients: %w", err)
+	}
+	if len(recipients) == 0 {
+		return nil, ErrEmptyBroadcastList
+	}
+
+	if err := s.prepareShared(ctx, content); err != nil {
+		return nil, err
+	}
+	msg, err := content.ToMessage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build message: %w", err)
+	}
+	cfg := applyOptions(opts)
+	if !cfg.NoFooter {
+		s.applyFooter(msg)
+	}
+
+	id := cfg.ID
+	if id == "" {
+		id = s.client.GenerateMessageID()
+	}
+	opts = append(opts[:len(opts):len(opts)], WithID(id), WithoutSave())
+
+	results := make([]*SendResult, len(recipients))
+	errs := make([]error, len(recipients))
+	s.sendEach(ctx, recipients, content, results, errs, opts)
+
+	out := make([]BroadcastResult, len(recipients))
+	var first *SendResult
+	for i, recipient := range recipients {
+		out[i] = BroadcastResult{Recipient: recipient, Result: results[i], Err: errs[i]}
+		if first == nil && results[i] != nil {
+			first = results[i]
+		}
+	}
+	if first == nil {
+		return out, fmt.Errorf("failed to send to any of %d recipients: %w", len(recipients), errs[0])
+	}
+
+	if !cfg.NoSave {
+		s.saveSentMessage(ctx, &SendResult{
+			MessageID: id,
+			Timestamp: first.Timestamp,
+			Recipient: broadcastJID,
+			Sender:    first.Sender,
+		}, content, msg)
+	}
+	return out, nil
+}
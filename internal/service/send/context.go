@@ -1,6 +1,8 @@
 package send
 
 import (
+	"slices"
+
 	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types"
 	"google.golang.org/protobuf/proto"
@@ -30,6 +32,17 @@ func NewContext() *ContextInfo {
 	return &ContextInfo{}
 }
 
+// Clone returns a copy of the context to change without affecting c.
+// A nil c gives an empty context.
+func (c *ContextInfo) Clone() *ContextInfo {
+	if c == nil {
+		return NewContext()
+	}
+	clone := *c
+	clone.MentionedJIDs = slices.Clone(c.MentionedJIDs)
+	return &clone
+}
+
 // ReplyTo sets up the context as a reply to a message.
 func (c *ContextInfo) ReplyTo(msgID types.MessageID, sender types.JID, quotedMsg *waE2E.Message) *ContextInfo {
 	c.QuotedMessageID = msgID
@@ -140,3 +153,85 @@ func ForwardContext(score uint32) *ContextInfo {
 func MentionContext(jids ...types.JID) *ContextInfo {
 	return NewContext().WithMentions(jids...)
 }
+
+// mergeRawContextInfo merges a caller-provided ContextInfo into the message.
+// Fields set on raw take precedence over those built from the content;
+// repeated fields (e.g. MentionedJID) are appended.
+func mergeRawContextInfo(msg *waE2E.Message, raw *waE2E.ContextInfo) {
+	if msg == nil || raw == nil {
+		return
+	}
+	slot := contextInfoSlot(msg)
+	if slot == nil {
+		return
+	}
+	if *slot == nil {
+		*slot = proto.Clone(raw).(*waE2E.ContextInfo)
+		return
+	}
+	proto.Merge(*slot, raw)
+}
+
+// messageContextInfo returns the ContextInfo of the message's content, if any.
+func messageContextInfo(msg *waE2E.Message) *waE2E.ContextInfo {
+	if msg == nil || msg.Conversation != nil {
+		return nil
+	}
+	if slot := contextInfoSlot(msg); slot != nil {
+		return *slot
+	}
+	return nil
+}
+
+// contextInfoSlot returns a pointer to the ContextInfo field of the message's
+// content, converting a plain conversation to extended text so it can hold one.
+// Returns nil for message types without a ContextInfo.
+func contextInfoSlot(msg *waE2E.Message) **waE2E.ContextInfo {
+	switch {
+	case msg.Conversation != nil:
+		msg.ExtendedTextMessage = &waE2E.ExtendedTextMessage{Text: msg.Conversation}
+		msg.Conversation = nil
+		return &msg.ExtendedTextMessage.ContextInfo
+	case msg.ExtendedTextMessage != nil:
+		return &msg.ExtendedTextMessage.ContextInfo
+	case msg.ImageMessage != nil:
+		return &msg.ImageMessage.ContextInfo
+	case msg.VideoMessage != nil:
+		return &msg.VideoMessage.ContextInfo
+	case msg.PtvMessage != nil:
+		return &msg.PtvMessage.ContextInfo
+	case msg.AudioMessage != nil:
+		return &msg.AudioMessage.ContextInfo
+	case msg.DocumentMessage != nil:
+		return &msg.DocumentMessage.ContextInfo
+	case msg.StickerMessage != nil:
+		return &msg.StickerMessage.ContextInfo
+	case msg.LocationMessage != nil:
+		return &msg.LocationMessage.ContextInfo
+	case msg.LiveLocationMessage != nil:
+		return &msg.LiveLocationMessage.ContextInfo
+	case msg.ContactMessage != nil:
+		return &msg.ContactMessage.ContextInfo
+	case msg.ContactsArrayMessage != nil:
+		return &msg.ContactsArrayMessage.ContextInfo
+	case msg.PollCreationMessage != nil:
+		return &msg.PollCreationMessage.ContextInfo
+	case msg.PollCreationMessageV2 != nil:
+		return &msg.PollCreationMessageV2.ContextInfo
+	case msg.PollCreationMessageV3 != nil:
+		return &msg.PollCreationMessageV3.ContextInfo
+	case msg.EventMessage != nil:
+		return &msg.EventMessage.ContextInfo
+	case msg.GroupInviteMessage != nil:
+		return &msg.GroupInviteMessage.ContextInfo
+	case msg.InteractiveMessage != nil:
+		return &msg.InteractiveMessage.ContextInfo
+	case msg.ButtonsMessage != nil:
+		return &msg.ButtonsMessage.ContextInfo
+	case msg.ListMessage != nil:
+		return &msg.ListMessage.ContextInfo
+	case msg.AlbumMessage != nil:
+		return &msg.AlbumMessage.ContextInfo
+	}
+	return nil
+}
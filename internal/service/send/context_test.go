@@ -0,0 +1,69 @@
+package send
+
+import (
+	"context"
+	"testing"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRawContextInfoMerged(t *testing.T) {
+	s := newTestSendService(t, newTestStore(t))
+	ctx := context.Background()
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+	alice := types.NewJID("900000000000003", types.HiddenUserServer)
+	bob := types.NewJID("900000000000004", types.HiddenUserServer)
+
+	raw := &waE2E.ContextInfo{
+		MentionedJID: []string{bob.String()},
+		Expiration:   proto.Uint32(604800),
+		DisappearingMode: &waE2E.DisappearingMode{
+			Initiator: waE2E.DisappearingMode_INITIATED_BY_ME.Enum(),
+		},
+	}
+	content := Text("hi").WithContext(MentionContext(alice).WithExpiration(86400))
+	msg, err := s.buildMessage(ctx, chat, content, applyOptions([]SendOption{WithRawContextInfo(raw)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := msg.GetExtendedTextMessage().GetContextInfo()
+	if got := info.GetMentionedJID(); len(got) != 2 || got[0] != alice.String() || got[1] != bob.String() {
+		t.Errorf("mentions = %v, want the built mention then the raw one", got)
+	}
+	if info.GetExpiration() != 604800 {
+		t.Errorf("expiration = %d, want the raw 604800", info.GetExpiration())
+	}
+	if info.GetDisappearingMode().GetInitiator() != waE2E.DisappearingMode_INITIATED_BY_ME {
+		t.Errorf("disappearing mode not merged: %v", info.GetDisappearingMode())
+	}
+	if len(raw.MentionedJID) != 1 {
+		t.Errorf("caller's raw context changed: %v", raw)
+	}
+}
+
+func TestRawContextInfoOnPlainText(t *testing.T) {
+	raw := &waE2E.ContextInfo{ActionLink: &waE2E.ActionLink{URL: proto.String("https://example.com")}}
+	msg := &waE2E.Message{Conversation: proto.String("hi")}
+	mergeRawContextInfo(msg, raw)
+
+	if msg.Conversation != nil || msg.GetExtendedTextMessage().GetText() != "hi" {
+		t.Fatalf("plain text not converted to extended text: %v", msg)
+	}
+	info := msg.GetExtendedTextMessage().GetContextInfo()
+	if info.GetActionLink().GetURL() != "https://example.com" {
+		t.Errorf("context = %v, want the raw action link", info)
+	}
+	if info == raw {
+		t.Error("message shares the caller's raw context")
+	}
+
+	// Types without a context are left alone
+	reaction := &waE2E.Message{ReactionMessage: &waE2E.ReactionMessage{Text: proto.String("👍")}}
+	mergeRawContextInfo(reaction, raw)
+	if !proto.Equal(reaction, &waE2E.Message{ReactionMessage: &waE2E.ReactionMessage{Text: proto.String("👍")}}) {
+		t.Errorf("reaction changed: %v", reaction)
+	}
+}
@@ -0,0 +1,155 @@
+package send
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	"orion-agent/internal/data/store"
+)
+
+// MaxFailedSendAttempts caps how many times a failed send is attempted.
+const MaxFailedSendAttempts = 5
+
+// retryBatchSize is how many failed sends are retried per RetryFailedSends call.
+const retryBatchSize = 100
+
+// recordFailedSend persists a message that failed to send so it can be retried.
+func (s *SendService) recordFailedSend(to types.JID, msgID types.MessageID, msg *waE2E.Message, messageType string, sendErr error) {
+	if s.failedSends == nil {
+		return
+	}
+	// The caller gave up on the send; resending it later isn't wanted
+	if errors.Is(sendErr, context.Canceled) || errors.Is(sendErr, context.DeadlineExceeded) {
+		return
+	}
+
+	// Load the count before adding to it, so the new row isn't counted twice
+	s.pendingOnce.Do(s.loadPendingRetries)
+
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		s.log.Warnf("Failed to serialize failed send %s: %v", msgID, err)
+		return
+	}
+
+	failed := &store.FailedSend{
+		MessageID:   string(msgID),
+		ChatJID:     to,
+		MessageType: messageType,
+		Payload:     payload,
+		Error:       sendErr.Error(),
+		IsPermanent: isPermanentSendError(sendErr),
+	}
+	if err := s.failedSends.Put(failed); err != nil {
+		s.log.Warnf("Failed to save failed send %s: %v", msgID, err)
+		return
+	}
+	if !failed.IsPermanent {
+		s.pendingRetries.Add(1)
+	}
+}
+
+// RetryFailedSends resends persisted transient failures.
+// Returns the number of messages sent successfully. Only one retry runs at
+// a time; a call made while one is running returns 0 right away.
+func (s *SendService) RetryFailedSends(ctx context.Context) (int, error) {
+	if s.client == nil {
+		return 0, fmt.Errorf("client not initialized")
+	}
+	if s.failedSends == nil {
+		return 0, nil
+	}
+	if !s.retrying.CompareAndSwap(false, true) {
+		s.log.Debugf("Failed sends are already being retried")
+		return 0, nil
+	}
+	defer s.retrying.Store(false)
+
+	pending, err := s.failedSends.GetRetryable(MaxFailedSendAttempts, retryBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get failed sends: %w", err)
+	}
+
+	sent := 0
+	for _, f := range pending {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+
+		var msg waE2E.Message
+		if err := proto.Unmarshal(f.Payload, &msg); err != nil {
+			s.log.Warnf("Failed to decode failed send %d: %v", f.ID, err)
+			s.recordRetryAttempt(f, err, true)
+			continue
+		}
+
+		resp, err := s.sendToClient(ctx, f.ChatJID, &msg, whatsmeow.SendRequestExtra{ID: types.MessageID(f.MessageID)})
+		if err != nil {
+			s.recordRetryAttempt(f, err, isPermanentSendError(err))
+			continue
+		}
+
+		sent++
+		if err := s.failedSends.Delete(f.ID); err != nil {
+			s.log.Warnf("Failed to delete failed send %d: %v", f.ID, err)
+		} else {
+			s.pendingRetries.Add(-1)
+		}
+		s.saveRawMessage(&SendResult{
+			MessageID: resp.ID,
+			ServerID:  resp.ServerID,
+			Timestamp: resp.Timestamp,
+			Recipient: f.ChatJID,
+			Sender:    resp.Sender,
+			DebugInfo: resp.DebugTimings,
+		}, &msg)
+	}
+
+	if len(pending) > 0 {
+		s.log.Infof("Retried %d failed sends, %d succeeded", len(pending), sent)
+	}
+	return sent, nil
+}
+
+// recordRetryAttempt records a failed retry of f, dropping it from the
+// pending count once it won't be retried again.
+func (s *SendService) recordRetryAttempt(f *store.FailedSend, sendErr error, permanent bool) {
+	if err := s.failedSends.RecordAttempt(f.ID, sendErr.Error(), permanent); err != nil {
+		s.log.Warnf("Failed to update failed send %d: %v", f.ID, err)
+		return
+	}
+	if permanent || f.Attempts+1 >= MaxFailedSendAttempts {
+		s.pendingRetries.Add(-1)
+	}
+}
+
+// isTransientSendError reports whether the send failed because of the
+// connection (disconnected, timed out) and may succeed if retried.
+func isTransientSendError(err error) bool {
+	var disconnected *whatsmeow.DisconnectedError
+	return errors.Is(err, whatsmeow.ErrNotConnected) ||
+		errors.Is(err, whatsmeow.ErrIQTimedOut) ||
+		errors.Is(err, whatsmeow.ErrMessageTimedOut) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.As(err, &disconnected)
+}
+
+// isPermanentSendError reports whether retrying the send cannot succeed
+// (invalid recipient, not a group member, etc.). Errors the server returns
+// carry their code only in the message text, so they count as transient
+// and are bounded by MaxFailedSendAttempts instead.
+func isPermanentSendError(err error) bool {
+	return errors.Is(err, whatsmeow.ErrBroadcastListUnsupported) ||
+		errors.Is(err, whatsmeow.ErrUnknownServer) ||
+		errors.Is(err, whatsmeow.ErrRecipientADJID) ||
+		errors.Is(err, whatsmeow.ErrInvalidInlineBotID) ||
+		errors.Is(err, whatsmeow.ErrNotInGroup) ||
+		errors.Is(err, whatsmeow.ErrGroupNotFound) ||
+		errors.Is(err, whatsmeow.ErrNotLoggedIn)
+}
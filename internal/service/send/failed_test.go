@@ -0,0 +1,68 @@
+package send
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	"orion-agent/internal/data/store"
+)
+
+func TestRecordFailedSendSkipsCallerCancellation(t *testing.T) {
+	db := newTestStore(t)
+	s := newTestSendService(t, db)
+	chat := types.NewJID("123", types.DefaultUserServer)
+	msg := &waE2E.Message{Conversation: proto.String("hi")}
+
+	s.recordFailedSend(chat, "A", msg, "text", fmt.Errorf("failed to send message: %w", context.Canceled))
+	s.recordFailedSend(chat, "B", msg, "text", context.DeadlineExceeded)
+	s.recordFailedSend(chat, "C", msg, "text", whatsmeow.ErrMessageTimedOut)
+	s.recordFailedSend(chat, "D", msg, "text", whatsmeow.ErrNotInGroup)
+
+	all, err := store.NewFailedSendStore(db).GetAll(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]bool)
+	for _, f := range all {
+		got[f.MessageID] = f.IsPermanent
+	}
+	if len(got) != 2 {
+		t.Fatalf("recorded %v, want only C and D", got)
+	}
+	if permanent, ok := got["C"]; !ok || permanent {
+		t.Errorf("send timeout should be recorded as transient")
+	}
+	if permanent, ok := got["D"]; !ok || !permanent {
+		t.Errorf("not-in-group should be recorded as permanent")
+	}
+	if n := s.QueueStats().PendingRetries; n != 1 {
+		t.Errorf("pending retries = %d, want 1", n)
+	}
+}
+
+func TestIsPermanentSendError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{whatsmeow.ErrNotInGroup, true},
+		{fmt.Errorf("wrapped: %w", whatsmeow.ErrGroupNotFound), true},
+		{whatsmeow.ErrUnknownServer, true},
+		{whatsmeow.ErrRecipientADJID, true},
+		{whatsmeow.ErrNotConnected, false},
+		{whatsmeow.ErrMessageTimedOut, false},
+		{fmt.Errorf("%w %d", whatsmeow.ErrServerReturnedError, 403), false},
+		{fmt.Errorf("something returned error 404"), false},
+	}
+	for _, tt := range tests {
+		if got := isPermanentSendError(tt.err); got != tt.want {
+			t.Errorf("isPermanentSendError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
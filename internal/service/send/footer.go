@@ -0,0 +1,102 @@
+package send
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+)
+
+// Length limits enforced by WhatsApp clients, in characters.
+const (
+	maxTextLength    = 65536
+	maxCaptionLength = 1024
+)
+
+// footerSeparator keeps the footer on its own paragraph so it never joins
+// formatting (bold, italics, code) at the end of the body.
+const footerSeparator = "\n\n"
+
+// SetFooter sets a footer appended to outgoing text and captions.
+// types lists the message types that get it (text, image, video, document);
+// empty means all of them. An empty footer disables it.
+func (s *SendService) SetFooter(footer string, types []string) {
+	s.footer = strings.TrimSpace(footer)
+	s.footerTypes = make(map[string]bool, len(types))
+	for _, t := range types {
+		s.footerTypes[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+}
+
+// applyFooter appends the configured footer to the message body.
+// If body and footer exceed the length limit, the body is truncated.
+func (s *SendService) applyFooter(msg *waE2E.Message) {
+	if s.footer == "" {
+		return
+	}
+
+	var body **string
+	var msgType string
+	limit := maxCaptionLength
+	switch {
+	case msg.Conversation != nil:
+		body, msgType, limit = &msg.Conversation, "text", maxTextLength
+	case msg.ExtendedTextMessage != nil:
+		body, msgType, limit = &msg.ExtendedTextMessage.Text, "text", maxTextLength
+	case msg.ImageMessage != nil:
+		body, msgType = &msg.ImageMessage.Caption, "image"
+	case msg.VideoMessage != nil:
+		body, msgType = &msg.VideoMessage.Caption, "video"
+	case msg.DocumentMessage != nil:
+		body, msgType = &msg.DocumentMessage.Caption, "document"
+	default:
+		return
+	}
+	if len(s.footerTypes) > 0 && !s.footerTypes[msgType] {
+		return
+	}
+
+	// Media without a caption stays without one
+	text := *body
+	if text == nil || strings.TrimSpace(*text) == "" || strings.HasSuffix(*text, s.footer) {
+		return
+	}
+
+	footed, ok := withFooter(*text, s.footer, limit)
+	if !ok {
+		s.log.Warnf("Footer exceeds the %d character limit, not applied", limit)
+		return
+	}
+	*body = proto.String(footed)
+}
+
+// withFooter appends footer to text, truncating text so the result fits in limit characters.
+// Returns false if the footer alone doesn't fit.
+func withFooter(text, footer string, limit int) (string, bool) {
+	suffix := footerSeparator + footer
+	room := limit - utf8.RuneCountInString(suffix)
+	if room <= 0 {
+		return "", false
+	}
+
+	text = strings.TrimRight(text, " \t\n")
+	if utf8.RuneCountInString(text) > room {
+		runes := []rune(text)
+		text = strings.TrimRight(string(runes[:room-1]), " \t\n") + "…"
+	}
+	return text + suffix, true
+}
+
+// sentCaption returns the caption of a sent media message.
+func sentCaption(msg *waE2E.Message) string {
+	switch {
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetCaption()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetCaption()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetCaption()
+	}
+	return ""
+}
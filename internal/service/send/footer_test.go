@@ -0,0 +1,83 @@
+package send
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestFooterApplied(t *testing.T) {
+	s := newTestSendService(t, newTestStore(t))
+	s.SetFooter(" — sent by Orion ", []string{"text", "Image"})
+	ctx := context.Background()
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+
+	build := func(content Content, opts ...SendOption) *waE2E.Message {
+		t.Helper()
+		msg, err := s.buildMessage(ctx, chat, content, applyOptions(opts))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+	image := func(caption string) *ImageContent {
+		c := Image([]byte("img"), "image/jpeg")
+		c.Caption = caption
+		c.uploaded = &whatsmeow.UploadResponse{URL: "https://mmg.whatsapp.net/img"}
+		return c
+	}
+
+	if got := build(Text("hello *world*")).GetConversation(); got != "hello *world*\n\n— sent by Orion" {
+		t.Errorf("text = %q, want the footer on its own paragraph", got)
+	}
+	if got := build(Text("hello"), WithoutFooter()).GetConversation(); got != "hello" {
+		t.Errorf("text without footer = %q", got)
+	}
+	if got := build(Text("hi\n\n— sent by Orion")).GetConversation(); got != "hi\n\n— sent by Orion" {
+		t.Errorf("footer added twice: %q", got)
+	}
+
+	if got := build(image("look")).GetImageMessage().GetCaption(); got != "look\n\n— sent by Orion" {
+		t.Errorf("image caption = %q", got)
+	}
+	if caption := build(image("")).GetImageMessage().Caption; caption != nil {
+		t.Errorf("caption %q added to uncaptioned image", *caption)
+	}
+
+	// Documents aren't in the configured types
+	doc := Document([]byte("pdf"), "application/pdf", "a.pdf")
+	doc.Caption = "report"
+	doc.uploaded = &whatsmeow.UploadResponse{URL: "https://mmg.whatsapp.net/doc"}
+	if got := build(doc).GetDocumentMessage().GetCaption(); got != "report" {
+		t.Errorf("document caption = %q, want no footer", got)
+	}
+}
+
+func TestFooterTruncatesBody(t *testing.T) {
+	s := newTestSendService(t, newTestStore(t))
+	s.SetFooter("footer", nil)
+
+	msg := &waE2E.Message{ImageMessage: &waE2E.ImageMessage{Caption: proto.String(strings.Repeat("é", maxCaptionLength))}}
+	s.applyFooter(msg)
+	caption := msg.GetImageMessage().GetCaption()
+	if n := utf8.RuneCountInString(caption); n != maxCaptionLength {
+		t.Errorf("caption is %d characters, want %d", n, maxCaptionLength)
+	}
+	if !strings.HasSuffix(caption, "é…\n\nfooter") {
+		t.Errorf("caption = %q, want the truncated body then the footer", caption)
+	}
+
+	// A footer that can't fit isn't applied
+	s.SetFooter(strings.Repeat("x", maxCaptionLength), nil)
+	msg = &waE2E.Message{ImageMessage: &waE2E.ImageMessage{Caption: proto.String("look")}}
+	s.applyFooter(msg)
+	if got := msg.GetImageMessage().GetCaption(); got != "look" {
+		t.Errorf("caption = %q, want it unchanged", got)
+	}
+}
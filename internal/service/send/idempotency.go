@@ -0,0 +1,74 @@
+package send
+
+import (
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"orion-agent/internal/data/store"
+)
+
+// defaultIdempotencyWindow is how long idempotency keys are remembered by default.
+const defaultIdempotencyWindow = 24 * time.Hour
+
+// SetIdempotencyWindow sets how long idempotency keys are remembered.
+// 0 keeps the default of 24 hours.
+func (s *SendService) SetIdempotencyWindow(window time.Duration) {
+	if window <= 0 {
+		window = defaultIdempotencyWindow
+	}
+	s.idempotencyWindow = window
+}
+
+// reserveIdempotencyKey claims cfg.IdempotencyKey for this send, pinning the
+// message ID in cfg. If the key was already used, the earlier result is
+// returned instead. A send still in progress under the key returns a result
+// with only the message ID and recipient set.
+func (s *SendService) reserveIdempotencyKey(to types.JID, cfg *sendConfig) (*SendResult, error) {
+	if s.idempotency == nil {
+		return nil, nil
+	}
+	if cfg.ID == "" {
+		cfg.ID = s.client.GenerateMessageID()
+	}
+
+	prior, err := s.idempotency.Reserve(&store.IdempotencyRecord{
+		Key:       cfg.IdempotencyKey,
+		MessageID: string(cfg.ID),
+		ChatJID:   to,
+	}, s.idempotencyWindow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check idempotency key: %w", err)
+	}
+	if prior == nil {
+		return nil, nil
+	}
+
+	s.log.Debugf("Skipping send with idempotency key %q, already sent as %s", cfg.IdempotencyKey, prior.MessageID)
+	return &SendResult{
+		MessageID: types.MessageID(prior.MessageID),
+		ServerID:  types.MessageServerID(prior.ServerID),
+		Timestamp: prior.Timestamp,
+		Recipient: prior.ChatJID,
+		Sender:    prior.Sender,
+	}, nil
+}
+
+// settleIdempotencyKey records the outcome of a send made under an
+// idempotency key. Failed sends release the key so they can be retried.
+func (s *SendService) settleIdempotencyKey(cfg *sendConfig, result *SendResult) {
+	if s.idempotency == nil {
+		return
+	}
+
+	var err error
+	if result != nil {
+		err = s.idempotency.Complete(cfg.IdempotencyKey, int(result.ServerID), result.Sender, result.Timestamp)
+	} else {
+		err = s.idempotency.Release(cfg.IdempotencyKey, string(cfg.ID))
+	}
+	if err != nil {
+		s.log.Warnf("Failed to update idempotency key %q: %v", cfg.IdempotencyKey, err)
+	}
+}
@@ -3,9 +3,11 @@ package send
 import (
 	"context"
 	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"orion-agent/internal/data/store"
 	"orion-agent/internal/utils"
+	"strings"
 	"time"
 
 	"go.mau.fi/whatsmeow"
@@ -14,6 +16,12 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// Poll option limits enforced by WhatsApp clients.
+const (
+	MinPollOptions = 2
+	MaxPollOptions = 12
+)
+
 // PollContent represents a poll creation message.
 type PollContent struct {
 	Question        string
@@ -33,12 +41,13 @@ func Poll(question string, options []string) *PollContent {
 	}
 }
 
-// PollMultiSelect creates a multi-select poll.
-func PollMultiSelect(question string, options []string, maxSelect int) *PollContent {
+// PollMultiSelect creates a poll allowing up to maxSelections options to be
+// picked, or any number of them if maxSelections is 0.
+func PollMultiSelect(question string, options []string, maxSelections int) *PollContent {
 	return &PollContent{
 		Question:        question,
 		Options:         options,
-		SelectableCount: maxSelect,
+		SelectableCount: maxSelections,
 	}
 }
 
@@ -50,6 +59,27 @@ func (p *PollContent) WithContext(ctx *ContextInfo) *PollContent {
 
 // ToMessage implements Content.
 func (p *PollContent) ToMessage() (*waE2E.Message, error) {
+	if p.Question == "" {
+		return nil, fmt.Errorf("poll question is required")
+	}
+	if len(p.Options) < MinPollOptions || len(p.Options) > MaxPollOptions {
+		return nil, fmt.Errorf("poll must have %d to %d options, got %d", MinPollOptions, MaxPollOptions, len(p.Options))
+	}
+	// Votes reference options by the hash of their name
+	seen := make(map[string]bool, len(p.Options))
+	for i, opt := range p.Options {
+		if opt == "" {
+			return nil, fmt.Errorf("poll option %d is empty", i+1)
+		}
+		if seen[opt] {
+			return nil, fmt.Errorf("duplicate poll option %q", opt)
+		}
+		seen[opt] = true
+	}
+	if p.SelectableCount < 0 || p.SelectableCount > len(p.Options) {
+		return nil, fmt.Errorf("poll selectable count must be 0 to %d, got %d", len(p.Options), p.SelectableCount)
+	}
+
 	if p.encKey == nil {
 		p.encKey = make([]byte, 32)
 		if _, err := rand.Read(p.encKey); err != nil {
@@ -122,18 +152,17 @@ func (s *SendService) SendPollVote(ctx context.Context, pollInfo *types.MessageI
 		return nil, fmt.Errorf("failed to build poll vote: %w", err)
 	}
 
-	resp, err := s.client.SendMessage(ctx, pollInfo.Chat, voteMsg)
+	resp, err := s.sendToClient(ctx, pollInfo.Chat, voteMsg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send poll vote: %w", err)
 	}
 
-	// Save vote to database
+	// Save vote to database, under the normalized JIDs like received votes
 	if s.polls != nil {
-		ownJID := s.utils.OwnJID()
 		vote := &store.PollVote{
 			MessageID:       string(pollInfo.ID),
-			ChatJID:         pollInfo.Chat,
-			VoterLID:        ownJID,
+			ChatJID:         s.utils.NormalizeJID(ctx, pollInfo.Chat),
+			VoterLID:        s.utils.NormalizeJID(ctx, s.utils.OwnJID()),
 			SelectedOptions: selectedOptions,
 			Timestamp:       resp.Timestamp,
 		}
@@ -163,13 +192,20 @@ type GroupInviteContent struct {
 	ContextInfo   *ContextInfo
 }
 
-// GroupInvite creates a group invite message.
-func GroupInvite(groupJID types.JID, inviteCode, groupName string) *GroupInviteContent {
-	return &GroupInviteContent{
-		GroupJID:   groupJID,
-		GroupName:  groupName,
-		InviteCode: inviteCode,
+// GroupInvite creates a group invite message. A zero expiration means the
+// invite doesn't expire; thumbnail is an optional JPEG of the group icon.
+func GroupInvite(groupJID types.JID, code string, expiration time.Time, name, caption string, thumbnail []byte) *GroupInviteContent {
+	g := &GroupInviteContent{
+		GroupJID:      groupJID,
+		GroupName:     name,
+		InviteCode:    code,
+		Caption:       caption,
+		ThumbnailJPEG: thumbnail,
+	}
+	if !expiration.IsZero() {
+		g.Expiration = expiration.Unix()
 	}
+	return g
 }
 
 // WithExpiration sets the invite expiration time.
@@ -198,6 +234,13 @@ func (g *GroupInviteContent) WithContext(ctx *ContextInfo) *GroupInviteContent {
 
 // ToMessage implements Content.
 func (g *GroupInviteContent) ToMessage() (*waE2E.Message, error) {
+	if g.GroupJID.Server != types.GroupServer {
+		return nil, fmt.Errorf("not a group JID: %s", g.GroupJID)
+	}
+	if g.InviteCode == "" {
+		return nil, fmt.Errorf("invite code is required")
+	}
+
 	invite := &waE2E.GroupInviteMessage{
 		GroupJID:   proto.String(g.GroupJID.String()),
 		GroupName:  proto.String(g.GroupName),
@@ -258,7 +301,9 @@ func (s *SendService) BuildGroupInvite(ctx context.Context, groupJID types.JID)
 		return nil, fmt.Errorf("failed to get invite link: %w", err)
 	}
 
-	return GroupInvite(groupJID, inviteCode, groupInfo.Name), nil
+	inviteCode = strings.TrimPrefix(inviteCode, whatsmeow.InviteLinkPrefix)
+
+	return GroupInvite(groupJID, inviteCode, time.Time{}, groupInfo.Name, "", nil), nil
 }
 
 // EventContent represents an event message.
@@ -272,17 +317,37 @@ type EventContent struct {
 	ExtraGuestsAllowed bool
 	IsCanceled         bool
 	ContextInfo        *ContextInfo
+
+	// Reminder asks clients to remind attendees ReminderOffset before the
+	// event starts.
+	Reminder       bool
+	ReminderOffset time.Duration
 }
 
-// Event creates an event message.
-func Event(name string, startTime, endTime time.Time) *EventContent {
+// Event creates an event message. description and location may be empty.
+func Event(name, description string, startTime time.Time, location *LocationContent) *EventContent {
 	return &EventContent{
-		Name:      name,
-		StartTime: startTime,
-		EndTime:   endTime,
+		Name:        name,
+		Description: description,
+		StartTime:   startTime,
+		Location:    location,
 	}
 }
 
+// WithEndTime sets when the event ends.
+func (e *EventContent) WithEndTime(endTime time.Time) *EventContent {
+	e.EndTime = endTime
+	return e
+}
+
+// WithReminder asks attendees' clients to remind them offset before the
+// event starts.
+func (e *EventContent) WithReminder(offset time.Duration) *EventContent {
+	e.Reminder = true
+	e.ReminderOffset = offset
+	return e
+}
+
 // WithDescription adds a description.
 func (e *EventContent) WithDescription(desc string) *EventContent {
 	e.Description = desc
@@ -321,25 +386,42 @@ func (e *EventContent) WithContext(ctx *ContextInfo) *EventContent {
 
 // ToMessage implements Content.
 func (e *EventContent) ToMessage() (*waE2E.Message, error) {
+	if e.Name == "" {
+		return nil, fmt.Errorf("event name is required")
+	}
+	if e.StartTime.IsZero() {
+		return nil, fmt.Errorf("event start time is required")
+	}
+	if !e.EndTime.IsZero() && e.EndTime.Before(e.StartTime) {
+		return nil, fmt.Errorf("event ends before it starts")
+	}
+
 	event := &waE2E.EventMessage{
 		Name:               proto.String(e.Name),
 		StartTime:          proto.Int64(e.StartTime.Unix()),
-		EndTime:            proto.Int64(e.EndTime.Unix()),
 		ExtraGuestsAllowed: proto.Bool(e.ExtraGuestsAllowed),
 		IsCanceled:         proto.Bool(e.IsCanceled),
 	}
 
+	if !e.EndTime.IsZero() {
+		event.EndTime = proto.Int64(e.EndTime.Unix())
+	}
 	if e.Description != "" {
 		event.Description = proto.String(e.Description)
 	}
 	if e.JoinLink != "" {
 		event.JoinLink = proto.String(e.JoinLink)
 	}
+	if e.Reminder {
+		event.HasReminder = proto.Bool(true)
+		event.ReminderOffsetSec = proto.Int64(int64(e.ReminderOffset / time.Second))
+	}
 	if e.Location != nil {
 		locMsg, err := e.Location.ToMessage()
-		if err == nil && locMsg.LocationMessage != nil {
-			event.Location = locMsg.LocationMessage
+		if err != nil {
+			return nil, fmt.Errorf("invalid event location: %w", err)
 		}
+		event.Location = locMsg.GetLocationMessage()
 	}
 	if e.ContextInfo != nil {
 		event.ContextInfo = e.ContextInfo.Build()
@@ -368,6 +450,396 @@ func (e *EventContent) GetMentionedJIDs() []types.JID { return nil }
 // GetContextInfo implements Content.
 func (e *EventContent) GetContextInfo() *ContextInfo { return e.ContextInfo }
 
+// FlowContent represents a WhatsApp Flow (structured form) message.
+// It is sent as an interactive message with a single native-flow button.
+type FlowContent struct {
+	FlowID      string
+	FlowToken   string
+	CTA         string // Button text
+	Body        string
+	Header      string
+	Footer      string
+	Screen      string          // First screen to navigate to
+	Params      json.RawMessage // Initial screen data
+	Draft       bool            // Send the draft version of the flow
+	ContextInfo *ContextInfo
+}
+
+// Flow creates a flow message.
+func Flow(flowID, flowToken, cta, body string) *FlowContent {
+	return &FlowContent{
+		FlowID:    flowID,
+		FlowToken: flowToken,
+		CTA:       cta,
+		Body:      body,
+	}
+}
+
+// WithHeader sets the header title.
+func (f *FlowContent) WithHeader(header string) *FlowContent {
+	f.Header = header
+	return f
+}
+
+// WithFooter sets the footer text.
+func (f *FlowContent) WithFooter(footer string) *FlowContent {
+	f.Footer = footer
+	return f
+}
+
+// WithScreen sets the first screen and its initial data.
+func (f *FlowContent) WithScreen(screen string, params json.RawMessage) *FlowContent {
+	f.Screen = screen
+	f.Params = params
+	return f
+}
+
+// AsDraft sends the draft version of the flow.
+func (f *FlowContent) AsDraft() *FlowContent {
+	f.Draft = true
+	return f
+}
+
+// WithContext adds context info.
+func (f *FlowContent) WithContext(ctx *ContextInfo) *FlowContent {
+	f.ContextInfo = ctx
+	return f
+}
+
+// ToMessage implements Content.
+func (f *FlowContent) ToMessage() (*waE2E.Message, error) {
+	if f.FlowID == "" {
+		return nil, fmt.Errorf("flow ID is required")
+	}
+	if f.FlowToken == "" {
+		return nil, fmt.Errorf("flow token is required")
+	}
+	if len(f.Params) > 0 && !json.Valid(f.Params) {
+		return nil, fmt.Errorf("flow params are not valid JSON")
+	}
+
+	mode := "published"
+	if f.Draft {
+		mode = "draft"
+	}
+	params := map[string]any{
+		"flow_message_version": "3",
+		"flow_id":              f.FlowID,
+		"flow_token":           f.FlowToken,
+		"flow_cta":             f.CTA,
+		"flow_action":          "navigate",
+		"mode":                 mode,
+	}
+	if f.Screen != "" || len(f.Params) > 0 {
+		payload := map[string]any{}
+		if f.Screen != "" {
+			payload["screen"] = f.Screen
+		}
+		if len(f.Params) > 0 {
+			payload["data"] = f.Params
+		}
+		params["flow_action_payload"] = payload
+	}
+	buttonParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode flow params: %w", err)
+	}
+
+	interactive := &waE2E.InteractiveMessage{
+		Body: &waE2E.InteractiveMessage_Body{Text: proto.String(f.Body)},
+		InteractiveMessage: &waE2E.InteractiveMessage_NativeFlowMessage_{
+			NativeFlowMessage: &waE2E.InteractiveMessage_NativeFlowMessage{
+				Buttons: []*waE2E.InteractiveMessage_NativeFlowMessage_NativeFlowButton{{
+					Name:             proto.String("galaxy_message"),
+					ButtonParamsJSON: proto.String(string(buttonParams)),
+				}},
+				MessageVersion: proto.Int32(3),
+			},
+		},
+	}
+
+	if f.Header != "" {
+		interactive.Header = &waE2E.InteractiveMessage_Header{
+			Title:              proto.String(f.Header),
+			HasMediaAttachment: proto.Bool(false),
+		}
+	}
+	if f.Footer != "" {
+		interactive.Footer = &waE2E.InteractiveMessage_Footer{Text: proto.String(f.Footer)}
+	}
+	if f.ContextInfo != nil {
+		interactive.ContextInfo = f.ContextInfo.Build()
+	}
+
+	return &waE2E.Message{InteractiveMessage: interactive}, nil
+}
+
+// MediaType implements Content.
+func (f *FlowContent) MediaType() utils.Type {
+	return ""
+}
+
+// MessageType implements Content.
+func (f *FlowContent) MessageType() string { return "interactive" }
+
+// TextContent implements Content.
+func (f *FlowContent) TextContent() string { return f.Body }
+
+// Caption implements Content.
+func (f *FlowContent) GetCaption() string { return f.Footer }
+
+// GetMentionedJIDs implements Content.
+func (f *FlowContent) GetMentionedJIDs() []types.JID { return nil }
+
+// GetContextInfo implements Content.
+func (f *FlowContent) GetContextInfo() *ContextInfo { return f.ContextInfo }
+
+// SendInteractiveNativeFlow sends a WhatsApp Flow message.
+func (s *SendService) SendInteractiveNativeFlow(ctx context.Context, to types.JID, flow *FlowContent, opts ...SendOption) (*SendResult, error) {
+	return s.Send(ctx, to, flow, opts...)
+}
+
+// MaxButtons is the number of quick-reply buttons WhatsApp displays.
+const MaxButtons = 3
+
+// Button is a quick-reply button.
+type Button struct {
+	ID   string // Returned in the ButtonsResponseMessage when tapped
+	Text string // Display text
+}
+
+// ButtonsContent represents a message with quick-reply buttons.
+type ButtonsContent struct {
+	Body        string
+	Buttons     []Button
+	Header      string
+	Footer      string
+	ContextInfo *ContextInfo
+}
+
+// Buttons creates a quick-reply buttons message.
+func Buttons(bodyText string, buttons []Button) *ButtonsContent {
+	return &ButtonsContent{
+		Body:    bodyText,
+		Buttons: buttons,
+	}
+}
+
+// WithHeader sets the header text.
+func (b *ButtonsContent) WithHeader(header string) *ButtonsContent {
+	b.Header = header
+	return b
+}
+
+// WithFooter sets the footer text.
+func (b *ButtonsContent) WithFooter(footer string) *ButtonsContent {
+	b.Footer = footer
+	return b
+}
+
+// WithContext adds context info.
+func (b *ButtonsContent) WithContext(ctx *ContextInfo) *ButtonsContent {
+	b.ContextInfo = ctx
+	return b
+}
+
+// ToMessage implements Content.
+func (b *ButtonsContent) ToMessage() (*waE2E.Message, error) {
+	if b.Body == "" {
+		return nil, fmt.Errorf("buttons body is required")
+	}
+	if len(b.Buttons) == 0 || len(b.Buttons) > MaxButtons {
+		return nil, fmt.Errorf("buttons message must have 1 to %d buttons, got %d", MaxButtons, len(b.Buttons))
+	}
+
+	buttons := make([]*waE2E.ButtonsMessage_Button, len(b.Buttons))
+	for i, btn := range b.Buttons {
+		if btn.Text == "" {
+			return nil, fmt.Errorf("button %d has no text", i+1)
+		}
+		id := btn.ID
+		if id == "" {
+			id = fmt.Sprintf("%d", i+1)
+		}
+		buttons[i] = &waE2E.ButtonsMessage_Button{
+			ButtonID:   proto.String(id),
+			ButtonText: &waE2E.ButtonsMessage_Button_ButtonText{DisplayText: proto.String(btn.Text)},
+			Type:       waE2E.ButtonsMessage_Button_RESPONSE.Enum(),
+		}
+	}
+
+	msg := &waE2E.ButtonsMessage{
+		ContentText: proto.String(b.Body),
+		Buttons:     buttons,
+		HeaderType:  waE2E.ButtonsMessage_EMPTY.Enum(),
+	}
+
+	if b.Header != "" {
+		msg.HeaderType = waE2E.ButtonsMessage_TEXT.Enum()
+		msg.Header = &waE2E.ButtonsMessage_Text{Text: b.Header}
+	}
+	if b.Footer != "" {
+		msg.FooterText = proto.String(b.Footer)
+	}
+	if b.ContextInfo != nil {
+		msg.ContextInfo = b.ContextInfo.Build()
+	}
+
+	return &waE2E.Message{ButtonsMessage: msg}, nil
+}
+
+// MediaType implements Content.
+func (b *ButtonsContent) MediaType() utils.Type {
+	return ""
+}
+
+// MessageType implements Content.
+func (b *ButtonsContent) MessageType() string { return "buttons" }
+
+// TextContent implements Content.
+func (b *ButtonsContent) TextContent() string { return b.Body }
+
+// Caption implements Content.
+func (b *ButtonsContent) GetCaption() string { return b.Footer }
+
+// GetMentionedJIDs implements Content.
+func (b *ButtonsContent) GetMentionedJIDs() []types.JID { return nil }
+
+// GetContextInfo implements Content.
+func (b *ButtonsContent) GetContextInfo() *ContextInfo { return b.ContextInfo }
+
+// ListRow is a selectable row in a list message.
+type ListRow struct {
+	ID          string // Returned in the ListResponseMessage when selected
+	Title       string
+	Description string
+}
+
+// ListSection is a titled group of rows in a list message.
+type ListSection struct {
+	Title string
+	Rows  []ListRow
+}
+
+// ListContent represents a single-select list message.
+type ListContent struct {
+	Title       string
+	Description string
+	ButtonText  string // Text of the button that opens the list
+	Footer      string
+	Sections    []ListSection
+	ContextInfo *ContextInfo
+}
+
+// List creates a list message.
+func List(title, buttonText string, sections []ListSection) *ListContent {
+	return &ListContent{
+		Title:      title,
+		ButtonText: buttonText,
+		Sections:   sections,
+	}
+}
+
+// WithDescription sets the body text shown above the button.
+func (l *ListContent) WithDescription(desc string) *ListContent {
+	l.Description = desc
+	return l
+}
+
+// WithFooter sets the footer text.
+func (l *ListContent) WithFooter(footer string) *ListContent {
+	l.Footer = footer
+	return l
+}
+
+// WithContext adds context info.
+func (l *ListContent) WithContext(ctx *ContextInfo) *ListContent {
+	l.ContextInfo = ctx
+	return l
+}
+
+// ToMessage implements Content.
+func (l *ListContent) ToMessage() (*waE2E.Message, error) {
+	if l.ButtonText == "" {
+		return nil, fmt.Errorf("list button text is required")
+	}
+	if len(l.Sections) == 0 {
+		return nil, fmt.Errorf("list must have at least one section")
+	}
+
+	seen := make(map[string]bool)
+	sections := make([]*waE2E.ListMessage_Section, len(l.Sections))
+	for i, sec := range l.Sections {
+		if len(sec.Rows) == 0 {
+			return nil, fmt.Errorf("list section %d has no rows", i+1)
+		}
+		rows := make([]*waE2E.ListMessage_Row, len(sec.Rows))
+		for j, row := range sec.Rows {
+			if row.Title == "" {
+				return nil, fmt.Errorf("list section %d row %d has no title", i+1, j+1)
+			}
+			id := row.ID
+			if id == "" {
+				id = fmt.Sprintf("%d.%d", i+1, j+1)
+			}
+			if seen[id] {
+				return nil, fmt.Errorf("duplicate list row ID %q", id)
+			}
+			seen[id] = true
+			rows[j] = &waE2E.ListMessage_Row{
+				RowID: proto.String(id),
+				Title: proto.String(row.Title),
+			}
+			if row.Description != "" {
+				rows[j].Description = proto.String(row.Description)
+			}
+		}
+		sections[i] = &waE2E.ListMessage_Section{
+			Title: proto.String(sec.Title),
+			Rows:  rows,
+		}
+	}
+
+	list := &waE2E.ListMessage{
+		Title:      proto.String(l.Title),
+		ButtonText: proto.String(l.ButtonText),
+		ListType:   waE2E.ListMessage_SINGLE_SELECT.Enum(),
+		Sections:   sections,
+	}
+
+	if l.Description != "" {
+		list.Description = proto.String(l.Description)
+	}
+	if l.Footer != "" {
+		list.FooterText = proto.String(l.Footer)
+	}
+	if l.ContextInfo != nil {
+		list.ContextInfo = l.ContextInfo.Build()
+	}
+
+	return &waE2E.Message{ListMessage: list}, nil
+}
+
+// MediaType implements Content.
+func (l *ListContent) MediaType() utils.Type {
+	return ""
+}
+
+// MessageType implements Content.
+func (l *ListContent) MessageType() string { return "list" }
+
+// TextContent implements Content.
+func (l *ListContent) TextContent() string { return l.Title }
+
+// Caption implements Content.
+func (l *ListContent) GetCaption() string { return l.Description }
+
+// GetMentionedJIDs implements Content.
+func (l *ListContent) GetMentionedJIDs() []types.JID { return nil }
+
+// GetContextInfo implements Content.
+func (l *ListContent) GetContextInfo() *ContextInfo { return l.ContextInfo }
+
 // GetClient returns the underlying whatsmeow client for advanced operations.
 func (s *SendService) GetClient() *whatsmeow.Client {
 	return s.client
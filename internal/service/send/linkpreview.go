@@ -0,0 +1,273 @@
+package send
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Link preview fetch settings.
+const (
+	linkPreviewTimeout     = 10 * time.Second
+	linkPreviewMaxPage     = 512 << 10 // Only the head is needed
+	linkPreviewMaxImage    = 4 << 20
+	linkPreviewMaxPixels   = 4096 * 4096 // Caps the memory a decode can take
+	linkPreviewThumbnailPx = 300
+)
+
+// urlPattern finds the first link in a message text.
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// linkPreviewClient fetches pages named in message texts, which anyone in a
+// chat can write, so it only connects to public addresses. The check runs
+// on the resolved address of every connection, redirects included.
+var linkPreviewClient = &http.Client{
+	Timeout: linkPreviewTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: linkPreviewTimeout,
+			Control: refuseNonPublic,
+		}).DialContext,
+		TLSHandshakeTimeout: linkPreviewTimeout,
+	},
+}
+
+// refuseNonPublic is a net.Dialer Control func that refuses loopback,
+// private, link-local (e.g. cloud metadata at 169.254.169.254) and other
+// non-public addresses.
+func refuseNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", host)
+	}
+	return nil
+}
+
+// isPublicIP reports whether ip is a globally routable unicast address.
+func isPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsUnspecified()
+}
+
+// ensureLinkPreview fills a link preview from the page's OpenGraph tags for
+// texts sent with WithFetchedPreview. Fields already set are kept. On
+// failure the text is sent without a preview.
+func (s *SendService) ensureLinkPreview(ctx context.Context, e *ExtendedTextContent) {
+	if !e.FetchPreview || e.previewAttempted {
+		return
+	}
+	e.previewAttempted = true
+
+	link := e.CanonicalURL
+	if link == "" {
+		link = urlPattern.FindString(e.Text)
+	}
+	if link == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, linkPreviewTimeout)
+	defer cancel()
+
+	og, err := fetchOpenGraph(ctx, link)
+	if err != nil {
+		s.log.Warnf("Failed to fetch link preview for %s: %v", link, err)
+		return
+	}
+	if og.title == "" && og.description == "" && og.image == "" {
+		return
+	}
+
+	var thumb []byte
+	if og.image != "" && len(e.ThumbnailJPEG) == 0 {
+		if thumb, err = fetchThumbnail(ctx, og.image); err != nil {
+			s.log.Debugf("Failed to fetch link preview image %s: %v", og.image, err)
+		}
+	}
+
+	e.WithLinkPreview(link, firstNonEmpty(e.Title, og.title), firstNonEmpty(e.Description, og.description), thumb)
+}
+
+// openGraph holds the preview tags of a page.
+type openGraph struct {
+	title       string
+	description string
+	image       string
+}
+
+// fetchOpenGraph reads the og: tags from a page's head, falling back to
+// <title> and the description meta tag.
+func fetchOpenGraph(ctx context.Context, link string) (*openGraph, error) {
+	body, base, err := httpGet(ctx, link, linkPreviewMaxPage, "text/html")
+	if err != nil {
+		return nil, err
+	}
+
+	var og openGraph
+	var pageTitle, metaDescription string
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		tok := z.Token()
+		if tok.DataAtom == atom.Body || (tt == html.EndTagToken && tok.DataAtom == atom.Head) {
+			break
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+
+		switch tok.DataAtom {
+		case atom.Title:
+			if z.Next() == html.TextToken {
+				pageTitle = strings.TrimSpace(z.Token().Data)
+			}
+		case atom.Meta:
+			var key, content string
+			for _, attr := range tok.Attr {
+				switch attr.Key {
+				case "property", "name":
+					key = strings.ToLower(attr.Val)
+				case "content":
+					content = strings.TrimSpace(attr.Val)
+				}
+			}
+			switch key {
+			case "og:title":
+				og.title = content
+			case "og:description":
+				og.description = content
+			case "og:image", "og:image:url", "og:image:secure_url":
+				if og.image == "" {
+					og.image = content
+				}
+			case "description":
+				metaDescription = content
+			}
+		}
+	}
+
+	og.title = firstNonEmpty(og.title, pageTitle)
+	og.description = firstNonEmpty(og.description, metaDescription)
+	if og.image != "" {
+		if ref, err := base.Parse(og.image); err == nil {
+			og.image = ref.String()
+		}
+	}
+	return &og, nil
+}
+
+// fetchThumbnail downloads an image and re-encodes it as a small JPEG.
+func fetchThumbnail(ctx context.Context, link string) ([]byte, error) {
+	data, _, err := httpGet(ctx, link, linkPreviewMaxImage, "image/")
+	if err != nil {
+		return nil, err
+	}
+	img, err := decodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, downscale(img, linkPreviewThumbnailPx), &jpeg.Options{Quality: 75}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeImage decodes data, reading the header first to refuse images
+// whose pixel count would take too much memory to decode.
+func decodeImage(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > linkPreviewMaxPixels {
+		return nil, fmt.Errorf("image too large: %dx%d", cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// httpGet fetches up to limit bytes of link, checking the content type
+// starts with wantType. Returns the final URL after redirects.
+func httpGet(ctx context.Context, link string, limit int64, wantType string) ([]byte, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Some sites only serve OpenGraph tags to known crawlers
+	req.Header.Set("User-Agent", "WhatsApp/2.0")
+
+	resp, err := linkPreviewClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, wantType) {
+		return nil, nil, fmt.Errorf("unexpected content type %q", ct)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, resp.Request.URL, nil
+}
+
+// downscale shrinks img so its longest side is at most maxPx, using
+// nearest-neighbor sampling, which is enough for a thumbnail.
+func downscale(img image.Image, maxPx int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxPx && h <= maxPx {
+		return img
+	}
+
+	nw, nh := maxPx, h*maxPx/w
+	if h > w {
+		nw, nh = w*maxPx/h, maxPx
+	}
+	nw, nh = max(nw, 1), max(nh, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	for y := 0; y < nh; y++ {
+		sy := b.Min.Y + y*h/nh
+		for x := 0; x < nw; x++ {
+			dst.Set(x, y, img.At(b.Min.X+x*w/nw, sy))
+		}
+	}
+	return dst
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package send
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/png"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLinkPreviewRefusesNonPublicAddresses(t *testing.T) {
+	hit := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>internal</title></head></html>`))
+	}))
+	defer server.Close()
+
+	if _, err := fetchOpenGraph(context.Background(), server.URL); err == nil {
+		t.Fatal("expected fetching a loopback address to fail")
+	}
+	if hit {
+		t.Error("request reached the loopback server")
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"::1":             false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"0.0.0.0":         false,
+		"224.0.0.1":       false,
+	}
+	for addr, want := range tests {
+		if got := isPublicIP(net.ParseIP(addr)); got != want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestDecodeImageRejectsOversized(t *testing.T) {
+	// A GIF header claiming a 65535x65535 screen, with no pixel data
+	header := []byte("GIF89a\xff\xff\xff\xff\x00\x00\x00")
+	if _, err := decodeImage(header); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Fatalf("expected an oversized image to be rejected before decoding, got %v", err)
+	}
+
+	img := image.NewGray(image.Rect(0, 0, 640, 480))
+	img.Set(1, 1, color.White)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeImage(buf.Bytes())
+	if err != nil {
+		t.Fatalf("decodeImage: %v", err)
+	}
+	if b := decoded.Bounds(); b.Dx() != 640 || b.Dy() != 480 {
+		t.Errorf("decoded %v, want 640x480", b)
+	}
+}
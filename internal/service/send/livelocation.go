@@ -0,0 +1,103 @@
+package send
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// UpdateLiveLocation sends a position update for a live location started
+// with LiveLocationContent.
+//
+// Updates reuse the original message ID with an increasing sequence number,
+// which is tracked here per message. Send one every few seconds (WhatsApp
+// clients send roughly every 5-10s while moving) for as long as the share
+// lasts. To stop, stop sending updates and call StopLiveLocation; revoke the
+// original message to end the share for recipients right away.
+func (s *SendService) UpdateLiveLocation(ctx context.Context, chat types.JID, origMsgID types.MessageID, lat, lon float64, accuracy int, speed float64) error {
+	if s.client == nil {
+		return fmt.Errorf("client not initialized")
+	}
+
+	// The original may be stored under either form of the chat JID
+	var storedChat types.JID
+	var caption string
+	var started time.Time
+	var storedSeq int64
+	if s.messages != nil {
+		for _, c := range []types.JID{chat, s.utils.NormalizeJID(ctx, chat)} {
+			orig, err := s.messages.GetContent(string(origMsgID), c)
+			if err != nil {
+				continue
+			}
+			if !orig.IsLiveLocation {
+				return fmt.Errorf("message %s is not a live location", origMsgID)
+			}
+			storedChat = c
+			caption = orig.Caption
+			started = orig.Timestamp
+			storedSeq = int64(orig.LiveLocationSeq)
+			break
+		}
+	}
+
+	seq := s.nextLiveLocationSeq(origMsgID, storedSeq)
+
+	loc := &waE2E.LiveLocationMessage{
+		DegreesLatitude:  proto.Float64(lat),
+		DegreesLongitude: proto.Float64(lon),
+		SequenceNumber:   proto.Int64(seq),
+	}
+	if accuracy > 0 {
+		loc.AccuracyInMeters = proto.Uint32(uint32(accuracy))
+	}
+	if speed > 0 {
+		loc.SpeedInMps = proto.Float32(float32(speed))
+	}
+	if caption != "" {
+		loc.Caption = proto.String(caption)
+	}
+	if !started.IsZero() {
+		loc.TimeOffset = proto.Uint32(uint32(time.Since(started).Seconds()))
+	}
+
+	msg := &waE2E.Message{LiveLocationMessage: loc}
+	// A stale position isn't worth retrying; the next update supersedes it
+	if _, err := s.SendRaw(ctx, chat, msg, WithID(origMsgID), WithoutSave(), WithoutFooter(), WithoutFailedSendRecord()); err != nil {
+		return fmt.Errorf("failed to send live location update: %w", err)
+	}
+
+	if !storedChat.IsEmpty() {
+		if err := s.messages.UpdateLiveLocation(string(origMsgID), storedChat, lat, lon, accuracy, speed, int(seq)); err != nil {
+			s.log.Warnf("Failed to save live location update for %s: %v", origMsgID, err)
+		}
+	}
+
+	return nil
+}
+
+// StopLiveLocation forgets the sequence tracking of a live location.
+// Call it once no more updates will be sent for the message.
+func (s *SendService) StopLiveLocation(origMsgID types.MessageID) {
+	s.liveMu.Lock()
+	delete(s.liveSeq, origMsgID)
+	s.liveMu.Unlock()
+}
+
+// nextLiveLocationSeq returns the next sequence number for a live location,
+// continuing from the stored one if the message isn't tracked yet.
+func (s *SendService) nextLiveLocationSeq(msgID types.MessageID, stored int64) int64 {
+	s.liveMu.Lock()
+	defer s.liveMu.Unlock()
+
+	if s.liveSeq == nil {
+		s.liveSeq = make(map[types.MessageID]int64)
+	}
+	seq := max(s.liveSeq[msgID], stored) + 1
+	s.liveSeq[msgID] = seq
+	return seq
+}
@@ -16,6 +16,7 @@ type LocationContent struct {
 	Address     string
 	URL         string
 	Comment     string
+	Thumbnail   []byte // JPEG map preview
 	ContextInfo *ContextInfo
 }
 
@@ -40,6 +41,25 @@ func LocationWithURL(lat, lng float64, name, address, url string) *LocationConte
 	}
 }
 
+// WithAddress sets the place name and address of the location.
+func (l *LocationContent) WithAddress(name, address string) *LocationContent {
+	l.Name = name
+	l.Address = address
+	return l
+}
+
+// WithURL sets the maps URL of the location.
+func (l *LocationContent) WithURL(url string) *LocationContent {
+	l.URL = url
+	return l
+}
+
+// WithThumbnail sets the JPEG map thumbnail.
+func (l *LocationContent) WithThumbnail(jpeg []byte) *LocationContent {
+	l.Thumbnail = jpeg
+	return l
+}
+
 // WithComment adds a comment to the location.
 func (l *LocationContent) WithComment(comment string) *LocationContent {
 	l.Comment = comment
@@ -71,6 +91,9 @@ func (l *LocationContent) ToMessage() (*waE2E.Message, error) {
 	if l.Comment != "" {
 		loc.Comment = proto.String(l.Comment)
 	}
+	if len(l.Thumbnail) > 0 {
+		loc.JPEGThumbnail = l.Thumbnail
+	}
 	if l.ContextInfo != nil {
 		loc.ContextInfo = l.ContextInfo.Build()
 	}
@@ -28,7 +28,9 @@ type ImageContent struct {
 	ThumbnailJPEG []byte
 	ContextInfo   *ContextInfo
 
-	uploaded *whatsmeow.UploadResponse
+	stripMetadata    bool
+	metadataStripped bool
+	uploaded         *whatsmeow.UploadResponse
 }
 
 // Image creates an image message.
@@ -87,6 +89,9 @@ func (i *ImageContent) Upload(ctx context.Context, client *whatsmeow.Client) err
 	if i.uploaded != nil {
 		return nil
 	}
+	if err := i.applyStripMetadata(); err != nil {
+		return err
+	}
 	resp, err := client.Upload(ctx, i.Data, whatsmeow.MediaImage)
 	if err != nil {
 		return err
@@ -172,7 +177,8 @@ type VideoContent struct {
 	IsPTV           bool
 	ContextInfo     *ContextInfo
 
-	uploaded *whatsmeow.UploadResponse
+	uploaded       *whatsmeow.UploadResponse
+	thumbAttempted bool
 }
 
 // Video creates a video message.
@@ -319,7 +325,8 @@ type AudioContent struct {
 	Waveform        []byte
 	ContextInfo     *ContextInfo
 
-	uploaded *whatsmeow.UploadResponse
+	uploaded     *whatsmeow.UploadResponse
+	autoWaveform bool
 }
 
 // Audio creates an audio message.
@@ -349,6 +356,13 @@ func (a *AudioContent) WithWaveform(waveform []byte) *AudioContent {
 	return a
 }
 
+// WithAutoWaveform computes the waveform of a voice note on upload, if none was set.
+// Decoding needs ffmpeg; if it fails, the voice note is sent without a waveform.
+func (a *AudioContent) WithAutoWaveform() *AudioContent {
+	a.autoWaveform = true
+	return a
+}
+
 // WithContext adds context info.
 func (a *AudioContent) WithContext(ctx *ContextInfo) *AudioContent {
 	a.ContextInfo = ctx
@@ -360,6 +374,7 @@ func (a *AudioContent) Upload(ctx context.Context, client *whatsmeow.Client) err
 	if a.uploaded != nil {
 		return nil
 	}
+	a.ensureWaveform(ctx)
 	resp, err := client.Upload(ctx, a.Data, whatsmeow.MediaAudio)
 	if err != nil {
 		return err
@@ -368,6 +383,20 @@ func (a *AudioContent) Upload(ctx context.Context, client *whatsmeow.Client) err
 	return nil
 }
 
+// ensureWaveform computes the waveform of a voice note sent with
+// WithAutoWaveform, if it has none yet.
+func (a *AudioContent) ensureWaveform(ctx context.Context) {
+	if !a.autoWaveform || !a.IsPTT || len(a.Waveform) > 0 {
+		return
+	}
+	if samples, err := decodePCM(ctx, a.Data); err == nil {
+		a.Waveform = WaveformFromPCM(samples)
+		if a.DurationSeconds == 0 {
+			a.DurationSeconds = uint32(len(samples) / waveformSampleRate)
+		}
+	}
+}
+
 // IsUploaded implements MediaUploader.
 func (a *AudioContent) IsUploaded() bool {
 	return a.uploaded != nil
@@ -0,0 +1,222 @@
+package send
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+)
+
+// StripImageMetadata strips EXIF and other metadata from every image before
+// upload, not just those built with StripMetadata.
+var StripImageMetadata = true
+
+// strippedJPEGQuality is used when a JPEG has to be re-encoded to apply its
+// EXIF orientation.
+const strippedJPEGQuality = 92
+
+var (
+	jpegSOI   = []byte{0xff, 0xd8}
+	pngHeader = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// StripMetadata removes EXIF (GPS position, camera, timestamps), XMP and
+// comments from the image before it's uploaded. The EXIF orientation is
+// applied to the pixels first, so the image isn't shown sideways.
+// Only JPEG and PNG carry metadata we strip; other formats are sent as-is.
+func (i *ImageContent) StripMetadata() *ImageContent {
+	i.stripMetadata = true
+	return i
+}
+
+// applyStripMetadata strips the image's metadata if requested, once.
+func (i *ImageContent) applyStripMetadata() error {
+	if i.metadataStripped || (!i.stripMetadata && !StripImageMetadata) {
+		return nil
+	}
+
+	var data []byte
+	var err error
+	switch {
+	case bytes.HasPrefix(i.Data, jpegSOI):
+		data, err = stripJPEGMetadata(i.Data)
+	case bytes.HasPrefix(i.Data, pngHeader):
+		data, err = stripPNGMetadata(i.Data)
+	default:
+		data = i.Data
+	}
+	if err != nil {
+		return fmt.Errorf("failed to strip image metadata: %w", err)
+	}
+
+	i.Data = data
+	i.metadataStripped = true
+	i.detectDimensions() // Rotation swaps width and height
+	return nil
+}
+
+// stripJPEGMetadata drops the APPn and comment segments of a JPEG, keeping
+// JFIF, ICC profiles and Adobe color info. Rotated images are re-encoded
+// upright; others are copied without re-encoding.
+func stripJPEGMetadata(data []byte) ([]byte, error) {
+	out := append(make([]byte, 0, len(data)), jpegSOI...)
+	orientation := 1
+
+	pos := len(jpegSOI)
+	for {
+		if pos+4 > len(data) || data[pos] != 0xff {
+			return nil, errors.New("malformed jpeg")
+		}
+		marker := data[pos+1]
+		if marker == 0xff { // Fill byte
+			pos++
+			continue
+		}
+		if marker == 0xda { // Start of scan, the rest is image data
+			out = append(out, data[pos:]...)
+			break
+		}
+
+		end := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:]))
+		if end > len(data) {
+			return nil, errors.New("malformed jpeg")
+		}
+		segment := data[pos:end]
+		payload := segment[4:]
+		pos = end
+
+		switch {
+		case marker == 0xe1 && bytes.HasPrefix(payload, []byte("Exif\x00\x00")):
+			orientation = exifOrientation(payload[6:])
+		case marker == 0xe2 && bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00")):
+			out = append(out, segment...)
+		case marker == 0xe0 || marker == 0xee: // JFIF, Adobe
+			out = append(out, segment...)
+		case marker >= 0xe1 && marker <= 0xef, marker == 0xfe: // APPn, comment
+		default:
+			out = append(out, segment...)
+		}
+	}
+
+	if orientation == 1 {
+		return out, nil
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, applyOrientation(img, orientation), &jpeg.Options{Quality: strippedJPEGQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exifOrientation reads the orientation tag from a TIFF-structured EXIF
+// block. Returns 1 (upright) if it's missing or unreadable.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if o := int(order.Uint16(tiff[entry+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+			break
+		}
+	}
+	return 1
+}
+
+// applyOrientation transforms img as described by an EXIF orientation
+// value, so it displays upright without the tag.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	b := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	w, h := b.Dx(), b.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Mirrored
+				dx, dy = w-1-x, y
+			case 3: // Rotated 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // Flipped
+				dx, dy = x, h-1-y
+			case 5: // Transposed
+				dx, dy = y, x
+			case 6: // Rotated 90° clockwise
+				dx, dy = h-1-y, x
+			case 7: // Transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // Rotated 90° counter-clockwise
+				dx, dy = y, w-1-x
+			default:
+				dx, dy = x, y
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):][:4], src.Pix[src.PixOffset(x, y):][:4])
+		}
+	}
+	return dst
+}
+
+// pngMetadataChunks are the PNG chunks that hold metadata rather than
+// anything affecting how the image renders.
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+// stripPNGMetadata drops the metadata chunks of a PNG.
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	out := append(make([]byte, 0, len(data)), pngHeader...)
+	for pos := len(pngHeader); pos < len(data); {
+		if pos+12 > len(data) {
+			return nil, errors.New("malformed png")
+		}
+		end := pos + 12 + int(binary.BigEndian.Uint32(data[pos:]))
+		if end > len(data) || end < pos {
+			return nil, errors.New("malformed png")
+		}
+		if !pngMetadataChunks[string(data[pos+4:pos+8])] {
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	return out, nil
+}
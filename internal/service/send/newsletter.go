@@ -0,0 +1,69 @@
+package send
+
+import (
+	"context"
+	"fmt"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	"orion-agent/internal/data/store"
+)
+
+// SendNewsletter posts text, an image or a video to a newsletter (channel)
+// the account administers. Newsletter posts aren't end-to-end encrypted:
+// media is uploaded in plaintext and referenced by its upload handle, and
+// the server fans the post out to followers.
+func (s *SendService) SendNewsletter(ctx context.Context, newsletterJID types.JID, content Content, opts ...SendOption) (*SendResult, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("client not initialized")
+	}
+	if newsletterJID.Server != types.NewsletterServer {
+		return nil, fmt.Errorf("not a newsletter JID: %s", newsletterJID)
+	}
+
+	var handle string
+	var err error
+	switch c := content.(type) {
+	case *TextContent, *ExtendedTextContent:
+	case *ImageContent:
+		if err = c.applyStripMetadata(); err == nil {
+			handle, err = s.uploadNewsletterMedia(ctx, c.Data, whatsmeow.MediaImage, &c.uploaded)
+		}
+	case *VideoContent:
+		s.ensureVideoThumbnail(ctx, c)
+		handle, err = s.uploadNewsletterMedia(ctx, c.Data, whatsmeow.MediaVideo, &c.uploaded)
+	default:
+		return nil, fmt.Errorf("%s can't be posted to a newsletter, only text, images and videos", content.MessageType())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload media: %w", err)
+	}
+
+	if s.chats != nil {
+		if err := s.chats.EnsureExists(newsletterJID, store.ChatTypeNewsletter); err != nil {
+			s.log.Warnf("Failed to create newsletter chat %s: %v", newsletterJID, err)
+		}
+	}
+
+	opts = append(opts[:len(opts):len(opts)], WithoutAutoEphemeral())
+	if handle != "" {
+		opts = append(opts, WithMediaHandle(handle))
+	}
+	return s.Send(ctx, newsletterJID, content, opts...)
+}
+
+// uploadNewsletterMedia uploads media unencrypted, as newsletters require,
+// and returns its media handle. Media already uploaded for regular chats
+// is encrypted and has no handle, so it's uploaded again.
+func (s *SendService) uploadNewsletterMedia(ctx context.Context, data []byte, mediaType whatsmeow.MediaType, uploaded **whatsmeow.UploadResponse) (string, error) {
+	if *uploaded != nil && (*uploaded).Handle != "" {
+		return (*uploaded).Handle, nil
+	}
+	resp, err := s.client.UploadNewsletter(ctx, data, mediaType)
+	if err != nil {
+		return "", err
+	}
+	*uploaded = &resp
+	return resp.Handle, nil
+}
@@ -3,10 +3,14 @@ package send
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/appstate"
 	"go.mau.fi/whatsmeow/proto/waCommon"
 	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/proto/waSyncAction"
 	"go.mau.fi/whatsmeow/types"
 	"google.golang.org/protobuf/proto"
 
@@ -33,7 +37,7 @@ func (s *SendService) Edit(ctx context.Context, chat types.JID, msgID types.Mess
 		},
 	}
 
-	resp, err := s.client.SendMessage(ctx, chat, editMsg)
+	resp, err := s.sendToClient(ctx, chat, editMsg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to edit message: %w", err)
 	}
@@ -83,7 +87,7 @@ func (s *SendService) EditExtended(ctx context.Context, chat types.JID, msgID ty
 		},
 	}
 
-	resp, err := s.client.SendMessage(ctx, chat, editMsg)
+	resp, err := s.sendToClient(ctx, chat, editMsg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to edit message: %w", err)
 	}
@@ -105,6 +109,85 @@ func (s *SendService) EditExtended(ctx context.Context, chat types.JID, msgID ty
 	}, nil
 }
 
+// EditCaption edits the caption of a sent image or video.
+// The media fields are rebuilt from the stored message, since an edit
+// replaces the whole message body. Only our own messages can be edited.
+func (s *SendService) EditCaption(ctx context.Context, chat types.JID, msgID types.MessageID, newCaption string) error {
+	if s.client == nil {
+		return fmt.Errorf("client not initialized")
+	}
+	if s.messages == nil {
+		return fmt.Errorf("message store not available")
+	}
+
+	storedChat := s.utils.NormalizeJID(ctx, chat)
+	stored, err := s.messages.Get(string(msgID), storedChat)
+	if err != nil {
+		return fmt.Errorf("failed to get message: %w", err)
+	}
+	if !stored.FromMe {
+		return fmt.Errorf("message %s wasn't sent by us", msgID)
+	}
+
+	editedMsg := &waE2E.Message{}
+	switch stored.MessageType {
+	case "image":
+		editedMsg.ImageMessage = &waE2E.ImageMessage{
+			URL:               proto.String(stored.MediaURL),
+			DirectPath:        proto.String(stored.MediaDirectPath),
+			MediaKey:          stored.MediaKey,
+			MediaKeyTimestamp: proto.Int64(stored.MediaKeyTimestamp),
+			FileSHA256:        stored.FileSHA256,
+			FileEncSHA256:     stored.FileEncSHA256,
+			FileLength:        proto.Uint64(uint64(stored.FileLength)),
+			Mimetype:          proto.String(stored.Mimetype),
+			Width:             proto.Uint32(uint32(stored.Width)),
+			Height:            proto.Uint32(uint32(stored.Height)),
+			Caption:           proto.String(newCaption),
+		}
+	case "video":
+		editedMsg.VideoMessage = &waE2E.VideoMessage{
+			URL:               proto.String(stored.MediaURL),
+			DirectPath:        proto.String(stored.MediaDirectPath),
+			MediaKey:          stored.MediaKey,
+			MediaKeyTimestamp: proto.Int64(stored.MediaKeyTimestamp),
+			FileSHA256:        stored.FileSHA256,
+			FileEncSHA256:     stored.FileEncSHA256,
+			FileLength:        proto.Uint64(uint64(stored.FileLength)),
+			Mimetype:          proto.String(stored.Mimetype),
+			Width:             proto.Uint32(uint32(stored.Width)),
+			Height:            proto.Uint32(uint32(stored.Height)),
+			Seconds:           proto.Uint32(uint32(stored.DurationSeconds)),
+			Caption:           proto.String(newCaption),
+		}
+	default:
+		return fmt.Errorf("message %s is not an image or video (type %q)", msgID, stored.MessageType)
+	}
+
+	editMsg := &waE2E.Message{
+		ProtocolMessage: &waE2E.ProtocolMessage{
+			Key: &waCommon.MessageKey{
+				RemoteJID: proto.String(chat.String()),
+				FromMe:    proto.Bool(true),
+				ID:        proto.String(string(msgID)),
+			},
+			Type:          waE2E.ProtocolMessage_MESSAGE_EDIT.Enum(),
+			EditedMessage: editedMsg,
+		},
+	}
+
+	resp, err := s.sendToClient(ctx, chat, editMsg)
+	if err != nil {
+		return fmt.Errorf("failed to edit caption: %w", err)
+	}
+
+	if err := s.messages.UpdateCaption(string(msgID), storedChat, newCaption, resp.Timestamp); err != nil {
+		s.log.Warnf("Failed to save caption edit for message %s: %v", msgID, err)
+	}
+
+	return nil
+}
+
 // Revoke deletes a message for everyone.
 func (s *SendService) Revoke(ctx context.Context, chat types.JID, sender types.JID, msgID types.MessageID, fromMe bool) (*SendResult, error) {
 	if s.client == nil {
@@ -129,7 +212,7 @@ func (s *SendService) Revoke(ctx context.Context, chat types.JID, sender types.J
 		},
 	}
 
-	resp, err := s.client.SendMessage(ctx, chat, revokeMsg)
+	resp, err := s.sendToClient(ctx, chat, revokeMsg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to revoke message: %w", err)
 	}
@@ -158,6 +241,13 @@ func (s *SendService) RevokeOwn(ctx context.Context, chat types.JID, msgID types
 
 // React sends a reaction to a message.
 func (s *SendService) React(ctx context.Context, chat types.JID, targetMsgID types.MessageID, targetSender types.JID, emoji string) (*SendResult, error) {
+	return s.ReactAt(ctx, chat, targetMsgID, targetSender, emoji, time.Now())
+}
+
+// ReactAt sends a reaction to a message as if it was made at ts, for
+// replaying or backfilling reactions. Clients keep the reaction with the
+// latest ts from each sender, so this also decides which one wins.
+func (s *SendService) ReactAt(ctx context.Context, chat types.JID, targetMsgID types.MessageID, targetSender types.JID, emoji string, ts time.Time) (*SendResult, error) {
 	if s.client == nil {
 		return nil, fmt.Errorf("client not initialized")
 	}
@@ -183,11 +273,11 @@ func (s *SendService) React(ctx context.Context, chat types.JID, targetMsgID typ
 		ReactionMessage: &waE2E.ReactionMessage{
 			Key:               key,
 			Text:              proto.String(emoji),
-			SenderTimestampMS: proto.Int64(time.Now().UnixMilli()),
+			SenderTimestampMS: proto.Int64(ts.UnixMilli()),
 		},
 	}
 
-	resp, err := s.client.SendMessage(ctx, chat, reactionMsg)
+	resp, err := s.sendToClient(ctx, chat, reactionMsg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send reaction: %w", err)
 	}
@@ -207,7 +297,7 @@ func (s *SendService) React(ctx context.Context, chat types.JID, targetMsgID typ
 				ChatJID:   chat,
 				SenderLID: ownJID,
 				Emoji:     emoji,
-				Timestamp: resp.Timestamp,
+				Timestamp: ts,
 			}); err != nil {
 				s.log.Warnf("Failed to save reaction for message %s: %v", targetMsgID, err)
 			}
@@ -229,51 +319,155 @@ func (s *SendService) RemoveReaction(ctx context.Context, chat types.JID, target
 	return s.React(ctx, chat, targetMsgID, targetSender, "")
 }
 
+// ToggleReaction reacts with emoji, or removes the reaction if we already
+// reacted with that emoji. Reacting with a different emoji replaces ours.
+// Returns whether the reaction ended up added.
+func (s *SendService) ToggleReaction(ctx context.Context, chat types.JID, targetMsgID types.MessageID, targetSender types.JID, emoji string) (added bool, err error) {
+	if emoji == "" {
+		return false, fmt.Errorf("emoji is required")
+	}
+
+	if s.ownReaction(ctx, chat, targetMsgID) == emoji {
+		if _, err := s.RemoveReaction(ctx, chat, targetMsgID, targetSender); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+
+	if _, err := s.React(ctx, chat, targetMsgID, targetSender, emoji); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ownReaction returns our current reaction to a message, or "" if none.
+// Our reactions are stored under our phone JID when sent from here and under
+// our LID when synced from another device, so both are checked.
+func (s *SendService) ownReaction(ctx context.Context, chat types.JID, msgID types.MessageID) string {
+	if s.reactions == nil {
+		return ""
+	}
+
+	ownJID := s.utils.OwnJID()
+	ownLID := s.utils.NormalizeJID(ctx, ownJID)
+	for _, c := range []types.JID{chat, s.utils.NormalizeJID(ctx, chat)} {
+		reactions, err := s.reactions.GetByMessage(string(msgID), c)
+		if err != nil {
+			s.log.Warnf("Failed to get reactions for message %s: %v", msgID, err)
+			return ""
+		}
+		for _, r := range reactions {
+			if r.SenderLID == ownJID || r.SenderLID == ownLID {
+				return r.Emoji
+			}
+		}
+	}
+	return ""
+}
+
 // Reply sends a reply to a message.
+// The content's own context, such as mentions, is kept; the content itself
+// is copied rather than changed, so it can be reused.
 func (s *SendService) Reply(ctx context.Context, chat types.JID, replyToID types.MessageID, replyToSender types.JID, content Content, opts ...SendOption) (*SendResult, error) {
-	// Add reply context to the content
-	replyCtx := ReplyContext(replyToID, replyToSender, nil)
+	return s.Send(ctx, chat, s.replyContent(ctx, chat, replyToID, replyToSender, content, opts), opts...)
+}
+
+// replyContent returns a copy of content with the reply context set.
+func (s *SendService) replyContent(ctx context.Context, chat types.JID, replyToID types.MessageID, replyToSender types.JID, content Content, opts []SendOption) Content {
+	quoted := s.quotedMessage(ctx, chat, replyToID)
+
+	// Replies in a disappearing chat must disappear too
+	var duration uint32
+	if !applyOptions(opts).NoAutoEphemeral {
+		duration = s.chatEphemeralDuration(ctx, chat)
+	}
+
+	// Quote the original so clients can render the preview
+	withReply := func(base *ContextInfo) *ContextInfo {
+		replyCtx := base.Clone().ReplyTo(replyToID, replyToSender, quoted)
+		if duration > 0 && replyCtx.Expiration == 0 {
+			replyCtx.WithExpiration(duration)
+		}
+		return replyCtx
+	}
 
 	// Apply context based on content type
 	switch c := content.(type) {
 	case *TextContent:
-		c.ContextInfo = replyCtx
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
 	case *ExtendedTextContent:
-		c.ContextInfo = replyCtx
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
 	case *ImageContent:
-		c.ContextInfo = replyCtx
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
 	case *VideoContent:
-		c.ContextInfo = replyCtx
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
 	case *AudioContent:
-		c.ContextInfo = replyCtx
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
 	case *DocumentContent:
-		c.ContextInfo = replyCtx
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
 	case *StickerContent:
-		c.ContextInfo = replyCtx
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
 	case *LocationContent:
-		c.ContextInfo = replyCtx
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
 	case *LiveLocationContent:
-		c.ContextInfo = replyCtx
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
 	case *ContactContent:
-		c.ContextInfo = replyCtx
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
 	case *ContactsArrayContent:
-		c.ContextInfo = replyCtx
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
 	case *PollContent:
-		c.ContextInfo = replyCtx
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
 	case *GroupInviteContent:
-		c.ContextInfo = replyCtx
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
 	case *EventContent:
-		c.ContextInfo = replyCtx
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
+	case *FlowContent:
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
+	case *ButtonsContent:
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
+	case *ListContent:
+		cp := *c
+		cp.ContextInfo = withReply(c.ContextInfo)
+		content = &cp
 	}
-
-	return s.Send(ctx, chat, content, opts...)
+	return content
 }
 
 // Forward forwards a message to another chat with proper forwarding context.
+// It's sent like SendRaw, without the footer.
 func (s *SendService) Forward(ctx context.Context, to types.JID, originalMsg *waE2E.Message, opts ...SendOption) (*SendResult, error) {
-	if s.client == nil {
-		return nil, fmt.Errorf("client not initialized")
-	}
 	if originalMsg == nil {
 		return nil, fmt.Errorf("original message is nil")
 	}
@@ -290,219 +484,227 @@ func (s *SendService) Forward(ctx context.Context, to types.JID, originalMsg *wa
 	// Apply forward context to the appropriate message type
 	applyForwardContext(clonedMsg, forwardCtx)
 
-	cfg := applyOptions(opts)
-	extra := cfg.toSendRequestExtra()
-
-	resp, err := s.client.SendMessage(ctx, to, clonedMsg, extra)
+	result, err := s.SendRaw(ctx, to, clonedMsg, append(opts, WithoutFooter(), asForwarded())...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to forward message: %w", err)
 	}
-
-	result := &SendResult{
-		MessageID: resp.ID,
-		ServerID:  resp.ServerID,
-		Timestamp: resp.Timestamp,
-		Recipient: to,
-		Sender:    resp.Sender,
-		DebugInfo: resp.DebugTimings,
-	}
-
-	// Save forwarded message to database
-	s.saveForwardedMessage(result, clonedMsg)
-
 	return result, nil
 }
 
-// saveForwardedMessage saves a forwarded message to the database.
-func (s *SendService) saveForwardedMessage(result *SendResult, msg *waE2E.Message) {
+// quotedMessage rebuilds a stored message for a reply's quote preview.
+// Media is quoted by reference, so nothing is downloaded or re-uploaded.
+// Returns nil if the message isn't stored.
+func (s *SendService) quotedMessage(ctx context.Context, chat types.JID, msgID types.MessageID) *waE2E.Message {
 	if s.messages == nil {
-		return
+		return nil
+	}
+	stored, err := s.messages.GetContent(string(msgID), s.utils.NormalizeJID(ctx, chat))
+	if err != nil || stored.IsRevoked {
+		return nil
+	}
+	if msg, err := forwardableMessage(stored); err == nil {
+		return msg
 	}
 
-	ownJID := s.utils.OwnJID()
-
-	storeMsg := &store.Message{
-		ID:          string(result.MessageID),
-		ChatJID:     result.Recipient,
-		SenderLID:   ownJID,
-		FromMe:      true,
-		Timestamp:   result.Timestamp,
-		ServerID:    int(result.ServerID),
-		IsForwarded: true,
+	// Types that can't be rebuilt are quoted by their text
+	text := stored.TextContent
+	if text == "" {
+		text = stored.Caption
+	}
+	if text == "" {
+		return nil
 	}
+	return &waE2E.Message{Conversation: proto.String(text)}
+}
 
-	// Extract all available data based on message type
-	switch {
-	case msg.ExtendedTextMessage != nil:
-		ext := msg.ExtendedTextMessage
-		storeMsg.MessageType = "text"
-		storeMsg.TextContent = ext.GetText()
-		storeMsg.PreviewTitle = ext.GetTitle()
-		storeMsg.PreviewDescription = ext.GetDescription()
-		storeMsg.PreviewURL = ext.GetMatchedText() // URL is in MatchedText
-		storeMsg.PreviewMatchedText = ext.GetMatchedText()
-		if ctx := ext.ContextInfo; ctx != nil {
-			storeMsg.ForwardingScore = int(ctx.GetForwardingScore())
-			storeMsg.MentionedJIDs = parseJIDStrings(ctx.MentionedJID)
-		}
+// ForwardByID forwards a stored message, rebuilding it from the database.
+// Media is forwarded by reference to the original upload, without downloading
+// or re-uploading it.
+func (s *SendService) ForwardByID(ctx context.Context, to types.JID, srcChat types.JID, msgID types.MessageID, opts ...SendOption) (*SendResult, error) {
+	if s.messages == nil {
+		return nil, fmt.Errorf("message store not available")
+	}
 
-	case msg.ImageMessage != nil:
-		img := msg.ImageMessage
-		storeMsg.MessageType = "image"
-		storeMsg.Caption = img.GetCaption()
-		storeMsg.Mimetype = img.GetMimetype()
-		storeMsg.MediaURL = img.GetURL()
-		storeMsg.MediaDirectPath = img.GetDirectPath()
-		storeMsg.MediaKey = img.GetMediaKey()
-		storeMsg.FileSHA256 = img.GetFileSHA256()
-		storeMsg.FileEncSHA256 = img.GetFileEncSHA256()
-		storeMsg.FileLength = int64(img.GetFileLength())
-		storeMsg.Width = int(img.GetWidth())
-		storeMsg.Height = int(img.GetHeight())
-		storeMsg.IsViewOnce = img.GetViewOnce()
-		if ctx := img.ContextInfo; ctx != nil {
-			storeMsg.ForwardingScore = int(ctx.GetForwardingScore())
-		}
+	stored, err := s.messages.GetContent(string(msgID), s.utils.NormalizeJID(ctx, srcChat))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
 
-	case msg.VideoMessage != nil:
-		vid := msg.VideoMessage
-		storeMsg.MessageType = "video"
-		storeMsg.Caption = vid.GetCaption()
-		storeMsg.Mimetype = vid.GetMimetype()
-		storeMsg.MediaURL = vid.GetURL()
-		storeMsg.MediaDirectPath = vid.GetDirectPath()
-		storeMsg.MediaKey = vid.GetMediaKey()
-		storeMsg.FileSHA256 = vid.GetFileSHA256()
-		storeMsg.FileEncSHA256 = vid.GetFileEncSHA256()
-		storeMsg.FileLength = int64(vid.GetFileLength())
-		storeMsg.Width = int(vid.GetWidth())
-		storeMsg.Height = int(vid.GetHeight())
-		storeMsg.DurationSeconds = int(vid.GetSeconds())
-		storeMsg.IsGIF = vid.GetGifPlayback()
-		storeMsg.IsViewOnce = vid.GetViewOnce()
-		if ctx := vid.ContextInfo; ctx != nil {
-			storeMsg.ForwardingScore = int(ctx.GetForwardingScore())
-		}
+	msg, err := forwardableMessage(stored)
+	if err != nil {
+		return nil, err
+	}
 
-	case msg.AudioMessage != nil:
-		aud := msg.AudioMessage
-		storeMsg.MessageType = "audio"
-		storeMsg.Mimetype = aud.GetMimetype()
-		storeMsg.MediaURL = aud.GetURL()
-		storeMsg.MediaDirectPath = aud.GetDirectPath()
-		storeMsg.MediaKey = aud.GetMediaKey()
-		storeMsg.FileSHA256 = aud.GetFileSHA256()
-		storeMsg.FileEncSHA256 = aud.GetFileEncSHA256()
-		storeMsg.FileLength = int64(aud.GetFileLength())
-		storeMsg.DurationSeconds = int(aud.GetSeconds())
-		storeMsg.IsPTT = aud.GetPTT()
-		if ctx := aud.ContextInfo; ctx != nil {
-			storeMsg.ForwardingScore = int(ctx.GetForwardingScore())
-		}
+	return s.Forward(ctx, to, msg, opts...)
+}
 
-	case msg.DocumentMessage != nil:
-		doc := msg.DocumentMessage
-		storeMsg.MessageType = "document"
-		storeMsg.Caption = doc.GetCaption()
-		storeMsg.Mimetype = doc.GetMimetype()
-		storeMsg.DisplayName = doc.GetFileName()
-		storeMsg.MediaURL = doc.GetURL()
-		storeMsg.MediaDirectPath = doc.GetDirectPath()
-		storeMsg.MediaKey = doc.GetMediaKey()
-		storeMsg.FileSHA256 = doc.GetFileSHA256()
-		storeMsg.FileEncSHA256 = doc.GetFileEncSHA256()
-		storeMsg.FileLength = int64(doc.GetFileLength())
-		if ctx := doc.ContextInfo; ctx != nil {
-			storeMsg.ForwardingScore = int(ctx.GetForwardingScore())
-		}
+// forwardableMessage rebuilds the message content of a stored message.
+func forwardableMessage(m *store.Message) (*waE2E.Message, error) {
+	if m.IsRevoked {
+		return nil, fmt.Errorf("message %s was deleted", m.ID)
+	}
+	if m.IsViewOnce {
+		return nil, fmt.Errorf("view once message %s can't be forwarded", m.ID)
+	}
 
-	case msg.StickerMessage != nil:
-		stk := msg.StickerMessage
-		storeMsg.MessageType = "sticker"
-		storeMsg.Mimetype = stk.GetMimetype()
-		storeMsg.MediaURL = stk.GetURL()
-		storeMsg.MediaDirectPath = stk.GetDirectPath()
-		storeMsg.MediaKey = stk.GetMediaKey()
-		storeMsg.FileSHA256 = stk.GetFileSHA256()
-		storeMsg.FileEncSHA256 = stk.GetFileEncSHA256()
-		storeMsg.FileLength = int64(stk.GetFileLength())
-		storeMsg.Width = int(stk.GetWidth())
-		storeMsg.Height = int(stk.GetHeight())
-		storeMsg.IsAnimated = stk.GetIsAnimated()
-		if ctx := stk.ContextInfo; ctx != nil {
-			storeMsg.ForwardingScore = int(ctx.GetForwardingScore())
+	switch m.MessageType {
+	case "text":
+		return &waE2E.Message{Conversation: proto.String(m.TextContent)}, nil
+
+	case "extended_text":
+		ext := &waE2E.ExtendedTextMessage{Text: proto.String(m.TextContent)}
+		if m.PreviewMatchedText != "" {
+			ext.MatchedText = proto.String(m.PreviewMatchedText)
+			ext.Title = proto.String(m.PreviewTitle)
+			ext.Description = proto.String(m.PreviewDescription)
+			ext.JPEGThumbnail = m.PreviewThumbnail
 		}
-
-	case msg.LocationMessage != nil:
-		loc := msg.LocationMessage
-		storeMsg.MessageType = "location"
-		storeMsg.Latitude = loc.GetDegreesLatitude()
-		storeMsg.Longitude = loc.GetDegreesLongitude()
-		storeMsg.LocationName = loc.GetName()
-		storeMsg.LocationAddress = loc.GetAddress()
-		storeMsg.LocationURL = loc.GetURL()
-		storeMsg.TextContent = loc.GetComment()
-		if ctx := loc.ContextInfo; ctx != nil {
-			storeMsg.ForwardingScore = int(ctx.GetForwardingScore())
+		return &waE2E.Message{ExtendedTextMessage: ext}, nil
+
+	case "image":
+		return &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+			URL:               proto.String(m.MediaURL),
+			DirectPath:        proto.String(m.MediaDirectPath),
+			MediaKey:          m.MediaKey,
+			MediaKeyTimestamp: proto.Int64(m.MediaKeyTimestamp),
+			FileSHA256:        m.FileSHA256,
+			FileEncSHA256:     m.FileEncSHA256,
+			FileLength:        proto.Uint64(uint64(m.FileLength)),
+			Mimetype:          proto.String(m.Mimetype),
+			Width:             proto.Uint32(uint32(m.Width)),
+			Height:            proto.Uint32(uint32(m.Height)),
+			JPEGThumbnail:     m.Thumbnail,
+			Caption:           optionalString(m.Caption),
+		}}, nil
+
+	case "video":
+		return &waE2E.Message{VideoMessage: &waE2E.VideoMessage{
+			URL:               proto.String(m.MediaURL),
+			DirectPath:        proto.String(m.MediaDirectPath),
+			MediaKey:          m.MediaKey,
+			MediaKeyTimestamp: proto.Int64(m.MediaKeyTimestamp),
+			FileSHA256:        m.FileSHA256,
+			FileEncSHA256:     m.FileEncSHA256,
+			FileLength:        proto.Uint64(uint64(m.FileLength)),
+			Mimetype:          proto.String(m.Mimetype),
+			Width:             proto.Uint32(uint32(m.Width)),
+			Height:            proto.Uint32(uint32(m.Height)),
+			Seconds:           proto.Uint32(uint32(m.DurationSeconds)),
+			GifPlayback:       proto.Bool(m.IsGIF),
+			JPEGThumbnail:     m.Thumbnail,
+			Caption:           optionalString(m.Caption),
+		}}, nil
+
+	case "audio":
+		return &waE2E.Message{AudioMessage: &waE2E.AudioMessage{
+			URL:               proto.String(m.MediaURL),
+			DirectPath:        proto.String(m.MediaDirectPath),
+			MediaKey:          m.MediaKey,
+			MediaKeyTimestamp: proto.Int64(m.MediaKeyTimestamp),
+			FileSHA256:        m.FileSHA256,
+			FileEncSHA256:     m.FileEncSHA256,
+			FileLength:        proto.Uint64(uint64(m.FileLength)),
+			Mimetype:          proto.String(m.Mimetype),
+			Seconds:           proto.Uint32(uint32(m.DurationSeconds)),
+			PTT:               proto.Bool(m.IsPTT),
+			Waveform:          m.Waveform,
+		}}, nil
+
+	case "document":
+		return &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{
+			URL:               proto.String(m.MediaURL),
+			DirectPath:        proto.String(m.MediaDirectPath),
+			MediaKey:          m.MediaKey,
+			MediaKeyTimestamp: proto.Int64(m.MediaKeyTimestamp),
+			FileSHA256:        m.FileSHA256,
+			FileEncSHA256:     m.FileEncSHA256,
+			FileLength:        proto.Uint64(uint64(m.FileLength)),
+			Mimetype:          proto.String(m.Mimetype),
+			FileName:          optionalString(m.DisplayName),
+			JPEGThumbnail:     m.Thumbnail,
+			Caption:           optionalString(m.Caption),
+		}}, nil
+
+	case "sticker":
+		return &waE2E.Message{StickerMessage: &waE2E.StickerMessage{
+			URL:               proto.String(m.MediaURL),
+			DirectPath:        proto.String(m.MediaDirectPath),
+			MediaKey:          m.MediaKey,
+			MediaKeyTimestamp: proto.Int64(m.MediaKeyTimestamp),
+			FileSHA256:        m.FileSHA256,
+			FileEncSHA256:     m.FileEncSHA256,
+			FileLength:        proto.Uint64(uint64(m.FileLength)),
+			Mimetype:          proto.String(m.Mimetype),
+			Width:             proto.Uint32(uint32(m.Width)),
+			Height:            proto.Uint32(uint32(m.Height)),
+			IsAnimated:        proto.Bool(m.IsAnimated),
+		}}, nil
+
+	case "location", "live_location":
+		// Live locations are forwarded as their last known position
+		return &waE2E.Message{LocationMessage: &waE2E.LocationMessage{
+			DegreesLatitude:  proto.Float64(m.Latitude),
+			DegreesLongitude: proto.Float64(m.Longitude),
+			Name:             optionalString(m.LocationName),
+			Address:          optionalString(m.LocationAddress),
+			URL:              optionalString(m.LocationURL),
+		}}, nil
+
+	case "contact":
+		if len(m.VCards) == 0 {
+			return nil, fmt.Errorf("contact message %s has no vCard", m.ID)
 		}
-
-	case msg.LiveLocationMessage != nil:
-		loc := msg.LiveLocationMessage
-		storeMsg.MessageType = "live_location"
-		storeMsg.Latitude = loc.GetDegreesLatitude()
-		storeMsg.Longitude = loc.GetDegreesLongitude()
-		storeMsg.Caption = loc.GetCaption()
-		storeMsg.IsLiveLocation = true
-		storeMsg.AccuracyMeters = int(loc.GetAccuracyInMeters())
-		storeMsg.SpeedMPS = float64(loc.GetSpeedInMps())
-		storeMsg.DegreesClockwise = int(loc.GetDegreesClockwiseFromMagneticNorth())
-		storeMsg.LiveLocationSeq = int(loc.GetSequenceNumber())
-		if ctx := loc.ContextInfo; ctx != nil {
-			storeMsg.ForwardingScore = int(ctx.GetForwardingScore())
+		return &waE2E.Message{ContactMessage: &waE2E.ContactMessage{
+			DisplayName: proto.String(m.DisplayName),
+			Vcard:       proto.String(m.VCards[0]),
+		}}, nil
+
+	case "contacts_array":
+		contacts := make([]*waE2E.ContactMessage, 0, len(m.VCards))
+		for _, vcard := range m.VCards {
+			contacts = append(contacts, &waE2E.ContactMessage{Vcard: proto.String(vcard)})
 		}
+		return &waE2E.Message{ContactsArrayMessage: &waE2E.ContactsArrayMessage{
+			DisplayName: proto.String(m.DisplayName),
+			Contacts:    contacts,
+		}}, nil
+	}
 
-	case msg.ContactMessage != nil:
-		con := msg.ContactMessage
-		storeMsg.MessageType = "contact"
-		storeMsg.DisplayName = con.GetDisplayName()
-		storeMsg.VCards = []string{con.GetVcard()}
-		if ctx := con.ContextInfo; ctx != nil {
-			storeMsg.ForwardingScore = int(ctx.GetForwardingScore())
-		}
+	return nil, fmt.Errorf("message type %q can't be forwarded", m.MessageType)
+}
 
-	case msg.ContactsArrayMessage != nil:
-		arr := msg.ContactsArrayMessage
-		storeMsg.MessageType = "contacts"
-		storeMsg.DisplayName = arr.GetDisplayName()
-		for _, c := range arr.Contacts {
-			storeMsg.VCards = append(storeMsg.VCards, c.GetVcard())
-		}
-		if ctx := arr.ContextInfo; ctx != nil {
-			storeMsg.ForwardingScore = int(ctx.GetForwardingScore())
-		}
+// optionalString returns nil for an empty string so the field is omitted.
+func optionalString(str string) *string {
+	if str == "" {
+		return nil
+	}
+	return proto.String(str)
+}
 
-	default:
-		storeMsg.MessageType = "unknown"
-		storeMsg.ForwardingScore = 1
+// saveForwardedMessage saves a forwarded message to the database.
+// Any content type is extracted as received messages are; the forward flag
+// is set even on types that carry no context to hold it.
+func (s *SendService) saveForwardedMessage(result *SendResult, msg *waE2E.Message) {
+	if s.messages == nil {
+		return
 	}
 
-	if err := s.messages.Put(storeMsg); err != nil {
+	if err := s.messages.Put(s.forwardedStoreMessage(result, msg)); err != nil {
 		s.log.Warnf("Failed to save forwarded message %s: %v", result.MessageID, err)
+		return
 	}
+
+	s.reconcileServerID(result)
 }
 
-// parseJIDStrings converts a slice of JID strings to types.JID
-func parseJIDStrings(jidStrs []string) []types.JID {
-	if len(jidStrs) == 0 {
-		return nil
-	}
-	result := make([]types.JID, 0, len(jidStrs))
-	for _, s := range jidStrs {
-		if jid, err := types.ParseJID(s); err == nil {
-			result = append(result, jid)
-		}
+// forwardedStoreMessage extracts the stored form of a message we forwarded.
+func (s *SendService) forwardedStoreMessage(result *SendResult, msg *waE2E.Message) *store.Message {
+	storeMsg := s.sentStoreMessage(result, msg)
+	storeMsg.IsForwarded = true
+	if storeMsg.ForwardingScore == 0 {
+		storeMsg.ForwardingScore = 1
 	}
-	return result
+	return storeMsg
 }
 
 // applyForwardContext applies forwarding context to the message.
@@ -607,7 +809,7 @@ func (s *SendService) Pin(ctx context.Context, chat types.JID, msgID types.Messa
 		},
 	}
 
-	resp, err := s.client.SendMessage(ctx, chat, pinMsg)
+	resp, err := s.sendToClient(ctx, chat, pinMsg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to pin message: %w", err)
 	}
@@ -659,7 +861,7 @@ func (s *SendService) Unpin(ctx context.Context, chat types.JID, msgID types.Mes
 		},
 	}
 
-	resp, err := s.client.SendMessage(ctx, chat, unpinMsg)
+	resp, err := s.sendToClient(ctx, chat, unpinMsg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unpin message: %w", err)
 	}
@@ -712,7 +914,7 @@ func (s *SendService) Star(ctx context.Context, chat types.JID, msgID types.Mess
 		},
 	}
 
-	resp, err := s.client.SendMessage(ctx, chat, keepMsg)
+	resp, err := s.sendToClient(ctx, chat, keepMsg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to star message: %w", err)
 	}
@@ -760,7 +962,7 @@ func (s *SendService) Unstar(ctx context.Context, chat types.JID, msgID types.Me
 		},
 	}
 
-	resp, err := s.client.SendMessage(ctx, chat, keepMsg)
+	resp, err := s.sendToClient(ctx, chat, keepMsg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unstar message: %w", err)
 	}
@@ -781,3 +983,287 @@ func (s *SendService) Unstar(ctx context.Context, chat types.JID, msgID types.Me
 		DebugInfo: resp.DebugTimings,
 	}, nil
 }
+
+// DeleteForMe deletes a message for this account only (not a revoke).
+// The message is deleted from the local store and the deletion is synced to
+// the other linked devices through app state.
+// synced reports whether the sync succeeded; if not, the deletion is
+// local-only and the reason is logged. err is only returned if the local
+// delete failed.
+func (s *SendService) DeleteForMe(ctx context.Context, chat types.JID, msgID types.MessageID, sender types.JID, fromMe bool) (synced bool, err error) {
+	if s.client == nil {
+		return false, fmt.Errorf("client not initialized")
+	}
+
+	// The message may be stored under either form of the chat JID
+	chats := []types.JID{chat, s.utils.NormalizeJID(ctx, chat)}
+
+	// The action carries the message timestamp; use the stored one if we have it
+	msgTimestamp := time.Now()
+	if s.messages != nil {
+		for _, c := range chats {
+			if stored, err := s.messages.Get(string(msgID), c); err == nil {
+				msgTimestamp = stored.Timestamp
+				break
+			}
+		}
+	}
+
+	isFromMe := "0"
+	if fromMe {
+		isFromMe = "1"
+	}
+	// Same participant semantics as React: only group messages from others carry one
+	participant := "0"
+	if !fromMe && chat.Server == types.GroupServer && !sender.IsEmpty() {
+		participant = sender.ToNonAD().String()
+	}
+
+	patch := appstate.PatchInfo{
+		Type: appstate.WAPatchRegularHigh,
+		Mutations: []appstate.MutationInfo{{
+			Index:   []string{appstate.IndexDeleteMessageForMe, chat.String(), string(msgID), isFromMe, participant},
+			Version: 3,
+			Value: &waSyncAction.SyncActionValue{
+				DeleteMessageForMeAction: &waSyncAction.DeleteMessageForMeAction{
+					DeleteMedia:      proto.Bool(true),
+					MessageTimestamp: proto.Int64(msgTimestamp.Unix()),
+				},
+			},
+		}},
+	}
+
+	if err := s.client.SendAppState(ctx, patch); err != nil {
+		s.log.Warnf("Failed to sync delete for me of message %s, deleting locally only: %v", msgID, err)
+	} else {
+		synced = true
+	}
+
+	if s.messages != nil {
+		for _, c := range chats {
+			if err := s.messages.DeleteWithMedia(string(msgID), c); err != nil {
+				return synced, fmt.Errorf("failed to delete message locally: %w", err)
+			}
+		}
+	}
+
+	return synced, nil
+}
+
+// disappearingTimers are the disappearing message durations WhatsApp accepts.
+var disappearingTimers = []time.Duration{
+	whatsmeow.DisappearingTimerOff,
+	whatsmeow.DisappearingTimer24Hours,
+	whatsmeow.DisappearingTimer7Days,
+	whatsmeow.DisappearingTimer90Days,
+}
+
+// SetDisappearingTimer sets the disappearing message timer of a chat.
+// Valid durations are 0 (off), 24h, 7 days and 90 days.
+func (s *SendService) SetDisappearingTimer(ctx context.Context, chat types.JID, duration time.Duration) error {
+	if s.client == nil {
+		return fmt.Errorf("client not initialized")
+	}
+	if !slices.Contains(disappearingTimers, duration) {
+		return fmt.Errorf("invalid disappearing timer %s: must be one of 0 (off), 24h, 168h (7 days) or 2160h (90 days)", duration)
+	}
+
+	now := time.Now()
+	if err := s.client.SetDisappearingTimer(ctx, chat, duration, now); err != nil {
+		return fmt.Errorf("failed to set disappearing timer: %w", err)
+	}
+
+	if s.chats != nil {
+		if err := s.chats.SetEphemeral(s.utils.NormalizeJID(ctx, chat), uint32(duration.Seconds()), now); err != nil {
+			s.log.Warnf("Failed to save disappearing timer for %s: %v", chat, err)
+		}
+	}
+
+	return nil
+}
+
+// MarkChatUnread marks a chat as unread on all linked devices.
+func (s *SendService) MarkChatUnread(ctx context.Context, chat types.JID) error {
+	return s.markChatRead(ctx, chat, false)
+}
+
+// MarkChatRead marks a chat as read on all linked devices, clearing the
+// unread counters and any unread mark.
+func (s *SendService) MarkChatRead(ctx context.Context, chat types.JID) error {
+	return s.markChatRead(ctx, chat, true)
+}
+
+func (s *SendService) markChatRead(ctx context.Context, chat types.JID, read bool) error {
+	if s.client == nil {
+		return fmt.Errorf("client not initialized")
+	}
+
+	localChat := s.utils.NormalizeJID(ctx, chat)
+
+	lastTimestamp, lastKey := s.lastMessageRange(chat, localChat)
+	patch := appstate.BuildMarkChatAsRead(chat, read, lastTimestamp, lastKey)
+	if err := s.client.SendAppState(ctx, patch); err != nil {
+		return fmt.Errorf("failed to update chat read state: %w", err)
+	}
+
+	if s.chats != nil {
+		var err error
+		if read {
+			err = s.chats.MarkRead(localChat)
+		} else {
+			err = s.chats.SetMarkedAsUnread(localChat, true)
+		}
+		if err != nil {
+			s.log.Warnf("Failed to save read state for %s: %v", chat, err)
+		}
+	}
+
+	return nil
+}
+
+// lastMessageRange returns the timestamp and key of the latest stored message
+// in a chat, which chat-level app state actions use as their message range.
+// Returns zero values if the chat has no stored messages.
+func (s *SendService) lastMessageRange(chat, localChat types.JID) (time.Time, *waCommon.MessageKey) {
+	if s.messages == nil {
+		return time.Time{}, nil
+	}
+	latest, err := s.messages.GetByChat(localChat, 1, 0)
+	if err != nil || len(latest) == 0 {
+		return time.Time{}, nil
+	}
+
+	m := latest[0]
+	key := &waCommon.MessageKey{
+		RemoteJID: proto.String(chat.String()),
+		FromMe:    proto.Bool(m.FromMe),
+		ID:        proto.String(m.ID),
+	}
+	if !m.FromMe && chat.Server == types.GroupServer && !m.SenderLID.IsEmpty() {
+		key.Participant = proto.String(m.SenderLID.String())
+	}
+	return m.Timestamp, key
+}
+
+// MaxPinnedChats is how many chats WhatsApp allows to be pinned at once.
+const MaxPinnedChats = 3
+
+// ArchiveChat archives a chat on all linked devices.
+// Archiving also unpins the chat.
+func (s *SendService) ArchiveChat(ctx context.Context, chat types.JID) error {
+	return s.setArchived(ctx, chat, true)
+}
+
+// UnarchiveChat moves a chat out of the archive on all linked devices.
+func (s *SendService) UnarchiveChat(ctx context.Context, chat types.JID) error {
+	return s.setArchived(ctx, chat, false)
+}
+
+func (s *SendService) setArchived(ctx context.Context, chat types.JID, archive bool) error {
+	if s.client == nil {
+		return fmt.Errorf("client not initialized")
+	}
+
+	localChat := s.utils.NormalizeJID(ctx, chat)
+
+	lastTimestamp, lastKey := s.lastMessageRange(chat, localChat)
+	if err := s.client.SendAppState(ctx, appstate.BuildArchive(chat, archive, lastTimestamp, lastKey)); err != nil {
+		return fmt.Errorf("failed to update archive state: %w", err)
+	}
+
+	if s.chats != nil {
+		if err := s.chats.SetArchived(localChat, archive); err != nil {
+			s.log.Warnf("Failed to save archive state for %s: %v", chat, err)
+		}
+		if archive {
+			if err := s.chats.SetPinned(localChat, false, time.Time{}); err != nil {
+				s.log.Warnf("Failed to save pin state for %s: %v", chat, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// PinChat pins a chat on all linked devices.
+// Fails if MaxPinnedChats other chats are already pinned.
+func (s *SendService) PinChat(ctx context.Context, chat types.JID) error {
+	return s.setChatPinned(ctx, chat, true)
+}
+
+// UnpinChat unpins a chat on all linked devices.
+func (s *SendService) UnpinChat(ctx context.Context, chat types.JID) error {
+	return s.setChatPinned(ctx, chat, false)
+}
+
+func (s *SendService) setChatPinned(ctx context.Context, chat types.JID, pin bool) error {
+	if s.client == nil {
+		return fmt.Errorf("client not initialized")
+	}
+
+	localChat := s.utils.NormalizeJID(ctx, chat)
+
+	if pin && s.chats != nil {
+		pinned, err := s.chats.GetPinnedJIDs()
+		if err != nil {
+			return fmt.Errorf("failed to count pinned chats: %w", err)
+		}
+		if !slices.Contains(pinned, localChat) && len(pinned) >= MaxPinnedChats {
+			return fmt.Errorf("can't pin more than %d chats", MaxPinnedChats)
+		}
+	}
+
+	if err := s.client.SendAppState(ctx, appstate.BuildPin(chat, pin)); err != nil {
+		return fmt.Errorf("failed to update pin state: %w", err)
+	}
+
+	if s.chats != nil {
+		if err := s.chats.SetPinned(localChat, pin, time.Now()); err != nil {
+			s.log.Warnf("Failed to save pin state for %s: %v", chat, err)
+		}
+	}
+
+	return nil
+}
+
+// MuteChat mutes a chat on all linked devices until the given time.
+// A zero until mutes the chat forever.
+func (s *SendService) MuteChat(ctx context.Context, chat types.JID, until time.Time) error {
+	if s.client == nil {
+		return fmt.Errorf("client not initialized")
+	}
+	if !until.IsZero() && !until.After(time.Now()) {
+		return fmt.Errorf("mute end %s is in the past", until)
+	}
+
+	var muteEnd *int64
+	stored := time.Unix(-1, 0) // Same as a forever mute synced from another device
+	if !until.IsZero() {
+		muteEnd = proto.Int64(until.UnixMilli())
+		stored = until
+	}
+
+	return s.setMuted(ctx, chat, appstate.BuildMuteAbs(chat, true, muteEnd), stored)
+}
+
+// UnmuteChat unmutes a chat on all linked devices.
+func (s *SendService) UnmuteChat(ctx context.Context, chat types.JID) error {
+	if s.client == nil {
+		return fmt.Errorf("client not initialized")
+	}
+	return s.setMuted(ctx, chat, appstate.BuildMuteAbs(chat, false, nil), time.Time{})
+}
+
+func (s *SendService) setMuted(ctx context.Context, chat types.JID, patch appstate.PatchInfo, mutedUntil time.Time) error {
+	if err := s.client.SendAppState(ctx, patch); err != nil {
+		return fmt.Errorf("failed to update mute state: %w", err)
+	}
+
+	if s.chats != nil {
+		if err := s.chats.SetMuted(s.utils.NormalizeJID(ctx, chat), mutedUntil); err != nil {
+			s.log.Warnf("Failed to save mute state for %s: %v", chat, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,285 @@
+package send
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	"orion-agent/internal/data/extract"
+	"orion-agent/internal/data/store"
+)
+
+func TestReplyContentCopiesCallerContent(t *testing.T) {
+	db := newTestStore(t)
+	s := newTestSendService(t, db)
+	ctx := context.Background()
+
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+	alice := types.NewJID("900000000000003", types.HiddenUserServer)
+	if err := store.NewChatStore(db).Put(&store.Chat{JID: chat, ChatType: store.ChatTypeUser, EphemeralDuration: 86400}); err != nil {
+		t.Fatal(err)
+	}
+
+	content := Text("see above").WithContext(MentionContext(alice))
+	replied := s.replyContent(ctx, chat, "ORIG", chat, content, nil)
+
+	if content.ContextInfo.QuotedMessageID != "" || content.ContextInfo.Expiration != 0 {
+		t.Errorf("caller's context changed: %+v", content.ContextInfo)
+	}
+	got := replied.(*TextContent).ContextInfo
+	if got == content.ContextInfo {
+		t.Fatal("reply shares the caller's context")
+	}
+	if got.QuotedMessageID != "ORIG" || len(got.MentionedJIDs) != 1 || got.MentionedJIDs[0] != alice {
+		t.Errorf("reply context = %+v, want the quote and the caller's mention", got)
+	}
+
+	// The reply disappears with the chat
+	msg, err := s.buildMessage(ctx, chat, replied, applyOptions(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := msg.GetExtendedTextMessage().GetContextInfo()
+	if info.GetStanzaID() != "ORIG" || info.GetExpiration() != 86400 {
+		t.Errorf("built context = %v, want stanza ORIG and expiration 86400", info)
+	}
+
+	// An opt-out leaves the expiration off
+	replied = s.replyContent(ctx, chat, "ORIG", chat, Text("no timer"), []SendOption{WithoutAutoEphemeral()})
+	if exp := replied.(*TextContent).ContextInfo.Expiration; exp != 0 {
+		t.Errorf("expiration %d with auto ephemeral off, want 0", exp)
+	}
+}
+
+func TestSendDisappearingImageKeepsCallerContext(t *testing.T) {
+	s := newTestSendService(t, newTestStore(t))
+	ctx := NewContext()
+	image := Image([]byte("not sent"), "image/jpeg").WithContext(ctx)
+
+	// Without a client the send fails, after the expiration is applied
+	if _, err := s.SendDisappearingImage(context.Background(), types.NewJID("900000000000002", types.HiddenUserServer), image, 60); err == nil {
+		t.Fatal("send without a client succeeded")
+	}
+	if image.ContextInfo != ctx || ctx.Expiration != 0 {
+		t.Errorf("caller's context changed: %+v", image.ContextInfo)
+	}
+}
+
+func TestForwardedStoreMessageAnyType(t *testing.T) {
+	s := newTestSendService(t, newTestStore(t))
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+
+	for _, tc := range []struct {
+		msg         *waE2E.Message
+		messageType string
+		score       int
+	}{
+		{&waE2E.Message{PollCreationMessage: &waE2E.PollCreationMessage{
+			Name:    proto.String("Lunch?"),
+			Options: []*waE2E.PollCreationMessage_Option{{OptionName: proto.String("Pizza")}},
+		}}, "poll", 1},
+		{&waE2E.Message{EventMessage: &waE2E.EventMessage{Name: proto.String("Launch")}}, "event", 1},
+		{&waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+			Text:        proto.String("hello"),
+			ContextInfo: &waE2E.ContextInfo{IsForwarded: proto.Bool(true), ForwardingScore: proto.Uint32(5)},
+		}}, "extended_text", 5},
+	} {
+		m := s.forwardedStoreMessage(&SendResult{MessageID: "FWD", Recipient: chat, Timestamp: time.Now()}, tc.msg)
+		if m.MessageType != tc.messageType || !m.IsForwarded || m.ForwardingScore != tc.score || !m.FromMe || m.ChatJID != chat {
+			t.Errorf("stored as %s (forwarded %v, score %d, from me %v, chat %s), want %s forwarded with score %d",
+				m.MessageType, m.IsForwarded, m.ForwardingScore, m.FromMe, m.ChatJID, tc.messageType, tc.score)
+		}
+	}
+}
+
+func TestReplyContentEphemeral(t *testing.T) {
+	db := newTestStore(t)
+	s := newTestSendService(t, db)
+	ctx := context.Background()
+
+	disappearing := types.NewJID("900000000000002", types.HiddenUserServer)
+	plain := types.NewJID("900000000000003", types.HiddenUserServer)
+	chats := store.NewChatStore(db)
+	for _, c := range []*store.Chat{
+		{JID: disappearing, ChatType: store.ChatTypeUser, EphemeralDuration: 604800},
+		{JID: plain, ChatType: store.ChatTypeUser},
+	} {
+		if err := chats.Put(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	image := Image([]byte("img"), "image/jpeg")
+	image.uploaded = &whatsmeow.UploadResponse{URL: "https://mmg.whatsapp.net/img"}
+
+	for _, tc := range []struct {
+		name    string
+		chat    types.JID
+		content Content
+		want    uint32
+	}{
+		{"media", disappearing, image, 604800},
+		{"location", disappearing, Location(1, 2, "", ""), 604800},
+		// An expiration the caller set wins over the chat's
+		{"explicit", disappearing, Text("hi").WithContext(NewContext().WithExpiration(86400)), 86400},
+		{"no timer", plain, Text("hi"), 0},
+	} {
+		replied := s.replyContent(ctx, tc.chat, "ORIG", tc.chat, tc.content, nil)
+		msg, err := replied.ToMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		info := messageContextInfo(msg)
+		if info.GetStanzaID() != "ORIG" || info.GetExpiration() != tc.want {
+			t.Errorf("%s: context = %v, want stanza ORIG and expiration %d", tc.name, info, tc.want)
+		}
+	}
+}
+
+func TestEditCaption(t *testing.T) {
+	db := newTestStore(t)
+	s := newTestSendService(t, db)
+	sent := stubClient(s)
+	ctx := context.Background()
+	messages := store.NewMessageStore(db)
+
+	lid := types.NewJID("900000000000002", types.HiddenUserServer)
+	pn := types.NewJID("15550000002", types.DefaultUserServer)
+	s.utils.StoreMappingFromEvent(pn, lid)
+	for _, m := range []*store.Message{
+		{ID: "MINE", FromMe: true, Caption: "old"},
+		{ID: "THEIRS", Caption: "theirs"},
+	} {
+		m.ChatJID, m.SenderLID, m.Timestamp, m.MessageType = lid, lid, time.Now(), "image"
+		m.MediaDirectPath, m.Mimetype = "/v/t62.7118-24/1", "image/jpeg"
+		if err := messages.Put(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The chat is given by phone number, the message is stored under the LID
+	if err := s.EditCaption(ctx, pn, "MINE", "new"); err != nil {
+		t.Fatalf("EditCaption: %v", err)
+	}
+	if len(*sent) != 1 {
+		t.Fatalf("%d messages sent, want the edit", len(*sent))
+	}
+	edit := (*sent)[0].msg.GetProtocolMessage()
+	if edit.GetKey().GetID() != "MINE" || edit.GetEditedMessage().GetImageMessage().GetCaption() != "new" {
+		t.Errorf("edit = %v", edit)
+	}
+	if got, err := messages.Get("MINE", lid); err != nil || got.Caption != "new" {
+		t.Errorf("stored caption = %q, %v; want new", got.Caption, err)
+	}
+
+	// Others' messages can't be edited
+	if err := s.EditCaption(ctx, lid, "THEIRS", "new"); err == nil {
+		t.Error("edited a message we didn't send")
+	}
+	if len(*sent) != 1 {
+		t.Errorf("edit of another's message was sent")
+	}
+}
+
+func TestQuotedMediaKeepsThumbnail(t *testing.T) {
+	db := newTestStore(t)
+	s := newTestSendService(t, db)
+	ctx := context.Background()
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+	thumb := []byte("\xff\xd8jpeg")
+
+	for _, msg := range []*waE2E.Message{
+		{ImageMessage: &waE2E.ImageMessage{DirectPath: proto.String("/i"), Mimetype: proto.String("image/jpeg"), JPEGThumbnail: thumb}},
+		{VideoMessage: &waE2E.VideoMessage{DirectPath: proto.String("/v"), Mimetype: proto.String("video/mp4"), JPEGThumbnail: thumb}},
+		{DocumentMessage: &waE2E.DocumentMessage{DirectPath: proto.String("/d"), Mimetype: proto.String("application/pdf"), JPEGThumbnail: thumb}},
+	} {
+		stored := extract.MessageFromEvent(&events.Message{
+			Info:    types.MessageInfo{ID: "Q1", MessageSource: types.MessageSource{Chat: chat, Sender: chat}, Timestamp: time.Now()},
+			Message: msg,
+		})
+		if err := store.NewMessageStore(db).Put(stored); err != nil {
+			t.Fatal(err)
+		}
+
+		quoted := s.quotedMessage(ctx, chat, "Q1")
+		var got []byte
+		switch {
+		case quoted.GetImageMessage() != nil:
+			got = quoted.GetImageMessage().GetJPEGThumbnail()
+		case quoted.GetVideoMessage() != nil:
+			got = quoted.GetVideoMessage().GetJPEGThumbnail()
+		case quoted.GetDocumentMessage() != nil:
+			got = quoted.GetDocumentMessage().GetJPEGThumbnail()
+		}
+		if string(got) != string(thumb) {
+			t.Errorf("quote of %s has thumbnail %q, want the original", stored.MessageType, got)
+		}
+		if _, err := db.Exec(`DELETE FROM orion_messages`); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestForwardSendsLikeSendRaw(t *testing.T) {
+	db := newTestStore(t)
+	s := newTestSendService(t, db)
+	sent := stubClient(s)
+	s.SetFooter("footer", nil)
+	ctx := context.Background()
+
+	disappearing := types.NewJID("900000000000002", types.HiddenUserServer)
+	announce := types.NewJID("120363000000000001", types.GroupServer)
+	if err := store.NewChatStore(db).Put(&store.Chat{JID: disappearing, ChatType: store.ChatTypeUser, EphemeralDuration: 86400}); err != nil {
+		t.Fatal(err)
+	}
+	groups := store.NewGroupStore(db)
+	if err := groups.Put(&store.Group{JID: announce, IsAnnounce: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := groups.PutParticipant(&store.GroupParticipant{GroupJID: announce, MemberLID: s.utils.OwnJID()}); err != nil {
+		t.Fatal(err)
+	}
+
+	image := &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+		URL: proto.String("https://mmg.whatsapp.net/img"), Mimetype: proto.String("image/jpeg"), Caption: proto.String("look"),
+	}}
+	result, err := s.Forward(ctx, disappearing, image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*sent) != 1 {
+		t.Fatalf("%d messages sent, want 1", len(*sent))
+	}
+	got := (*sent)[0].msg.GetImageMessage()
+	if !got.GetContextInfo().GetIsForwarded() || got.GetContextInfo().GetExpiration() != 86400 || got.GetCaption() != "look" {
+		t.Errorf("forwarded %v, want it forwarded with the chat's timer and no footer", got)
+	}
+	stored, err := store.NewMessageStore(db).GetContent(result.MessageID, disappearing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stored.IsForwarded || !stored.IsEphemeral || stored.MessageType != "image" {
+		t.Errorf("stored as %s (forwarded %v, ephemeral %v)", stored.MessageType, stored.IsForwarded, stored.IsEphemeral)
+	}
+
+	if _, err := s.Forward(ctx, announce, image); !errors.Is(err, ErrCannotSendToAnnounceGroup) {
+		t.Errorf("Forward to announce group as member = %v, want ErrCannotSendToAnnounceGroup", err)
+	}
+
+	s.sendToClient = func(ctx context.Context, to types.JID, msg *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+		return whatsmeow.SendResponse{}, whatsmeow.ErrNotConnected
+	}
+	if _, err := s.Forward(ctx, disappearing, image); err == nil {
+		t.Fatal("forward without a connection succeeded")
+	}
+	if failed, err := store.NewFailedSendStore(db).GetAll(10); err != nil || len(failed) != 1 {
+		t.Errorf("failed sends = %v, %v; want the forward recorded", failed, err)
+	}
+}
@@ -0,0 +1,105 @@
+package send
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrQueueFull is returned when the outbox is at its configured capacity.
+var ErrQueueFull = errors.New("send queue is full")
+
+// MediaQueue reports the depth of a media download queue.
+type MediaQueue interface {
+	QueueDepth() int
+}
+
+// RateLimiter reports how long the next rate-limited query waits.
+type RateLimiter interface {
+	RateLimitWait() time.Duration
+}
+
+// QueueStats reports outgoing queue depths for backpressure decisions.
+type QueueStats struct {
+	InFlight       int           // Sends currently in progress
+	PendingRetries int           // Failed sends awaiting retry
+	OutboxSize     int           // InFlight + PendingRetries
+	MaxOutboxSize  int           // 0 = no limit
+	Scheduled      int           // Scheduled messages not yet sent
+	RateLimitWait  time.Duration // Wait for the next rate-limited query, if a limiter is attached
+	MediaQueue     int           // Pending media downloads, if a media queue is attached
+}
+
+// SetMaxOutboxSize sets the outbox capacity above which Send returns
+// ErrQueueFull. 0 disables the limit.
+func (s *SendService) SetMaxOutboxSize(max int) {
+	s.maxOutbox.Store(int64(max))
+}
+
+// SetMediaQueue attaches a media queue to report in QueueStats.
+func (s *SendService) SetMediaQueue(q MediaQueue) {
+	s.mediaQueue = q
+}
+
+// SetRateLimiter attaches a rate limiter to report in QueueStats.
+func (s *SendService) SetRateLimiter(l RateLimiter) {
+	s.rateLimiter = l
+}
+
+// QueueStats returns the current queue depths.
+func (s *SendService) QueueStats() QueueStats {
+	stats := QueueStats{
+		InFlight:       int(s.inFlight.Load()),
+		PendingRetries: s.pendingRetryCount(),
+		MaxOutboxSize:  int(s.maxOutbox.Load()),
+	}
+	stats.OutboxSize = stats.InFlight + stats.PendingRetries
+	if s.scheduled != nil {
+		if count, err := s.scheduled.CountPending(); err == nil {
+			stats.Scheduled = count
+		} else {
+			s.log.Warnf("Failed to count scheduled messages: %v", err)
+		}
+	}
+	if s.rateLimiter != nil {
+		stats.RateLimitWait = s.rateLimiter.RateLimitWait()
+	}
+	if s.mediaQueue != nil {
+		stats.MediaQueue = s.mediaQueue.QueueDepth()
+	}
+	return stats
+}
+
+// acquireOutbox reserves an outbox slot for a send.
+// The returned release func must be called when the send completes.
+func (s *SendService) acquireOutbox() (func(), error) {
+	inFlight := s.inFlight.Add(1)
+	release := func() { s.inFlight.Add(-1) }
+
+	if max := s.maxOutbox.Load(); max > 0 && inFlight+int64(s.pendingRetryCount()) > max {
+		release()
+		return nil, ErrQueueFull
+	}
+	return release, nil
+}
+
+// pendingRetryCount returns the number of failed sends awaiting retry.
+// Only retryable ones count; permanent failures never leave the table on
+// their own, so counting them would block sends for good. The count is
+// read from the store once and kept up to date in memory after that.
+func (s *SendService) pendingRetryCount() int {
+	if s.failedSends == nil {
+		return 0
+	}
+	s.pendingOnce.Do(s.loadPendingRetries)
+	return int(s.pendingRetries.Load())
+}
+
+// loadPendingRetries reads the number of retryable failed sends.
+func (s *SendService) loadPendingRetries() {
+	count, err := s.failedSends.CountRetryable(MaxFailedSendAttempts)
+	if err != nil {
+		s.log.Warnf("Failed to count failed sends: %v", err)
+		return
+	}
+	s.pendingRetries.Store(int64(count))
+}
@@ -0,0 +1,153 @@
+package send
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	wastore "go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+	"google.golang.org/protobuf/proto"
+
+	"orion-agent/internal/data/store"
+	"orion-agent/internal/utils"
+)
+
+// newTestStore opens a Store on an in-memory database.
+func newTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := store.NewWithOptions(":memory:", store.Options{MaxOpenConns: 1, MaxIdleConns: 1}, waLog.Noop)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// newTestSendService creates a SendService with no client, using db for
+// the stores tests need.
+func newTestSendService(t *testing.T, db *store.Store) *SendService {
+	t.Helper()
+	return NewSendService(nil, utils.New(store.NewContactStore(db), nil), Stores{
+		Messages:    store.NewMessageStore(db),
+		Chats:       store.NewChatStore(db),
+		Groups:      store.NewGroupStore(db),
+		FailedSends: store.NewFailedSendStore(db),
+		Scheduled:   store.NewScheduledMessageStore(db),
+	}, waLog.Noop)
+}
+
+// sentMessage is a message sent through a stubbed client.
+type sentMessage struct {
+	to    types.JID
+	msg   *waE2E.Message
+	extra whatsmeow.SendRequestExtra
+}
+
+// stubClient gives s a client whose sends succeed and are recorded in the
+// returned slice.
+func stubClient(s *SendService) *[]sentMessage {
+	own := types.NewJID("900000000000001", types.DefaultUserServer)
+	client := &whatsmeow.Client{Store: &wastore.Device{ID: &own}}
+	s.SetClient(client)
+	s.utils.SetClient(client)
+
+	var sent []sentMessage
+	s.sendToClient = func(ctx context.Context, to types.JID, msg *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+		m := sentMessage{to: to, msg: msg}
+		if len(extra) > 0 {
+			m.extra = extra[0]
+		}
+		sent = append(sent, m)
+		return whatsmeow.SendResponse{ID: m.extra.ID, Timestamp: time.Now(), Sender: own}, nil
+	}
+	return &sent
+}
+
+func TestAcquireOutboxRejectsWhenFull(t *testing.T) {
+	s := newTestSendService(t, newTestStore(t))
+	s.SetMaxOutboxSize(2)
+
+	first, err := s.acquireOutbox()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.acquireOutbox()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.acquireOutbox(); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("third send: got %v, want ErrQueueFull", err)
+	}
+	if stats := s.QueueStats(); stats.InFlight != 2 || stats.OutboxSize != 2 {
+		t.Errorf("stats after rejection = %+v, want 2 in flight", stats)
+	}
+
+	first()
+	third, err := s.acquireOutbox()
+	if err != nil {
+		t.Fatalf("send after release: %v", err)
+	}
+	second()
+	third()
+
+	// No limit
+	s.SetMaxOutboxSize(0)
+	for range 10 {
+		if _, err := s.acquireOutbox(); err != nil {
+			t.Fatalf("unlimited outbox: %v", err)
+		}
+	}
+}
+
+func TestPendingRetriesCountTowardOutbox(t *testing.T) {
+	db := newTestStore(t)
+	chat := types.NewJID("123", types.DefaultUserServer)
+	msg := &waE2E.Message{Conversation: proto.String("hi")}
+
+	// A failure persisted before the service started
+	earlier := newTestSendService(t, db)
+	earlier.recordFailedSend(chat, "A", msg, "text", whatsmeow.ErrNotConnected)
+
+	s := newTestSendService(t, db)
+	s.SetMaxOutboxSize(2)
+	if n := s.QueueStats().PendingRetries; n != 1 {
+		t.Fatalf("pending retries = %d, want 1 loaded from the store", n)
+	}
+
+	// Permanent failures are never retried, so they don't take a slot
+	for range 5 {
+		s.recordFailedSend(chat, "B", msg, "text", whatsmeow.ErrNotInGroup)
+	}
+	release, err := s.acquireOutbox()
+	if err != nil {
+		t.Fatalf("permanent failures blocked sends: %v", err)
+	}
+	release()
+
+	s.recordFailedSend(chat, "C", msg, "text", whatsmeow.ErrNotConnected)
+	if n := s.QueueStats().PendingRetries; n != 2 {
+		t.Fatalf("pending retries = %d, want 2", n)
+	}
+	if _, err := s.acquireOutbox(); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("got %v, want ErrQueueFull with the outbox taken by retries", err)
+	}
+}
+
+func TestQueueStatsReportsScheduled(t *testing.T) {
+	db := newTestStore(t)
+	s := newTestSendService(t, db)
+	scheduled := store.NewScheduledMessageStore(db)
+	for _, id := range []string{"S1", "S2"} {
+		if err := scheduled.Put(&store.ScheduledMessage{ID: id, ChatJID: types.NewJID("123", types.DefaultUserServer), Payload: []byte{0}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := s.QueueStats().Scheduled; n != 2 {
+		t.Errorf("scheduled = %d, want 2", n)
+	}
+}
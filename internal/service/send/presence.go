@@ -8,55 +8,72 @@ import (
 	"go.mau.fi/whatsmeow/types"
 )
 
-// SetAvailable marks the user as online.
-func (s *SendService) SetAvailable(ctx context.Context) error {
+// SetOnline marks the user as online.
+func (s *SendService) SetOnline(ctx context.Context) error {
 	if s.client == nil {
 		return fmt.Errorf("client not initialized")
 	}
-	return s.client.SendPresence(ctx, types.PresenceAvailable)
+	if err := s.client.SendPresence(ctx, types.PresenceAvailable); err != nil {
+		return fmt.Errorf("failed to set online: %w", err)
+	}
+	return nil
 }
 
-// SetUnavailable marks the user as offline.
-func (s *SendService) SetUnavailable(ctx context.Context) error {
+// SetOffline marks the user as offline.
+func (s *SendService) SetOffline(ctx context.Context) error {
 	if s.client == nil {
 		return fmt.Errorf("client not initialized")
 	}
-	return s.client.SendPresence(ctx, types.PresenceUnavailable)
+	if err := s.client.SendPresence(ctx, types.PresenceUnavailable); err != nil {
+		return fmt.Errorf("failed to set offline: %w", err)
+	}
+	return nil
+}
+
+// SetAvailable marks the user as online. Same as SetOnline.
+func (s *SendService) SetAvailable(ctx context.Context) error {
+	return s.SetOnline(ctx)
+}
+
+// SetUnavailable marks the user as offline. Same as SetOffline.
+func (s *SendService) SetUnavailable(ctx context.Context) error {
+	return s.SetOffline(ctx)
 }
 
 // StartTyping shows the typing indicator in a chat.
 func (s *SendService) StartTyping(ctx context.Context, chat types.JID) error {
-	if s.client == nil {
-		return fmt.Errorf("client not initialized")
-	}
-	return s.client.SendChatPresence(ctx, chat, types.ChatPresenceComposing, types.ChatPresenceMediaText)
+	return s.sendChatPresence(ctx, chat, types.ChatPresenceComposing, types.ChatPresenceMediaText)
 }
 
 // StopTyping clears the typing indicator in a chat.
 func (s *SendService) StopTyping(ctx context.Context, chat types.JID) error {
-	if s.client == nil {
-		return fmt.Errorf("client not initialized")
-	}
-	return s.client.SendChatPresence(ctx, chat, types.ChatPresencePaused, types.ChatPresenceMediaText)
+	return s.sendChatPresence(ctx, chat, types.ChatPresencePaused, types.ChatPresenceMediaText)
 }
 
 // StartRecording shows the recording indicator in a chat.
 func (s *SendService) StartRecording(ctx context.Context, chat types.JID) error {
-	if s.client == nil {
-		return fmt.Errorf("client not initialized")
-	}
-	return s.client.SendChatPresence(ctx, chat, types.ChatPresenceComposing, types.ChatPresenceMediaAudio)
+	return s.sendChatPresence(ctx, chat, types.ChatPresenceComposing, types.ChatPresenceMediaAudio)
 }
 
 // StopRecording clears the recording indicator in a chat.
 func (s *SendService) StopRecording(ctx context.Context, chat types.JID) error {
+	return s.sendChatPresence(ctx, chat, types.ChatPresencePaused, types.ChatPresenceMediaAudio)
+}
+
+// sendChatPresence sends a typing/recording state to a chat.
+func (s *SendService) sendChatPresence(ctx context.Context, chat types.JID, state types.ChatPresence, media types.ChatPresenceMedia) error {
 	if s.client == nil {
 		return fmt.Errorf("client not initialized")
 	}
-	return s.client.SendChatPresence(ctx, chat, types.ChatPresencePaused, types.ChatPresenceMediaAudio)
+	if err := s.client.SendChatPresence(ctx, chat, state, media); err != nil {
+		return fmt.Errorf("failed to send chat presence: %w", err)
+	}
+	return nil
 }
 
 // MarkRead marks messages as read.
+// In groups, sender is the participant who sent the messages and is required;
+// in DMs it is ignored, since the chat identifies the sender.
 func (s *SendService) MarkRead(ctx context.Context, chat types.JID, sender types.JID, messageIDs ...types.MessageID) error {
 	if s.client == nil {
 		return fmt.Errorf("client not initialized")
@@ -64,7 +81,20 @@ func (s *SendService) MarkRead(ctx context.Context, chat types.JID, sender types
 	if len(messageIDs) == 0 {
 		return nil
 	}
-	return s.client.MarkRead(ctx, messageIDs, time.Now(), chat, sender)
+
+	// Same sender semantics as React: only group messages carry a participant
+	if chat.Server == types.GroupServer {
+		if sender.IsEmpty() {
+			return fmt.Errorf("sender is required to mark group messages as read")
+		}
+	} else {
+		sender = types.EmptyJID
+	}
+
+	if err := s.client.MarkRead(ctx, messageIDs, time.Now(), chat, sender); err != nil {
+		return fmt.Errorf("failed to mark messages as read: %w", err)
+	}
+	return nil
 }
 
 // MarkReadSingle is a convenience method for marking a single message as read.
@@ -0,0 +1,136 @@
+package send
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	"orion-agent/internal/data/extract"
+	"orion-agent/internal/data/store"
+	"orion-agent/internal/infra/metrics"
+)
+
+// SendRaw sends a pre-built message, bypassing the Content abstraction.
+// Options are applied as in Send; the message is cloned before any
+// context is merged, so the caller's message is left untouched.
+func (s *SendService) SendRaw(ctx context.Context, chat types.JID, msg *waE2E.Message, opts ...SendOption) (result *SendResult, err error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("client not initialized")
+	}
+	if msg == nil || proto.Size(msg) == 0 {
+		return nil, fmt.Errorf("message is empty")
+	}
+
+	start := time.Now()
+	defer func() { metrics.ObserveSend(extract.MessageType(msg), time.Since(start), err) }()
+
+	release, err := s.acquireOutbox()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	cfg := applyOptions(opts)
+	if !cfg.NoAnnounceCheck && !s.canSendToGroup(ctx, chat) {
+		return nil, ErrCannotSendToAnnounceGroup
+	}
+	if cfg.IdempotencyKey != "" {
+		prior, err := s.reserveIdempotencyKey(chat, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if prior != nil {
+			return prior, nil
+		}
+		defer func() { s.settleIdempotencyKey(cfg, result) }()
+	}
+	extra := cfg.toSendRequestExtra()
+
+	msg = proto.Clone(msg).(*waE2E.Message)
+	if !cfg.NoFooter {
+		s.applyFooter(msg)
+	}
+	if !cfg.NoAutoEphemeral && hasMedia(msg) {
+		s.applyChatEphemeral(ctx, chat, msg)
+	}
+	if cfg.RawContextInfo != nil {
+		mergeRawContextInfo(msg, cfg.RawContextInfo)
+	}
+	if cfg.MessageAssociation != nil {
+		setMessageAssociation(msg, cfg.MessageAssociation)
+	}
+
+	if extra.ID == "" {
+		extra.ID = s.client.GenerateMessageID()
+	}
+
+	resp, err := s.sendMessage(ctx, chat, msg, extra, cfg)
+	if err != nil {
+		if !cfg.NoFailedSendRecord {
+			s.recordFailedSend(chat, extra.ID, msg, "", err)
+		}
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+
+	result = &SendResult{
+		MessageID: resp.ID,
+		ServerID:  resp.ServerID,
+		Timestamp: resp.Timestamp,
+		Recipient: chat,
+		Sender:    resp.Sender,
+		DebugInfo: resp.DebugTimings,
+	}
+
+	if cfg.NoSave {
+		return result, nil
+	}
+	if cfg.Forwarded {
+		s.saveForwardedMessage(result, msg)
+	} else {
+		s.saveRawMessage(result, msg)
+	}
+	return result, nil
+}
+
+// hasMedia reports whether msg carries an uploaded file.
+func hasMedia(msg *waE2E.Message) bool {
+	return msg.ImageMessage != nil || msg.VideoMessage != nil || msg.PtvMessage != nil ||
+		msg.AudioMessage != nil || msg.DocumentMessage != nil || msg.StickerMessage != nil
+}
+
+// saveRawMessage saves a sent message by extracting fields from the protobuf,
+// the same way received messages are extracted.
+func (s *SendService) saveRawMessage(result *SendResult, msg *waE2E.Message) {
+	if s.messages == nil {
+		return
+	}
+
+	if err := s.messages.Put(s.sentStoreMessage(result, msg)); err != nil {
+		s.log.Warnf("Failed to save sent message %s: %v", result.MessageID, err)
+		return
+	}
+
+	s.reconcileServerID(result)
+}
+
+// sentStoreMessage extracts the stored form of a message we sent.
+func (s *SendService) sentStoreMessage(result *SendResult, msg *waE2E.Message) *store.Message {
+	return extract.MessageFromEvent(&events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{
+				Chat:     result.Recipient,
+				Sender:   s.utils.OwnJID(),
+				IsFromMe: true,
+			},
+			ID:        result.MessageID,
+			ServerID:  result.ServerID,
+			Timestamp: result.Timestamp,
+		},
+		Message: msg,
+	})
+}
@@ -0,0 +1,268 @@
+package send
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	"orion-agent/internal/data/store"
+)
+
+// ErrScheduleNotFound is returned when cancelling a message that isn't pending.
+var ErrScheduleNotFound = errors.New("no pending scheduled message with this ID")
+
+// Scheduler settings.
+const (
+	schedulePollInterval = 10 * time.Second
+	scheduleBatchSize    = 50
+)
+
+// scheduler tracks the background loop that sends due messages.
+type scheduler struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Schedule queues content to be sent to chat at the given time.
+// The message is built now and sent by the scheduler loop once due (see
+// StartScheduler), including after a restart. Media is uploaded when the
+// message is due, since an upload made now could expire before then.
+// Returns the schedule ID, which is also the ID the message is sent with.
+func (s *SendService) Schedule(ctx context.Context, chat types.JID, content Content, at time.Time) (string, error) {
+	if s.client == nil {
+		return "", fmt.Errorf("client not initialized")
+	}
+	if s.scheduled == nil {
+		return "", fmt.Errorf("scheduled message store not configured")
+	}
+	if at.IsZero() {
+		return "", fmt.Errorf("send time is required")
+	}
+
+	if video, ok := content.(*VideoContent); ok {
+		s.ensureVideoThumbnail(ctx, video)
+	}
+
+	msg, media, mediaType, err := buildScheduled(ctx, content)
+	if err != nil {
+		return "", fmt.Errorf("failed to build message: %w", err)
+	}
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize message: %w", err)
+	}
+
+	scheduled := &store.ScheduledMessage{
+		ID:          string(s.client.GenerateMessageID()),
+		ChatJID:     chat,
+		MessageType: content.MessageType(),
+		Payload:     payload,
+		Media:       media,
+		MediaType:   string(mediaType),
+		FireAt:      at,
+	}
+	if err := s.scheduled.Put(scheduled); err != nil {
+		return "", fmt.Errorf("failed to save scheduled message: %w", err)
+	}
+	return scheduled.ID, nil
+}
+
+// buildScheduled builds content's message without uploading its media.
+// The upload fields are left empty and the media returned, to be uploaded
+// by setUploadFields when the message is due. Media the caller already
+// uploaded is kept as is.
+func buildScheduled(ctx context.Context, content Content) (*waE2E.Message, []byte, whatsmeow.MediaType, error) {
+	var data []byte
+	var mediaType whatsmeow.MediaType
+	var uploaded **whatsmeow.UploadResponse
+	switch c := content.(type) {
+	case *ImageContent:
+		if err := c.applyStripMetadata(); err != nil {
+			return nil, nil, "", err
+		}
+		data, mediaType, uploaded = c.Data, whatsmeow.MediaImage, &c.uploaded
+	case *VideoContent:
+		data, mediaType, uploaded = c.Data, whatsmeow.MediaVideo, &c.uploaded
+	case *AudioContent:
+		c.ensureWaveform(ctx)
+		data, mediaType, uploaded = c.Data, whatsmeow.MediaAudio, &c.uploaded
+	case *DocumentContent:
+		data, mediaType, uploaded = c.Data, whatsmeow.MediaDocument, &c.uploaded
+	case *StickerContent:
+		data, mediaType, uploaded = c.Data, whatsmeow.MediaImage, &c.uploaded
+	}
+
+	if uploaded != nil && *uploaded == nil {
+		// Build with empty upload fields, leaving the content not uploaded
+		*uploaded = &whatsmeow.UploadResponse{}
+		defer func() { *uploaded = nil }()
+	} else {
+		data, mediaType = nil, ""
+	}
+
+	msg, err := content.ToMessage()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return msg, data, mediaType, nil
+}
+
+// setUploadFields fills the media message in msg from an upload.
+func setUploadFields(msg *waE2E.Message, resp whatsmeow.UploadResponse) error {
+	url, directPath, length := proto.String(resp.URL), proto.String(resp.DirectPath), proto.Uint64(resp.FileLength)
+	switch {
+	case msg.GetImageMessage() != nil:
+		m := msg.ImageMessage
+		m.URL, m.DirectPath, m.MediaKey, m.FileEncSHA256, m.FileSHA256, m.FileLength = url, directPath, resp.MediaKey, resp.FileEncSHA256, resp.FileSHA256, length
+	case msg.GetVideoMessage() != nil:
+		m := msg.VideoMessage
+		m.URL, m.DirectPath, m.MediaKey, m.FileEncSHA256, m.FileSHA256, m.FileLength = url, directPath, resp.MediaKey, resp.FileEncSHA256, resp.FileSHA256, length
+	case msg.GetPtvMessage() != nil:
+		m := msg.PtvMessage
+		m.URL, m.DirectPath, m.MediaKey, m.FileEncSHA256, m.FileSHA256, m.FileLength = url, directPath, resp.MediaKey, resp.FileEncSHA256, resp.FileSHA256, length
+	case msg.GetAudioMessage() != nil:
+		m := msg.AudioMessage
+		m.URL, m.DirectPath, m.MediaKey, m.FileEncSHA256, m.FileSHA256, m.FileLength = url, directPath, resp.MediaKey, resp.FileEncSHA256, resp.FileSHA256, length
+	case msg.GetDocumentMessage() != nil:
+		m := msg.DocumentMessage
+		m.URL, m.DirectPath, m.MediaKey, m.FileEncSHA256, m.FileSHA256, m.FileLength = url, directPath, resp.MediaKey, resp.FileEncSHA256, resp.FileSHA256, length
+	case msg.GetStickerMessage() != nil:
+		m := msg.StickerMessage
+		m.URL, m.DirectPath, m.MediaKey, m.FileEncSHA256, m.FileSHA256, m.FileLength = url, directPath, resp.MediaKey, resp.FileEncSHA256, resp.FileSHA256, length
+	default:
+		return fmt.Errorf("message has no media")
+	}
+	return nil
+}
+
+// CancelScheduled cancels a pending scheduled message.
+func (s *SendService) CancelScheduled(scheduleID string) error {
+	if s.scheduled == nil {
+		return fmt.Errorf("scheduled message store not configured")
+	}
+	if err := s.scheduled.Cancel(scheduleID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrScheduleNotFound
+		}
+		return fmt.Errorf("failed to cancel scheduled message: %w", err)
+	}
+	return nil
+}
+
+// StartScheduler starts sending scheduled messages when they are due.
+// Messages that came due while the app was stopped are sent right away.
+func (s *SendService) StartScheduler() {
+	if s.scheduled == nil {
+		return
+	}
+
+	s.sched.mu.Lock()
+	defer s.sched.mu.Unlock()
+	if s.sched.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.sched.cancel = cancel
+	s.sched.wg.Add(1)
+	go s.runScheduler(ctx)
+}
+
+// StopScheduler stops the scheduler loop and waits for it to finish.
+func (s *SendService) StopScheduler() {
+	s.sched.mu.Lock()
+	cancel := s.sched.cancel
+	s.sched.cancel = nil
+	s.sched.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		s.sched.wg.Wait()
+	}
+}
+
+// runScheduler sends due messages every schedulePollInterval.
+func (s *SendService) runScheduler(ctx context.Context) {
+	defer s.sched.wg.Done()
+
+	ticker := time.NewTicker(schedulePollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.sendDueMessages(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendDueMessages sends pending messages whose time has come.
+// Nothing is sent while disconnected, and a send cut short by ctx is left
+// pending; those messages go out on a later run.
+func (s *SendService) sendDueMessages(ctx context.Context) {
+	if s.client == nil || !s.client.IsConnected() {
+		return
+	}
+
+	due, err := s.scheduled.GetDue(time.Now(), scheduleBatchSize)
+	if err != nil {
+		s.log.Warnf("Failed to get scheduled messages: %v", err)
+		return
+	}
+
+	for _, m := range due {
+		if ctx.Err() != nil {
+			return
+		}
+
+		result, err := s.sendScheduled(ctx, m)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.markScheduledFailed(m.ID, err)
+			continue
+		}
+		if err := s.scheduled.MarkSent(m.ID, string(result.MessageID)); err != nil {
+			s.log.Warnf("Failed to update scheduled message %s: %v", m.ID, err)
+		}
+	}
+}
+
+// sendScheduled uploads a scheduled message's media and sends it. Failures
+// are tracked on the scheduled message, not as failed sends.
+func (s *SendService) sendScheduled(ctx context.Context, m *store.ScheduledMessage) (*SendResult, error) {
+	var msg waE2E.Message
+	if err := proto.Unmarshal(m.Payload, &msg); err != nil {
+		return nil, fmt.Errorf("failed to decode message: %w", err)
+	}
+	if len(m.Media) > 0 {
+		resp, err := s.client.Upload(ctx, m.Media, whatsmeow.MediaType(m.MediaType))
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload media: %w", err)
+		}
+		if err := setUploadFields(&msg, resp); err != nil {
+			return nil, err
+		}
+	}
+	return s.SendRaw(ctx, m.ChatJID, &msg, WithID(types.MessageID(m.ID)), WithoutFailedSendRecord())
+}
+
+// markScheduledFailed records a failed scheduled send.
+func (s *SendService) markScheduledFailed(id string, sendErr error) {
+	s.log.Warnf("Failed to send scheduled message %s: %v", id, sendErr)
+	if err := s.scheduled.MarkFailed(id, sendErr.Error()); err != nil {
+		s.log.Warnf("Failed to update scheduled message %s: %v", id, err)
+	}
+}
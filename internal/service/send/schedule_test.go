@@ -0,0 +1,91 @@
+package send
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	"orion-agent/internal/data/store"
+)
+
+func TestBuildScheduledDefersUpload(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	content := ImageWithCaption(buf.Bytes(), "image/png", "later")
+
+	msg, media, mediaType, err := buildScheduled(context.Background(), content)
+	if err != nil {
+		t.Fatalf("buildScheduled: %v", err)
+	}
+	if content.IsUploaded() {
+		t.Error("content left marked as uploaded")
+	}
+	if !bytes.Equal(media, buf.Bytes()) || mediaType != whatsmeow.MediaImage {
+		t.Errorf("got %d bytes of %q media, want the image", len(media), mediaType)
+	}
+	if msg.GetImageMessage().GetURL() != "" || msg.GetImageMessage().GetCaption() != "later" {
+		t.Fatalf("unexpected message %v", msg)
+	}
+
+	// The message survives the store round trip and gets the upload when due
+	db := newTestStore(t)
+	scheduled := store.NewScheduledMessageStore(db)
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := scheduled.Put(&store.ScheduledMessage{
+		ID:        "SCHED1",
+		ChatJID:   types.NewJID("100", types.DefaultUserServer),
+		Payload:   payload,
+		Media:     media,
+		MediaType: string(mediaType),
+		FireAt:    time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	due, err := scheduled.GetDue(time.Now(), 10)
+	if err != nil || len(due) != 1 {
+		t.Fatalf("GetDue = %d, %v", len(due), err)
+	}
+	if !bytes.Equal(due[0].Media, media) || due[0].MediaType != string(mediaType) {
+		t.Errorf("media not stored with the scheduled message")
+	}
+
+	resp := whatsmeow.UploadResponse{URL: "https://mmg.whatsapp.net/x", DirectPath: "/x", MediaKey: []byte{1}, FileLength: 42}
+	if err := setUploadFields(msg, resp); err != nil {
+		t.Fatal(err)
+	}
+	if img := msg.GetImageMessage(); img.GetURL() != resp.URL || img.GetFileLength() != 42 || img.GetCaption() != "later" {
+		t.Errorf("upload fields not set: %v", img)
+	}
+}
+
+func TestBuildScheduledKeepsUploadedMedia(t *testing.T) {
+	content := Document([]byte("pdf"), "application/pdf", "a.pdf")
+	content.uploaded = &whatsmeow.UploadResponse{URL: "https://mmg.whatsapp.net/doc"}
+
+	msg, media, _, err := buildScheduled(context.Background(), content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media != nil || msg.GetDocumentMessage().GetURL() != "https://mmg.whatsapp.net/doc" {
+		t.Errorf("already uploaded media should be sent as is")
+	}
+}
+
+func TestSetUploadFieldsRequiresMedia(t *testing.T) {
+	msg, _ := Text("hi").ToMessage()
+	if err := setUploadFields(msg, whatsmeow.UploadResponse{}); err == nil {
+		t.Error("expected an error for a message without media")
+	}
+}
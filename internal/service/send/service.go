@@ -2,36 +2,112 @@ package send
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types"
 	waLog "go.mau.fi/whatsmeow/util/log"
+	"google.golang.org/protobuf/proto"
 
 	"orion-agent/internal/data/store"
+	"orion-agent/internal/infra/metrics"
 	"orion-agent/internal/utils"
 )
 
+// ErrCannotSendToAnnounceGroup is returned when sending to an announce-only
+// group in which we are not an admin.
+var ErrCannotSendToAnnounceGroup = errors.New("only admins can send messages to this group")
+
 // SendService provides a high-level API for sending messages via WhatsApp.
 type SendService struct {
-	client    *whatsmeow.Client
-	utils     *utils.Utils
-	messages  *store.MessageStore
-	reactions *store.ReactionStore
-	polls     *store.PollStore
-	log       waLog.Logger
+	client      *whatsmeow.Client
+	utils       *utils.Utils
+	messages    *store.MessageStore
+	reactions   *store.ReactionStore
+	polls       *store.PollStore
+	chats       *store.ChatStore
+	groups      *store.GroupStore
+	failedSends *store.FailedSendStore
+	scheduled   *store.ScheduledMessageStore
+	statuses    *store.StatusStore
+	idempotency *store.IdempotencyStore
+	blocklist   *store.BlocklistStore
+	broadcasts  *store.BroadcastStore
+	log         waLog.Logger
+
+	// Outbox backpressure
+	inFlight       atomic.Int64
+	maxOutbox      atomic.Int64
+	pendingOnce    sync.Once
+	pendingRetries atomic.Int64 // Retryable failed sends
+	mediaQueue     MediaQueue
+	rateLimiter    RateLimiter
+
+	// Set while RetryFailedSends runs
+	retrying atomic.Bool
+
+	// Outbound footer
+	footer      string
+	footerTypes map[string]bool
+
+	// Scheduled message loop
+	sched scheduler
+
+	// How long idempotency keys are remembered
+	idempotencyWindow time.Duration
+
+	// Last sent live location sequence per message
+	liveMu  sync.Mutex
+	liveSeq map[types.MessageID]int64
+
+	// Replaces the client's SendMessage in tests
+	sendToClient func(ctx context.Context, to types.JID, msg *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error)
+}
+
+// Stores holds the stores SendService reads chats from and records sends in.
+type Stores struct {
+	Messages    *store.MessageStore
+	Reactions   *store.ReactionStore
+	Polls       *store.PollStore
+	Chats       *store.ChatStore
+	Groups      *store.GroupStore
+	FailedSends *store.FailedSendStore
+	Scheduled   *store.ScheduledMessageStore
+	Statuses    *store.StatusStore
+	Idempotency *store.IdempotencyStore
+	Blocklist   *store.BlocklistStore
+	Broadcasts  *store.BroadcastStore
 }
 
 // NewSendService creates a new SendService.
-func NewSendService(client *whatsmeow.Client, utils *utils.Utils, messages *store.MessageStore, reactions *store.ReactionStore, polls *store.PollStore, log waLog.Logger) *SendService {
-	return &SendService{
-		client:    client,
-		utils:     utils,
-		messages:  messages,
-		reactions: reactions,
-		polls:     polls,
-		log:       log.Sub("SendService"),
+func NewSendService(client *whatsmeow.Client, utils *utils.Utils, stores Stores, log waLog.Logger) *SendService {
+	s := &SendService{
+		client:      client,
+		utils:       utils,
+		messages:    stores.Messages,
+		reactions:   stores.Reactions,
+		polls:       stores.Polls,
+		chats:       stores.Chats,
+		groups:      stores.Groups,
+		failedSends: stores.FailedSends,
+		scheduled:   stores.Scheduled,
+		statuses:    stores.Statuses,
+		idempotency: stores.Idempotency,
+		blocklist:   stores.Blocklist,
+		broadcasts:  stores.Broadcasts,
+		log:         log.Sub("SendService"),
+
+		idempotencyWindow: defaultIdempotencyWindow,
 	}
+	s.sendToClient = func(ctx context.Context, to types.JID, msg *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+		return s.client.SendMessage(ctx, to, msg, extra...)
+	}
+	return s
 }
 
 // SetClient updates the whatsmeow client (for delayed initialization).
@@ -45,11 +121,40 @@ func (s *SendService) Client() *whatsmeow.Client {
 }
 
 // Send sends content to a recipient.
-func (s *SendService) Send(ctx context.Context, to types.JID, content Content, opts ...SendOption) (*SendResult, error) {
+func (s *SendService) Send(ctx context.Context, to types.JID, content Content, opts ...SendOption) (result *SendResult, err error) {
 	if s.client == nil {
 		return nil, fmt.Errorf("client not initialized")
 	}
 
+	start := time.Now()
+	defer func() { metrics.ObserveSend(content.MessageType(), time.Since(start), err) }()
+
+	release, err := s.acquireOutbox()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Apply options
+	cfg := applyOptions(opts)
+
+	// WhatsApp silently drops messages from non-admins in announce-only groups
+	if !cfg.NoAnnounceCheck && !s.canSendToGroup(ctx, to) {
+		return nil, ErrCannotSendToAnnounceGroup
+	}
+
+	// Don't send the same logical message twice
+	if cfg.IdempotencyKey != "" {
+		prior, err := s.reserveIdempotencyKey(to, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if prior != nil {
+			return prior, nil
+		}
+		defer func() { s.settleIdempotencyKey(cfg, result) }()
+	}
+
 	// Upload media if needed (before building message)
 	if content.MediaType() != "" {
 		if uploader, ok := content.(MediaUploader); ok && !uploader.IsUploaded() {
@@ -59,23 +164,35 @@ func (s *SendService) Send(ctx context.Context, to types.JID, content Content, o
 		}
 	}
 
-	// Build the message
-	msg, err := content.ToMessage()
-	if err != nil {
-		return nil, fmt.Errorf("failed to build message: %w", err)
+	// Recipients see a black placeholder for videos without a thumbnail
+	if video, ok := content.(*VideoContent); ok {
+		s.ensureVideoThumbnail(ctx, video)
+	}
+	if text, ok := content.(*ExtendedTextContent); ok {
+		s.ensureLinkPreview(ctx, text)
 	}
 
-	// Apply options
-	cfg := applyOptions(opts)
+	msg, err := s.buildMessage(ctx, to, content, cfg)
+	if err != nil {
+		return nil, err
+	}
 	extra := cfg.toSendRequestExtra()
 
+	// Pin the ID up front so a failed send can be retried idempotently
+	if extra.ID == "" {
+		extra.ID = s.client.GenerateMessageID()
+	}
+
 	// Send
-	resp, err := s.client.SendMessage(ctx, to, msg, extra)
+	resp, err := s.sendMessage(ctx, to, msg, extra, cfg)
 	if err != nil {
+		if !cfg.NoFailedSendRecord {
+			s.recordFailedSend(to, extra.ID, msg, content.MessageType(), err)
+		}
 		return nil, fmt.Errorf("failed to send message: %w", err)
 	}
 
-	result := &SendResult{
+	result = &SendResult{
 		MessageID: resp.ID,
 		ServerID:  resp.ServerID,
 		Timestamp: resp.Timestamp,
@@ -83,15 +200,155 @@ func (s *SendService) Send(ctx context.Context, to types.JID, content Content, o
 		Sender:    resp.Sender,
 		DebugInfo: resp.DebugTimings,
 	}
+	if poll, ok := content.(*PollContent); ok {
+		result.PollEncryptionKey = poll.EncryptionKey()
+	}
 
 	// Save sent message to database
-	s.saveSentMessage(result, content)
+	if !cfg.NoSave {
+		s.saveSentMessage(ctx, result, content, msg)
+	}
 
 	return result, nil
 }
 
+// buildMessage builds the message for prepared content, with the footer,
+// the chat's disappearing timer and any caller-provided context applied.
+func (s *SendService) buildMessage(ctx context.Context, to types.JID, content Content, cfg *sendConfig) (*waE2E.Message, error) {
+	msg, err := content.ToMessage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build message: %w", err)
+	}
+
+	if !cfg.NoFooter {
+		s.applyFooter(msg)
+	}
+
+	// Media sent to a disappearing chat should disappear too
+	if !cfg.NoAutoEphemeral && content.MediaType() != "" {
+		s.applyChatEphemeral(ctx, to, msg)
+	}
+
+	// Caller-provided context overrides the built context
+	if cfg.RawContextInfo != nil {
+		mergeRawContextInfo(msg, cfg.RawContextInfo)
+	}
+	if cfg.MessageAssociation != nil {
+		setMessageAssociation(msg, cfg.MessageAssociation)
+	}
+	return msg, nil
+}
+
+// maxRetryBackoff caps the wait between send retries.
+const maxRetryBackoff = 30 * time.Second
+
+// sendMessage sends a built message, retrying transient failures as configured by WithRetry.
+// The message ID must be set so retries are idempotent.
+func (s *SendService) sendMessage(ctx context.Context, to types.JID, msg *waE2E.Message, extra whatsmeow.SendRequestExtra, cfg *sendConfig) (whatsmeow.SendResponse, error) {
+	attempts := max(cfg.RetryAttempts, 1)
+	wait := cfg.RetryBackoff
+	if wait <= 0 {
+		wait = time.Second
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := s.sendToClient(ctx, to, msg, extra)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= attempts || !isTransientSendError(err) {
+			if attempt > 1 {
+				return resp, fmt.Errorf("failed after %d attempts: %w", attempt, err)
+			}
+			return resp, err
+		}
+
+		s.log.Debugf("Send %s failed (attempt %d/%d), retrying in %s: %v", extra.ID, attempt, attempts, wait, err)
+		select {
+		case <-ctx.Done():
+			return resp, fmt.Errorf("failed after %d attempts: %w", attempt, err)
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, maxRetryBackoff)
+	}
+}
+
+// SendDisappearingImage sends an image that disappears after expiration seconds.
+// If expiration is 0, the chat's disappearing-message timer is used.
+func (s *SendService) SendDisappearingImage(ctx context.Context, to types.JID, image *ImageContent, expiration uint32, opts ...SendOption) (*SendResult, error) {
+	if expiration == 0 {
+		expiration = s.chatEphemeralDuration(ctx, to)
+	}
+	if expiration > 0 {
+		// Leave the caller's content and context untouched
+		img := *image
+		img.ContextInfo = image.ContextInfo.Clone().WithExpiration(expiration)
+		image = &img
+	}
+	return s.Send(ctx, to, image, opts...)
+}
+
+// chatEphemeralDuration returns the chat's disappearing timer in seconds, or 0.
+func (s *SendService) chatEphemeralDuration(ctx context.Context, to types.JID) uint32 {
+	if s.chats == nil {
+		return 0
+	}
+	chat, err := s.chats.Get(s.utils.NormalizeJID(ctx, to))
+	if err != nil {
+		return 0
+	}
+	return chat.EphemeralDuration
+}
+
+// canSendToGroup reports whether we may post to the recipient.
+// Only announce-only groups in which we are a known non-admin are refused;
+// anything not in the store is allowed through.
+func (s *SendService) canSendToGroup(ctx context.Context, to types.JID) bool {
+	if s.groups == nil || !s.utils.IsGroup(to) {
+		return true
+	}
+	group, err := s.groups.Get(to)
+	if err != nil || !group.IsAnnounce {
+		return true
+	}
+	own := s.utils.OwnJID()
+	if own.IsEmpty() {
+		return true
+	}
+	participant, err := s.groups.GetParticipant(to, s.utils.NormalizeJID(ctx, own))
+	if err != nil {
+		return true
+	}
+	return participant.IsAdmin || participant.IsSuperAdmin
+}
+
+// applyChatEphemeral sets the chat's disappearing timer on the message,
+// unless the content already set an expiration.
+func (s *SendService) applyChatEphemeral(ctx context.Context, to types.JID, msg *waE2E.Message) {
+	duration := s.chatEphemeralDuration(ctx, to)
+	if duration == 0 {
+		return
+	}
+	slot := contextInfoSlot(msg)
+	if slot == nil {
+		return
+	}
+	if *slot == nil {
+		*slot = &waE2E.ContextInfo{}
+	}
+	if (*slot).Expiration == nil {
+		(*slot).Expiration = proto.Uint32(duration)
+	}
+}
+
+// SendWithCustomContextInfo sends content with a raw ContextInfo merged in.
+// It is shorthand for Send with WithRawContextInfo.
+func (s *SendService) SendWithCustomContextInfo(ctx context.Context, to types.JID, content Content, ctxInfo *waE2E.ContextInfo, opts ...SendOption) (*SendResult, error) {
+	return s.Send(ctx, to, content, append(opts, WithRawContextInfo(ctxInfo))...)
+}
+
 // saveSentMessage saves a sent message to the database.
-func (s *SendService) saveSentMessage(result *SendResult, content Content) {
+func (s *SendService) saveSentMessage(ctx context.Context, result *SendResult, content Content, sent *waE2E.Message) {
 	if s.messages == nil {
 		return
 	}
@@ -110,25 +367,43 @@ func (s *SendService) saveSentMessage(result *SendResult, content Content) {
 		Caption:     content.GetCaption(),
 	}
 
+	// Store the body as sent, including any footer
+	if text := sent.GetConversation(); text != "" && msg.TextContent != "" {
+		msg.TextContent = text
+	} else if text := sent.GetExtendedTextMessage().GetText(); text != "" && msg.TextContent != "" {
+		msg.TextContent = text
+	}
+	if caption := sentCaption(sent); caption != "" && msg.Caption != "" {
+		msg.Caption = caption
+	}
+
+	if result.Recipient.Server == types.BroadcastServer {
+		msg.IsBroadcast = true
+		msg.BroadcastListJID = result.Recipient
+	}
+
 	// Add mentioned JIDs
 	if mentions := content.GetMentionedJIDs(); len(mentions) > 0 {
 		msg.MentionedJIDs = mentions
 	}
 
 	// Add reply/forward context
-	if ctx := content.GetContextInfo(); ctx != nil {
-		if ctx.QuotedMessageID != "" {
-			msg.QuotedMessageID = string(ctx.QuotedMessageID)
+	if info := content.GetContextInfo(); info != nil {
+		if info.QuotedMessageID != "" {
+			msg.QuotedMessageID = string(info.QuotedMessageID)
 		}
-		if !ctx.QuotedParticipant.IsEmpty() {
-			msg.QuotedSenderLID = ctx.QuotedParticipant
+		if !info.QuotedParticipant.IsEmpty() {
+			msg.QuotedSenderLID = info.QuotedParticipant
 		}
-		msg.IsForwarded = ctx.IsForwarded
-		msg.ForwardingScore = int(ctx.ForwardingScore)
-		if ctx.Expiration > 0 {
+		msg.IsForwarded = info.IsForwarded
+		msg.ForwardingScore = int(info.ForwardingScore)
+		if info.Expiration > 0 {
 			msg.IsEphemeral = true
 		}
 	}
+	if sentCtx := messageContextInfo(sent); sentCtx.GetExpiration() > 0 {
+		msg.IsEphemeral = true
+	}
 
 	// Type-specific fields
 	switch c := content.(type) {
@@ -233,8 +508,10 @@ func (s *SendService) saveSentMessage(result *SendResult, content Content) {
 		msg.PollOptions = c.Options
 		msg.PollSelectMax = c.SelectableCount
 		msg.PollEncryptionKey = c.encKey
+		s.savePoll(ctx, msg)
 
 	case *ExtendedTextContent:
+		msg.GroupMentions = c.GroupMentions
 		msg.PreviewTitle = c.Title
 		msg.PreviewDescription = c.Description
 		msg.PreviewURL = c.CanonicalURL
@@ -250,7 +527,9 @@ func (s *SendService) saveSentMessage(result *SendResult, content Content) {
 		msg.EventName = c.Name
 		msg.EventDescription = c.Description
 		msg.EventStartTime = c.StartTime.Unix()
-		msg.EventEndTime = c.EndTime.Unix()
+		if !c.EndTime.IsZero() {
+			msg.EventEndTime = c.EndTime.Unix()
+		}
 		msg.EventJoinLink = c.JoinLink
 		msg.EventIsCanceled = c.IsCanceled
 		if c.Location != nil {
@@ -263,6 +542,42 @@ func (s *SendService) saveSentMessage(result *SendResult, content Content) {
 
 	if err := s.messages.Put(msg); err != nil {
 		s.log.Warnf("Failed to save sent message %s: %v", result.MessageID, err)
+		return
+	}
+
+	s.reconcileServerID(result)
+}
+
+// savePoll records a sent poll, so votes on it can be decrypted and tallied.
+// It's stored under the normalized JIDs, as received votes are.
+func (s *SendService) savePoll(ctx context.Context, msg *store.Message) {
+	if s.polls == nil {
+		return
+	}
+	poll := &store.Poll{
+		MessageID:     msg.ID,
+		ChatJID:       s.utils.NormalizeJID(ctx, msg.ChatJID),
+		CreatorLID:    s.utils.NormalizeJID(ctx, msg.SenderLID),
+		Question:      msg.PollName,
+		Options:       msg.PollOptions,
+		IsMultiSelect: msg.PollSelectMax != 1,
+		SelectMax:     msg.PollSelectMax,
+		EncryptionKey: msg.PollEncryptionKey,
+		CreatedAt:     msg.Timestamp,
+	}
+	if err := s.polls.Put(poll); err != nil {
+		s.log.Warnf("Failed to save sent poll %s: %v", msg.ID, err)
+	}
+}
+
+// reconcileServerID stores the server ID from the send result.
+// The echo of our own message may have been saved first without it.
+func (s *SendService) reconcileServerID(result *SendResult) {
+	if result.ServerID == 0 {
+		return
+	}
+	if err := s.messages.UpdateServerID(result.MessageID, result.Recipient, int(result.ServerID)); err != nil {
+		s.log.Warnf("Failed to update server ID for message %s: %v", result.MessageID, err)
 	}
 }
 
@@ -275,11 +590,9 @@ func (s *SendService) SendToGroup(ctx context.Context, groupJID types.JID, conte
 }
 
 // SendToNewsletter sends content to a newsletter/channel.
+// See SendNewsletter.
 func (s *SendService) SendToNewsletter(ctx context.Context, newsletterJID types.JID, content Content, opts ...SendOption) (*SendResult, error) {
-	if newsletterJID.Server != types.NewsletterServer {
-		return nil, fmt.Errorf("not a newsletter JID: %s", newsletterJID)
-	}
-	return s.Send(ctx, newsletterJID, content, opts...)
+	return s.SendNewsletter(ctx, newsletterJID, content, opts...)
 }
 
 // SendMany sends content to multiple recipients.
@@ -288,13 +601,9 @@ func (s *SendService) SendMany(ctx context.Context, recipients []types.JID, cont
 		return nil, fmt.Errorf("no recipients provided")
 	}
 
-	// For media, upload once then reuse
-	if content.MediaType() != "" {
-		if uploader, ok := content.(MediaUploader); ok && !uploader.IsUploaded() {
-			if err := uploader.Upload(ctx, s.client); err != nil {
-				return nil, fmt.Errorf("failed to upload media: %w", err)
-			}
-		}
+	// Upload media once, then reuse it
+	if err := s.prepareShared(ctx, content); err != nil {
+		return nil, err
 	}
 
 	results := make([]*SendResult, 0, len(recipients))
@@ -358,7 +667,100 @@ func (s *SendService) SendManyWithErrors(ctx context.Context, recipients []types
 	return result
 }
 
+// SendBulk sends the same content to every recipient, at most
+// WithConcurrency sends at a time (sequentially by default).
+// Media is uploaded once and the upload is reused for every recipient.
+// Results and errors are aligned with recipients by index; a failed
+// recipient has a nil result and a non-nil error.
+func (s *SendService) SendBulk(ctx context.Context, recipients []types.JID, content Content, opts ...SendOption) ([]*SendResult, []error) {
+	results := make([]*SendResult, len(recipients))
+	errs := make([]error, len(recipients))
+	if len(recipients) == 0 {
+		return results, errs
+	}
+
+	fail := func(err error) ([]*SendResult, []error) {
+		for i := range errs {
+			errs[i] = err
+		}
+		return results, errs
+	}
+	if s.client == nil {
+		return fail(fmt.Errorf("client not initialized"))
+	}
+
+	// Prepare the content once; concurrent sends then only read it
+	if err := s.prepareShared(ctx, content); err != nil {
+		return fail(err)
+	}
+
+	// Every recipient needs its own message ID
+	opts = append(opts[:len(opts):len(opts)], WithID(""))
+	s.sendEach(ctx, recipients, content, results, errs, opts)
+	return results, errs
+}
+
+// prepareShared uploads media, generates thumbnails and fetches link
+// previews up front for content sent to several recipients. It also builds the message once, so state
+// ToMessage fills in lazily (like a poll's encryption key) is set before
+// concurrent sends read it, and every recipient gets the same.
+func (s *SendService) prepareShared(ctx context.Context, content Content) error {
+	if content.MediaType() != "" {
+		if uploader, ok := content.(MediaUploader); ok && !uploader.IsUploaded() {
+			if err := uploader.Upload(ctx, s.client); err != nil {
+				return fmt.Errorf("failed to upload media: %w", err)
+			}
+		}
+	}
+	if video, ok := content.(*VideoContent); ok {
+		s.ensureVideoThumbnail(ctx, video)
+	}
+	if text, ok := content.(*ExtendedTextContent); ok {
+		s.ensureLinkPreview(ctx, text)
+	}
+	if _, err := content.ToMessage(); err != nil {
+		return fmt.Errorf("failed to build message: %w", err)
+	}
+	return nil
+}
+
+// sendEach sends prepared content to every recipient, filling results and
+// errs by index. Message IDs are up to opts.
+func (s *SendService) sendEach(ctx context.Context, recipients []types.JID, content Content, results []*SendResult, errs []error, opts []SendOption) {
+	cfg := applyOptions(opts)
+	workers := min(max(cfg.Concurrency, 1), len(recipients))
+
+	var wg sync.WaitGroup
+	next := make(chan int)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				sendOpts := opts
+				if cfg.IdempotencyKey != "" {
+					// The key covers the whole bulk send, so scope it per recipient
+					sendOpts = append(opts[:len(opts):len(opts)], WithIdempotencyKey(cfg.IdempotencyKey+":"+recipients[i].String()))
+				}
+				results[i], errs[i] = s.Send(ctx, recipients[i], content, sendOpts...)
+			}
+		}()
+	}
+
+	for i := range recipients {
+		if ctx.Err() != nil {
+			errs[i] = ctx.Err()
+			continue
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
 // MediaUploader is implemented by content types that need to upload media.
+// Once uploaded, media content is only read by ToMessage, so the same
+// content may be sent to several recipients concurrently.
 type MediaUploader interface {
 	Upload(ctx context.Context, client *whatsmeow.Client) error
 	IsUploaded() bool
@@ -370,13 +772,9 @@ func (s *SendService) Broadcast(ctx context.Context, recipients []types.JID, con
 		return nil, fmt.Errorf("no recipients provided")
 	}
 
-	// For media, upload once then reuse
-	if content.MediaType() != "" {
-		if uploader, ok := content.(MediaUploader); ok && !uploader.IsUploaded() {
-			if err := uploader.Upload(ctx, s.client); err != nil {
-				return nil, fmt.Errorf("failed to upload media: %w", err)
-			}
-		}
+	// Upload media once, then reuse it
+	if err := s.prepareShared(ctx, content); err != nil {
+		return nil, err
 	}
 
 	results := make([]*SendResult, 0, len(recipients))
@@ -0,0 +1,133 @@
+package send
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	wastore "go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+
+	"orion-agent/internal/data/store"
+	"orion-agent/internal/utils"
+)
+
+func TestPrepareSharedFixesPollKey(t *testing.T) {
+	s := newTestSendService(t, newTestStore(t))
+	poll := Poll("Lunch?", []string{"Pizza", "Sushi"})
+
+	if err := s.prepareShared(context.Background(), poll); err != nil {
+		t.Fatalf("prepareShared: %v", err)
+	}
+	key := poll.EncryptionKey()
+	if len(key) != 32 {
+		t.Fatalf("encryption key not set before fan-out: %x", key)
+	}
+
+	// Recipients build the message concurrently and must share the key
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msg, err := poll.ToMessage()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if got := msg.GetPollCreationMessage().GetEncKey(); !bytes.Equal(got, key) {
+				t.Errorf("recipient got key %x, want %x", got, key)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestPrepareSharedRejectsInvalidContent(t *testing.T) {
+	s := newTestSendService(t, newTestStore(t))
+	if err := s.prepareShared(context.Background(), Poll("Lunch?", []string{"Pizza"})); err == nil {
+		t.Fatal("expected a poll with one option to be rejected before fan-out")
+	}
+}
+
+func TestReconcileServerID(t *testing.T) {
+	db := newTestStore(t)
+	s := newTestSendService(t, db)
+	messages := store.NewMessageStore(db)
+	channel := types.NewJID("120363000000000009", types.NewsletterServer)
+	own := types.NewJID("900000000000001", types.HiddenUserServer)
+
+	// The echo of our own message is saved before the send returns
+	m := &store.Message{ID: "SENT1", ChatJID: channel, SenderLID: own, FromMe: true, Timestamp: time.Now(), MessageType: "text", TextContent: "hi"}
+	if err := messages.Put(m); err != nil {
+		t.Fatal(err)
+	}
+
+	s.reconcileServerID(&SendResult{MessageID: "SENT1", Recipient: channel})
+	s.reconcileServerID(&SendResult{MessageID: "SENT1", Recipient: channel, ServerID: 42})
+	got, err := messages.GetByServerID(channel, 42)
+	if err != nil || got == nil || got.ID != "SENT1" {
+		t.Errorf("GetByServerID = %v, %v, want SENT1", got, err)
+	}
+}
+
+func TestAnnounceGroupNeedsAdmin(t *testing.T) {
+	db := newTestStore(t)
+	own := types.NewADJID("900000000000001", 0, 5)
+	own.Server = types.HiddenUserServer
+	client := &whatsmeow.Client{Store: &wastore.Device{ID: &own}}
+	groups := store.NewGroupStore(db)
+	s := NewSendService(client, utils.New(store.NewContactStore(db), client), Stores{
+		Messages: store.NewMessageStore(db),
+		Chats:    store.NewChatStore(db),
+		Groups:   groups,
+	}, waLog.Noop)
+	ctx := context.Background()
+
+	announce := types.NewJID("120363000000000001", types.GroupServer)
+	adminOnly := types.NewJID("120363000000000002", types.GroupServer)
+	open := types.NewJID("120363000000000003", types.GroupServer)
+	for _, g := range []*store.Group{
+		{JID: announce, IsAnnounce: true},
+		{JID: adminOnly, IsAnnounce: true},
+		{JID: open},
+	} {
+		if err := groups.Put(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range []store.GroupParticipant{
+		{GroupJID: announce, MemberLID: own.ToNonAD()},
+		{GroupJID: adminOnly, MemberLID: own.ToNonAD(), IsAdmin: true},
+		{GroupJID: open, MemberLID: own.ToNonAD()},
+	} {
+		if err := groups.PutParticipant(&p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for jid, want := range map[types.JID]bool{
+		announce:  false,
+		adminOnly: true,
+		open:      true,
+		// Groups and members not in the store are let through
+		types.NewJID("120363000000000004", types.GroupServer):   true,
+		types.NewJID("900000000000002", types.HiddenUserServer): true,
+	} {
+		if got := s.canSendToGroup(ctx, jid); got != want {
+			t.Errorf("canSendToGroup(%s) = %v, want %v", jid, got, want)
+		}
+	}
+
+	if _, err := s.Send(ctx, announce, Text("hi")); !errors.Is(err, ErrCannotSendToAnnounceGroup) {
+		t.Errorf("Send to announce group as member = %v, want ErrCannotSendToAnnounceGroup", err)
+	}
+	if _, _, err := s.SendAlbum(ctx, announce, []Content{Image([]byte("a"), "image/jpeg"), Image([]byte("b"), "image/jpeg")}); !errors.Is(err, ErrCannotSendToAnnounceGroup) {
+		t.Errorf("SendAlbum to announce group as member = %v, want ErrCannotSendToAnnounceGroup", err)
+	}
+}
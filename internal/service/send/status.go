@@ -0,0 +1,117 @@
+package send
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	"orion-agent/internal/data/extract"
+	"orion-agent/internal/data/store"
+)
+
+// StatusLifetime is how long a posted status stays visible.
+const StatusLifetime = 24 * time.Hour
+
+// defaultStatusBackground is the background color of text statuses (ARGB).
+const defaultStatusBackground = 0xFF1E6E4F
+
+// ErrStatusAudienceUnsupported is returned when PostStatus is given an explicit
+// audience. Statuses are delivered to the recipients of the account's status
+// privacy setting, which is managed from the phone.
+var ErrStatusAudienceUnsupported = errors.New("custom status audiences are not supported, statuses go to the status privacy list")
+
+// PostStatus posts a text, image or video status.
+// The status is delivered according to the account's status privacy setting,
+// so audience must be empty; it's reserved for per-post audiences.
+func (s *SendService) PostStatus(ctx context.Context, content Content, audience []types.JID, opts ...SendOption) (*SendResult, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("client not initialized")
+	}
+	if len(audience) > 0 {
+		return nil, ErrStatusAudienceUnsupported
+	}
+
+	switch c := content.(type) {
+	case *TextContent, *ExtendedTextContent, *ImageContent:
+	case *VideoContent:
+		s.ensureVideoThumbnail(ctx, c)
+	default:
+		return nil, fmt.Errorf("%s can't be posted as a status, only text, images and videos", content.MessageType())
+	}
+
+	if uploader, ok := content.(MediaUploader); ok && !uploader.IsUploaded() {
+		if err := uploader.Upload(ctx, s.client); err != nil {
+			return nil, fmt.Errorf("failed to upload media: %w", err)
+		}
+	}
+
+	msg, err := content.ToMessage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build message: %w", err)
+	}
+	setStatusTextStyle(msg)
+
+	opts = append(opts[:len(opts):len(opts)], WithoutSave(), WithoutFooter(), WithoutAutoEphemeral())
+	result, err := s.SendRaw(ctx, types.StatusBroadcastJID, msg, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post status: %w", err)
+	}
+
+	s.saveStatus(ctx, result, msg)
+	return result, nil
+}
+
+// setStatusTextStyle turns text into an extended text with a background,
+// which is how text statuses are rendered.
+func setStatusTextStyle(msg *waE2E.Message) {
+	if msg.Conversation != nil {
+		msg.ExtendedTextMessage = &waE2E.ExtendedTextMessage{Text: msg.Conversation}
+		msg.Conversation = nil
+	}
+	if ext := msg.ExtendedTextMessage; ext != nil && ext.BackgroundArgb == nil {
+		ext.BackgroundArgb = proto.Uint32(defaultStatusBackground)
+		ext.TextArgb = proto.Uint32(0xFFFFFFFF)
+	}
+}
+
+// saveStatus persists a posted status.
+func (s *SendService) saveStatus(ctx context.Context, result *SendResult, msg *waE2E.Message) {
+	if s.statuses == nil {
+		return
+	}
+
+	sender := s.utils.OwnJID()
+	m := extract.MessageFromEvent(&events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: types.StatusBroadcastJID, Sender: sender, IsFromMe: true},
+			ID:            result.MessageID,
+			Timestamp:     result.Timestamp,
+		},
+		Message: msg,
+	})
+
+	status := &store.StatusUpdate{
+		ID:              string(result.MessageID),
+		SenderLID:       s.utils.NormalizeJID(ctx, sender),
+		MessageType:     m.MessageType,
+		TextContent:     m.TextContent,
+		Caption:         m.Caption,
+		MediaDirectPath: m.MediaDirectPath,
+		MediaKey:        m.MediaKey,
+		FileSHA256:      m.FileSHA256,
+		FileEncSHA256:   m.FileEncSHA256,
+		FileLength:      m.FileLength,
+		Mimetype:        m.Mimetype,
+		Timestamp:       result.Timestamp,
+		ExpiresAt:       result.Timestamp.Add(StatusLifetime),
+	}
+	if err := s.statuses.Put(status); err != nil {
+		s.log.Warnf("Failed to save status %s: %v", result.MessageID, err)
+	}
+}
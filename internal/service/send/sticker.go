@@ -0,0 +1,250 @@
+package send
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	_ "image/jpeg" // Register decoder
+	_ "image/png"  // Register decoder
+)
+
+// stickerSize is the width and height WhatsApp expects stickers to have.
+const stickerSize = 512
+
+// defaultStickerFrameDelay is used for GIF frames without a delay, in ms.
+const defaultStickerFrameDelay = 100
+
+// Largest stickers WhatsApp delivers; bigger ones are dropped.
+const (
+	maxStickerSize         = 100 << 10
+	maxAnimatedStickerSize = 500 << 10
+)
+
+// ErrUnsupportedStickerImage is returned by StickerFromImage for inputs
+// that aren't PNG, JPEG or GIF.
+var ErrUnsupportedStickerImage = errors.New("unsupported sticker image, only PNG, JPEG and GIF are supported")
+
+// ErrStickerTooLarge is returned by StickerFromImage when the encoded
+// sticker is over WhatsApp's size limit.
+var ErrStickerTooLarge = errors.New("sticker too large")
+
+// stickerReductions are tried in turn until a sticker fits WhatsApp's limit:
+// fewer bits per color channel, which compress much better, then a smaller
+// image on the 512x512 canvas.
+var stickerReductions = []struct{ bits, size int }{
+	{8, 512}, {6, 512}, {5, 512}, {4, 512}, {4, 448}, {4, 384}, {3, 320}, {3, 256}, {3, 192}, {3, 128},
+}
+
+// StickerFromImage converts a PNG, JPEG or GIF into a sticker. The image is
+// scaled to fit 512x512, keeping its aspect ratio, padded with transparency
+// and encoded as lossless WebP. Animated GIFs become animated stickers.
+//
+// WhatsApp drops stickers over 100 KB (500 KB animated), which lossless
+// photos exceed, so the image is posterized and shrunk until it fits.
+// ErrStickerTooLarge is returned if it still doesn't.
+func StickerFromImage(data []byte) (*StickerContent, error) {
+	if bytes.HasPrefix(data, []byte("GIF8")) {
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gif: %w", err)
+		}
+		if len(g.Image) > 1 {
+			return animatedSticker(g)
+		}
+		return stillSticker(g.Image[0])
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, ErrUnsupportedStickerImage
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if format != "png" && format != "jpeg" {
+		return nil, ErrUnsupportedStickerImage
+	}
+	return stillSticker(img)
+}
+
+// stillSticker encodes img at the first reduction that fits.
+func stillSticker(img image.Image) (*StickerContent, error) {
+	var err error
+	for _, r := range stickerReductions {
+		var sticker *StickerContent
+		sticker, err = checkStickerSize(Sticker(encodeWebP(posterize(fitStickerTo(img, r.size), r.bits))))
+		if err == nil {
+			return sticker, nil
+		}
+	}
+	return nil, err
+}
+
+// animatedSticker encodes the GIF's frames at the first reduction that fits.
+// Frames stop being encoded at a reduction once they're over the limit.
+func animatedSticker(g *gif.GIF) (*StickerContent, error) {
+	canvases, delays := gifCanvases(g)
+	for _, r := range stickerReductions {
+		frames := make([][]byte, 0, len(canvases))
+		size := 0
+		for _, canvas := range canvases {
+			frame := encodeVP8L(posterize(fitStickerTo(canvas, r.size), r.bits))
+			frames = append(frames, frame)
+			if size += len(frame); size > maxAnimatedStickerSize {
+				break
+			}
+		}
+		if size > maxAnimatedStickerSize {
+			continue
+		}
+		sticker, err := checkStickerSize(AnimatedSticker(encodeAnimatedWebP(stickerSize, stickerSize, frames, delays)))
+		if err == nil {
+			return sticker, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: the limit is %d KB", ErrStickerTooLarge, maxAnimatedStickerSize>>10)
+}
+
+// checkStickerSize returns ErrStickerTooLarge for a sticker over the limit.
+func checkStickerSize(sticker *StickerContent) (*StickerContent, error) {
+	limit := maxStickerSize
+	if sticker.IsAnimated {
+		limit = maxAnimatedStickerSize
+	}
+	if len(sticker.Data) > limit {
+		return nil, fmt.Errorf("%w: %d KB, the limit is %d KB", ErrStickerTooLarge, len(sticker.Data)>>10, limit>>10)
+	}
+	return sticker, nil
+}
+
+// gifCanvases renders each GIF frame onto the full canvas, honoring
+// disposal, and returns the canvas as it is shown with each frame.
+func gifCanvases(g *gif.GIF) ([]*image.RGBA, []int) {
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		bounds = g.Image[0].Bounds()
+	}
+	canvas := image.NewRGBA(bounds)
+
+	frames := make([]*image.RGBA, 0, len(g.Image))
+	delays := make([]int, 0, len(g.Image))
+	for i, frame := range g.Image {
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		var previous []byte
+		if disposal == gif.DisposalPrevious {
+			previous = append([]byte(nil), canvas.Pix...)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		frames = append(frames, &image.RGBA{Pix: append([]byte(nil), canvas.Pix...), Stride: canvas.Stride, Rect: canvas.Rect})
+
+		delay := defaultStickerFrameDelay
+		if i < len(g.Delay) && g.Delay[i] > 0 {
+			delay = g.Delay[i] * 10 // GIF delays are in 1/100 s
+		}
+		delays = append(delays, delay)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			copy(canvas.Pix, previous)
+		}
+	}
+	return frames, delays
+}
+
+// fitSticker scales img to fit stickerSize, centered on a transparent canvas.
+func fitSticker(img image.Image) *image.NRGBA {
+	return fitStickerTo(img, stickerSize)
+}
+
+// fitStickerTo scales img to fit size, centered on a transparent canvas of
+// stickerSize.
+func fitStickerTo(img image.Image, size int) *image.NRGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	nw, nh := size, h*size/w
+	if h > w {
+		nw, nh = w*size/h, size
+	}
+	nw, nh = max(nw, 1), max(nh, 1)
+
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, stickerSize, stickerSize))
+	offset := image.Pt((stickerSize-nw)/2, (stickerSize-nh)/2)
+	draw.Draw(dst, image.Rectangle{Min: offset, Max: offset.Add(image.Pt(nw, nh))}, resample(src, nw, nh), image.Point{}, draw.Src)
+	return dst
+}
+
+// posterize rounds the color channels of img to bits bits, in place.
+// Alpha is kept, so edges stay smooth.
+func posterize(img *image.NRGBA, bits int) *image.NRGBA {
+	if bits >= 8 {
+		return img
+	}
+	levels := 1<<bits - 1
+	var table [256]uint8
+	for v := range table {
+		table[v] = uint8((v*levels + 127) / 255 * 255 / levels)
+	}
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i] = table[img.Pix[i]]
+		img.Pix[i+1] = table[img.Pix[i+1]]
+		img.Pix[i+2] = table[img.Pix[i+2]]
+	}
+	return img
+}
+
+// resample resizes premultiplied src to nw x nh, averaging source pixels
+// when shrinking and interpolating bilinearly when enlarging.
+func resample(src *image.RGBA, nw, nh int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+
+	for y := 0; y < nh; y++ {
+		for x := 0; x < nw; x++ {
+			var px [4]float64
+			if nw < w {
+				x0, x1 := x*w/nw, max((x+1)*w/nw, x*w/nw+1)
+				y0, y1 := y*h/nh, max((y+1)*h/nh, y*h/nh+1)
+				for sy := y0; sy < y1; sy++ {
+					for sx := x0; sx < x1; sx++ {
+						i := src.PixOffset(sx, sy)
+						for c := range px {
+							px[c] += float64(src.Pix[i+c])
+						}
+					}
+				}
+				for c := range px {
+					px[c] /= float64((x1 - x0) * (y1 - y0))
+				}
+			} else {
+				fx := max((float64(x)+0.5)*float64(w)/float64(nw)-0.5, 0)
+				fy := max((float64(y)+0.5)*float64(h)/float64(nh)-0.5, 0)
+				x0, y0 := int(fx), int(fy)
+				x1, y1 := min(x0+1, w-1), min(y0+1, h-1)
+				dx, dy := fx-float64(x0), fy-float64(y0)
+				for c := range px {
+					top := float64(src.Pix[src.PixOffset(x0, y0)+c])*(1-dx) + float64(src.Pix[src.PixOffset(x1, y0)+c])*dx
+					bottom := float64(src.Pix[src.PixOffset(x0, y1)+c])*(1-dx) + float64(src.Pix[src.PixOffset(x1, y1)+c])*dx
+					px[c] = top*(1-dy) + bottom*dy
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			for c, v := range px {
+				dst.Pix[i+c] = uint8(v + 0.5)
+			}
+		}
+	}
+	return dst
+}
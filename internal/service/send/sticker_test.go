@@ -0,0 +1,182 @@
+package send
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"os"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// testPattern draws gradients, flat areas and transparency, which exercise
+// the predictor modes, backward references and the alpha channel.
+func testPattern(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{uint8(x * 255 / w), uint8(y * 255 / h), uint8((x ^ y) & 0xff), 0xff}
+			switch {
+			case x < w/4:
+				c = color.NRGBA{200, 30, 60, 0xff}
+			case y < h/4:
+				c.A = uint8(x & 0xff)
+			case (x/16+y/16)%5 == 0:
+				c = color.NRGBA{}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// assertSamePixels compares visible pixels; invisible ones may differ in color.
+func assertSamePixels(t *testing.T, want *image.NRGBA, got image.Image) {
+	t.Helper()
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("decoded bounds %v, want %v", got.Bounds(), want.Bounds())
+	}
+	b := want.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			w := want.NRGBAAt(x, y)
+			g := color.NRGBAModel.Convert(got.At(x, y)).(color.NRGBA)
+			if w.A == 0 && g.A == 0 {
+				continue
+			}
+			if w != g {
+				t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, g, w)
+			}
+		}
+	}
+}
+
+func TestWebPRoundTrip(t *testing.T) {
+	for _, size := range []image.Point{{1, 1}, {3, 7}, {100, 37}, {512, 512}} {
+		img := testPattern(size.X, size.Y)
+		decoded, err := webp.Decode(bytes.NewReader(encodeWebP(img)))
+		if err != nil {
+			t.Fatalf("%v: decode: %v", size, err)
+		}
+		assertSamePixels(t, img, decoded)
+	}
+}
+
+func TestStickerFromImageRoundTrip(t *testing.T) {
+	src := testPattern(300, 200)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	sticker, err := StickerFromImage(buf.Bytes())
+	if err != nil {
+		t.Fatalf("StickerFromImage: %v", err)
+	}
+	decoded, err := webp.Decode(bytes.NewReader(sticker.Data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	assertSamePixels(t, fitSticker(src), decoded)
+}
+
+func TestStickerFromPhoto(t *testing.T) {
+	photo, err := os.ReadFile("testdata/photo.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(photo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Enlarged to the size of a phone camera's photo
+	src := image.NewRGBA(img.Bounds())
+	draw.Draw(src, src.Bounds(), img, img.Bounds().Min, draw.Src)
+	var large bytes.Buffer
+	if err := jpeg.Encode(&large, resample(src, 4*src.Bounds().Dx(), 4*src.Bounds().Dy()), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	sticker, err := StickerFromImage(large.Bytes())
+	if err != nil {
+		t.Fatalf("StickerFromImage: %v", err)
+	}
+	if len(sticker.Data) > maxStickerSize {
+		t.Errorf("sticker is %d KB, over the limit", len(sticker.Data)>>10)
+	}
+	decoded, err := webp.Decode(bytes.NewReader(sticker.Data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got := decoded.Bounds(); got != image.Rect(0, 0, stickerSize, stickerSize) {
+		t.Errorf("decoded bounds %v", got)
+	}
+}
+
+func TestStickerFromNoise(t *testing.T) {
+	// Noise doesn't compress, so it only fits once reduced
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewNRGBA(image.Rect(0, 0, stickerSize, stickerSize))
+	rng.Read(img.Pix)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	sticker, err := StickerFromImage(buf.Bytes())
+	if err != nil {
+		t.Fatalf("StickerFromImage: %v", err)
+	}
+	if len(sticker.Data) > maxStickerSize {
+		t.Errorf("sticker is %d KB, over the limit", len(sticker.Data)>>10)
+	}
+}
+
+func TestCheckStickerSize(t *testing.T) {
+	for _, tc := range []struct {
+		sticker *StickerContent
+		fits    bool
+	}{
+		{Sticker(make([]byte, maxStickerSize)), true},
+		{Sticker(make([]byte, maxStickerSize+1)), false},
+		{AnimatedSticker(make([]byte, maxAnimatedStickerSize)), true},
+		{AnimatedSticker(make([]byte, maxAnimatedStickerSize+1)), false},
+	} {
+		_, err := checkStickerSize(tc.sticker)
+		if tooLarge := errors.Is(err, ErrStickerTooLarge); tooLarge == tc.fits {
+			t.Errorf("%d bytes, animated %v: got %v", len(tc.sticker.Data), tc.sticker.IsAnimated, err)
+		}
+	}
+}
+
+func TestStickerFromAnimatedGIF(t *testing.T) {
+	palette := color.Palette{color.Transparent, color.NRGBA{200, 30, 60, 0xff}}
+	g := &gif.GIF{}
+	for i := range 3 {
+		frame := image.NewPaletted(image.Rect(0, 0, 64, 48), palette)
+		for x := range 64 {
+			frame.SetColorIndex(x, i*16, 1)
+		}
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 5)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+
+	sticker, err := StickerFromImage(buf.Bytes())
+	if err != nil {
+		t.Fatalf("StickerFromImage: %v", err)
+	}
+	if !sticker.IsAnimated || !bytes.HasPrefix(sticker.Data, []byte("RIFF")) || bytes.Count(sticker.Data, []byte("ANMF")) != 3 {
+		t.Errorf("got animated %v with %d frames", sticker.IsAnimated, bytes.Count(sticker.Data, []byte("ANMF")))
+	}
+}
@@ -1,7 +1,11 @@
 package send
 
 import (
+	"context"
+	"fmt"
+	"orion-agent/internal/data/store"
 	"orion-agent/internal/utils"
+	"strings"
 
 	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types"
@@ -63,6 +67,21 @@ func (t *TextContent) GetMentionedJIDs() []types.JID { return nil }
 // GetContextInfo implements Content.
 func (t *TextContent) GetContextInfo() *ContextInfo { return t.ContextInfo }
 
+// SendText sends a text message, mentioning the given users.
+// Mentions are converted to the phone-number JIDs WhatsApp expects, and
+// missing @<number> tokens are added to the text.
+func (s *SendService) SendText(ctx context.Context, to types.JID, text string, mentions ...types.JID) (*SendResult, error) {
+	if len(mentions) == 0 {
+		return s.Send(ctx, to, Text(text))
+	}
+
+	pns := make([]types.JID, len(mentions))
+	for i, jid := range mentions {
+		pns[i] = s.utils.ToPN(jid.ToNonAD())
+	}
+	return s.Send(ctx, to, TextWithMentions(text, pns...))
+}
+
 // ExtendedTextContent represents a text message with link preview, mentions, etc.
 type ExtendedTextContent struct {
 	Text          string
@@ -73,7 +92,13 @@ type ExtendedTextContent struct {
 	PreviewType   waE2E.ExtendedTextMessage_PreviewType
 	ThumbnailJPEG []byte
 	MentionedJIDs []types.JID
+	GroupMentions []store.GroupMention
 	ContextInfo   *ContextInfo
+
+	// Fill the link preview from the page's OpenGraph tags when sending
+	FetchPreview bool
+
+	previewAttempted bool
 }
 
 // ExtendedText creates an extended text message.
@@ -94,13 +119,50 @@ func TextWithPreview(text, url, title, description string) *ExtendedTextContent
 }
 
 // TextWithMentions creates a text message with mentions.
+// WhatsApp only highlights a mention if the text contains its @<user> token,
+// so tokens missing from the text are appended at the end.
 func TextWithMentions(text string, mentions ...types.JID) *ExtendedTextContent {
+	seen := make(map[types.JID]bool, len(mentions))
+	jids := make([]types.JID, 0, len(mentions))
+	for _, jid := range mentions {
+		jid = jid.ToNonAD()
+		if jid.IsEmpty() || seen[jid] {
+			continue
+		}
+		seen[jid] = true
+		jids = append(jids, jid)
+
+		if !hasMentionToken(text, jid.User) {
+			if text != "" && !strings.HasSuffix(text, " ") && !strings.HasSuffix(text, "\n") {
+				text += " "
+			}
+			text += "@" + jid.User
+		}
+	}
+
 	return &ExtendedTextContent{
 		Text:          text,
-		MentionedJIDs: mentions,
+		MentionedJIDs: jids,
 	}
 }
 
+// hasMentionToken reports whether text contains @user not followed by another digit.
+func hasMentionToken(text, user string) bool {
+	token := "@" + user
+	for i := strings.Index(text, token); i >= 0; {
+		end := i + len(token)
+		if end == len(text) || text[end] < '0' || text[end] > '9' {
+			return true
+		}
+		next := strings.Index(text[end:], token)
+		if next < 0 {
+			break
+		}
+		i = end + next
+	}
+	return false
+}
+
 // WithTitle sets the link preview title.
 func (e *ExtendedTextContent) WithTitle(title string) *ExtendedTextContent {
 	e.Title = title
@@ -126,12 +188,40 @@ func (e *ExtendedTextContent) WithThumbnail(jpeg []byte) *ExtendedTextContent {
 	return e
 }
 
+// WithLinkPreview sets a link preview with a custom title, description and
+// JPEG thumbnail.
+func (e *ExtendedTextContent) WithLinkPreview(url, title, description string, thumbnailJPEG []byte) *ExtendedTextContent {
+	e.CanonicalURL = url
+	e.MatchedText = url
+	e.Title = title
+	e.Description = description
+	if len(thumbnailJPEG) > 0 {
+		e.ThumbnailJPEG = thumbnailJPEG
+	}
+	return e
+}
+
+// WithFetchedPreview fetches the link preview from the URL (or the first
+// link in the text) when sending. Fields set explicitly take precedence.
+// If the page can't be fetched, the text is sent without a preview.
+func (e *ExtendedTextContent) WithFetchedPreview() *ExtendedTextContent {
+	e.FetchPreview = true
+	return e
+}
+
 // WithMentions adds mentioned JIDs.
 func (e *ExtendedTextContent) WithMentions(jids ...types.JID) *ExtendedTextContent {
 	e.MentionedJIDs = append(e.MentionedJIDs, jids...)
 	return e
 }
 
+// WithGroupMentions adds mentions of groups, such as a community's
+// subgroups in an announcement. Each is shown as its subject.
+func (e *ExtendedTextContent) WithGroupMentions(mentions []store.GroupMention) *ExtendedTextContent {
+	e.GroupMentions = append(e.GroupMentions, mentions...)
+	return e
+}
+
 // WithContext adds context info.
 func (e *ExtendedTextContent) WithContext(ctx *ContextInfo) *ExtendedTextContent {
 	e.ContextInfo = ctx
@@ -174,6 +264,24 @@ func (e *ExtendedTextContent) ToMessage() (*waE2E.Message, error) {
 		ctxInfo.MentionedJID = mentions
 	}
 
+	// Add group mentions to context
+	if len(e.GroupMentions) > 0 {
+		if ctxInfo == nil {
+			ctxInfo = &waE2E.ContextInfo{}
+		}
+		mentions := make([]*waE2E.GroupMention, len(e.GroupMentions))
+		for i, gm := range e.GroupMentions {
+			if gm.GroupJID.Server != types.GroupServer {
+				return nil, fmt.Errorf("mentioned %s is not a group", gm.GroupJID)
+			}
+			mentions[i] = &waE2E.GroupMention{
+				GroupJID:     proto.String(gm.GroupJID.String()),
+				GroupSubject: proto.String(gm.GroupSubject),
+			}
+		}
+		ctxInfo.GroupMentions = mentions
+	}
+
 	if ctxInfo != nil {
 		ext.ContextInfo = ctxInfo
 	}
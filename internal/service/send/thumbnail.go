@@ -0,0 +1,89 @@
+package send
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AutoVideoThumbnails enables generating a thumbnail with ffmpeg for videos
+// sent without one. Set to false to avoid the ffmpeg dependency.
+var AutoVideoThumbnails = true
+
+// Thumbnail generation settings.
+const (
+	videoThumbnailWidth   = 320
+	videoThumbnailTimeout = 15 * time.Second
+)
+
+// ffmpegMissing is logged once when ffmpeg isn't installed.
+var ffmpegMissing sync.Once
+
+// ensureVideoThumbnail fills in a missing video thumbnail from its first keyframe.
+// Best-effort: failures are logged and the video is sent without one.
+// It's only attempted once per video, so resending doesn't rerun ffmpeg.
+func (s *SendService) ensureVideoThumbnail(ctx context.Context, v *VideoContent) {
+	if !AutoVideoThumbnails || v.thumbAttempted || len(v.ThumbnailJPEG) > 0 || len(v.Data) == 0 {
+		return
+	}
+	v.thumbAttempted = true
+
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		ffmpegMissing.Do(func() {
+			s.log.Warnf("ffmpeg not found, sending videos without thumbnails")
+		})
+		return
+	}
+
+	thumb, err := videoThumbnail(ctx, ffmpeg, v.Data)
+	if err != nil {
+		s.log.Warnf("Failed to generate video thumbnail: %v", err)
+		return
+	}
+	v.ThumbnailJPEG = thumb
+}
+
+// videoThumbnail extracts the first keyframe of a video as a JPEG.
+func videoThumbnail(ctx context.Context, ffmpeg string, data []byte) ([]byte, error) {
+	// MP4 files often keep their index at the end, so ffmpeg needs a seekable file, not a pipe
+	tmp, err := os.CreateTemp("", "orion-video-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, videoThumbnailTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, ffmpeg,
+		"-hide_banner", "-loglevel", "error",
+		"-skip_frame", "nokey", "-i", tmp.Name(),
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale='min(%d,iw)':-2", videoThumbnailWidth),
+		"-f", "image2", "-c:v", "mjpeg", "-q:v", "5",
+		"pipe:1",
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("ffmpeg produced no frame")
+	}
+	return out, nil
+}
@@ -41,6 +41,9 @@ type SendResult struct {
 	Recipient types.JID
 	Sender    types.JID
 	DebugInfo whatsmeow.MessageDebugTimings
+
+	// PollEncryptionKey is the key votes on a sent poll are encrypted with.
+	PollEncryptionKey []byte
 }
 
 // SendOption is a functional option for configuring send operations.
@@ -52,6 +55,40 @@ type sendConfig struct {
 	Timeout     time.Duration
 	Peer        bool
 	MediaHandle string
+
+	// RawContextInfo is merged into the built message's ContextInfo.
+	RawContextInfo *waE2E.ContextInfo
+
+	// NoAutoEphemeral disables applying the chat's disappearing timer to media and replies.
+	NoAutoEphemeral bool
+
+	// NoSave skips saving the sent message to the database.
+	NoSave bool
+
+	// NoAnnounceCheck disables the admin check for announce-only groups.
+	NoAnnounceCheck bool
+
+	// NoFooter skips appending the configured outbound footer.
+	NoFooter bool
+
+	// NoFailedSendRecord skips recording a failed send for later retry.
+	NoFailedSendRecord bool
+
+	// MessageAssociation links the message to a parent (e.g. an album).
+	MessageAssociation *waE2E.MessageAssociation
+
+	// Forwarded saves a raw message as forwarded.
+	Forwarded bool
+
+	// RetryAttempts and RetryBackoff retry transient send failures.
+	RetryAttempts int
+	RetryBackoff  time.Duration
+
+	// Concurrency limits parallel sends in SendBulk (default 1, sequential).
+	Concurrency int
+
+	// IdempotencyKey deduplicates sends of the same logical message.
+	IdempotencyKey string
 }
 
 // WithID sets a custom message ID.
@@ -82,6 +119,94 @@ func WithMediaHandle(handle string) SendOption {
 	}
 }
 
+// WithRawContextInfo merges a caller-provided ContextInfo into the message.
+// This exposes ContextInfo fields that have no builder method
+// (e.g. DisappearingMode, ActionLink, EphemeralSharedSecret).
+// Fields set on ctx override values built from the content; repeated fields
+// are appended.
+func WithRawContextInfo(ctx *waE2E.ContextInfo) SendOption {
+	return func(c *sendConfig) {
+		c.RawContextInfo = ctx
+	}
+}
+
+// WithoutAutoEphemeral opts out of applying the chat's disappearing-message
+// timer to media sends and replies.
+func WithoutAutoEphemeral() SendOption {
+	return func(c *sendConfig) {
+		c.NoAutoEphemeral = true
+	}
+}
+
+// WithoutSave skips saving the sent message to the database.
+func WithoutSave() SendOption {
+	return func(c *sendConfig) {
+		c.NoSave = true
+	}
+}
+
+// WithoutAnnounceCheck skips the admin check for announce-only groups.
+func WithoutAnnounceCheck() SendOption {
+	return func(c *sendConfig) {
+		c.NoAnnounceCheck = true
+	}
+}
+
+// WithoutFooter skips appending the configured outbound footer.
+func WithoutFooter() SendOption {
+	return func(c *sendConfig) {
+		c.NoFooter = true
+	}
+}
+
+// WithoutFailedSendRecord skips recording a failed send for
+// RetryFailedSends, for callers that track failures themselves.
+func WithoutFailedSendRecord() SendOption {
+	return func(c *sendConfig) {
+		c.NoFailedSendRecord = true
+	}
+}
+
+// WithRetry retries sends that fail with transient errors (disconnection,
+// timeouts) up to maxAttempts times in total, doubling the wait from backoff
+// between attempts. Media is uploaded once and reused on every attempt.
+func WithRetry(maxAttempts int, backoff time.Duration) SendOption {
+	return func(c *sendConfig) {
+		c.RetryAttempts = maxAttempts
+		c.RetryBackoff = backoff
+	}
+}
+
+// WithConcurrency lets SendBulk send to up to n recipients at once.
+func WithConcurrency(n int) SendOption {
+	return func(c *sendConfig) {
+		c.Concurrency = n
+	}
+}
+
+// WithIdempotencyKey makes Send return the earlier result instead of sending
+// again when a message was already sent with the same key within the
+// idempotency window.
+func WithIdempotencyKey(key string) SendOption {
+	return func(c *sendConfig) {
+		c.IdempotencyKey = key
+	}
+}
+
+// withMessageAssociation links the message to a parent message.
+func withMessageAssociation(assoc *waE2E.MessageAssociation) SendOption {
+	return func(c *sendConfig) {
+		c.MessageAssociation = assoc
+	}
+}
+
+// asForwarded saves a raw message as forwarded.
+func asForwarded() SendOption {
+	return func(c *sendConfig) {
+		c.Forwarded = true
+	}
+}
+
 // applyOptions applies all options to a config.
 func applyOptions(opts []SendOption) *sendConfig {
 	cfg := &sendConfig{}
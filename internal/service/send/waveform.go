@@ -0,0 +1,85 @@
+package send
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Waveform settings matching the WhatsApp apps.
+const (
+	waveformSamples    = 64
+	waveformSampleRate = 8000
+	waveformTimeout    = 15 * time.Second
+)
+
+// WaveformFromPCM computes a voice note waveform from mono PCM samples:
+// 64 amplitude values normalized to 0–100.
+// Returns nil if there are fewer samples than waveform points.
+func WaveformFromPCM(samples []int16) []byte {
+	if len(samples) < waveformSamples {
+		return nil
+	}
+
+	levels := make([]float64, waveformSamples)
+	var peak float64
+	for i := range levels {
+		bucket := samples[i*len(samples)/waveformSamples : (i+1)*len(samples)/waveformSamples]
+		var sum float64
+		for _, v := range bucket {
+			if v < 0 {
+				sum -= float64(v)
+			} else {
+				sum += float64(v)
+			}
+		}
+		levels[i] = sum / float64(len(bucket))
+		if levels[i] > peak {
+			peak = levels[i]
+		}
+	}
+
+	waveform := make([]byte, waveformSamples)
+	if peak == 0 {
+		return waveform
+	}
+	for i, level := range levels {
+		waveform[i] = byte(level / peak * 100)
+	}
+	return waveform
+}
+
+// decodePCM decodes audio to mono 16-bit PCM at waveformSampleRate using ffmpeg.
+func decodePCM(ctx context.Context, data []byte) ([]int16, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, waveformTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, ffmpeg,
+		"-hide_banner", "-loglevel", "error",
+		"-i", "pipe:0",
+		"-f", "s16le", "-ac", "1", "-ar", fmt.Sprint(waveformSampleRate),
+		"pipe:1",
+	)
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	samples := make([]int16, len(out)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(out[i*2:]))
+	}
+	return samples, nil
+}
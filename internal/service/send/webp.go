@@ -0,0 +1,603 @@
+package send
+
+import (
+	"encoding/binary"
+	"image"
+	"sort"
+)
+
+// Lossless WebP (VP8L) encoder, enough to turn images into stickers.
+// Pixels go through the subtract-green and predictor transforms, then LZ77
+// and a single group of prefix codes. No color cache or meta prefix codes.
+
+// VP8L encoder settings.
+const (
+	vp8lSignature     = 0x2f
+	vp8lPredictorBits = 5 // 32x32 predictor blocks
+	vp8lMaxCodeLength = 15
+	vp8lMaxCLLength   = 7 // Code length code lengths are written in 3 bits
+
+	lz77MinLength = 3
+	lz77MaxLength = 4096
+	lz77MaxChain  = 16
+	lz77HashBits  = 16
+	lz77DistShift = 120 // Distance codes 1..120 are 2D neighbourhood codes
+)
+
+// Transform types.
+const (
+	vp8lPredictorTransform     = 0
+	vp8lSubtractGreenTransform = 2
+)
+
+// vp8lCodeLengthOrder is the order code length code lengths are written in.
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// encodeWebP encodes img as a still lossless WebP.
+func encodeWebP(img *image.NRGBA) []byte {
+	return riffWebP(riffChunk("VP8L", encodeVP8L(img)))
+}
+
+// encodeAnimatedWebP wraps width x height VP8L frames, as returned by
+// encodeVP8L, in a looping animated WebP. delays are the frame durations in
+// milliseconds.
+func encodeAnimatedWebP(width, height int, frames [][]byte, delays []int) []byte {
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x10 | 0x02 // Alpha, animation
+	putUint24(vp8x[4:], width-1)
+	putUint24(vp8x[7:], height-1)
+
+	anim := make([]byte, 6) // Transparent background, loop forever
+
+	data := append(riffChunk("VP8X", vp8x), riffChunk("ANIM", anim)...)
+	for i, frame := range frames {
+		header := make([]byte, 16)
+		putUint24(header[6:], width-1)
+		putUint24(header[9:], height-1)
+		putUint24(header[12:], delays[i])
+		header[15] = 0x02 // Don't blend, frames are complete
+		anmf := append(header, riffChunk("VP8L", frame)...)
+		data = append(data, riffChunk("ANMF", anmf)...)
+	}
+	return riffWebP(data)
+}
+
+func riffWebP(chunks []byte) []byte {
+	out := make([]byte, 12, 12+len(chunks))
+	copy(out, "RIFF")
+	binary.LittleEndian.PutUint32(out[4:], uint32(4+len(chunks)))
+	copy(out[8:], "WEBP")
+	return append(out, chunks...)
+}
+
+func riffChunk(fourcc string, payload []byte) []byte {
+	out := make([]byte, 8, 8+len(payload)+1)
+	copy(out, fourcc)
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(payload)))
+	out = append(out, payload...)
+	if len(payload)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+func putUint24(b []byte, v int) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
+
+// encodeVP8L encodes img as a VP8L bitstream.
+func encodeVP8L(img *image.NRGBA) []byte {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+
+	pix := make([]uint32, 0, width*height)
+	hasAlpha := false
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, y):]
+		for x := 0; x < width; x++ {
+			r, g, b, a := uint32(row[x*4]), uint32(row[x*4+1]), uint32(row[x*4+2]), uint32(row[x*4+3])
+			if a == 0 {
+				r, g, b = 0, 0, 0 // Invisible, so pick what compresses best
+			}
+			if a != 0xff {
+				hasAlpha = true
+			}
+			pix = append(pix, a<<24|r<<16|g<<8|b)
+		}
+	}
+
+	w := &bitWriter{}
+	w.writeBits(vp8lSignature, 8)
+	w.writeBits(uint32(width-1), 14)
+	w.writeBits(uint32(height-1), 14)
+	w.writeBits(boolBit(hasAlpha), 1)
+	w.writeBits(0, 3) // Version
+
+	// Transforms are undone in reverse, so subtract green is applied first
+	w.writeBits(1, 1)
+	w.writeBits(vp8lSubtractGreenTransform, 2)
+	subtractGreen(pix)
+
+	w.writeBits(1, 1)
+	w.writeBits(vp8lPredictorTransform, 2)
+	w.writeBits(vp8lPredictorBits-2, 3)
+	residuals, modes := predict(pix, width, height)
+	writeEntropyImage(w, modes, false)
+
+	w.writeBits(0, 1) // No more transforms
+	writeEntropyImage(w, residuals, true)
+	return w.bytes()
+}
+
+func boolBit(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func subtractGreen(pix []uint32) {
+	for i, p := range pix {
+		g := (p >> 8) & 0xff
+		r := ((p >> 16) - g) & 0xff
+		b := (p - g) & 0xff
+		pix[i] = p&0xff00ff00 | r<<16 | b
+	}
+}
+
+// predict applies the predictor transform. Each block uses the predictor
+// mode with the smallest residuals; the modes are returned as an image with
+// the mode in the green channel.
+func predict(pix []uint32, width, height int) (residuals, modes []uint32) {
+	blockSize := 1 << vp8lPredictorBits
+	modesW := (width + blockSize - 1) / blockSize
+	modesH := (height + blockSize - 1) / blockSize
+	modes = make([]uint32, modesW*modesH)
+
+	for by := 0; by < modesH; by++ {
+		for bx := 0; bx < modesW; bx++ {
+			best, bestCost := 0, -1
+			for mode := 0; mode < 14; mode++ {
+				cost := 0
+				for y := by * blockSize; y < min((by+1)*blockSize, height); y++ {
+					for x := bx * blockSize; x < min((bx+1)*blockSize, width); x++ {
+						cost += residualCost(argbSub(pix[y*width+x], predictPixel(pix, width, x, y, mode)))
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+			modes[by*modesW+bx] = 0xff000000 | uint32(best)<<8
+		}
+	}
+
+	residuals = make([]uint32, len(pix))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			mode := int(modes[(y>>vp8lPredictorBits)*modesW+(x>>vp8lPredictorBits)]>>8) & 0xf
+			residuals[y*width+x] = argbSub(pix[y*width+x], predictPixel(pix, width, x, y, mode))
+		}
+	}
+	return residuals, modes
+}
+
+// predictPixel predicts the pixel at x, y from its decoded neighbours.
+// The first row and column have fixed predictors.
+func predictPixel(pix []uint32, width, x, y, mode int) uint32 {
+	i := y*width + x
+	switch {
+	case x == 0 && y == 0:
+		return 0xff000000
+	case y == 0:
+		return pix[i-1]
+	case x == 0:
+		return pix[i-width]
+	}
+
+	// TR of the last column wraps to the first pixel of the current row
+	l, t, tr, tl := pix[i-1], pix[i-width], pix[i-width+1], pix[i-width-1]
+	switch mode {
+	case 0:
+		return 0xff000000
+	case 1:
+		return l
+	case 2:
+		return t
+	case 3:
+		return tr
+	case 4:
+		return tl
+	case 5:
+		return argbAverage(argbAverage(l, tr), t)
+	case 6:
+		return argbAverage(l, tl)
+	case 7:
+		return argbAverage(l, t)
+	case 8:
+		return argbAverage(tl, t)
+	case 9:
+		return argbAverage(t, tr)
+	case 10:
+		return argbAverage(argbAverage(l, tl), argbAverage(t, tr))
+	case 11:
+		return argbSelect(l, t, tl)
+	case 12:
+		return argbMap3(l, t, tl, func(a, b, c int) int { return a + b - c })
+	default:
+		return argbMap3(argbAverage(l, t), tl, 0, func(a, b, _ int) int { return a + (a-b)/2 })
+	}
+}
+
+func argbSub(a, b uint32) uint32 {
+	var out uint32
+	for shift := 0; shift < 32; shift += 8 {
+		out |= (((a >> shift) - (b >> shift)) & 0xff) << shift
+	}
+	return out
+}
+
+func argbAverage(a, b uint32) uint32 {
+	return argbMap3(a, b, 0, func(a, b, _ int) int { return (a + b) / 2 })
+}
+
+// argbMap3 applies fn to each channel, clamping the result to 0..255.
+func argbMap3(a, b, c uint32, fn func(a, b, c int) int) uint32 {
+	var out uint32
+	for shift := 0; shift < 32; shift += 8 {
+		v := fn(int(a>>shift&0xff), int(b>>shift&0xff), int(c>>shift&0xff))
+		out |= uint32(min(max(v, 0), 255)) << shift
+	}
+	return out
+}
+
+func argbSelect(l, t, tl uint32) uint32 {
+	var pl, pt int
+	for shift := 0; shift < 32; shift += 8 {
+		cl, ct, ctl := int(l>>shift&0xff), int(t>>shift&0xff), int(tl>>shift&0xff)
+		p := cl + ct - ctl
+		pl += abs(p - cl)
+		pt += abs(p - ct)
+	}
+	if pl < pt {
+		return l
+	}
+	return t
+}
+
+// residualCost estimates how well a residual compresses: small values
+// either side of zero are cheap.
+func residualCost(r uint32) int {
+	cost := 0
+	for shift := 0; shift < 32; shift += 8 {
+		cost += abs(int(int8(r >> shift)))
+	}
+	return cost
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// vp8lToken is a literal pixel or a backward reference.
+type vp8lToken struct {
+	argb   uint32
+	length int // 0 for literals
+	dist   int // Distance code
+}
+
+// writeEntropyImage writes pixels with a single group of prefix codes.
+// main is set for the ARGB image, which also signals meta prefix codes.
+func writeEntropyImage(w *bitWriter, pix []uint32, main bool) {
+	tokens := lz77(pix)
+
+	w.writeBits(0, 1) // No color cache
+	if main {
+		w.writeBits(0, 1) // No meta prefix codes
+	}
+
+	green := make([]int, 256+24)
+	red, blue, alpha := make([]int, 256), make([]int, 256), make([]int, 256)
+	dist := make([]int, 40)
+	for _, t := range tokens {
+		if t.length == 0 {
+			green[t.argb>>8&0xff]++
+			red[t.argb>>16&0xff]++
+			blue[t.argb&0xff]++
+			alpha[t.argb>>24]++
+			continue
+		}
+		code, _, _ := lz77Prefix(t.length)
+		green[256+code]++
+		code, _, _ = lz77Prefix(t.dist)
+		dist[code]++
+	}
+
+	codes := [5]*prefixCode{
+		writePrefixCode(w, green),
+		writePrefixCode(w, red),
+		writePrefixCode(w, blue),
+		writePrefixCode(w, alpha),
+		writePrefixCode(w, dist),
+	}
+
+	for _, t := range tokens {
+		if t.length == 0 {
+			codes[0].write(w, int(t.argb>>8&0xff))
+			codes[1].write(w, int(t.argb>>16&0xff))
+			codes[2].write(w, int(t.argb&0xff))
+			codes[3].write(w, int(t.argb>>24))
+			continue
+		}
+		code, extraBits, extra := lz77Prefix(t.length)
+		codes[0].write(w, 256+code)
+		w.writeBits(extra, extraBits)
+		code, extraBits, extra = lz77Prefix(t.dist)
+		codes[4].write(w, code)
+		w.writeBits(extra, extraBits)
+	}
+}
+
+// lz77 finds backward references in pix with a hash chain.
+func lz77(pix []uint32) []vp8lToken {
+	head := make([]int32, 1<<lz77HashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	prev := make([]int32, len(pix))
+	insert := func(i int) {
+		if i+1 >= len(pix) {
+			return
+		}
+		h := lz77Hash(pix[i], pix[i+1])
+		prev[i] = head[h]
+		head[h] = int32(i)
+	}
+
+	tokens := make([]vp8lToken, 0, len(pix)/2)
+	for i := 0; i < len(pix); {
+		bestLen, bestDist := 0, 0
+		if i+1 < len(pix) {
+			j := head[lz77Hash(pix[i], pix[i+1])]
+			for n := 0; j >= 0 && n < lz77MaxChain; n++ {
+				l := 0
+				for l < lz77MaxLength && i+l < len(pix) && pix[int(j)+l] == pix[i+l] {
+					l++
+				}
+				if l > bestLen {
+					bestLen, bestDist = l, i-int(j)
+				}
+				j = prev[j]
+			}
+		}
+
+		if bestLen < lz77MinLength {
+			tokens = append(tokens, vp8lToken{argb: pix[i]})
+			insert(i)
+			i++
+			continue
+		}
+		tokens = append(tokens, vp8lToken{length: bestLen, dist: bestDist + lz77DistShift})
+		for k := 0; k < bestLen; k++ {
+			insert(i + k)
+		}
+		i += bestLen
+	}
+	return tokens
+}
+
+func lz77Hash(a, b uint32) uint32 {
+	return (a*0x1e35a7bd ^ b*0x9e3779b1) >> (32 - lz77HashBits)
+}
+
+// lz77Prefix splits a length or distance into its prefix code and extra bits.
+func lz77Prefix(v int) (code int, extraBits uint, extra uint32) {
+	d := v - 1
+	if d < 4 {
+		return d, 0, 0
+	}
+	h := 31
+	for d>>h == 0 {
+		h--
+	}
+	second := (d >> (h - 1)) & 1
+	extraBits = uint(h - 1)
+	return 2*h + second, extraBits, uint32(d) & (1<<extraBits - 1)
+}
+
+// prefixCode is a canonical prefix code, with codes bit-reversed for writing.
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint32
+}
+
+func (c *prefixCode) write(w *bitWriter, symbol int) {
+	w.writeBits(c.codes[symbol], uint(c.lengths[symbol]))
+}
+
+// writePrefixCode builds a prefix code from symbol counts and writes it.
+func writePrefixCode(w *bitWriter, counts []int) *prefixCode {
+	var used []int
+	for symbol, n := range counts {
+		if n > 0 {
+			used = append(used, symbol)
+		}
+	}
+
+	// Up to two 8-bit symbols fit a simple code; a single symbol takes no bits
+	if len(used) <= 2 && (len(used) == 0 || used[len(used)-1] < 256) {
+		if len(used) == 0 {
+			used = []int{0}
+		}
+		w.writeBits(1, 1)
+		w.writeBits(uint32(len(used)-1), 1)
+		if used[0] < 2 {
+			w.writeBits(0, 1)
+			w.writeBits(uint32(used[0]), 1)
+		} else {
+			w.writeBits(1, 1)
+			w.writeBits(uint32(used[0]), 8)
+		}
+		lengths := make([]uint8, len(counts))
+		if len(used) == 2 {
+			w.writeBits(uint32(used[1]), 8)
+			lengths[used[0]], lengths[used[1]] = 1, 1
+		}
+		return newPrefixCode(lengths)
+	}
+
+	w.writeBits(0, 1)
+	lengths := huffmanLengths(counts, vp8lMaxCodeLength)
+
+	var clCounts [19]int
+	for _, l := range lengths {
+		clCounts[l]++
+	}
+	clLengths := huffmanLengths(clCounts[:], vp8lMaxCLLength)
+	n := len(vp8lCodeLengthOrder)
+	for n > 4 && clLengths[vp8lCodeLengthOrder[n-1]] == 0 {
+		n--
+	}
+	w.writeBits(uint32(n-4), 4)
+	for _, symbol := range vp8lCodeLengthOrder[:n] {
+		w.writeBits(uint32(clLengths[symbol]), 3)
+	}
+	w.writeBits(0, 1) // Lengths for the whole alphabet follow
+
+	clCode := newPrefixCode(clLengths)
+	for _, l := range lengths {
+		clCode.write(w, int(l))
+	}
+	return newPrefixCode(lengths)
+}
+
+// huffmanLengths computes code lengths of at most limit bits. Decoders
+// require complete codes, so a lone symbol is paired with a dummy one.
+func huffmanLengths(counts []int, limit int) []uint8 {
+	type node struct {
+		weight      int
+		symbol      int // -1 for internal nodes
+		left, right *node
+	}
+
+	counts = append([]int(nil), counts...)
+	used := 0
+	for _, n := range counts {
+		if n > 0 {
+			used++
+		}
+	}
+	if used < 2 {
+		for i := range counts {
+			if counts[i] == 0 {
+				counts[i] = 1
+				if used++; used == 2 {
+					break
+				}
+			}
+		}
+	}
+
+	lengths := make([]uint8, len(counts))
+	for minWeight := 1; ; minWeight *= 2 {
+		var leaves []*node
+		for symbol, n := range counts {
+			if n > 0 {
+				leaves = append(leaves, &node{weight: max(n, minWeight), symbol: symbol})
+			}
+		}
+		sort.SliceStable(leaves, func(i, j int) bool { return leaves[i].weight < leaves[j].weight })
+
+		// Two-queue construction: leaves and merged nodes both come out sorted
+		var merged []*node
+		pop := func() *node {
+			if len(merged) == 0 || (len(leaves) > 0 && leaves[0].weight <= merged[0].weight) {
+				n := leaves[0]
+				leaves = leaves[1:]
+				return n
+			}
+			n := merged[0]
+			merged = merged[1:]
+			return n
+		}
+		for len(leaves)+len(merged) > 1 {
+			a, b := pop(), pop()
+			merged = append(merged, &node{weight: a.weight + b.weight, symbol: -1, left: a, right: b})
+		}
+
+		maxDepth := 0
+		var walk func(n *node, depth int)
+		walk = func(n *node, depth int) {
+			if n.symbol >= 0 {
+				lengths[n.symbol] = uint8(depth)
+				maxDepth = max(maxDepth, depth)
+				return
+			}
+			walk(n.left, depth+1)
+			walk(n.right, depth+1)
+		}
+		walk(merged[0], 0)
+		if maxDepth <= limit {
+			return lengths
+		}
+	}
+}
+
+// newPrefixCode assigns canonical codes to lengths.
+func newPrefixCode(lengths []uint8) *prefixCode {
+	var countPerLength [vp8lMaxCodeLength + 1]uint32
+	for _, l := range lengths {
+		countPerLength[l]++
+	}
+	countPerLength[0] = 0
+
+	var next [vp8lMaxCodeLength + 1]uint32
+	code := uint32(0)
+	for l := 1; l <= vp8lMaxCodeLength; l++ {
+		code = (code + countPerLength[l-1]) << 1
+		next[l] = code
+	}
+
+	c := &prefixCode{lengths: lengths, codes: make([]uint32, len(lengths))}
+	for symbol, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		code := next[l]
+		next[l]++
+		var reversed uint32
+		for i := uint8(0); i < l; i++ {
+			reversed = reversed<<1 | (code>>i)&1
+		}
+		c.codes[symbol] = reversed
+	}
+	return c
+}
+
+// bitWriter packs bits least significant first.
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+func (w *bitWriter) writeBits(v uint32, n uint) {
+	w.acc |= uint64(v) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nbits = 0, 0
+	}
+	return w.buf
+}
@@ -2,6 +2,8 @@ package sync
 
 import (
 	"context"
+	"slices"
+	"strings"
 
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
@@ -14,17 +16,34 @@ import (
 // =============================================================================
 
 // SyncUserInfo fetches user info for the given JIDs using GetUserInfo.
+// Concurrent calls for the same JIDs share one query.
 func (s *SyncService) SyncUserInfo(ctx context.Context, jids ...types.JID) error {
-	if s.client == nil || ctx.Err() != nil {
+	if (s.client == nil && s.getUserInfo == nil) || ctx.Err() != nil {
 		return ctx.Err()
 	}
-	s.log.Debugf("Syncing user info for %v", jids)
 
 	jids = s.utils.NormalizeJIDs(ctx, jids)
+	key := make([]string, len(jids))
+	for i, jid := range jids {
+		key[i] = jid.String()
+	}
+	slices.Sort(key)
+	return s.coalesceKey("user_info:"+strings.Join(slices.Compact(key), ","), func() error {
+		return s.syncUserInfo(ctx, jids)
+	})
+}
+
+func (s *SyncService) syncUserInfo(ctx context.Context, jids []types.JID) error {
+	s.log.Debugf("Syncing user info for %v", jids)
+
 	var infos map[types.JID]types.UserInfo
 	err := s.performUSync(ctx, func(ctx context.Context) error {
 		var err error
-		infos, err = s.client.GetUserInfo(ctx, jids)
+		if s.getUserInfo != nil {
+			infos, err = s.getUserInfo(ctx, jids)
+		} else {
+			infos, err = s.client.GetUserInfo(ctx, jids)
+		}
 		return err
 	})
 
@@ -65,9 +84,14 @@ func (s *SyncService) SyncProfilePicture(ctx context.Context, jid types.JID) err
 	if jid.Server != types.DefaultUserServer && jid.Server != types.HiddenUserServer {
 		return nil
 	}
-	s.log.Debugf("Syncing profile picture for %s", jid)
-
 	jid = s.utils.NormalizeJID(ctx, jid)
+	return s.coalesce("profile_picture", jid, func() error {
+		return s.syncProfilePicture(ctx, jid)
+	})
+}
+
+func (s *SyncService) syncProfilePicture(ctx context.Context, jid types.JID) error {
+	s.log.Debugf("Syncing profile picture for %s", jid)
 
 	existingID := ""
 	if contact, err := s.contacts.Get(jid); err == nil && contact != nil {
@@ -172,6 +196,100 @@ func (s *SyncService) ResolvePhoneNumbers(ctx context.Context, phones ...string)
 	return result, nil
 }
 
+// contactImportBatchSize is how many phone numbers go in one IsOnWhatsApp
+// query. The server rejects oversized usync queries.
+const contactImportBatchSize = 50
+
+// ImportContacts resolves phone numbers, stores the LID/PN mappings of those on
+// WhatsApp and syncs them as new contacts in the background.
+// Returns how many numbers were found on WhatsApp.
+func (s *SyncService) ImportContacts(ctx context.Context, phones []string) (resolved int, err error) {
+	if s.client == nil || ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	queries := make([]string, 0, len(phones))
+	seen := make(map[string]bool, len(phones))
+	for _, phone := range phones {
+		user := s.utils.FromPhone(phone).User
+		if user == "" || seen[user] {
+			continue
+		}
+		seen[user] = true
+		queries = append(queries, "+"+user)
+	}
+	s.log.Infof("Importing %d phone numbers", len(queries))
+
+	var found []types.JID
+	for start := 0; start < len(queries); start += contactImportBatchSize {
+		batch := queries[start:min(start+contactImportBatchSize, len(queries))]
+
+		var responses []types.IsOnWhatsAppResponse
+		err := s.performUSync(ctx, func(ctx context.Context) error {
+			var err error
+			responses, err = s.client.IsOnWhatsApp(ctx, batch)
+			return err
+		})
+		if err != nil {
+			s.log.Errorf("Failed to resolve phone numbers: %v", err)
+			return resolved, err
+		}
+
+		var pns []types.JID
+		for _, resp := range responses {
+			if resp.IsIn {
+				pns = append(pns, resp.JID.ToNonAD())
+			}
+		}
+		if len(pns) == 0 {
+			continue
+		}
+		resolved += len(pns)
+
+		// IsOnWhatsApp only returns phone numbers, user info carries the LIDs
+		var infos map[types.JID]types.UserInfo
+		err = s.performUSync(ctx, func(ctx context.Context) error {
+			var err error
+			infos, err = s.client.GetUserInfo(ctx, pns)
+			return err
+		})
+		if err != nil {
+			s.log.Warnf("Failed to get LIDs for imported contacts: %v", err)
+		}
+
+		var mappings []store.JIDMapping
+		for _, pn := range pns {
+			lid := infos[pn].LID
+			if lid.IsEmpty() {
+				found = append(found, pn)
+				continue
+			}
+			lid = lid.ToNonAD()
+			s.utils.StoreMappingFromEvent(pn, lid)
+			mappings = append(mappings, store.JIDMapping{LID: lid, PN: pn})
+			found = append(found, lid)
+		}
+		if err := s.contacts.PutJIDMappings(mappings); err != nil {
+			s.log.Warnf("Failed to save contact mappings: %v", err)
+		}
+	}
+
+	// Full contact syncs go through the rate-limited usync queue and take a
+	// while, so don't hold the caller
+	s.spawn(func(ctx context.Context) {
+		for _, jid := range found {
+			if ctx.Err() != nil {
+				return
+			}
+			s.OnNewContact(ctx, jid)
+		}
+	})
+
+	s.log.Infof("Imported %d of %d phone numbers", resolved, len(queries))
+	s.recordSync("import_contacts")
+	return resolved, nil
+}
+
 // SyncUserDevices fetches device list for JIDs.
 func (s *SyncService) SyncUserDevices(ctx context.Context, jids ...types.JID) ([]types.JID, error) {
 	if s.client == nil || ctx.Err() != nil {
@@ -202,18 +320,22 @@ func (s *SyncService) SyncUserDevices(ctx context.Context, jids ...types.JID) ([
 
 // SubscribePresence subscribes to presence updates for a JID.
 func (s *SyncService) SubscribePresence(ctx context.Context, jid types.JID) error {
-	if s.client == nil || ctx.Err() != nil {
+	if (s.client == nil && s.subscribePresence == nil) || ctx.Err() != nil {
 		return ctx.Err()
 	}
 	s.log.Debugf("Subscribing to presence for %s", jid)
 
 	jid = s.utils.NormalizeJID(ctx, jid)
-	err := s.client.SubscribePresence(ctx, jid)
+	if err := s.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	err := s.sendPresenceSubscription(ctx, jid)
 	if err != nil {
 		s.log.Errorf("Failed to subscribe presence for %s: %v", jid, err)
 		return err
 	}
 
+	s.markPresenceSubscribed(jid)
 	s.log.Infof("Subscribed to presence for %s", jid)
 	s.recordSync("subscribe_presence")
 	return nil
@@ -275,20 +397,23 @@ func (s *SyncService) OnNewContact(ctx context.Context, jid types.JID) {
 
 	jid = s.utils.NormalizeJID(ctx, jid)
 
-	if err := s.SyncUserInfo(ctx, jid); err != nil {
-		s.log.Warnf("Failed to sync user info for %s: %v", jid, err)
-	}
+	s.coalesce("new_contact", jid, func() error {
+		if err := s.SyncUserInfo(ctx, jid); err != nil {
+			s.log.Warnf("Failed to sync user info for %s: %v", jid, err)
+		}
 
-	if err := s.SyncProfilePicture(ctx, jid); err != nil {
-		s.log.Warnf("Failed to sync profile pic for %s: %v", jid, err)
-	}
+		if err := s.SyncProfilePicture(ctx, jid); err != nil {
+			s.log.Warnf("Failed to sync profile pic for %s: %v", jid, err)
+		}
 
-	if err := s.SubscribePresence(ctx, jid); err != nil {
-		s.log.Warnf("Failed to subscribe presence for %s: %v", jid, err)
-	}
+		if err := s.SubscribePresence(ctx, jid); err != nil {
+			s.log.Warnf("Failed to subscribe presence for %s: %v", jid, err)
+		}
 
-	s.log.Infof("Full sync for new contact %s completed", jid)
-	s.recordSync("new_contact")
+		s.log.Infof("Full sync for new contact %s completed", jid)
+		s.recordSync("new_contact")
+		return nil
+	})
 }
 
 // OnNewMessage handles coalescence when a new message is received.
@@ -418,23 +543,26 @@ func (s *SyncService) OnGroupNeedsSync(ctx context.Context, jid types.JID) {
 
 	jid = s.utils.NormalizeJID(ctx, jid)
 
-	if err := s.SyncGroupInfo(ctx, jid); err != nil {
-		s.log.Warnf("Failed to sync group info for %s: %v", jid, err)
-	}
+	s.coalesce("group_sync", jid, func() error {
+		if err := s.SyncGroupInfo(ctx, jid); err != nil {
+			s.log.Warnf("Failed to sync group info for %s: %v", jid, err)
+		}
 
-	if err := s.SyncGroupInviteLink(ctx, jid); err != nil {
-		s.log.Warnf("Failed to sync invite link for %s: %v", jid, err)
-	}
+		if err := s.SyncGroupInviteLink(ctx, jid); err != nil {
+			s.log.Warnf("Failed to sync invite link for %s: %v", jid, err)
+		}
 
-	// Get group to check if community
-	group, _ := s.groups.Get(jid)
-	isCommunity := group != nil && group.IsCommunity
-	if err := s.SyncGroupPicture(ctx, jid, isCommunity); err != nil {
-		s.log.Warnf("Failed to sync picture for %s: %v", jid, err)
-	}
+		// Get group to check if community
+		group, _ := s.groups.Get(jid)
+		isCommunity := group != nil && group.IsCommunity
+		if err := s.SyncGroupPicture(ctx, jid, isCommunity); err != nil {
+			s.log.Warnf("Failed to sync picture for %s: %v", jid, err)
+		}
 
-	s.log.Infof("Full sync for group %s completed", jid)
-	s.recordSync("group_sync")
+		s.log.Infof("Full sync for group %s completed", jid)
+		s.recordSync("group_sync")
+		return nil
+	})
 }
 
 // OnGroupJoined handles coalescence when joining a new group.
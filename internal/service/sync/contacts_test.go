@@ -0,0 +1,105 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+
+	"orion-agent/internal/data/store"
+	"orion-agent/internal/utils"
+)
+
+// newTestStoreService creates a SyncService with no client, backed by an
+// in-memory store.
+func newTestStoreService(t *testing.T) (*SyncService, *store.Store) {
+	t.Helper()
+	db, err := store.NewWithOptions(":memory:", store.Options{MaxOpenConns: 1, MaxIdleConns: 1}, waLog.Noop)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	contacts := store.NewContactStore(db)
+	s := NewSyncService(nil, utils.New(contacts, nil), nil, contacts, store.NewGroupStore(db), store.NewChatStore(db),
+		nil, nil, nil, store.NewSyncStateStore(db), store.NewPresenceWatchStore(db), waLog.Noop)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.Stop(ctx)
+		db.Close()
+	})
+	return s, db
+}
+
+func TestConcurrentNewContactQueriesOnce(t *testing.T) {
+	s, db := newTestStoreService(t)
+	jid := types.NewJID("900000000000002", types.HiddenUserServer)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	s.getUserInfo = func(ctx context.Context, jids []types.JID) (map[types.JID]types.UserInfo, error) {
+		calls.Add(1)
+		<-release
+		return map[types.JID]types.UserInfo{jid: {Status: "busy"}}, nil
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.OnNewContact(context.Background(), jid)
+		}()
+	}
+	// Let every call reach the in-flight query before it completes
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("GetUserInfo ran %d times, want 1", n)
+	}
+	if c, err := store.NewContactStore(db).Get(jid); err != nil || c.Status != "busy" {
+		t.Errorf("contact = %+v, %v; want status saved", c, err)
+	}
+}
+
+func TestConcurrentSyncUserInfoQueriesOnce(t *testing.T) {
+	s, _ := newTestStoreService(t)
+	a := types.NewJID("900000000000002", types.HiddenUserServer)
+	b := types.NewJID("900000000000003", types.HiddenUserServer)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	s.getUserInfo = func(ctx context.Context, jids []types.JID) (map[types.JID]types.UserInfo, error) {
+		calls.Add(1)
+		<-release
+		return nil, nil
+	}
+
+	// The same JIDs in any order share a query
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			jids := []types.JID{a, b}
+			if i%2 == 1 {
+				jids = []types.JID{b, a}
+			}
+			if err := s.SyncUserInfo(context.Background(), jids...); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("GetUserInfo ran %d times, want 1", n)
+	}
+}
@@ -27,59 +27,66 @@ func NewDispatcher(service *SyncService, ctx context.Context, log waLog.Logger)
 }
 
 // Handle routes an event to the appropriate coalescence handler.
-// All handlers are called asynchronously (goroutines) to avoid blocking.
+// Handlers run asynchronously on the service's worker pool to avoid
+// blocking, and are cancelled when the service stops.
 func (d *Dispatcher) Handle(evt interface{}) {
 	if d.ctx.Err() != nil {
 		return
 	}
 	switch e := evt.(type) {
 	case *events.Connected:
-		go func() {
-			if err := d.service.FullSync(d.ctx); err != nil {
+		d.service.startPresenceRefresh()
+		d.service.spawn(func(ctx context.Context) {
+			if _, err := d.service.ResubscribePresence(ctx); err != nil {
+				d.log.Warnf("Failed to renew presence subscriptions: %v", err)
+			}
+		})
+		d.service.spawn(func(ctx context.Context) {
+			if err := d.service.FullSync(ctx); err != nil {
 				d.log.Warnf("Initial sync failed: %v", err)
 				return
 			}
-			if d.ctx.Err() != nil {
+			if ctx.Err() != nil {
 				return
 			}
 			d.service.StartScheduler(DefaultSchedulerConfig())
-		}()
+		})
 
 	case *events.PairSuccess:
 		d.log.Infof("Paired successfully as %s", e.ID)
 
 	case *events.Message:
 		// Coalescence: sync sender + group if unknown
-		go d.service.OnNewMessage(d.ctx, e.Info.Chat, e.Info.Sender, e.Info.IsGroup)
+		d.service.submit("new_message:"+e.Info.Chat.String()+":"+e.Info.Sender.String(), func(ctx context.Context) { d.service.OnNewMessage(ctx, e.Info.Chat, e.Info.Sender, e.Info.IsGroup) })
 
 	case *events.Receipt:
 		// Coalescence: sync receipt sender
-		go d.service.OnNewContact(d.ctx, e.Sender)
+		d.service.submit("new_contact:"+e.Sender.String(), func(ctx context.Context) { d.service.OnNewContact(ctx, e.Sender) })
 
 	case *events.Presence:
 		// Coalescence: sync contact from presence
-		go d.service.OnPresenceUpdate(d.ctx, e.From)
+		d.service.submit("presence:"+e.From.String(), func(ctx context.Context) { d.service.OnPresenceUpdate(ctx, e.From) })
 
 	case *events.ChatPresence:
 		// Coalescence: sync sender from typing status
-		go d.service.OnChatPresenceUpdate(d.ctx, e.Chat, e.Sender)
+		d.service.submit("chat_presence:"+e.Chat.String()+":"+e.Sender.String(), func(ctx context.Context) { d.service.OnChatPresenceUpdate(ctx, e.Chat, e.Sender) })
 
 	case *events.PushName:
 		// Coalescence: sync profile pic on push name update
-		go d.service.OnPushNameUpdate(d.ctx, e.JID)
+		d.service.submit("push_name:"+e.JID.String(), func(ctx context.Context) { d.service.OnPushNameUpdate(ctx, e.JID) })
 
 	case *events.Picture:
 		// Coalescence: fetch full picture info
-		go d.service.OnPictureUpdate(d.ctx, e.JID, e.PictureID)
+		d.service.submit("picture:"+e.JID.String()+":"+e.PictureID, func(ctx context.Context) { d.service.OnPictureUpdate(ctx, e.JID, e.PictureID) })
 
 	case *events.JoinedGroup:
 		// Coalescence: full sync for new group
-		go d.service.OnGroupJoined(d.ctx, e.JID)
+		d.service.submit("group_joined:"+e.JID.String(), func(ctx context.Context) { d.service.OnGroupJoined(ctx, e.JID) })
 
 	case *events.GroupInfo:
 		// Coalescence: sync group info changes + participants
-		go func() {
-			d.service.OnGroupInfoChange(d.ctx, e.JID)
+		d.service.submit("", func(ctx context.Context) {
+			d.service.OnGroupInfoChange(ctx, e.JID)
 			// Sync all mentioned participants
 			var participantJIDs []types.JID
 			if e.Join != nil {
@@ -89,13 +96,15 @@ func (d *Dispatcher) Handle(evt interface{}) {
 				participantJIDs = append(participantJIDs, e.Leave...)
 			}
 			if len(participantJIDs) > 0 {
-				d.service.OnGroupParticipantsChange(d.ctx, e.JID, participantJIDs)
+				d.service.OnGroupParticipantsChange(ctx, e.JID, participantJIDs)
 			}
-		}()
+		})
 
 	case *events.HistorySync:
-		// Coalescence: batch sync contacts/groups from history
-		go func() {
+		// Coalescence: batch sync contacts/groups from history. Each batch
+		// is synced only once, so it gets its own goroutine rather than a
+		// place in the queue
+		d.service.spawn(func(ctx context.Context) {
 			var contactJIDs []types.JID
 			var groupJIDs []types.JID
 			if e.Data != nil && e.Data.Conversations != nil {
@@ -111,32 +120,32 @@ func (d *Dispatcher) Handle(evt interface{}) {
 					}
 				}
 			}
-			d.service.OnHistorySyncContacts(d.ctx, contactJIDs)
-			d.service.OnHistorySyncGroups(d.ctx, groupJIDs)
-		}()
+			d.service.OnHistorySyncContacts(ctx, contactJIDs)
+			d.service.OnHistorySyncGroups(ctx, groupJIDs)
+		})
 
 	case *events.CallOffer:
 		// Coalescence: sync caller info
-		go d.service.OnCallReceived(d.ctx, e.CallCreator)
+		d.service.submit("call:"+e.CallCreator.String(), func(ctx context.Context) { d.service.OnCallReceived(ctx, e.CallCreator) })
 
 	case *events.Blocklist:
 		// Coalescence: refresh blocklist
-		go d.service.OnBlocklistChange(d.ctx)
+		d.service.submit("blocklist", func(ctx context.Context) { d.service.OnBlocklistChange(ctx) })
 
 	case *events.PrivacySettings:
 		// Coalescence: refresh privacy settings
-		go d.service.OnPrivacySettingsChange(d.ctx)
+		d.service.submit("privacy", func(ctx context.Context) { d.service.OnPrivacySettingsChange(ctx) })
 
 	case *events.BusinessName:
 		// Coalescence: sync contact with business name
-		go d.service.OnNewContact(d.ctx, e.JID)
+		d.service.submit("new_contact:"+e.JID.String(), func(ctx context.Context) { d.service.OnNewContact(ctx, e.JID) })
 
 	case *events.Contact:
 		// Coalescence: sync full contact info
-		go d.service.OnNewContact(d.ctx, e.JID)
+		d.service.submit("new_contact:"+e.JID.String(), func(ctx context.Context) { d.service.OnNewContact(ctx, e.JID) })
 
 	case *events.NewsletterJoin:
 		// Coalescence: newsletter info
-		go d.service.OnNewsletterMessage(d.ctx, e.ID)
+		d.service.submit("newsletter:"+e.ID.String(), func(ctx context.Context) { d.service.OnNewsletterMessage(ctx, e.ID) })
 	}
 }
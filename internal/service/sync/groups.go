@@ -7,6 +7,7 @@ import (
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
 
+	"orion-agent/internal/data/extract"
 	"orion-agent/internal/data/store"
 )
 
@@ -44,7 +45,7 @@ func (s *SyncService) SyncAllGroups(ctx context.Context) error {
 		g.NameSetBy = s.utils.NormalizeJID(ctx, g.NameSetBy)
 		g.TopicSetBy = s.utils.NormalizeJID(ctx, g.TopicSetBy)
 
-		group := groupInfoToStore(g)
+		group := extract.GroupFromInfo(g)
 
 		if err := s.groups.Put(group); err != nil {
 			s.log.Warnf("Failed to save group %s: %v", g.JID, err)
@@ -83,9 +84,15 @@ func (s *SyncService) SyncGroupInfo(ctx context.Context, jid types.JID) error {
 	if jid.Server != types.GroupServer {
 		return nil
 	}
+	jid = s.utils.NormalizeJID(ctx, jid)
+	return s.coalesce("group_info", jid, func() error {
+		return s.syncGroupInfo(ctx, jid)
+	})
+}
+
+func (s *SyncService) syncGroupInfo(ctx context.Context, jid types.JID) error {
 	s.log.Debugf("Syncing group info for %s", jid)
 
-	jid = s.utils.NormalizeJID(ctx, jid)
 	var info *types.GroupInfo
 	err := s.performUSync(ctx, func(ctx context.Context) error {
 		var err error
@@ -105,27 +112,30 @@ func (s *SyncService) SyncGroupInfo(ctx context.Context, jid types.JID) error {
 	info.NameSetBy = s.utils.NormalizeJID(ctx, info.NameSetBy)
 	info.TopicSetBy = s.utils.NormalizeJID(ctx, info.TopicSetBy)
 
-	group := groupInfoToStore(info)
+	group := extract.GroupFromInfo(info)
 	if err := s.groups.Put(group); err != nil {
 		s.log.Errorf("Failed to save group %s: %v", jid, err)
 		return err
 	}
 
+	participants := make([]store.GroupParticipant, 0, len(info.Participants))
 	for _, p := range info.Participants {
-		p.JID = s.utils.NormalizeJID(ctx, p.JID)
-
-		participant := &store.GroupParticipant{
+		participants = append(participants, store.GroupParticipant{
 			GroupJID:     jid,
-			MemberLID:    p.JID,
+			MemberLID:    s.utils.NormalizeJID(ctx, p.JID),
 			IsAdmin:      p.IsAdmin,
 			IsSuperAdmin: p.IsSuperAdmin,
 			DisplayName:  p.DisplayName,
 			ErrorCode:    int(p.Error),
-		}
-		if err := s.groups.PutParticipant(participant); err != nil {
-			s.log.Errorf("Failed to save participant %s: %v", p.JID, err)
-			return err
-		}
+		})
+	}
+	added, removed, err := s.groups.ReconcileParticipants(jid, participants)
+	if err != nil {
+		s.log.Errorf("Failed to save participants of %s: %v", jid, err)
+		return err
+	}
+	if len(added) > 0 || len(removed) > 0 {
+		s.log.Debugf("Membership of %s changed: added %v, removed %v", jid, added, removed)
 	}
 
 	s.log.Infof("Synced group info for %s", jid)
@@ -243,7 +253,7 @@ func (s *SyncService) SyncGroupFromLink(ctx context.Context, code string) (*type
 	info.NameSetBy = s.utils.NormalizeJID(ctx, info.NameSetBy)
 	info.TopicSetBy = s.utils.NormalizeJID(ctx, info.TopicSetBy)
 
-	group := groupInfoToStore(info)
+	group := extract.GroupFromInfo(info)
 	if err := s.groups.Put(group); err != nil {
 		s.log.Errorf("Failed to save group from link: %v", err)
 		return nil, err
@@ -298,37 +308,6 @@ func (s *SyncService) SyncAllGroupPictures(ctx context.Context) error {
 
 // Helper functions
 
-func groupInfoToStore(g *types.GroupInfo) *store.Group {
-	group := &store.Group{
-		JID:               g.JID,
-		Name:              g.Name,
-		NameSetAt:         g.NameSetAt,
-		NameSetByLID:      g.NameSetBy,
-		Topic:             g.Topic,
-		TopicID:           g.TopicID,
-		TopicSetAt:        g.TopicSetAt,
-		TopicSetByLID:     g.TopicSetBy,
-		OwnerLID:          g.OwnerJID,
-		CreatedAtWA:       g.GroupCreated,
-		IsAnnounce:        g.IsAnnounce,
-		IsLocked:          g.IsLocked,
-		IsIncognito:       g.IsIncognito,
-		EphemeralDuration: uint32(g.DisappearingTimer),
-		MemberAddMode:     string(g.MemberAddMode),
-		IsCommunity:       g.IsParent,
-		IsParentGroup:     g.IsParent,
-		IsDefaultSubgroup: g.IsDefaultSubGroup,
-		ParticipantCount:  g.ParticipantCount,
-		UpdatedAt:         time.Now(),
-	}
-
-	if !g.LinkedParentJID.IsEmpty() {
-		group.LinkedParentJID = g.LinkedParentJID
-	}
-
-	return group
-}
-
 func extractInviteCode(link string) string {
 	if len(link) > 24 {
 		return link[len(link)-22:]
@@ -0,0 +1,203 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// =============================================================================
+// Presence Subscriptions
+// =============================================================================
+
+const (
+	// MaxPresenceSubscriptions caps concurrent presence subscriptions.
+	// WhatsApp silently stops delivering presence past its own limit.
+	MaxPresenceSubscriptions = 200
+
+	presenceBatchSize  = 20
+	presenceBatchDelay = 2 * time.Second
+
+	// presenceRefreshInterval renews watched subscriptions before WhatsApp
+	// expires them, about 10 minutes after subscribing.
+	presenceRefreshInterval = 8 * time.Minute
+)
+
+// SubscribePresenceBatch subscribes to presence for many JIDs in batches.
+// JIDs are normalized and deduplicated; already subscribed JIDs are skipped.
+// Returns the number of new subscriptions.
+func (s *SyncService) SubscribePresenceBatch(ctx context.Context, jids []types.JID) (int, error) {
+	if (s.client == nil && s.subscribePresence == nil) || ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	pending := s.pendingPresenceSubscriptions(ctx, jids)
+	if len(pending) == 0 {
+		return 0, nil
+	}
+	s.log.Debugf("Subscribing to presence for %d JIDs", len(pending))
+
+	subscribed := 0
+	for i, jid := range pending {
+		if i > 0 && i%presenceBatchSize == 0 {
+			select {
+			case <-ctx.Done():
+				return subscribed, ctx.Err()
+			case <-time.After(presenceBatchDelay):
+			}
+		}
+
+		if err := s.limiter.Wait(ctx); err != nil {
+			return subscribed, err
+		}
+		if err := s.sendPresenceSubscription(ctx, jid); err != nil {
+			s.log.Warnf("Failed to subscribe presence for %s: %v", jid, err)
+			continue
+		}
+		s.markPresenceSubscribed(jid)
+		subscribed++
+	}
+
+	s.log.Infof("Subscribed to presence for %d/%d JIDs", subscribed, len(pending))
+	s.recordSync("subscribe_presence")
+	return subscribed, nil
+}
+
+// sendPresenceSubscription subscribes to presence for a normalized JID.
+func (s *SyncService) sendPresenceSubscription(ctx context.Context, jid types.JID) error {
+	if s.subscribePresence != nil {
+		return s.subscribePresence(ctx, jid)
+	}
+	return s.client.SubscribePresence(ctx, jid)
+}
+
+// ResubscribePresence renews all recorded presence subscriptions and the
+// watch list. Subscriptions don't survive reconnects, so this runs on every
+// connect.
+func (s *SyncService) ResubscribePresence(ctx context.Context) (int, error) {
+	// Watched JIDs go first so they're kept if the cap is reached
+	jids := append(s.WatchedPresence(), s.PresenceSubscriptions()...)
+	if len(jids) == 0 {
+		return 0, nil
+	}
+
+	s.presenceMu.Lock()
+	s.presenceSubs = make(map[types.JID]time.Time)
+	s.presenceMu.Unlock()
+
+	return s.SubscribePresenceBatch(ctx, jids)
+}
+
+// RefreshPresence renews the subscriptions of watched JIDs, which otherwise
+// expire and stop delivering presence. It runs every presenceRefreshInterval
+// once connected.
+func (s *SyncService) RefreshPresence(ctx context.Context) (int, error) {
+	jids := s.WatchedPresence()
+	if len(jids) == 0 {
+		return 0, nil
+	}
+
+	s.presenceMu.Lock()
+	for _, jid := range jids {
+		delete(s.presenceSubs, jid)
+	}
+	s.presenceMu.Unlock()
+
+	return s.SubscribePresenceBatch(ctx, jids)
+}
+
+// WatchPresence subscribes to presence for jid and keeps the subscription
+// alive: it's renewed before expiring, on reconnect, and after a restart.
+func (s *SyncService) WatchPresence(ctx context.Context, jid types.JID) error {
+	if !s.utils.IsUser(jid) {
+		return fmt.Errorf("can't watch presence of %s", jid)
+	}
+	jid = s.utils.NormalizeJID(ctx, jid)
+	if err := s.watches.Put(jid); err != nil {
+		return fmt.Errorf("failed to save presence watch: %w", err)
+	}
+
+	s.presenceMu.Lock()
+	delete(s.presenceSubs, jid)
+	s.presenceMu.Unlock()
+
+	return s.SubscribePresence(ctx, jid)
+}
+
+// UnwatchPresence stops renewing the presence subscription for jid. WhatsApp
+// has no unsubscribe, so updates continue until the current one expires.
+func (s *SyncService) UnwatchPresence(ctx context.Context, jid types.JID) error {
+	jid = s.utils.NormalizeJID(ctx, jid)
+	if err := s.watches.Remove(jid); err != nil {
+		return fmt.Errorf("failed to remove presence watch: %w", err)
+	}
+
+	s.presenceMu.Lock()
+	delete(s.presenceSubs, jid)
+	s.presenceMu.Unlock()
+	return nil
+}
+
+// WatchedPresence returns the watched JIDs.
+func (s *SyncService) WatchedPresence() []types.JID {
+	jids, err := s.watches.GetAll()
+	if err != nil {
+		s.log.Warnf("Failed to get presence watches: %v", err)
+	}
+	return jids
+}
+
+// PresenceSubscriptions returns the JIDs with an active presence subscription.
+func (s *SyncService) PresenceSubscriptions() []types.JID {
+	s.presenceMu.Lock()
+	defer s.presenceMu.Unlock()
+
+	jids := make([]types.JID, 0, len(s.presenceSubs))
+	for jid := range s.presenceSubs {
+		jids = append(jids, jid)
+	}
+	return jids
+}
+
+// pendingPresenceSubscriptions normalizes and dedupes jids, dropping
+// non-users, already subscribed JIDs, and anything past the cap.
+func (s *SyncService) pendingPresenceSubscriptions(ctx context.Context, jids []types.JID) []types.JID {
+	s.presenceMu.Lock()
+	defer s.presenceMu.Unlock()
+
+	available := MaxPresenceSubscriptions - len(s.presenceSubs)
+	seen := make(map[types.JID]struct{}, len(jids))
+	var pending []types.JID
+	for _, jid := range jids {
+		if !s.utils.IsUser(jid) {
+			continue
+		}
+		jid = s.utils.NormalizeJID(ctx, jid)
+		if _, ok := seen[jid]; ok {
+			continue
+		}
+		seen[jid] = struct{}{}
+		if _, ok := s.presenceSubs[jid]; ok {
+			continue
+		}
+		pending = append(pending, jid)
+	}
+
+	if available < 0 {
+		available = 0
+	}
+	if len(pending) > available {
+		s.log.Warnf("Presence subscription cap reached, dropping %d JIDs", len(pending)-available)
+		pending = pending[:available]
+	}
+	return pending
+}
+
+// markPresenceSubscribed records an active presence subscription.
+func (s *SyncService) markPresenceSubscribed(jid types.JID) {
+	s.presenceMu.Lock()
+	s.presenceSubs[jid] = time.Now()
+	s.presenceMu.Unlock()
+}
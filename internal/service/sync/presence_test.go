@@ -0,0 +1,48 @@
+package sync
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestPresenceRefreshRunsWithoutScheduler(t *testing.T) {
+	s, _ := newTestStoreService(t)
+	s.SetRateLimit(0)
+	s.presenceRefreshEvery = 10 * time.Millisecond
+	jid := types.NewJID("900000000000002", types.HiddenUserServer)
+
+	var calls atomic.Int32
+	s.subscribePresence = func(ctx context.Context, got types.JID) error {
+		if got == jid {
+			calls.Add(1)
+		}
+		return nil
+	}
+	if err := s.WatchPresence(context.Background(), jid); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForCalls := func(n int32) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for calls.Load() < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("%d subscriptions, want at least %d", calls.Load(), n)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// Connecting starts the refresh, which outlives the scheduler
+	s.SetDispatcher(context.Background())
+	s.Handle(&events.Connected{})
+	s.Handle(&events.Connected{})
+	waitForCalls(4)
+	s.StopScheduler()
+	waitForCalls(calls.Load() + 2)
+}
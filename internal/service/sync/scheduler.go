@@ -33,12 +33,14 @@ func DefaultSchedulerConfig() SchedulerConfig {
 
 // StartScheduler starts the periodic sync scheduler.
 func (s *SyncService) StartScheduler(cfg SchedulerConfig) {
+	s.schedulerMu.Lock()
+	defer s.schedulerMu.Unlock()
 	if s.schedulerCancel != nil {
 		s.log.Warnf("Scheduler already running")
 		return
 	}
 
-	s.schedulerCtx, s.schedulerCancel = context.WithCancel(context.Background())
+	s.schedulerCtx, s.schedulerCancel = context.WithCancel(s.ctx)
 	s.log.Infof("Starting sync scheduler...")
 
 	// Blocklist - every hour
@@ -74,6 +76,8 @@ func (s *SyncService) StartScheduler(cfg SchedulerConfig) {
 
 // StopScheduler stops the periodic sync scheduler.
 func (s *SyncService) StopScheduler() {
+	s.schedulerMu.Lock()
+	defer s.schedulerMu.Unlock()
 	if s.schedulerCancel != nil {
 		s.log.Infof("Stopping sync scheduler...")
 		s.schedulerCancel()
@@ -104,3 +108,34 @@ func (s *SyncService) runPeriodic(name string, interval time.Duration, syncFn fu
 		}
 	}
 }
+
+// startPresenceRefresh starts renewing watched presence subscriptions, once
+// for the service's lifetime. Unlike the periodic syncs it doesn't wait for
+// the initial sync, which may fail, and isn't persisted, as subscriptions
+// end with the connection.
+func (s *SyncService) startPresenceRefresh() {
+	s.presenceRefreshOnce.Do(func() {
+		s.spawn(s.runPresenceRefresh)
+	})
+}
+
+// runPresenceRefresh renews watched presence subscriptions before they
+// expire, while connected.
+func (s *SyncService) runPresenceRefresh(ctx context.Context) {
+	ticker := time.NewTicker(s.presenceRefreshEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.subscribePresence == nil && (s.client == nil || !s.client.IsConnected()) {
+				continue
+			}
+			if _, err := s.RefreshPresence(ctx); err != nil {
+				s.log.Warnf("Failed to refresh presence subscriptions: %v", err)
+			}
+		}
+	}
+}
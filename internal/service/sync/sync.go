@@ -5,18 +5,32 @@ package sync
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
 	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
 	waLog "go.mau.fi/whatsmeow/util/log"
+	"golang.org/x/sync/singleflight"
 
 	"orion-agent/internal/data/store"
 	"orion-agent/internal/service/media"
 	"orion-agent/internal/utils"
 )
 
+// syncWorkerCount is the coalescence worker pool size.
+const syncWorkerCount = 4
+
+// syncJob is queued coalescence work. Jobs with the same non-empty key
+// are the same work, so one queued job stands for all of them.
+type syncJob struct {
+	key string
+	fn  func(context.Context)
+}
+
 type usyncRequest struct {
+	ctx    context.Context
 	fn     func(context.Context) error
 	result chan error
 }
@@ -38,14 +52,48 @@ type SyncService struct {
 	privacy     *store.PrivacyStore
 	newsletters *store.NewsletterStore
 	syncState   *store.SyncStateStore
+	watches     *store.PresenceWatchStore
 
-	// Scheduler
+	// Scheduler, started after each initial sync. schedulerMu orders
+	// starts and stops from concurrent connects and Stop.
+	schedulerMu     sync.Mutex
 	schedulerCtx    context.Context
 	schedulerCancel context.CancelFunc
 	schedulerWg     sync.WaitGroup
 
 	// Usync Queue
 	usyncQueue chan usyncRequest
+
+	// Coalescence work, run by a bounded worker pool
+	workMu    sync.Mutex
+	work      []syncJob
+	workKeys  map[string]bool // Keys of queued jobs
+	workReady chan struct{}
+
+	// Coalesces concurrent syncs of the same kind for the same JID
+	flight singleflight.Group
+
+	// Replace the client's GetUserInfo and SubscribePresence in tests
+	getUserInfo       func(context.Context, []types.JID) (map[types.JID]types.UserInfo, error)
+	subscribePresence func(context.Context, types.JID) error
+
+	// Cancelled by Stop; all sync work runs under it. stopMu orders
+	// wg.Add against Stop's cancel, so nothing is added once Stop waits.
+	ctx    context.Context
+	cancel context.CancelFunc
+	stopMu sync.Mutex
+	wg     sync.WaitGroup
+
+	// Limits outbound queries to avoid server-side rate limiting
+	limiter *rateLimiter
+
+	// Active presence subscriptions, renewed on reconnect
+	presenceMu   sync.Mutex
+	presenceSubs map[types.JID]time.Time
+
+	// Renews watched subscriptions, started on the first connect
+	presenceRefreshOnce  sync.Once
+	presenceRefreshEvery time.Duration
 }
 
 // NewSyncService creates a new SyncService.
@@ -60,8 +108,10 @@ func NewSyncService(
 	privacy *store.PrivacyStore,
 	newsletters *store.NewsletterStore,
 	syncState *store.SyncStateStore, // Add this
+	watches *store.PresenceWatchStore,
 	log waLog.Logger,
 ) *SyncService {
+	ctx, cancel := context.WithCancel(context.Background())
 	s := &SyncService{
 		client:      client,
 		utils:       utils,
@@ -73,46 +123,84 @@ func NewSyncService(
 		privacy:     privacy,
 		newsletters: newsletters,
 		syncState:   syncState,
+		watches:     watches,
 		log:         log.Sub("SyncService"),
 		usyncQueue:  make(chan usyncRequest, 100),
+		workKeys:    make(map[string]bool),
+		workReady:   make(chan struct{}, 1),
+		limiter:     newRateLimiter(defaultRateLimit, 1),
+		ctx:         ctx,
+		cancel:      cancel,
+
+		presenceSubs:         make(map[types.JID]time.Time),
+		presenceRefreshEvery: presenceRefreshInterval,
 	}
-	// Start the worker immediately, it will block on channel receive
+	// Start the workers immediately, they will block on channel receive
+	s.wg.Add(1 + syncWorkerCount)
 	go s.startUSyncWorker()
+	for i := 0; i < syncWorkerCount; i++ {
+		go s.startWorker()
+	}
 	return s
 }
 
-// performUSync adds a request to the queue and waits for it to complete.
+// SetRateLimit sets how many outbound queries per second the service makes
+// (0 = unlimited).
+func (s *SyncService) SetRateLimit(perSecond float64) {
+	s.limiter.SetRate(perSecond)
+}
+
+// RateLimitWait estimates how long the next outbound query waits for the
+// rate limiter.
+func (s *SyncService) RateLimitWait() time.Duration {
+	return s.limiter.EstimateWait()
+}
+
+// performUSync waits for a rate limit token, adds a request to the queue and
+// waits for it to complete.
 func (s *SyncService) performUSync(ctx context.Context, fn func(context.Context) error) error {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
 	resultChan := make(chan error, 1)
 	select {
-	case s.usyncQueue <- usyncRequest{fn: fn, result: resultChan}:
+	case s.usyncQueue <- usyncRequest{ctx: ctx, fn: fn, result: resultChan}:
 		select {
 		case err := <-resultChan:
 			return err
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-s.ctx.Done():
+			return s.ctx.Err()
 		}
 	case <-ctx.Done():
 		return ctx.Err()
+	case <-s.ctx.Done():
+		return s.ctx.Err()
 	}
 }
 
-// startUSyncWorker processes usync requests sequentially with rate limiting.
+// startUSyncWorker processes usync requests sequentially, backing off when
+// the server reports a rate limit. Request pacing is done by performUSync.
 func (s *SyncService) startUSyncWorker() {
-	// Global delay between requests
-	const globalDelay = 3 * time.Second
-
-	for req := range s.usyncQueue {
-		// Execute the request
-		// We use a background context or the service's context if we had one global one,
-		// but here we pass a fresh context or rely on the one inside 'fn' if strictly needed,
-		// usually the 'fn' closure captures the context from the caller.
-		// However, the caller is waiting on 'resultChan'.
-		// We should respect the caller's context cancellation in 'performUSync', but here we just run it.
-		// NOTE: The 'fn' passed usually uses 'ctx' captured from caller. If caller cancels, 'fn' might fail quickly.
-
-		// Run the function
-		err := req.fn(context.Background()) // We use background here as the func likely has the caller ctx embedded or we don't want to cancel the WORKER.
+	defer s.wg.Done()
+
+	for {
+		var req usyncRequest
+		select {
+		case <-s.ctx.Done():
+			return
+		case req = <-s.usyncQueue:
+		}
+
+		// The caller gave up while the request was queued
+		if req.ctx.Err() != nil {
+			req.result <- req.ctx.Err()
+			continue
+		}
+
+		err := req.fn(req.ctx)
 		req.result <- err
 
 		// Handle rate limits / backoff
@@ -131,11 +219,131 @@ func (s *SyncService) startUSyncWorker() {
 
 		if backoff > 0 {
 			s.log.Warnf("Global USync Worker: Rate limit hit. Sleeping for %d seconds...", backoff)
-			time.Sleep(time.Duration(backoff) * time.Second)
-		} else {
-			// standard delay to be nice to the server
-			time.Sleep(globalDelay)
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(time.Duration(backoff) * time.Second):
+			}
+		}
+	}
+}
+
+// startWorker runs coalescence work from the queue until the service stops.
+func (s *SyncService) startWorker() {
+	defer s.wg.Done()
+
+	for {
+		job, ok := s.nextJob()
+		if !ok {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-s.workReady:
+			}
+			continue
 		}
+		if s.ctx.Err() != nil {
+			return
+		}
+		job.fn(s.ctx)
+	}
+}
+
+// nextJob takes the oldest queued job, waking another worker if more remain.
+func (s *SyncService) nextJob() (syncJob, bool) {
+	s.workMu.Lock()
+	defer s.workMu.Unlock()
+
+	if len(s.work) == 0 {
+		return syncJob{}, false
+	}
+	job := s.work[0]
+	s.work[0] = syncJob{}
+	s.work = s.work[1:]
+	delete(s.workKeys, job.key)
+	if len(s.work) > 0 {
+		s.signalWork()
+	}
+	return job, true
+}
+
+// signalWork wakes a waiting worker.
+func (s *SyncService) signalWork() {
+	select {
+	case s.workReady <- struct{}{}:
+	default:
+	}
+}
+
+// coalesce runs fn for the sync of kind for jid, unless one is already in
+// flight, in which case it waits for that one and shares its result. Waiting
+// callers don't get to apply their own ctx; fn runs under the first caller's.
+func (s *SyncService) coalesce(kind string, jid types.JID, fn func() error) error {
+	return s.coalesceKey(kind+":"+jid.String(), fn)
+}
+
+// coalesceKey is coalesce for syncs keyed other than by a single JID.
+func (s *SyncService) coalesceKey(key string, fn func() error) error {
+	_, err, _ := s.flight.Do(key, func() (interface{}, error) {
+		return nil, fn()
+	})
+	return err
+}
+
+// submit queues coalescence work for the worker pool. Work for a key
+// that is already queued is coalesced into the queued job, which syncs the
+// same data; an empty key is never coalesced. Nothing is dropped, so the
+// queue only grows with distinct keys.
+func (s *SyncService) submit(key string, fn func(context.Context)) {
+	if s.ctx.Err() != nil {
+		return
+	}
+
+	s.workMu.Lock()
+	defer s.workMu.Unlock()
+	if key != "" {
+		if s.workKeys[key] {
+			return
+		}
+		s.workKeys[key] = true
+	}
+	s.work = append(s.work, syncJob{key: key, fn: fn})
+	s.signalWork()
+}
+
+// spawn runs long-running work, like a full sync, in its own goroutine that
+// Stop cancels and waits for.
+func (s *SyncService) spawn(fn func(context.Context)) {
+	s.stopMu.Lock()
+	defer s.stopMu.Unlock()
+	if s.ctx.Err() != nil {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		fn(s.ctx)
+	}()
+}
+
+// Stop cancels in-flight syncs, stops the scheduler and workers, and waits
+// for them to finish or ctx to be done.
+func (s *SyncService) Stop(ctx context.Context) error {
+	s.stopMu.Lock()
+	s.cancel()
+	s.stopMu.Unlock()
+	s.StopScheduler()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for syncs to stop: %w", ctx.Err())
 	}
 }
 
@@ -0,0 +1,84 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// newTestService creates a SyncService with no client or stores.
+func newTestService(t *testing.T) *SyncService {
+	t.Helper()
+	s := NewSyncService(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, waLog.Noop)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.Stop(ctx)
+	})
+	return s
+}
+
+func TestSubmitCoalescesQueuedWork(t *testing.T) {
+	s := newTestService(t)
+
+	// Occupy every worker so submitted work stays queued
+	release := make(chan struct{})
+	var busy sync.WaitGroup
+	busy.Add(syncWorkerCount)
+	for range syncWorkerCount {
+		s.submit("", func(context.Context) {
+			busy.Done()
+			<-release
+		})
+	}
+	busy.Wait()
+
+	var keyed, unkeyed atomic.Int32
+	var done sync.WaitGroup
+	done.Add(1 + 1000)
+	s.submit("new_message:a", func(context.Context) {
+		keyed.Add(1)
+		done.Done()
+	})
+	for range 999 {
+		s.submit("new_message:a", func(context.Context) { keyed.Add(1) })
+	}
+	for range 1000 {
+		s.submit("", func(context.Context) {
+			unkeyed.Add(1)
+			done.Done()
+		})
+	}
+	close(release)
+	done.Wait()
+
+	if n := keyed.Load(); n != 1 {
+		t.Errorf("keyed work ran %d times, want 1", n)
+	}
+	if n := unkeyed.Load(); n != 1000 {
+		t.Errorf("unkeyed work ran %d times, want all 1000", n)
+	}
+
+	// Once run, the key can be queued again
+	ran := make(chan struct{})
+	s.submit("new_message:a", func(context.Context) { close(ran) })
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("resubmitted work never ran")
+	}
+}
+
+func TestSpawnAfterStop(t *testing.T) {
+	s := newTestService(t)
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	s.spawn(func(context.Context) { t.Error("spawned after Stop") })
+	s.submit("", func(context.Context) { t.Error("submitted after Stop") })
+	time.Sleep(10 * time.Millisecond)
+}
@@ -0,0 +1,301 @@
+// Package webhook forwards WhatsApp events to an HTTP endpoint.
+//
+// WebhookHandler turns incoming messages, receipts and reactions into JSON
+// and POSTs them to the configured URL from a bounded queue, signing each
+// body with HMAC-SHA256 and retrying failed deliveries with backoff.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	waLog "go.mau.fi/whatsmeow/util/log"
+
+	"orion-agent/internal/data/extract"
+	"orion-agent/internal/data/store"
+	"orion-agent/internal/infra/config"
+	"orion-agent/internal/utils"
+)
+
+// Event types, as configured in webhook.events and sent in the payload.
+const (
+	EventMessage  = "message"
+	EventReceipt  = "receipt"
+	EventReaction = "reaction"
+)
+
+// Request headers.
+const (
+	HeaderEvent     = "X-Orion-Event"
+	HeaderDelivery  = "X-Orion-Delivery"
+	HeaderSignature = "X-Orion-Signature" // "sha256=" + hex HMAC of the body
+)
+
+// Event is the JSON body POSTed for each event.
+type Event struct {
+	ID        string    `json:"id"` // Unique per event, the same across retries
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Data      any       `json:"data"` // *store.Message, []store.Receipt or *Reaction
+}
+
+// Reaction is the data of a reaction event. An empty Emoji removes the
+// sender's reaction. Like the stored message and receipts, it's encoded with
+// its Go field names.
+type Reaction struct {
+	MessageID string
+	ChatJID   types.JID
+	SenderLID types.JID
+	FromMe    bool
+	Emoji     string
+	Timestamp time.Time
+}
+
+// WebhookHandler forwards events to the configured endpoint.
+type WebhookHandler struct {
+	ctx    context.Context
+	cfg    *config.WebhookConfig
+	utils  *utils.Utils
+	client *http.Client
+	events map[string]bool // Forwarded types, nil = all
+	log    waLog.Logger
+
+	queue    chan *Event
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+	stopCtx  context.Context // Cancelled by Stop, aborting the delivery in progress
+	stop     context.CancelFunc
+}
+
+// NewWebhookHandler creates a new WebhookHandler. Call Start to begin
+// delivering events.
+func NewWebhookHandler(ctx context.Context, cfg *config.WebhookConfig, utils *utils.Utils, log waLog.Logger) *WebhookHandler {
+	timeout := time.Duration(cfg.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = 1000
+	}
+
+	var forwarded map[string]bool
+	if len(cfg.Events) > 0 {
+		forwarded = make(map[string]bool, len(cfg.Events))
+		for _, t := range cfg.Events {
+			forwarded[t] = true
+		}
+	}
+
+	stopCtx, stop := context.WithCancel(context.Background())
+	return &WebhookHandler{
+		ctx:     ctx,
+		cfg:     cfg,
+		utils:   utils,
+		client:  &http.Client{Timeout: timeout},
+		events:  forwarded,
+		log:     log.Sub("Webhook"),
+		queue:   make(chan *Event, queueSize),
+		stopCtx: stopCtx,
+		stop:    stop,
+	}
+}
+
+// Start starts the delivery worker. Events are delivered one at a time, in
+// the order they arrived.
+func (h *WebhookHandler) Start() {
+	h.log.Infof("Forwarding events to %s", h.cfg.URL)
+	h.wg.Add(1)
+	go h.worker()
+}
+
+// Stop stops the delivery worker, aborting a delivery in progress. Queued
+// events are dropped.
+func (h *WebhookHandler) Stop() {
+	h.stopOnce.Do(func() {
+		h.stop()
+		h.wg.Wait()
+		if n := len(h.queue); n > 0 {
+			h.log.Warnf("Stopped with %d undelivered events", n)
+		}
+	})
+}
+
+// Handle is a whatsmeow event handler queueing the events to forward.
+func (h *WebhookHandler) Handle(evt interface{}) {
+	switch e := evt.(type) {
+	case *events.Message:
+		if rm := e.Message.GetReactionMessage(); rm != nil {
+			h.enqueue(EventReaction, e.Info.Timestamp, h.reactionFromEvent(e))
+			return
+		}
+		// Edits, revokes, votes and pins update other messages and aren't
+		// stored as messages of their own
+		if e.Message.GetProtocolMessage() != nil || e.Message.GetPollUpdateMessage() != nil ||
+			e.Message.GetPinInChatMessage() != nil || e.Message.GetKeepInChatMessage() != nil {
+			return
+		}
+		h.enqueue(EventMessage, e.Info.Timestamp, h.messageFromEvent(e))
+	case *events.Receipt:
+		h.enqueue(EventReceipt, e.Timestamp, h.receiptsFromEvent(e))
+	}
+}
+
+// messageFromEvent extracts a message with its JIDs normalized to LIDs, as
+// it's stored.
+func (h *WebhookHandler) messageFromEvent(evt *events.Message) *store.Message {
+	msg := extract.MessageFromEvent(evt)
+	msg.ChatJID = h.utils.NormalizeJID(h.ctx, msg.ChatJID)
+	msg.SenderLID = h.utils.NormalizeJID(h.ctx, msg.SenderLID)
+	msg.QuotedSenderLID = h.utils.NormalizeJID(h.ctx, msg.QuotedSenderLID)
+	msg.ForwardedFromJID = h.utils.NormalizeJID(h.ctx, msg.ForwardedFromJID)
+	for i := range msg.MentionedJIDs {
+		msg.MentionedJIDs[i] = h.utils.NormalizeJID(h.ctx, msg.MentionedJIDs[i])
+	}
+	return msg
+}
+
+func (h *WebhookHandler) reactionFromEvent(evt *events.Message) *Reaction {
+	rm := evt.Message.GetReactionMessage()
+	chat, _ := types.ParseJID(rm.GetKey().GetRemoteJID())
+	if chat.IsEmpty() {
+		chat = evt.Info.Chat
+	}
+	return &Reaction{
+		MessageID: rm.GetKey().GetID(),
+		ChatJID:   h.utils.NormalizeJID(h.ctx, chat),
+		SenderLID: h.utils.NormalizeJID(h.ctx, evt.Info.Sender),
+		FromMe:    evt.Info.IsFromMe,
+		Emoji:     rm.GetText(),
+		Timestamp: evt.Info.Timestamp,
+	}
+}
+
+func (h *WebhookHandler) receiptsFromEvent(evt *events.Receipt) []store.Receipt {
+	receipts := extract.ReceiptFromEvent(evt)
+	for i := range receipts {
+		receipts[i].ChatJID = h.utils.NormalizeJID(h.ctx, receipts[i].ChatJID)
+		receipts[i].RecipientLID = h.utils.NormalizeJID(h.ctx, receipts[i].RecipientLID)
+	}
+	return receipts
+}
+
+// enqueue queues an event if its type is forwarded, dropping it when the
+// queue is full.
+func (h *WebhookHandler) enqueue(eventType string, ts time.Time, data any) {
+	if h.events != nil && !h.events[eventType] {
+		return
+	}
+
+	id := make([]byte, 16)
+	rand.Read(id)
+	select {
+	case h.queue <- &Event{ID: hex.EncodeToString(id), Type: eventType, Timestamp: ts, Data: data}:
+	default:
+		h.log.Warnf("Webhook queue full, dropping %s event", eventType)
+	}
+}
+
+// worker delivers queued events until stopped.
+func (h *WebhookHandler) worker() {
+	defer h.wg.Done()
+
+	for {
+		select {
+		case <-h.stopCtx.Done():
+			return
+		case evt := <-h.queue:
+			if err := h.deliverWithRetry(evt); err != nil {
+				h.log.Errorf("Failed to deliver %s event %s: %v", evt.Type, evt.ID, err)
+			}
+		}
+	}
+}
+
+// deliverWithRetry POSTs evt, retrying with exponential backoff on network
+// errors and 5xx or 429 responses.
+func (h *WebhookHandler) deliverWithRetry(evt *Event) error {
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	maxAttempts := h.cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	wait := time.Duration(h.cfg.RetryBackoffMs) * time.Millisecond
+	if wait <= 0 {
+		wait = time.Second
+	}
+
+	for attempt := 1; ; attempt++ {
+		retry, err := h.deliver(evt, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == maxAttempts {
+			return err
+		}
+
+		h.log.Debugf("Delivery of %s failed (attempt %d/%d): %v, retrying in %v", evt.ID, attempt, maxAttempts, err, wait)
+		select {
+		case <-h.stopCtx.Done():
+			return errors.New("webhook stopped")
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// deliver POSTs a signed body once. Returns whether a failure is worth
+// retrying.
+func (h *WebhookHandler) deliver(evt *Event, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(h.stopCtx, http.MethodPost, h.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, evt.Type)
+	req.Header.Set(HeaderDelivery, evt.ID)
+	if h.cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, "sha256="+Sign(h.cfg.Secret, body))
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("http status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("http status %d", resp.StatusCode)
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of body, as sent in X-Orion-Signature
+// after "sha256=". Receivers should compare it with hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
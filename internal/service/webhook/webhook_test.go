@@ -0,0 +1,155 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	waLog "go.mau.fi/whatsmeow/util/log"
+
+	"orion-agent/internal/infra/config"
+	"orion-agent/internal/utils"
+)
+
+// newTestHandler returns a handler POSTing to a server answering with
+// handler, with quick retries.
+func newTestHandler(t *testing.T, cfg config.WebhookConfig, handler http.HandlerFunc) *WebhookHandler {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cfg.URL = srv.URL
+	if cfg.RetryBackoffMs == 0 {
+		cfg.RetryBackoffMs = 1
+	}
+	h := NewWebhookHandler(context.Background(), &cfg, utils.New(nil, nil), waLog.Noop)
+	t.Cleanup(h.Stop)
+	return h
+}
+
+func TestDeliverSigned(t *testing.T) {
+	type request struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan request, 1)
+	h := newTestHandler(t, config.WebhookConfig{Secret: "s3cret"}, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- request{r.Header, body}
+	})
+	h.Start()
+	h.enqueue(EventReaction, time.Unix(1700000000, 0), &Reaction{MessageID: "M1", Emoji: "👍"})
+
+	var req request
+	select {
+	case req = <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery")
+	}
+	if sig, want := req.header.Get(HeaderSignature), "sha256="+Sign("s3cret", req.body); sig != want {
+		t.Errorf("signature = %q, want %q", sig, want)
+	}
+	var evt struct {
+		ID   string
+		Type string
+		Data Reaction
+	}
+	if err := json.Unmarshal(req.body, &evt); err != nil {
+		t.Fatal(err)
+	}
+	if evt.Type != EventReaction || req.header.Get(HeaderEvent) != EventReaction ||
+		evt.ID == "" || req.header.Get(HeaderDelivery) != evt.ID || evt.Data.Emoji != "👍" {
+		t.Errorf("delivered %s with headers %v", req.body, req.header)
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	for _, tc := range []struct {
+		status   int
+		attempts int32
+	}{
+		{http.StatusInternalServerError, 3},
+		{http.StatusBadGateway, 3},
+		{http.StatusTooManyRequests, 3},
+		{http.StatusBadRequest, 1},
+		{http.StatusNotFound, 1},
+		{http.StatusOK, 1},
+	} {
+		var attempts atomic.Int32
+		h := newTestHandler(t, config.WebhookConfig{MaxAttempts: 3}, func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(tc.status)
+		})
+		err := h.deliverWithRetry(&Event{ID: "E1", Type: EventMessage})
+		if (err == nil) != (tc.status == http.StatusOK) {
+			t.Errorf("status %d: err = %v", tc.status, err)
+		}
+		if n := attempts.Load(); n != tc.attempts {
+			t.Errorf("status %d: %d attempts, want %d", tc.status, n, tc.attempts)
+		}
+	}
+}
+
+func TestEventFilter(t *testing.T) {
+	h := newTestHandler(t, config.WebhookConfig{Events: []string{EventReceipt}}, func(w http.ResponseWriter, r *http.Request) {})
+	chat := types.NewJID("900000000000002", types.HiddenUserServer)
+
+	h.Handle(&events.Message{Info: types.MessageInfo{MessageSource: types.MessageSource{Chat: chat, Sender: chat}}})
+	h.Handle(&events.Receipt{MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+		MessageIDs: []string{"M1"}, Type: types.ReceiptTypeRead})
+
+	if n := len(h.queue); n != 1 {
+		t.Fatalf("%d events queued, want only the receipt", n)
+	}
+	if evt := <-h.queue; evt.Type != EventReceipt {
+		t.Errorf("queued %s event", evt.Type)
+	}
+}
+
+func TestQueueFullDrops(t *testing.T) {
+	// Not started, so nothing leaves the queue
+	h := newTestHandler(t, config.WebhookConfig{QueueSize: 2}, func(w http.ResponseWriter, r *http.Request) {})
+	for _, id := range []string{"M1", "M2", "M3"} {
+		h.enqueue(EventReaction, time.Now(), &Reaction{MessageID: id})
+	}
+
+	if n := len(h.queue); n != 2 {
+		t.Fatalf("%d events queued, want 2", n)
+	}
+	for _, want := range []string{"M1", "M2"} {
+		if got := (<-h.queue).Data.(*Reaction).MessageID; got != want {
+			t.Errorf("queued %s, want %s", got, want)
+		}
+	}
+}
+
+func TestStopAbortsDelivery(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	h := newTestHandler(t, config.WebhookConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	h.Start()
+	h.enqueue(EventMessage, time.Now(), nil)
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		h.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop waited for the delivery to time out")
+	}
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// JID cache defaults.
+const (
+	DefaultJIDCacheSize = 50000
+	DefaultJIDCacheTTL  = time.Hour
+)
+
+// jidCacheMissTTL is how long a miss is cached. It's short because
+// whatsmeow learns PN → LID mappings mid-session without telling the cache.
+const jidCacheMissTTL = 10 * time.Second
+
+// jidCache is an LRU cache of PN → LID lookups with a TTL. Misses are
+// cached as empty JIDs for jidCacheMissTTL, so a burst of lookups for an
+// unknown PN hits the database once, and a mapping learned later is picked
+// up within seconds.
+type jidCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List // Most recently used first
+}
+
+type jidCacheEntry struct {
+	key     string
+	lid     types.JID
+	expires time.Time
+}
+
+func newJIDCache(size int, ttl time.Duration) *jidCache {
+	c := &jidCache{entries: make(map[string]*list.Element), order: list.New()}
+	c.setLimits(size, ttl)
+	return c
+}
+
+// setLimits changes the size and TTL, evicting entries over the new size.
+func (c *jidCache) setLimits(size int, ttl time.Duration) {
+	if size <= 0 {
+		size = DefaultJIDCacheSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultJIDCacheTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.size, c.ttl = size, ttl
+	for c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// get returns the cached LID for key; an empty LID is a cached miss.
+func (c *jidCache) get(key string) (types.JID, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return types.JID{}, false
+	}
+	entry := el.Value.(*jidCacheEntry)
+	if time.Now().After(entry.expires) {
+		c.removeElement(el)
+		return types.JID{}, false
+	}
+	c.order.MoveToFront(el)
+	return entry.lid, true
+}
+
+func (c *jidCache) put(key string, lid types.JID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ttl := c.ttl
+	if lid.IsEmpty() {
+		ttl = min(ttl, jidCacheMissTTL)
+	}
+	expires := time.Now().Add(ttl)
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*jidCacheEntry)
+		entry.lid, entry.expires = lid, expires
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&jidCacheEntry{key: key, lid: lid, expires: expires})
+	if c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *jidCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+func (c *jidCache) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*jidCacheEntry).key)
+}
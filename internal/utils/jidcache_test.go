@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestJIDCacheMissExpiresSooner(t *testing.T) {
+	c := newJIDCache(10, time.Hour)
+	lid := types.NewJID("100", types.HiddenUserServer)
+	c.put("hit", lid)
+	c.put("miss", types.JID{})
+
+	if got, ok := c.get("miss"); !ok || !got.IsEmpty() {
+		t.Fatalf("fresh miss not cached")
+	}
+
+	// Age both entries past the miss TTL
+	for _, key := range []string{"hit", "miss"} {
+		c.entries[key].Value.(*jidCacheEntry).expires = c.entries[key].Value.(*jidCacheEntry).expires.Add(-jidCacheMissTTL - time.Second)
+	}
+	if _, ok := c.get("miss"); ok {
+		t.Error("miss still cached after the miss TTL")
+	}
+	if got, ok := c.get("hit"); !ok || got != lid {
+		t.Error("hit expired with the miss TTL")
+	}
+}
+
+func TestJIDCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newJIDCache(2, time.Hour)
+	c.put("a", types.NewJID("1", types.HiddenUserServer))
+	c.put("b", types.NewJID("2", types.HiddenUserServer))
+	c.get("a")
+	c.put("c", types.NewJID("3", types.HiddenUserServer))
+
+	if _, ok := c.get("b"); ok {
+		t.Error("least recently used entry not evicted")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Error("recently used entry evicted")
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"math"
 	"path/filepath"
 	"strings"
-	"sync"
 	"time"
 
 	"go.mau.fi/whatsmeow"
@@ -27,7 +26,7 @@ type ContactGetter interface {
 type Utils struct {
 	contacts ContactGetter
 	client   *whatsmeow.Client
-	cache    sync.Map // JID cache: pn.String() -> LID
+	cache    *jidCache // PN (without device) -> LID
 }
 
 // New creates a new Utils instance.
@@ -35,9 +34,22 @@ func New(contacts ContactGetter, client *whatsmeow.Client) *Utils {
 	return &Utils{
 		contacts: contacts,
 		client:   client,
+		cache:    newJIDCache(DefaultJIDCacheSize, DefaultJIDCacheTTL),
 	}
 }
 
+// SetJIDCacheLimits sets how many PN → LID lookups are cached and for how
+// long. 0 keeps the default.
+func (u *Utils) SetJIDCacheLimits(size int, ttl time.Duration) {
+	u.cache.setLimits(size, ttl)
+}
+
+// InvalidateJIDCache drops the cached LID of a PN, so the next
+// normalization reads the mapping again.
+func (u *Utils) InvalidateJIDCache(pn types.JID) {
+	u.cache.remove(pn.ToNonAD().String())
+}
+
 // SetClient sets the WhatsApp client (for delayed initialization).
 func (u *Utils) SetClient(client *whatsmeow.Client) {
 	u.client = client
@@ -76,17 +88,28 @@ func (u *Utils) ToLID(ctx context.Context, pn types.JID) types.JID {
 		return pn
 	}
 
-	// Check cache first
-	if cached, ok := u.cache.Load(pn.String()); ok {
-		return cached.(types.JID)
+	// Mappings are per user, so look up without the device
+	key := pn.ToNonAD()
+	lid, ok := u.cache.get(key.String())
+	if !ok {
+		lid = u.lookupLID(ctx, key)
+		u.cache.put(key.String(), lid)
+	}
+	if lid.IsEmpty() {
+		return pn
 	}
+	lid.Device = pn.Device
+	return lid
+}
 
+// lookupLID finds the LID of a device-less PN in the database, then in the
+// client's store. Returns an empty JID if neither knows it.
+func (u *Utils) lookupLID(ctx context.Context, pn types.JID) types.JID {
 	// Check database
 	if u.contacts != nil {
 		lid, err := u.contacts.GetLIDForPN(pn)
 		if err == nil && !lid.IsEmpty() {
-			u.cache.Store(pn.String(), lid)
-			return lid
+			return lid.ToNonAD()
 		}
 	}
 
@@ -94,7 +117,7 @@ func (u *Utils) ToLID(ctx context.Context, pn types.JID) types.JID {
 	if u.client != nil && u.client.Store != nil && u.client.Store.LIDs != nil {
 		lid, err := u.client.Store.LIDs.GetLIDForPN(ctx, pn)
 		if err == nil && !lid.IsEmpty() {
-			u.cache.Store(pn.String(), lid)
+			lid = lid.ToNonAD()
 			// Store the mapping
 			if u.contacts != nil {
 				u.contacts.UpdatePN(lid, pn)
@@ -103,7 +126,7 @@ func (u *Utils) ToLID(ctx context.Context, pn types.JID) types.JID {
 		}
 	}
 
-	return pn
+	return types.JID{}
 }
 
 // ToPN converts a LID to PN. Returns as-is if not found.
@@ -131,10 +154,10 @@ func (u *Utils) StoreMappingFromEvent(pn, lid types.JID) {
 		return
 	}
 
-	u.cache.Store(pn.String(), lid)
 	if u.contacts != nil {
 		u.contacts.UpdatePN(lid, pn)
 	}
+	u.cache.put(pn.ToNonAD().String(), lid.ToNonAD())
 }
 
 // IsLID returns true if this JID is a LID (local identifier).